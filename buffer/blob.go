@@ -0,0 +1,346 @@
+package buffer
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// Size of the chunks returned by the Blob stream reader.
+const blobChunkSize = 65536
+
+type blob struct {
+	data []byte
+	typ  string
+
+	isFile       bool
+	name         string
+	lastModified int64
+}
+
+var reflectTypeBlob = reflect.TypeOf((*blob)(nil))
+
+// IsBlob returns true if the value is a Blob (or a File).
+func IsBlob(v goja.Value) bool {
+	if v.ExportType() == reflectTypeBlob {
+		return v.Export().(*blob) != nil
+	}
+	return false
+}
+
+func (b *Buffer) toBlob(v goja.Value) *blob {
+	if v.ExportType() == reflectTypeBlob {
+		if bl := v.Export().(*blob); bl != nil {
+			return bl
+		}
+	}
+	return nil
+}
+
+func (b *Buffer) thisBlob(v goja.Value) *blob {
+	if bl := b.toBlob(v); bl != nil {
+		return bl
+	}
+	panic(errors.NewTypeError(b.r, errors.ErrCodeInvalidThis, `Value of "this" must be of type Blob`))
+}
+
+func (b *Buffer) thisFile(v goja.Value) *blob {
+	if bl := b.toBlob(v); bl != nil && bl.isFile {
+		return bl
+	}
+	panic(errors.NewTypeError(b.r, errors.ErrCodeInvalidThis, `Value of "this" must be of type File`))
+}
+
+// wrapBlob returns a new Blob (or File) object for the blob. Blobs are immutable, so the data can be shared.
+func (b *Buffer) wrapBlob(bl *blob) *goja.Object {
+	o := b.r.ToValue(bl).(*goja.Object)
+	if bl.isFile {
+		o.SetPrototype(b.fileProto)
+	} else {
+		o.SetPrototype(b.blobProto)
+	}
+	return o
+}
+
+func (b *Buffer) newUint8Array(data []byte) *goja.Object {
+	o, err := b.uint8ArrayCtor(b.uint8ArrayCtorObj, b.r.ToValue(b.r.NewArrayBuffer(data)))
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+func (b *Buffer) optionsArg(v goja.Value) *goja.Object {
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil
+	}
+	if o, ok := v.(*goja.Object); ok {
+		return o
+	}
+	panic(errors.NewArgTypeError(b.r, "options", "of type object", v))
+}
+
+func getOption(o *goja.Object, name string) goja.Value {
+	if o == nil {
+		return goja.Undefined()
+	}
+	if v := o.Get(name); v != nil {
+		return v
+	}
+	return goja.Undefined()
+}
+
+// blobType returns the normalised MIME type, or an empty string if it contains characters outside of
+// the printable ASCII range.
+func blobType(v goja.Value) string {
+	if goja.IsUndefined(v) {
+		return ""
+	}
+	s := v.String()
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7E {
+			return ""
+		}
+	}
+	return strings.ToLower(s)
+}
+
+func convertLineEndings(s string) string {
+	nl := "\n"
+	if runtime.GOOS == "windows" {
+		nl = "\r\n"
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	if nl != "\n" {
+		s = strings.ReplaceAll(s, "\n", nl)
+	}
+	return s
+}
+
+func (b *Buffer) blobParts(sources goja.Value, opts *goja.Object, argName string) []byte {
+	var data []byte
+	if goja.IsUndefined(sources) {
+		return data
+	}
+	if o, ok := sources.(*goja.Object); !ok || o.GetSymbol(goja.SymIterator) == nil || goja.IsUndefined(o.GetSymbol(goja.SymIterator)) {
+		panic(errors.NewArgTypeError(b.r, argName, "a sequence", sources))
+	}
+	native := false
+	if endings := getOption(opts, "endings"); !goja.IsUndefined(endings) {
+		switch strings.ToLower(endings.String()) {
+		case "transparent":
+		case "native":
+			native = true
+		default:
+			panic(errors.NewTypeError(b.r, errors.ErrCodeInvalidArgValue, "The property 'options.endings' must be one of: 'transparent', 'native'. Received '%s'", endings.String()))
+		}
+	}
+	b.r.ForOf(sources, func(part goja.Value) bool {
+		if bl := b.toBlob(part); bl != nil {
+			data = append(data, bl.data...)
+		} else if bytes, ok := SourceBytes(b.r, part); ok {
+			data = append(data, bytes...)
+		} else {
+			s := part.String()
+			if native {
+				s = convertLineEndings(s)
+			}
+			data = append(data, s...)
+		}
+		return true
+	})
+	return data
+}
+
+func (b *Buffer) createBlobConstructor() *goja.Object {
+	r := b.r
+	ctor := r.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		opts := b.optionsArg(call.Argument(1))
+		bl := &blob{
+			data: b.blobParts(call.Argument(0), opts, "sources"),
+			typ:  blobType(getOption(opts, "type")),
+		}
+		res := r.ToValue(bl).(*goja.Object)
+		res.SetPrototype(call.This.Prototype())
+		return res
+	}).(*goja.Object)
+	ctor.DefineDataProperty("name", r.ToValue("Blob"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+
+	proto := r.NewObject()
+	proto.DefineAccessorProperty("size", r.ToValue(func(call goja.FunctionCall) goja.Value {
+		return r.ToValue(len(b.thisBlob(call.This).data))
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	proto.DefineAccessorProperty("type", r.ToValue(func(call goja.FunctionCall) goja.Value {
+		return r.ToValue(b.thisBlob(call.This).typ)
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	proto.Set("slice", b.blob_slice)
+	proto.Set("text", b.blob_text)
+	proto.Set("arrayBuffer", b.blob_arrayBuffer)
+	proto.Set("bytes", b.blob_bytes)
+	proto.Set("stream", b.blob_stream)
+	proto.DefineDataPropertySymbol(goja.SymToStringTag, r.ToValue("Blob"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	proto.DefineDataProperty("constructor", ctor, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	ctor.Set("prototype", proto)
+	b.blobProto = proto
+	return ctor
+}
+
+func (b *Buffer) createFileConstructor(blobCtor *goja.Object) *goja.Object {
+	r := b.r
+	ctor := r.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		if len(call.Arguments) < 2 {
+			panic(errors.NewTypeError(r, errors.ErrCodeMissingArgs, `The "fileBits" and "fileName" arguments must be specified`))
+		}
+		opts := b.optionsArg(call.Argument(2))
+		bl := &blob{
+			data:   b.blobParts(call.Argument(0), opts, "fileBits"),
+			typ:    blobType(getOption(opts, "type")),
+			isFile: true,
+			name:   call.Argument(1).String(),
+		}
+		if lm := getOption(opts, "lastModified"); !goja.IsUndefined(lm) {
+			if f := lm.ToFloat(); !math.IsNaN(f) {
+				bl.lastModified = lm.ToInteger()
+			}
+		} else {
+			bl.lastModified = time.Now().UnixNano() / int64(time.Millisecond)
+		}
+		res := r.ToValue(bl).(*goja.Object)
+		res.SetPrototype(call.This.Prototype())
+		return res
+	}).(*goja.Object)
+	ctor.DefineDataProperty("name", r.ToValue("File"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	ctor.SetPrototype(blobCtor)
+
+	proto := r.NewObject()
+	proto.SetPrototype(b.blobProto)
+	proto.DefineAccessorProperty("name", r.ToValue(func(call goja.FunctionCall) goja.Value {
+		return r.ToValue(b.thisFile(call.This).name)
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	proto.DefineAccessorProperty("lastModified", r.ToValue(func(call goja.FunctionCall) goja.Value {
+		return r.ToValue(b.thisFile(call.This).lastModified)
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	proto.DefineAccessorProperty("webkitRelativePath", r.ToValue(func(call goja.FunctionCall) goja.Value {
+		b.thisFile(call.This)
+		return r.ToValue("")
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	proto.DefineDataPropertySymbol(goja.SymToStringTag, r.ToValue("File"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	proto.DefineDataProperty("constructor", ctor, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	ctor.Set("prototype", proto)
+	b.fileProto = proto
+	return ctor
+}
+
+func relativeIndex(v goja.Value, length, defaultVal int64) int64 {
+	if goja.IsUndefined(v) {
+		return defaultVal
+	}
+	i := v.ToInteger()
+	if i < 0 {
+		i += length
+		if i < 0 {
+			i = 0
+		}
+	} else if i > length {
+		i = length
+	}
+	return i
+}
+
+func (b *Buffer) blob_slice(call goja.FunctionCall) goja.Value {
+	bl := b.thisBlob(call.This)
+	l := int64(len(bl.data))
+	start := relativeIndex(call.Argument(0), l, 0)
+	end := relativeIndex(call.Argument(1), l, l)
+	if end < start {
+		end = start
+	}
+	return b.wrapBlob(&blob{
+		data: bl.data[start:end:end],
+		typ:  blobType(call.Argument(2)),
+	})
+}
+
+func (b *Buffer) resolved(v interface{}) goja.Value {
+	p, resolve, _ := b.r.NewPromise()
+	resolve(v)
+	return b.r.ToValue(p)
+}
+
+func (b *Buffer) blob_text(call goja.FunctionCall) goja.Value {
+	return b.resolved(utf8Codec.Encode(b.thisBlob(call.This).data))
+}
+
+func (b *Buffer) blob_arrayBuffer(call goja.FunctionCall) goja.Value {
+	data := b.thisBlob(call.This).data
+	return b.resolved(b.r.NewArrayBuffer(append([]byte(nil), data...)))
+}
+
+func (b *Buffer) blob_bytes(call goja.FunctionCall) goja.Value {
+	data := b.thisBlob(call.This).data
+	return b.resolved(b.newUint8Array(append([]byte(nil), data...)))
+}
+
+// blob_stream returns a minimal ReadableStream-like object, as the runtime does not implement WHATWG streams.
+// Only the default reader is supported, i.e. getReader() with read(), releaseLock(), cancel() and closed.
+func (b *Buffer) blob_stream(call goja.FunctionCall) goja.Value {
+	r := b.r
+	data := b.thisBlob(call.This).data
+	locked, finished := false, false
+	closed, resolveClosed, _ := r.NewPromise()
+	finish := func() {
+		if !finished {
+			finished = true
+			data = nil
+			resolveClosed(goja.Undefined())
+		}
+	}
+
+	stream := r.NewObject()
+	stream.DefineAccessorProperty("locked", r.ToValue(func(goja.FunctionCall) goja.Value {
+		return r.ToValue(locked)
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	cancel := func(goja.FunctionCall) goja.Value {
+		finish()
+		return b.resolved(goja.Undefined())
+	}
+	stream.Set("cancel", cancel)
+	stream.Set("getReader", func(goja.FunctionCall) goja.Value {
+		if locked {
+			panic(errors.NewTypeError(r, "ERR_INVALID_STATE", "Invalid state: ReadableStream is locked"))
+		}
+		locked = true
+		reader := r.NewObject()
+		reader.Set("read", func(goja.FunctionCall) goja.Value {
+			res := r.NewObject()
+			if len(data) == 0 {
+				finish()
+				res.Set("value", goja.Undefined())
+				res.Set("done", true)
+			} else {
+				n := len(data)
+				if n > blobChunkSize {
+					n = blobChunkSize
+				}
+				res.Set("value", b.newUint8Array(append([]byte(nil), data[:n]...)))
+				res.Set("done", false)
+				data = data[n:]
+			}
+			return b.resolved(res)
+		})
+		reader.Set("releaseLock", func(goja.FunctionCall) goja.Value {
+			locked = false
+			return goja.Undefined()
+		})
+		reader.Set("cancel", cancel)
+		reader.Set("closed", closed)
+		return reader
+	})
+	return stream
+}
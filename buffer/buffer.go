@@ -4,12 +4,18 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/hex"
+	"fmt"
+	"math"
 	"reflect"
 	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/nuvolaris/goja"
 	"github.com/nuvolaris/goja_nodejs/errors"
 	"github.com/nuvolaris/goja_nodejs/require"
+	"github.com/nuvolaris/goja_nodejs/util/types"
 
 	"golang.org/x/text/encoding/unicode"
 )
@@ -23,6 +29,10 @@ type Buffer struct {
 
 	uint8ArrayCtorObj *goja.Object
 	uint8ArrayCtor    goja.Constructor
+
+	types *types.Types
+
+	blobProto, fileProto *goja.Object
 }
 
 var (
@@ -37,11 +47,40 @@ var (
 	reflectTypeBytes       = reflect.TypeOf(([]byte)(nil))
 )
 
-func Enable(runtime *goja.Runtime) {
-	runtime.Set("Buffer", require.Require(runtime, ModuleName).ToObject(runtime).Get("Buffer"))
+type enableOptions struct {
+	webGlobals bool
+}
+
+type EnableOption func(*enableOptions)
+
+// WithWebGlobals controls whether Enable also installs Blob, File, atob, btoa and structuredClone
+// as globals. By default only Buffer is installed.
+func WithWebGlobals(enable bool) EnableOption {
+	return func(o *enableOptions) {
+		o.webGlobals = enable
+	}
+}
+
+func Enable(runtime *goja.Runtime, opts ...EnableOption) {
+	var options enableOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	m := require.Require(runtime, ModuleName).ToObject(runtime)
+	runtime.Set("Buffer", m.Get("Buffer"))
+	if options.webGlobals {
+		for _, name := range []string{"Blob", "File", "atob", "btoa", "structuredClone"} {
+			runtime.Set(name, m.Get(name))
+		}
+	}
 }
 
+// Bytes returns the bytes of a Buffer, a TypedArray, a DataView or an ArrayBuffer without copying (see SourceBytes).
+// Other values are converted to a string and returned as UTF-8.
 func Bytes(r *goja.Runtime, v goja.Value) []byte {
+	if data, ok := SourceBytes(r, v); ok {
+		return data
+	}
 	var b []byte
 	err := r.ExportTo(v, &b)
 	if err != nil {
@@ -82,7 +121,7 @@ func DecodeBytes(r *goja.Runtime, arg, enc goja.Value) []byte {
 	case reflectTypeString:
 		var codec StringCodec
 		if !goja.IsUndefined(enc) {
-			codec = stringCodecs[enc.String()]
+			codec = StringCodecByName(enc.String())
 		}
 		if codec == nil {
 			codec = utf8Codec
@@ -98,6 +137,10 @@ func DecodeBytes(r *goja.Runtime, arg, enc goja.Value) []byte {
 	panic(errors.NewTypeError(r, errors.ErrCodeInvalidArgType, "The \"data\" argument must be of type string or an instance of Buffer, TypedArray, or DataView."))
 }
 
+// WrapBytes returns a Buffer backed by data. The data is not copied, i.e. changes made to the Buffer by JS code are
+// visible in the slice and vice versa. The slice must not be accessed concurrently with the runtime.
+// Note that typed arrays of multibyte types created over the Buffer's ArrayBuffer require the slice to be aligned
+// (see goja.Runtime.NewArrayBuffer).
 func WrapBytes(r *goja.Runtime, data []byte) *goja.Object {
 	m := mod(r)
 	if api := api(m); api != nil {
@@ -183,8 +226,7 @@ type base64UrlCodec struct {
 }
 
 func (base64Codec) DecodeAppend(s string, b []byte) []byte {
-	res, _ := Base64DecodeAppend(b, s)
-	return res
+	return base64DecodeLooseAppend(b, s)
 }
 
 func (base64Codec) Encode(b []byte) string {
@@ -192,7 +234,59 @@ func (base64Codec) Encode(b []byte) string {
 }
 
 func (base64UrlCodec) Encode(b []byte) string {
-	return base64.URLEncoding.EncodeToString(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// latin1Codec is used for both "latin1" and "binary". When decoding strings only the lower byte of each UTF-16
+// code unit is used.
+type latin1Codec struct{}
+
+func (latin1Codec) DecodeAppend(s string, b []byte) []byte {
+	for _, r := range s {
+		if r > 0xFFFF {
+			r1, r2 := utf16.EncodeRune(r)
+			b = append(b, byte(r1), byte(r2))
+		} else {
+			b = append(b, byte(r))
+		}
+	}
+	return b
+}
+
+func (latin1Codec) Encode(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+type asciiCodec struct {
+	latin1Codec
+}
+
+func (asciiCodec) Encode(b []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(b))
+	for _, c := range b {
+		sb.WriteByte(c & 0x7f)
+	}
+	return sb.String()
+}
+
+type utf16leCodec struct{}
+
+func (utf16leCodec) DecodeAppend(s string, b []byte) []byte {
+	r, _ := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder().String(s)
+	dst, res := expandSlice(b, len(r))
+	copy(dst, r)
+	return res
+}
+
+func (utf16leCodec) Encode(b []byte) string {
+	// a trailing odd byte is ignored
+	r, _ := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder().Bytes(b[:len(b)&^1])
+	return string(r)
 }
 
 var utf8Codec StringCodec = _utf8Codec{}
@@ -202,7 +296,14 @@ var stringCodecs = map[string]StringCodec{
 	"utf8":      utf8Codec,
 	"utf-8":     utf8Codec,
 	"base64":    base64Codec{},
-	"base64Url": base64UrlCodec{},
+	"base64url": base64UrlCodec{},
+	"latin1":    latin1Codec{},
+	"binary":    latin1Codec{},
+	"ascii":     asciiCodec{},
+	"ucs2":      utf16leCodec{},
+	"ucs-2":     utf16leCodec{},
+	"utf16le":   utf16leCodec{},
+	"utf-16le":  utf16leCodec{},
 }
 
 func expandSlice(b []byte, l int) (dst, res []byte) {
@@ -234,14 +335,102 @@ func Base64DecodeAppend(dst []byte, src string) ([]byte, error) {
 	return res, err
 }
 
-func (b *Buffer) fromString(str, enc string) *goja.Object {
-	codec := stringCodecs[enc]
-	if codec == nil {
-		codec = utf8Codec
+var base64DecodeTable = func() (t [256]byte) {
+	for i := range t {
+		t[i] = 0xff
+	}
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	for i := 0; i < len(alphabet); i++ {
+		t[alphabet[i]] = byte(i)
+	}
+	t['-'], t['_'] = 62, 63
+	return
+}()
+
+// base64DecodeLooseAppend decodes base64 the way nodejs does: both the standard and the URL alphabets are accepted,
+// characters outside the alphabet (such as whitespace) are skipped, and decoding stops at the first '='.
+func base64DecodeLooseAppend(dst []byte, src string) []byte {
+	var acc uint32
+	n := 0
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if c == '=' {
+			break
+		}
+		v := base64DecodeTable[c]
+		if v == 0xff {
+			continue
+		}
+		acc = acc<<6 | uint32(v)
+		n++
+		if n == 4 {
+			dst = append(dst, byte(acc>>16), byte(acc>>8), byte(acc))
+			acc, n = 0, 0
+		}
+	}
+	switch n {
+	case 2:
+		dst = append(dst, byte(acc>>4))
+	case 3:
+		dst = append(dst, byte(acc>>10), byte(acc>>2))
+	}
+	return dst
+}
+
+func (b *Buffer) fromString(str string, enc goja.Value) *goja.Object {
+	codec := utf8Codec
+	if isString(enc) && enc.String() != "" {
+		codec = b.getStringCodec(enc)
 	}
 	return b.fromBytes(codec.DecodeAppend(str, nil))
 }
 
+func (b *Buffer) isEncoding(call goja.FunctionCall) goja.Value {
+	enc := call.Argument(0)
+	return b.r.ToValue(isString(enc) && StringCodecByName(enc.String()) != nil)
+}
+
+func (b *Buffer) btoa(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) == 0 {
+		panic(errors.NewTypeError(b.r, errors.ErrCodeMissingArgs, `The "input" argument must be specified`))
+	}
+	s := call.Argument(0).String()
+	data := make([]byte, 0, len(s))
+	for _, c := range s {
+		if c > 0xff {
+			panic(errors.NewDOMException(b.r, "InvalidCharacterError", "Invalid character"))
+		}
+		data = append(data, byte(c))
+	}
+	return b.r.ToValue(base64.StdEncoding.EncodeToString(data))
+}
+
+// atob implements the forgiving-base64 decode, see https://infra.spec.whatwg.org/#forgiving-base64-decode
+func (b *Buffer) atob(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) == 0 {
+		panic(errors.NewTypeError(b.r, errors.ErrCodeMissingArgs, `The "input" argument must be specified`))
+	}
+	s := strings.Map(func(c rune) rune {
+		switch c {
+		case '\t', '\n', '\f', '\r', ' ':
+			return -1
+		}
+		return c
+	}, call.Argument(0).String())
+	if len(s)%4 == 0 {
+		if strings.HasSuffix(s, "==") {
+			s = s[:len(s)-2]
+		} else if strings.HasSuffix(s, "=") {
+			s = s[:len(s)-1]
+		}
+	}
+	data, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		panic(errors.NewDOMException(b.r, "InvalidCharacterError", "The string to be decoded is not correctly encoded."))
+	}
+	return b.r.ToValue(latin1Codec{}.Encode(data))
+}
+
 func (b *Buffer) fromBytes(data []byte) *goja.Object {
 	o, err := b.uint8ArrayCtor(b.bufferCtorObj, b.r.ToValue(b.r.NewArrayBuffer(data)))
 	if err != nil {
@@ -263,9 +452,9 @@ func (b *Buffer) _from(args ...goja.Value) *goja.Object {
 		}
 		return v
 	case reflectTypeString:
-		var enc string
+		enc := goja.Undefined()
 		if len(args) > 1 {
-			enc = args[1].String()
+			enc = args[1]
 		}
 		return b.fromString(arg.String(), enc)
 	default:
@@ -331,15 +520,20 @@ func isString(v goja.Value) bool {
 	return v.ExportType() == reflectTypeString
 }
 
+// StringCodecByName returns the codec for the given encoding name (case-insensitive) or nil if the encoding
+// is not supported.
 func StringCodecByName(name string) StringCodec {
-	return stringCodecs[name]
+	if codec := stringCodecs[name]; codec != nil {
+		return codec
+	}
+	return stringCodecs[strings.ToLower(name)]
 }
 
 func (b *Buffer) getStringCodec(enc goja.Value) (codec StringCodec) {
 	if !goja.IsUndefined(enc) {
-		codec = stringCodecs[enc.String()]
+		codec = StringCodecByName(enc.String())
 		if codec == nil {
-			panic(errors.NewTypeError(b.r, "ERR_UNKNOWN_ENCODING", "Unknown encoding: %s", enc))
+			panic(errors.NewTypeError(b.r, errors.ErrCodeUnknownEncoding, "Unknown encoding: %s", enc))
 		}
 	} else {
 		codec = utf8Codec
@@ -350,6 +544,9 @@ func (b *Buffer) getStringCodec(enc goja.Value) (codec StringCodec) {
 func (b *Buffer) fill(buf []byte, fill string, enc goja.Value) []byte {
 	codec := b.getStringCodec(enc)
 	b1 := codec.DecodeAppend(fill, buf[:0])
+	if len(b1) == 0 && len(buf) > 0 {
+		panic(errors.NewTypeError(b.r, errors.ErrCodeInvalidArgValue, "The argument 'value' is invalid. Received '%s'", fill))
+	}
 	if len(b1) > len(buf) {
 		return b1[:len(buf)]
 	}
@@ -360,25 +557,20 @@ func (b *Buffer) fill(buf []byte, fill string, enc goja.Value) []byte {
 }
 
 func (b *Buffer) alloc(call goja.FunctionCall) goja.Value {
-	arg0 := call.Argument(0)
-	size := -1
-	if isNumber(arg0) {
-		size = int(arg0.ToInteger())
-	}
-	if size < 0 {
-		panic(errors.NewTypeError(b.r, errors.ErrCodeInvalidArgType, "The \"size\" argument must be of type number."))
-	}
+	size := b.checkSize(call.Argument(0))
 	fill := call.Argument(1)
 	buf := make([]byte, size)
 	if !goja.IsUndefined(fill) {
 		if isString(fill) {
-			var enc goja.Value
-			if a := call.Argument(2); isString(a) {
-				enc = a
-			} else {
-				enc = goja.Undefined()
+			if s := fill.String(); s != "" {
+				var enc goja.Value
+				if a := call.Argument(2); isString(a) {
+					enc = a
+				} else {
+					enc = goja.Undefined()
+				}
+				buf = b.fill(buf, s, enc)
 			}
-			buf = b.fill(buf, fill.String(), enc)
 		} else {
 			fill = fill.ToNumber()
 			if !goja.IsNaN(fill) && !goja.IsInfinity(fill) {
@@ -400,18 +592,760 @@ func (b *Buffer) proto_toString(call goja.FunctionCall) goja.Value {
 	return b.r.ToValue(codec.Encode(bb))
 }
 
+// inspectMaxBytes is the maximum number of bytes shown by util.inspect() (see buffer.INSPECT_MAX_BYTES).
+const inspectMaxBytes = 50
+
+func (b *Buffer) proto_inspect(call goja.FunctionCall) goja.Value {
+	bb := Bytes(b.r, call.This)
+	l := len(bb)
+	if l > inspectMaxBytes {
+		l = inspectMaxBytes
+	}
+	var sb strings.Builder
+	sb.WriteByte('<')
+	if ctor, ok := call.This.ToObject(b.r).Get("constructor").(*goja.Object); ok {
+		sb.WriteString(ctor.Get("name").String())
+	} else {
+		sb.WriteString("Buffer")
+	}
+	sb.WriteByte(' ')
+	for i, c := range bb[:l] {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(hex.EncodeToString([]byte{c}))
+	}
+	if remaining := len(bb) - inspectMaxBytes; remaining > 0 {
+		sb.WriteString(" ... ")
+		sb.WriteString(strconv.Itoa(remaining))
+		sb.WriteString(" more byte")
+		if remaining > 1 {
+			sb.WriteByte('s')
+		}
+	}
+	sb.WriteByte('>')
+	return b.r.ToValue(sb.String())
+}
+
 func (b *Buffer) proto_equals(call goja.FunctionCall) goja.Value {
 	bb := Bytes(b.r, call.This)
 	other := call.Argument(0)
-	if b.r.InstanceOf(other, b.uint8ArrayCtorObj) {
+	if b.isUint8Array(other) {
 		otherBytes := Bytes(b.r, other)
 		return b.r.ToValue(bytes.Equal(bb, otherBytes))
 	}
 	panic(errors.NewTypeError(b.r, errors.ErrCodeInvalidArgType, "The \"otherBuffer\" argument must be an instance of Buffer or Uint8Array."))
 }
 
+// kMaxLength is the maximum size of a Buffer (see buffer.constants.MAX_LENGTH).
+const kMaxLength = 1 << 32
+
+func (b *Buffer) isUint8Array(v goja.Value) bool {
+	return b.types.IsUint8Array(v)
+}
+
+func (b *Buffer) isBuffer(v goja.Value) bool {
+	return b.r.InstanceOf(v, b.bufferCtorObj)
+}
+
+// validateInteger checks that the value is an integer number within [min, max] and returns it as int.
+func (b *Buffer) validateInteger(v goja.Value, name string, min, max int64) int64 {
+	if !isNumber(v) {
+		panic(errors.NewArgTypeError(b.r, name, "of type number", v))
+	}
+	f := v.ToFloat()
+	if f != math.Trunc(f) {
+		panic(errors.NewOutOfRangeError(b.r, name, "an integer", v))
+	}
+	if f < float64(min) || f > float64(max) {
+		panic(errors.NewOutOfRangeError(b.r, name, fmt.Sprintf(">= %d && <= %d", min, max), v))
+	}
+	return int64(f)
+}
+
+// toInteger implements the toInteger() helper of the nodejs buffer module.
+func toInteger(v goja.Value, defaultVal int64) int64 {
+	f := v.ToFloat()
+	if math.IsNaN(f) || f < -(1<<53-1) || f > 1<<53-1 {
+		return defaultVal
+	}
+	return int64(math.Floor(f))
+}
+
+// adjustOffset implements the offset clamping used by Buffer.prototype.slice().
+func adjustOffset(v goja.Value, length int64) int64 {
+	f := math.Trunc(v.ToFloat())
+	if f == 0 || math.IsNaN(f) {
+		return 0
+	}
+	if f < 0 {
+		f += float64(length)
+		if f > 0 {
+			return int64(f)
+		}
+		return 0
+	}
+	if f < float64(length) {
+		return int64(f)
+	}
+	return length
+}
+
+func (b *Buffer) toObject(v goja.Value) *goja.Object {
+	if o, ok := v.(*goja.Object); ok {
+		return o
+	}
+	panic(errors.NewTypeError(b.r, errors.ErrCodeInvalidThis, "Value of \"this\" must be of type Buffer or Uint8Array"))
+}
+
+// newView returns a Buffer that shares the memory of the given Uint8Array.
+func (b *Buffer) newView(arr *goja.Object, start, length int64) *goja.Object {
+	byteOffset := arr.Get("byteOffset").ToInteger()
+	v, err := b.uint8ArrayCtor(b.bufferCtorObj, arr.Get("buffer"), b.r.ToValue(byteOffset+start), b.r.ToValue(length))
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (b *Buffer) checkSize(v goja.Value) int {
+	if !isNumber(v) {
+		panic(errors.NewArgTypeError(b.r, "size", "of type number", v))
+	}
+	size := v.ToFloat()
+	if !(size >= 0 && size <= kMaxLength) {
+		panic(errors.NewOutOfRangeError(b.r, "size", fmt.Sprintf(">= 0 && <= %d", kMaxLength), v))
+	}
+	return int(size)
+}
+
+func (b *Buffer) allocUnsafe(call goja.FunctionCall) goja.Value {
+	return b.fromBytes(make([]byte, b.checkSize(call.Argument(0))))
+}
+
+func (b *Buffer) isBufferFn(call goja.FunctionCall) goja.Value {
+	return b.r.ToValue(b.isBuffer(call.Argument(0)))
+}
+
+func (b *Buffer) byteLength(call goja.FunctionCall) goja.Value {
+	arg := call.Argument(0)
+	if isString(arg) {
+		return b.r.ToValue(len(b.getStringCodec(call.Argument(1)).DecodeAppend(arg.String(), nil)))
+	}
+	if arg.ExportType() == reflectTypeArrayBuffer {
+		return b.r.ToValue(len(arg.Export().(goja.ArrayBuffer).Bytes()))
+	}
+	if o, ok := arg.(*goja.Object); ok {
+		if l := o.Get("byteLength"); l != nil && o.Get("buffer") != nil && isNumber(l) {
+			return l
+		}
+	}
+	panic(errors.NewArgTypeError(b.r, "string", "of type string or an instance of Buffer or ArrayBuffer", arg))
+}
+
+func (b *Buffer) concat(call goja.FunctionCall) goja.Value {
+	list, ok := call.Argument(0).(*goja.Object)
+	if !ok || list.ClassName() != "Array" {
+		panic(errors.NewArgTypeError(b.r, "list", "an instance of Array", call.Argument(0)))
+	}
+	n := int(list.Get("length").ToInteger())
+	if n == 0 {
+		return b.fromBytes([]byte{})
+	}
+	items := make([][]byte, n)
+	total := 0
+	for i := 0; i < n; i++ {
+		item := list.Get(strconv.Itoa(i))
+		if item == nil || !b.isUint8Array(item) {
+			if item == nil {
+				item = goja.Undefined()
+			}
+			panic(errors.NewArgTypeError(b.r, "list["+strconv.Itoa(i)+"]", "an instance of Buffer or Uint8Array", item))
+		}
+		items[i] = Bytes(b.r, item)
+		total += len(items[i])
+	}
+	if arg := call.Argument(1); !goja.IsUndefined(arg) {
+		total = int(b.validateInteger(arg, "length", 0, kMaxLength))
+	}
+	res := make([]byte, total)
+	pos := 0
+	for _, item := range items {
+		if pos >= total {
+			break
+		}
+		pos += copy(res[pos:], item)
+	}
+	return b.fromBytes(res)
+}
+
+func (b *Buffer) compare(call goja.FunctionCall) goja.Value {
+	buf1, buf2 := call.Argument(0), call.Argument(1)
+	if !b.isUint8Array(buf1) {
+		panic(errors.NewArgTypeError(b.r, "buf1", "an instance of Buffer or Uint8Array", buf1))
+	}
+	if !b.isUint8Array(buf2) {
+		panic(errors.NewArgTypeError(b.r, "buf2", "an instance of Buffer or Uint8Array", buf2))
+	}
+	return b.r.ToValue(bytes.Compare(Bytes(b.r, buf1), Bytes(b.r, buf2)))
+}
+
+// rangeArg returns the value of an optional start/end argument of compare().
+func (b *Buffer) rangeArg(v goja.Value, name string, defaultVal int64) int64 {
+	if goja.IsUndefined(v) {
+		return defaultVal
+	}
+	return b.validateInteger(v, name, 0, kMaxLength)
+}
+
+func (b *Buffer) proto_compare(call goja.FunctionCall) goja.Value {
+	target := call.Argument(0)
+	if !b.isUint8Array(target) {
+		panic(errors.NewArgTypeError(b.r, "target", "an instance of Buffer or Uint8Array", target))
+	}
+	src, dst := Bytes(b.r, call.This), Bytes(b.r, target)
+	targetStart := b.rangeArg(call.Argument(1), "targetStart", 0)
+	targetEnd := b.rangeArg(call.Argument(2), "targetEnd", int64(len(dst)))
+	sourceStart := b.rangeArg(call.Argument(3), "sourceStart", 0)
+	sourceEnd := b.rangeArg(call.Argument(4), "sourceEnd", int64(len(src)))
+	if targetEnd > int64(len(dst)) {
+		panic(errors.NewOutOfRangeError(b.r, "targetEnd", fmt.Sprintf(">= 0 && <= %d", len(dst)), call.Argument(2)))
+	}
+	if sourceEnd > int64(len(src)) {
+		panic(errors.NewOutOfRangeError(b.r, "sourceEnd", fmt.Sprintf(">= 0 && <= %d", len(src)), call.Argument(4)))
+	}
+	if sourceStart >= sourceEnd {
+		if targetStart >= targetEnd {
+			return b.r.ToValue(0)
+		}
+		return b.r.ToValue(-1)
+	}
+	if targetStart >= targetEnd {
+		return b.r.ToValue(1)
+	}
+	return b.r.ToValue(bytes.Compare(src[sourceStart:sourceEnd], dst[targetStart:targetEnd]))
+}
+
+func (b *Buffer) proto_copy(call goja.FunctionCall) goja.Value {
+	if !b.isUint8Array(call.This) {
+		panic(errors.NewArgTypeError(b.r, "source", "an instance of Buffer or Uint8Array", call.This))
+	}
+	target := call.Argument(0)
+	if !b.isUint8Array(target) {
+		panic(errors.NewArgTypeError(b.r, "target", "an instance of Buffer or Uint8Array", target))
+	}
+	src, dst := Bytes(b.r, call.This), Bytes(b.r, target)
+
+	var targetStart, sourceStart int64
+	sourceEnd := int64(len(src))
+	if arg := call.Argument(1); !goja.IsUndefined(arg) {
+		if targetStart = toInteger(arg, 0); targetStart < 0 {
+			panic(errors.NewOutOfRangeError(b.r, "targetStart", ">= 0", arg))
+		}
+	}
+	if arg := call.Argument(2); !goja.IsUndefined(arg) {
+		if sourceStart = toInteger(arg, 0); sourceStart < 0 || sourceStart > int64(len(src)) {
+			panic(errors.NewOutOfRangeError(b.r, "sourceStart", fmt.Sprintf(">= 0 && <= %d", len(src)), arg))
+		}
+	}
+	if arg := call.Argument(3); !goja.IsUndefined(arg) {
+		if sourceEnd = toInteger(arg, 0); sourceEnd < 0 {
+			panic(errors.NewOutOfRangeError(b.r, "sourceEnd", ">= 0", arg))
+		}
+		if sourceEnd > int64(len(src)) {
+			sourceEnd = int64(len(src))
+		}
+	}
+	if targetStart >= int64(len(dst)) || sourceStart >= sourceEnd {
+		return b.r.ToValue(0)
+	}
+	return b.r.ToValue(copy(dst[targetStart:], src[sourceStart:sourceEnd]))
+}
+
+func (b *Buffer) proto_fill(call goja.FunctionCall) goja.Value {
+	bb := Bytes(b.r, call.This)
+	value, offsetVal, endVal, enc := call.Argument(0), call.Argument(1), call.Argument(2), call.Argument(3)
+	if isString(value) {
+		if goja.IsUndefined(offsetVal) || isString(offsetVal) {
+			enc, offsetVal, endVal = offsetVal, goja.Undefined(), goja.Undefined()
+		} else if isString(endVal) {
+			enc, endVal = endVal, goja.Undefined()
+		}
+		if !goja.IsUndefined(enc) && !isString(enc) {
+			panic(errors.NewArgTypeError(b.r, "encoding", "of type string", enc))
+		}
+		b.getStringCodec(enc)
+	}
+	offset, end := int64(0), int64(len(bb))
+	if !goja.IsUndefined(offsetVal) {
+		offset = b.validateInteger(offsetVal, "offset", 0, kMaxLength)
+		if !goja.IsUndefined(endVal) {
+			end = b.validateInteger(endVal, "end", 0, int64(len(bb)))
+		}
+		if offset >= end {
+			return call.This
+		}
+	}
+	if offset > end || end > int64(len(bb)) {
+		panic(errors.NewBufferOutOfBoundsError(b.r, ""))
+	}
+	dst := bb[offset:end]
+	switch {
+	case isString(value):
+		if value.String() == "" {
+			fillBytes(dst, 0)
+		} else {
+			copy(dst, b.fill(dst[:len(dst):len(dst)], value.String(), enc))
+		}
+	case b.isUint8Array(value):
+		src := Bytes(b.r, value)
+		if len(src) == 0 {
+			panic(errors.NewTypeError(b.r, errors.ErrCodeInvalidArgValue, "The argument 'value' is invalid. Received %s", errors.DescribeReceived(b.r, value)))
+		}
+		for i := 0; i < len(dst); {
+			i += copy(dst[i:], src)
+		}
+	default:
+		fillBytes(dst, byte(toUint32(value)))
+	}
+	return call.This
+}
+
+func fillBytes(dst []byte, c byte) {
+	for i := range dst {
+		dst[i] = c
+	}
+}
+
+func toUint32(v goja.Value) uint32 {
+	f := v.ToFloat()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return uint32(int64(math.Mod(math.Trunc(f), 1<<32)))
+}
+
+func (b *Buffer) indexOf(call goja.FunctionCall, forward bool) int {
+	bb := Bytes(b.r, call.This)
+	val, byteOffsetVal, enc := call.Argument(0), call.Argument(1), call.Argument(2)
+	if isString(byteOffsetVal) {
+		enc, byteOffsetVal = byteOffsetVal, goja.Undefined()
+	}
+	byteOffset := byteOffsetVal.ToFloat()
+	if byteOffset > math.MaxInt32 {
+		byteOffset = math.MaxInt32
+	} else if byteOffset < math.MinInt32 {
+		byteOffset = math.MinInt32
+	}
+	if math.IsNaN(byteOffset) {
+		if forward {
+			byteOffset = 0
+		} else {
+			byteOffset = float64(len(bb))
+		}
+	}
+
+	var needle []byte
+	switch {
+	case isNumber(val):
+		needle = []byte{byte(toUint32(val))}
+	case isString(val):
+		needle = b.getStringCodec(enc).DecodeAppend(val.String(), nil)
+	case b.isUint8Array(val):
+		needle = Bytes(b.r, val)
+	default:
+		panic(errors.NewArgTypeError(b.r, "value", "one of type number or string or an instance of Buffer or Uint8Array", val))
+	}
+	return searchBytes(bb, needle, int64(byteOffset), forward)
+}
+
+func searchBytes(haystack, needle []byte, offset int64, forward bool) int {
+	length := int64(len(haystack))
+	if offset < 0 {
+		offset += length
+		if offset < 0 {
+			if !forward {
+				return -1
+			}
+			offset = 0
+		}
+	}
+	if len(needle) == 0 {
+		if offset > length {
+			return int(length)
+		}
+		return int(offset)
+	}
+	if forward {
+		if offset >= length {
+			return -1
+		}
+		if idx := bytes.Index(haystack[offset:], needle); idx >= 0 {
+			return idx + int(offset)
+		}
+		return -1
+	}
+	start := length - int64(len(needle))
+	if offset < start {
+		start = offset
+	}
+	if start < 0 {
+		return -1
+	}
+	return bytes.LastIndex(haystack[:start+int64(len(needle))], needle)
+}
+
+func (b *Buffer) proto_indexOf(call goja.FunctionCall) goja.Value {
+	return b.r.ToValue(b.indexOf(call, true))
+}
+
+func (b *Buffer) proto_lastIndexOf(call goja.FunctionCall) goja.Value {
+	return b.r.ToValue(b.indexOf(call, false))
+}
+
+func (b *Buffer) proto_includes(call goja.FunctionCall) goja.Value {
+	return b.r.ToValue(b.indexOf(call, true) != -1)
+}
+
+func (b *Buffer) proto_slice(call goja.FunctionCall) goja.Value {
+	this := b.toObject(call.This)
+	length := int64(len(Bytes(b.r, this)))
+	start := adjustOffset(call.Argument(0), length)
+	end := length
+	if arg := call.Argument(1); !goja.IsUndefined(arg) {
+		end = adjustOffset(arg, length)
+	}
+	newLength := int64(0)
+	if end > start {
+		newLength = end - start
+	}
+	return b.newView(this, start, newLength)
+}
+
+func (b *Buffer) proto_toJSON(call goja.FunctionCall) goja.Value {
+	bb := Bytes(b.r, call.This)
+	data := make([]interface{}, len(bb))
+	for i, c := range bb {
+		data[i] = int64(c)
+	}
+	res := b.r.NewObject()
+	res.Set("type", "Buffer")
+	res.Set("data", b.r.NewArray(data...))
+	return res
+}
+
+func (b *Buffer) swap(size int) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		bb := Bytes(b.r, call.This)
+		if len(bb)%size != 0 {
+			panic(errors.NewRangeError(b.r, "ERR_INVALID_BUFFER_SIZE", "Buffer size must be a multiple of %d-bits", size*8))
+		}
+		for i := 0; i < len(bb); i += size {
+			for j, k := i, i+size-1; j < k; j, k = j+1, k-1 {
+				bb[j], bb[k] = bb[k], bb[j]
+			}
+		}
+		return call.This
+	}
+}
+
+func (b *Buffer) proto_write(call goja.FunctionCall) goja.Value {
+	bb := Bytes(b.r, call.This)
+	str, offsetVal, lengthVal, enc := call.Argument(0), call.Argument(1), call.Argument(2), call.Argument(3)
+	if !isString(str) {
+		panic(errors.NewArgTypeError(b.r, "string", "of type string", str))
+	}
+	offset, length := int64(0), int64(len(bb))
+	if !goja.IsUndefined(offsetVal) {
+		if goja.IsUndefined(lengthVal) && isString(offsetVal) {
+			enc = offsetVal
+		} else {
+			offset = b.validateInteger(offsetVal, "offset", 0, int64(len(bb)))
+			remaining := int64(len(bb)) - offset
+			switch {
+			case goja.IsUndefined(lengthVal):
+				length = remaining
+			case isString(lengthVal):
+				enc, length = lengthVal, remaining
+			default:
+				length = b.validateInteger(lengthVal, "length", 0, int64(len(bb)))
+				if length > remaining {
+					length = remaining
+				}
+			}
+		}
+	}
+	codec := b.getStringCodec(enc)
+	data := codec.DecodeAppend(str.String(), nil)
+	if int64(len(data)) > length {
+		n := length
+		if codec == utf8Codec {
+			// do not write partial characters
+			for n > 0 && !utf8.RuneStart(data[n]) {
+				n--
+			}
+		}
+		data = data[:n]
+	}
+	return b.r.ToValue(copy(bb[offset:], data))
+}
+
+// checkOffset validates the offset argument of the read and write methods and returns it as int.
+func (b *Buffer) checkOffset(offsetVal goja.Value, byteLength, bufLen int) int {
+	if goja.IsUndefined(offsetVal) {
+		offsetVal = b.r.ToValue(0)
+	}
+	if !isNumber(offsetVal) {
+		panic(errors.NewArgTypeError(b.r, "offset", "of type number", offsetVal))
+	}
+	offset := offsetVal.ToFloat()
+	if offset != math.Floor(offset) {
+		panic(errors.NewOutOfRangeError(b.r, "offset", "an integer", offsetVal))
+	}
+	maxOffset := bufLen - byteLength
+	if maxOffset < 0 {
+		panic(errors.NewBufferOutOfBoundsError(b.r, ""))
+	}
+	if offset < 0 || offset > float64(maxOffset) {
+		panic(errors.NewOutOfRangeError(b.r, "offset", fmt.Sprintf(">= 0 and <= %d", maxOffset), offsetVal))
+	}
+	return int(offset)
+}
+
+func (b *Buffer) checkByteLength(byteLengthVal goja.Value) int {
+	if !isNumber(byteLengthVal) {
+		panic(errors.NewArgTypeError(b.r, "byteLength", "of type number", byteLengthVal))
+	}
+	byteLength := byteLengthVal.ToFloat()
+	if byteLength != math.Floor(byteLength) {
+		panic(errors.NewOutOfRangeError(b.r, "byteLength", "an integer", byteLengthVal))
+	}
+	if byteLength < 1 || byteLength > 6 {
+		panic(errors.NewOutOfRangeError(b.r, "byteLength", ">= 1 and <= 6", byteLengthVal))
+	}
+	return int(byteLength)
+}
+
+// checkInt validates the value argument of the integer write methods and returns it truncated to an integer.
+func (b *Buffer) checkInt(value goja.Value, byteLength int, signed bool) int64 {
+	f := value.ToFloat()
+	bits := uint(byteLength * 8)
+	var min, max float64
+	if signed {
+		min, max = -math.Ldexp(1, int(bits-1)), math.Ldexp(1, int(bits-1))-1
+	} else {
+		min, max = 0, math.Ldexp(1, int(bits))-1
+	}
+	if f < min || f > max {
+		var rng string
+		switch {
+		case byteLength <= 4:
+			rng = fmt.Sprintf(">= %s and <= %s", strconv.FormatFloat(min, 'f', -1, 64), strconv.FormatFloat(max, 'f', -1, 64))
+		case signed:
+			rng = fmt.Sprintf(">= -(2 ** %d) and < 2 ** %d", bits-1, bits-1)
+		default:
+			rng = fmt.Sprintf(">= 0 and < 2 ** %d", bits)
+		}
+		panic(errors.NewOutOfRangeError(b.r, "value", rng, b.r.ToValue(f)))
+	}
+	if math.IsNaN(f) {
+		return 0
+	}
+	return int64(f)
+}
+
+func putUintBE(p []byte, v uint64) {
+	for i := len(p) - 1; i >= 0; i-- {
+		p[i] = byte(v)
+		v >>= 8
+	}
+}
+
+func putUintLE(p []byte, v uint64) {
+	for i := range p {
+		p[i] = byte(v)
+		v >>= 8
+	}
+}
+
+func uintBE(p []byte) uint64 {
+	var v uint64
+	for _, c := range p {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func uintLE(p []byte) uint64 {
+	var v uint64
+	for i := len(p) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(p[i])
+	}
+	return v
+}
+
+func signExtend(v uint64, byteLength int) int64 {
+	shift := 64 - uint(byteLength*8)
+	return int64(v<<shift) >> shift
+}
+
+func (b *Buffer) readInt(call goja.FunctionCall, offsetVal goja.Value, byteLength int, littleEndian, signed bool) goja.Value {
+	bb := Bytes(b.r, call.This)
+	offset := b.checkOffset(offsetVal, byteLength, len(bb))
+	p := bb[offset : offset+byteLength]
+	var v uint64
+	if littleEndian {
+		v = uintLE(p)
+	} else {
+		v = uintBE(p)
+	}
+	if signed {
+		return b.r.ToValue(signExtend(v, byteLength))
+	}
+	return b.r.ToValue(int64(v))
+}
+
+func (b *Buffer) writeInt(call goja.FunctionCall, offsetVal goja.Value, byteLength int, littleEndian, signed bool) goja.Value {
+	bb := Bytes(b.r, call.This)
+	if !goja.IsUndefined(offsetVal) && !isNumber(offsetVal) {
+		panic(errors.NewArgTypeError(b.r, "offset", "of type number", offsetVal))
+	}
+	v := b.checkInt(call.Argument(0), byteLength, signed)
+	offset := b.checkOffset(offsetVal, byteLength, len(bb))
+	p := bb[offset : offset+byteLength]
+	if littleEndian {
+		putUintLE(p, uint64(v))
+	} else {
+		putUintBE(p, uint64(v))
+	}
+	return b.r.ToValue(offset + byteLength)
+}
+
+func (b *Buffer) intReader(byteLength int, littleEndian, signed bool) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		return b.readInt(call, call.Argument(0), byteLength, littleEndian, signed)
+	}
+}
+
+func (b *Buffer) intWriter(byteLength int, littleEndian, signed bool) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		return b.writeInt(call, call.Argument(1), byteLength, littleEndian, signed)
+	}
+}
+
+func (b *Buffer) varIntReader(littleEndian, signed bool) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		byteLengthVal := call.Argument(1)
+		if goja.IsUndefined(byteLengthVal) {
+			panic(errors.NewArgTypeError(b.r, "byteLength", "of type number", byteLengthVal))
+		}
+		return b.readInt(call, call.Argument(0), b.checkByteLength(byteLengthVal), littleEndian, signed)
+	}
+}
+
+func (b *Buffer) varIntWriter(littleEndian, signed bool) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		byteLengthVal := call.Argument(2)
+		if goja.IsUndefined(byteLengthVal) {
+			panic(errors.NewArgTypeError(b.r, "byteLength", "of type number", byteLengthVal))
+		}
+		return b.writeInt(call, call.Argument(1), b.checkByteLength(byteLengthVal), littleEndian, signed)
+	}
+}
+
+func (b *Buffer) floatReader(byteLength int, littleEndian bool) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		bb := Bytes(b.r, call.This)
+		offset := b.checkOffset(call.Argument(0), byteLength, len(bb))
+		p := bb[offset : offset+byteLength]
+		var v uint64
+		if littleEndian {
+			v = uintLE(p)
+		} else {
+			v = uintBE(p)
+		}
+		if byteLength == 4 {
+			return b.r.ToValue(float64(math.Float32frombits(uint32(v))))
+		}
+		return b.r.ToValue(math.Float64frombits(v))
+	}
+}
+
+func (b *Buffer) floatWriter(byteLength int, littleEndian bool) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		bb := Bytes(b.r, call.This)
+		offsetVal := call.Argument(1)
+		if !goja.IsUndefined(offsetVal) && !isNumber(offsetVal) {
+			panic(errors.NewArgTypeError(b.r, "offset", "of type number", offsetVal))
+		}
+		f := call.Argument(0).ToFloat()
+		offset := b.checkOffset(offsetVal, byteLength, len(bb))
+		var v uint64
+		if byteLength == 4 {
+			v = uint64(math.Float32bits(float32(f)))
+		} else {
+			v = math.Float64bits(f)
+		}
+		p := bb[offset : offset+byteLength]
+		if littleEndian {
+			putUintLE(p, v)
+		} else {
+			putUintBE(p, v)
+		}
+		return b.r.ToValue(offset + byteLength)
+	}
+}
+
+func (b *Buffer) defineAccessors(proto *goja.Object) {
+	for _, size := range []int{1, 2, 4} {
+		bits := strconv.Itoa(size * 8)
+		for _, e := range []struct {
+			suffix       string
+			littleEndian bool
+		}{{"BE", false}, {"LE", true}} {
+			suffix := e.suffix
+			if size == 1 {
+				if e.littleEndian {
+					continue
+				}
+				suffix = ""
+			}
+			readUInt := b.intReader(size, e.littleEndian, false)
+			writeUInt := b.intWriter(size, e.littleEndian, false)
+			proto.Set("readUInt"+bits+suffix, readUInt)
+			proto.Set("readUint"+bits+suffix, readUInt)
+			proto.Set("writeUInt"+bits+suffix, writeUInt)
+			proto.Set("writeUint"+bits+suffix, writeUInt)
+			proto.Set("readInt"+bits+suffix, b.intReader(size, e.littleEndian, true))
+			proto.Set("writeInt"+bits+suffix, b.intWriter(size, e.littleEndian, true))
+		}
+	}
+
+	for _, e := range []struct {
+		suffix       string
+		littleEndian bool
+	}{{"BE", false}, {"LE", true}} {
+		readUInt := b.varIntReader(e.littleEndian, false)
+		writeUInt := b.varIntWriter(e.littleEndian, false)
+		proto.Set("readUInt"+e.suffix, readUInt)
+		proto.Set("readUint"+e.suffix, readUInt)
+		proto.Set("writeUInt"+e.suffix, writeUInt)
+		proto.Set("writeUint"+e.suffix, writeUInt)
+		proto.Set("readInt"+e.suffix, b.varIntReader(e.littleEndian, true))
+		proto.Set("writeInt"+e.suffix, b.varIntWriter(e.littleEndian, true))
+
+		proto.Set("readFloat"+e.suffix, b.floatReader(4, e.littleEndian))
+		proto.Set("writeFloat"+e.suffix, b.floatWriter(4, e.littleEndian))
+		proto.Set("readDouble"+e.suffix, b.floatReader(8, e.littleEndian))
+		proto.Set("writeDouble"+e.suffix, b.floatWriter(8, e.littleEndian))
+	}
+
+	// readBigInt64*, readBigUInt64* and their write counterparts are not provided because the runtime
+	// does not support BigInt.
+}
+
 func Require(runtime *goja.Runtime, module *goja.Object) {
-	b := &Buffer{r: runtime}
+	b := &Buffer{r: runtime, types: types.New(runtime)}
 	uint8Array := runtime.Get("Uint8Array")
 	if c, ok := goja.AssertConstructor(uint8Array); ok {
 		b.uint8ArrayCtor = c
@@ -421,6 +1355,7 @@ func Require(runtime *goja.Runtime, module *goja.Object) {
 	uint8ArrayObj := uint8Array.ToObject(runtime)
 
 	ctor := runtime.ToValue(b.ctor).ToObject(runtime)
+	ctor.DefineDataProperty("name", runtime.ToValue("Buffer"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
 	ctor.SetPrototype(uint8ArrayObj)
 	ctor.DefineDataPropertySymbol(symApi, runtime.ToValue(b), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
 	b.bufferCtorObj = ctor
@@ -431,14 +1366,48 @@ func Require(runtime *goja.Runtime, module *goja.Object) {
 	proto.DefineDataProperty("constructor", ctor, goja.FLAG_TRUE, goja.FLAG_TRUE, goja.FLAG_FALSE)
 	proto.Set("equals", b.proto_equals)
 	proto.Set("toString", b.proto_toString)
+	proto.Set("compare", b.proto_compare)
+	proto.Set("copy", b.proto_copy)
+	proto.Set("fill", b.proto_fill)
+	proto.Set("indexOf", b.proto_indexOf)
+	proto.Set("lastIndexOf", b.proto_lastIndexOf)
+	proto.Set("includes", b.proto_includes)
+	proto.Set("slice", b.proto_slice)
+	proto.Set("subarray", b.proto_slice)
+	proto.Set("toJSON", b.proto_toJSON)
+	proto.Set("swap16", b.swap(2))
+	proto.Set("swap32", b.swap(4))
+	proto.Set("swap64", b.swap(8))
+	proto.Set("write", b.proto_write)
+	b.defineAccessors(proto)
+	if symFor, ok := goja.AssertFunction(runtime.Get("Symbol").ToObject(runtime).Get("for")); ok {
+		if sym, err := symFor(nil, runtime.ToValue("nodejs.util.inspect.custom")); err == nil {
+			proto.SetSymbol(sym.(*goja.Symbol), b.proto_inspect)
+		}
+	}
 
 	ctor.Set("prototype", proto)
 	ctor.Set("poolSize", 8192)
 	ctor.Set("from", b.from)
 	ctor.Set("alloc", b.alloc)
+	ctor.Set("allocUnsafe", b.allocUnsafe)
+	ctor.Set("allocUnsafeSlow", b.allocUnsafe)
+	ctor.Set("byteLength", b.byteLength)
+	ctor.Set("compare", b.compare)
+	ctor.Set("concat", b.concat)
+	ctor.Set("isBuffer", b.isBufferFn)
+	ctor.Set("isEncoding", b.isEncoding)
+
+	blobCtor := b.createBlobConstructor()
 
 	exports := module.Get("exports").(*goja.Object)
 	exports.Set("Buffer", ctor)
+	exports.Set("Blob", blobCtor)
+	exports.Set("File", b.createFileConstructor(blobCtor))
+	exports.Set("atob", b.atob)
+	exports.Set("btoa", b.btoa)
+	exports.Set("structuredClone", b.structuredClone)
+	exports.Set("INSPECT_MAX_BYTES", inspectMaxBytes)
 }
 
 func init() {
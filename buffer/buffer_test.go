@@ -1,6 +1,10 @@
 package buffer
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/nuvolaris/goja"
@@ -220,3 +224,513 @@ func TestBuffer_alloc(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestBuffer_readWrite(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunString(`
+	const assert = require("../assert.js");
+	const Buffer = require("node:buffer").Buffer;
+
+	{
+		const b = Buffer.from([1, 2, 3, 4, 5, 6, 7, 0xff]);
+		assert.sameValue(b.readUInt8(0), 1);
+		assert.sameValue(b.readUint8(7), 255);
+		assert.sameValue(b.readInt8(7), -1);
+		assert.sameValue(b.readUInt16BE(0), 0x0102);
+		assert.sameValue(b.readUInt16LE(0), 0x0201);
+		assert.sameValue(b.readUInt32BE(0), 0x01020304);
+		assert.sameValue(b.readUInt32LE(4), 0xff070605);
+		assert.sameValue(b.readInt32LE(4), -16316923);
+		assert.sameValue(b.readInt16BE(6), 0x07ff);
+		assert.sameValue(b.readUIntBE(0, 6), 0x010203040506);
+		assert.sameValue(b.readUIntLE(5, 3), 0xff0706);
+		assert.sameValue(b.readIntLE(5, 3), -63738);
+		assert.sameValue(b.readIntBE(7, 1), -1);
+	}
+
+	{
+		const b = Buffer.alloc(8);
+		assert.sameValue(b.writeUInt16BE(0xabcd, 1), 3);
+		assert.sameValue(b.toString("hex"), "00abcd0000000000");
+		assert.sameValue(b.writeInt32LE(-2, 4), 8);
+		assert.sameValue(b.toString("hex"), "00abcd00feffffff");
+		assert.sameValue(b.writeUIntBE(0x123456789a, 0, 5), 5);
+		assert.sameValue(b.readUIntBE(0, 5), 0x123456789a);
+		assert.sameValue(b.writeIntLE(-0x123456, 2, 3), 5);
+		assert.sameValue(b.readIntLE(2, 3), -0x123456);
+		b.writeUInt8(1.9);
+		assert.sameValue(b[0], 1);
+	}
+
+	{
+		const b = Buffer.alloc(8);
+		assert.sameValue(b.writeDoubleBE(1.5), 8);
+		assert.sameValue(b.toString("hex"), "3ff8000000000000");
+		assert.sameValue(b.readDoubleBE(0), 1.5);
+		b.writeDoubleLE(-0.1);
+		assert.sameValue(b.readDoubleLE(0), -0.1);
+		assert.sameValue(b.writeFloatLE(0.5, 4), 8);
+		assert.sameValue(b.readFloatLE(4), 0.5);
+		b.writeFloatBE(NaN);
+		assert.sameValue(b.readFloatBE(), NaN);
+	}
+
+	{
+		const b = Buffer.alloc(4);
+		assert.throwsNodeError(() => b.readUInt32BE(1), RangeError, "ERR_OUT_OF_RANGE");
+		assert.throwsNodeError(() => b.readUInt8(1.5), RangeError, "ERR_OUT_OF_RANGE");
+		assert.throwsNodeError(() => b.readUInt8("1"), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.throwsNodeError(() => b.readDoubleLE(0), RangeError, "ERR_BUFFER_OUT_OF_BOUNDS");
+		assert.throwsNodeError(() => b.writeUInt8(256), RangeError, "ERR_OUT_OF_RANGE");
+		assert.throwsNodeError(() => b.writeInt16LE(-32769), RangeError, "ERR_OUT_OF_RANGE");
+		assert.throwsNodeError(() => b.readUIntBE(0), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.throwsNodeError(() => b.readUIntBE(0, 7), RangeError, "ERR_OUT_OF_RANGE");
+
+		function assertMessage(f, message) {
+			try {
+				f();
+			} catch (e) {
+				assert.sameValue(e.message, message);
+				return;
+			}
+			throw new Error("No exception was thrown");
+		}
+		assertMessage(() => b.writeUInt32BE(-1), 'The value of "value" is out of range. It must be >= 0 and <= 4294967295. Received -1');
+		assertMessage(() => b.readUInt16LE(3), 'The value of "offset" is out of range. It must be >= 0 and <= 2. Received 3');
+		assertMessage(() => b.writeUIntBE(2 ** 48, 0, 6), 'The value of "value" is out of range. It must be >= 0 and < 2 ** 48. Received 281_474_976_710_656');
+	}
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuffer_operations(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunString(`
+	const assert = require("../assert.js");
+	const Buffer = require("node:buffer").Buffer;
+
+	// concat
+	{
+		const b = Buffer.concat([Buffer.from("ab"), new Uint8Array([0x63]), Buffer.from("de")]);
+		assert.sameValue(b.toString(), "abcde");
+		assert.sameValue(Buffer.concat([Buffer.from("abc")], 2).toString(), "ab");
+		assert.sameValue(Buffer.concat([Buffer.from("ab")], 4).toString("hex"), "61620000");
+		assert.sameValue(Buffer.concat([]).length, 0);
+		assert.throwsNodeError(() => Buffer.concat("abc"), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.throwsNodeError(() => Buffer.concat(["abc"]), TypeError, "ERR_INVALID_ARG_TYPE");
+	}
+
+	// compare, isBuffer, byteLength, allocUnsafe
+	{
+		assert.sameValue(Buffer.compare(Buffer.from("a"), Buffer.from("b")), -1);
+		assert.sameValue(Buffer.compare(Buffer.from("b"), Buffer.from("a")), 1);
+		assert.sameValue(Buffer.from("abc").compare(Buffer.from("abc")), 0);
+		assert.sameValue(Buffer.from("xbc").compare(Buffer.from("abcd"), 1, 3, 1), 0);
+		assert.throwsNodeError(() => Buffer.compare("a", Buffer.from("b")), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.throwsNodeError(() => Buffer.compare(Object.create(Uint8Array.prototype), Buffer.from("b")), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.sameValue(Buffer.from("a").equals(new Uint8Array([0x61])), true);
+		assert.throwsNodeError(() => Buffer.from("a").equals(new Uint8ClampedArray([0x61])), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.sameValue(Buffer.isBuffer(Buffer.alloc(1)), true);
+		assert.sameValue(Buffer.isBuffer(new Uint8Array(1)), false);
+		assert.sameValue(Buffer.byteLength("é"), 2);
+		assert.sameValue(Buffer.byteLength("abcd", "hex"), 2);
+		assert.sameValue(Buffer.byteLength(new ArrayBuffer(5)), 5);
+		assert.sameValue(Buffer.byteLength(new Uint16Array(3)), 6);
+		assert.throwsNodeError(() => Buffer.byteLength(5), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.sameValue(Buffer.allocUnsafe(3).length, 3);
+		assert.throwsNodeError(() => Buffer.allocUnsafe(-1), RangeError, "ERR_OUT_OF_RANGE");
+		assert.throwsNodeError(() => Buffer.alloc("1"), TypeError, "ERR_INVALID_ARG_TYPE");
+	}
+
+	// slice and subarray share memory
+	{
+		const b = Buffer.from("abcdef");
+		const s = b.slice(1, -1);
+		assert.sameValue(s instanceof Buffer, true);
+		assert.sameValue(s.toString(), "bcde");
+		s[0] = 0x42;
+		assert.sameValue(b.toString(), "aBcdef");
+		const s1 = s.subarray(2);
+		assert.sameValue(s1.toString(), "de");
+		s1[1] = 0x45;
+		assert.sameValue(b.toString(), "aBcdEf");
+		assert.sameValue(b.slice(10).length, 0);
+		assert.sameValue(b.slice(-2).toString(), "Ef");
+		assert.sameValue(s1.readUInt16BE(0), 0x6445);
+	}
+
+	// copy
+	{
+		const src = Buffer.from("abcdef");
+		const dst = Buffer.alloc(4, ".");
+		assert.sameValue(src.copy(dst, 1, 2), 3);
+		assert.sameValue(dst.toString(), ".cde");
+		assert.sameValue(src.copy(dst, 10), 0);
+		assert.sameValue(src.copy(src, 0, 2), 4);
+		assert.sameValue(src.toString(), "cdefef");
+		assert.throwsNodeError(() => src.copy(dst, -1), RangeError, "ERR_OUT_OF_RANGE");
+		assert.throwsNodeError(() => src.copy("abc"), TypeError, "ERR_INVALID_ARG_TYPE");
+	}
+
+	// fill
+	{
+		const b = Buffer.alloc(6);
+		assert.sameValue(b.fill("ab"), b);
+		assert.sameValue(b.toString(), "ababab");
+		b.fill(0x7a, 2, 4);
+		assert.sameValue(b.toString(), "abzzab");
+		b.fill("6869", 4, "hex");
+		assert.sameValue(b.toString(), "abzzhi");
+		b.fill(Buffer.from("xy"), 1);
+		assert.sameValue(b.toString(), "axyxyx");
+		b.fill("");
+		assert.sameValue(b.toString("hex"), "000000000000");
+		assert.throwsNodeError(() => b.fill("zz", "hex"), TypeError, "ERR_INVALID_ARG_VALUE");
+		assert.throwsNodeError(() => b.fill("a", 0, 7), RangeError, "ERR_OUT_OF_RANGE");
+		assert.throwsNodeError(() => b.fill("a", "bad"), TypeError, "ERR_UNKNOWN_ENCODING");
+	}
+
+	// indexOf, lastIndexOf, includes
+	{
+		const b = Buffer.from("this is a buffer");
+		assert.sameValue(b.indexOf("this"), 0);
+		assert.sameValue(b.indexOf("is"), 2);
+		assert.sameValue(b.indexOf("is", 3), 5);
+		assert.sameValue(b.indexOf(Buffer.from("a buffer")), 8);
+		assert.sameValue(b.indexOf(97), 8);
+		assert.sameValue(b.indexOf(97 + 256), 8);
+		assert.sameValue(b.indexOf("zz"), -1);
+		assert.sameValue(b.indexOf("is", -4), -1);
+		assert.sameValue(b.indexOf("", 100), 16);
+		assert.sameValue(b.indexOf("6973", "hex"), 2);
+		assert.sameValue(b.lastIndexOf("is"), 5);
+		assert.sameValue(b.lastIndexOf("is", 4), 2);
+		assert.sameValue(b.lastIndexOf("is", -12), 2);
+		assert.sameValue(b.lastIndexOf("is", -20), -1);
+		assert.sameValue(b.lastIndexOf(0x72), 15);
+		assert.sameValue(b.includes("buf"), true);
+		assert.sameValue(b.includes("buf", 11), false);
+		assert.throwsNodeError(() => b.indexOf({}), TypeError, "ERR_INVALID_ARG_TYPE");
+	}
+
+	// toJSON, swap
+	{
+		const b = Buffer.from([1, 2, 3, 4, 5, 6, 7, 8]);
+		assert.sameValue(JSON.stringify(b.subarray(0, 3)), '{"type":"Buffer","data":[1,2,3]}');
+		assert.sameValue(b.swap16().toString("hex"), "0201040306050807");
+		assert.sameValue(b.swap16().swap32().toString("hex"), "0403020108070605");
+		assert.sameValue(b.swap32().swap64().toString("hex"), "0807060504030201");
+		assert.throwsNodeError(() => Buffer.alloc(3).swap16(), RangeError, "ERR_INVALID_BUFFER_SIZE");
+	}
+
+	// write
+	{
+		const b = Buffer.alloc(6);
+		assert.sameValue(b.write("abc"), 3);
+		assert.sameValue(b.write("xyz", 4), 2);
+		assert.sameValue(b.toString("hex"), "61626300" + "7879");
+		assert.sameValue(b.write("éé", 1, 3), 2);
+		assert.sameValue(b.toString("hex"), "61c3a900" + "7879");
+		assert.sameValue(b.write("ffee", 2, "hex"), 2);
+		assert.sameValue(b.toString("hex"), "61c3ffee7879");
+		assert.sameValue(b.write("0102", 4, 1, "hex"), 1);
+		assert.sameValue(b.toString("hex"), "61c3ffee0179");
+		assert.throwsNodeError(() => b.write("a", 7), RangeError, "ERR_OUT_OF_RANGE");
+		assert.throwsNodeError(() => b.write(1), TypeError, "ERR_INVALID_ARG_TYPE");
+	}
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuffer_encodings(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunString(`
+	const assert = require("../assert.js");
+	const Buffer = require("node:buffer").Buffer;
+
+	{
+		const b = Buffer.from("aé€", "latin1");
+		assert.sameValue(b.toString("hex"), "61e9ac");
+		assert.sameValue(b.toString("latin1"), "aé¬");
+		assert.sameValue(Buffer.from("aé", "binary").toString("binary"), "aé");
+		assert.sameValue(Buffer.from("💩", "latin1").toString("hex"), "3da9");
+	}
+
+	{
+		const b = Buffer.from("aé", "ascii");
+		assert.sameValue(b.toString("hex"), "61e9");
+		assert.sameValue(b.toString("ascii"), "ai");
+	}
+
+	{
+		const b = Buffer.from("a€💩", "utf16le");
+		assert.sameValue(b.toString("hex"), "6100ac203dd8a9dc");
+		assert.sameValue(b.toString("ucs2"), "a€💩");
+		assert.sameValue(Buffer.from("6100ac", "hex").toString("utf-16le"), "a");
+		assert.sameValue(Buffer.from("a", "UCS-2").length, 2);
+	}
+
+	{
+		assert.sameValue(Buffer.from("aGVs bG8=", "base64").toString(), "hello");
+		assert.sameValue(Buffer.from("aGVsbG8", "base64").toString(), "hello");
+		assert.sameValue(Buffer.from("aGVs\nbG8h\n", "base64").toString(), "hello!");
+		assert.sameValue(Buffer.from("SQ==QQ==", "base64").toString(), "I");
+		assert.sameValue(Buffer.from([0xfb, 0xff]).toString("base64url"), "-_8");
+		assert.sameValue(Buffer.from("-_8", "base64url").toString("hex"), "fbff");
+		assert.sameValue(Buffer.from("+/8=", "base64url").toString("hex"), "fbff");
+	}
+
+	{
+		assert.sameValue(Buffer.from("abc", "UTF8").toString("HEX"), "616263");
+		assert.sameValue(Buffer.from("abc", "").toString(), "abc");
+		assert.sameValue(Buffer.from("abc", undefined).toString(), "abc");
+		assert.throwsNodeError(() => Buffer.from("abc", "utf9"), TypeError, "ERR_UNKNOWN_ENCODING");
+		assert.throwsNodeError(() => Buffer.from("abc").toString("utf9"), TypeError, "ERR_UNKNOWN_ENCODING");
+	}
+
+	{
+		for (const enc of ["utf8", "UTF-8", "hex", "base64", "Base64url", "latin1", "binary", "ascii", "ucs2", "ucs-2", "utf16le", "utf-16le"]) {
+			assert.sameValue(Buffer.isEncoding(enc), true, enc);
+		}
+		for (const enc of ["", "utf9", 1, undefined, null]) {
+			assert.sameValue(Buffer.isEncoding(enc), false, String(enc));
+		}
+	}
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuffer_webAPIs(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+	Enable(vm, WithWebGlobals(true))
+
+	_, err := vm.RunString(`
+	const assert = require("../assert.js");
+	var results = [];
+
+	{
+		const b = new Blob(["ab", new Uint8Array([0x63, 0x64]), new Blob(["€"])], {type: "Text/Plain"});
+		assert.sameValue(b.size, 7);
+		assert.sameValue(b.type, "text/plain");
+		assert.sameValue(Object.prototype.toString.call(b), "[object Blob]");
+		assert.sameValue(new Blob().size, 0);
+		assert.sameValue(new Blob([], {type: "aé"}).type, "");
+		const s = b.slice(1, -3, "x/Y");
+		assert.sameValue(s.size, 3);
+		assert.sameValue(s.type, "x/y");
+		assert.sameValue(s instanceof Blob, true);
+		s.text().then(t => results.push(t));
+		b.arrayBuffer().then(ab => results.push(ab.byteLength));
+		const reader = b.stream().getReader();
+		reader.read().then(r => {
+			results.push(r.value.length, r.done);
+			return reader.read();
+		}).then(r => results.push(r.done));
+		assert.throwsNodeError(() => new Blob("str"), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.throwsNodeError(() => new Blob([], {endings: "x"}), TypeError, "ERR_INVALID_ARG_VALUE");
+		assert.throwsNodeError(() => Blob.prototype.slice.call({}), TypeError, "ERR_INVALID_THIS");
+	}
+
+	{
+		const f = new File(["data"], "a.txt", {type: "text/plain", lastModified: 42});
+		assert.sameValue(f instanceof Blob, true);
+		assert.sameValue(f.name, "a.txt");
+		assert.sameValue(f.lastModified, 42);
+		assert.sameValue(f.size, 4);
+		assert.sameValue(Object.prototype.toString.call(f), "[object File]");
+		assert.sameValue(typeof new File([], "b").lastModified, "number");
+		assert.throwsNodeError(() => new File([]), TypeError, "ERR_MISSING_ARGS");
+	}
+
+	{
+		assert.sameValue(btoa("hello\xff"), "aGVsbG//");
+		assert.sameValue(atob(" aGVs bG//\n"), "hello\xff");
+		assert.sameValue(atob("YQ"), "a");
+		assert.sameValue(atob("YQ=="), "a");
+		for (const s of ["YQ=", "Y", "Y*Q="]) {
+			try {
+				atob(s);
+				throw new Error("no exception for " + s);
+			} catch (e) {
+				assert.sameValue(e.name, "InvalidCharacterError");
+			}
+		}
+		try {
+			btoa("€");
+			throw new Error("no exception");
+		} catch (e) {
+			assert.sameValue(e.name, "InvalidCharacterError");
+		}
+	}
+
+	{
+		const o = {
+			n: 1, s: "str", arr: [1, , 3], d: new Date(1000), re: /a+/gi,
+			m: new Map([[{k: 1}, "v"]]), set: new Set([1, 2]),
+			u8: new Uint8Array([1, 2, 3]).subarray(1), i16: new Int16Array([-1]),
+			dv: new DataView(new ArrayBuffer(4), 1), buf: Buffer.from("ab"),
+			err: new RangeError("bad"), num: new Number(5), blob: new Blob(["x"]),
+		};
+		o.self = o;
+		const c = structuredClone(o);
+		assert.sameValue(c === o, false);
+		assert.sameValue(c.self, c);
+		assert.sameValue(c.arr.length, 3);
+		assert.sameValue(1 in c.arr, false);
+		assert.sameValue(c.d instanceof Date && c.d.getTime(), 1000);
+		assert.sameValue(c.re instanceof RegExp && c.re.flags, "gi");
+		assert.sameValue(c.m instanceof Map && [...c.m.keys()][0].k, 1);
+		assert.sameValue(c.set instanceof Set && c.set.has(2), true);
+		assert.sameValue(c.u8 instanceof Uint8Array && c.u8.join(), "2,3");
+		assert.sameValue(c.u8.buffer.byteLength, 3);
+		assert.sameValue(c.i16 instanceof Int16Array && c.i16[0], -1);
+		assert.sameValue(c.dv instanceof DataView && c.dv.byteOffset, 1);
+		assert.sameValue(c.buf instanceof Uint8Array && c.buf.join(), "97,98");
+		assert.sameValue(c.err instanceof RangeError && c.err.message, "bad");
+		assert.sameValue(typeof c.num === "object" && c.num.valueOf(), 5);
+		assert.sameValue(c.blob instanceof Blob && c.blob.size, 1);
+		assert.sameValue(structuredClone("s"), "s");
+
+		for (const v of [function() {}, Symbol("s"), new WeakMap(), Promise.resolve()]) {
+			try {
+				structuredClone(v);
+				throw new Error("no exception");
+			} catch (e) {
+				assert.sameValue(e.name, "DataCloneError");
+			}
+		}
+
+		const ab = new Uint8Array([1, 2]).buffer;
+		const t = structuredClone({ab}, {transfer: [ab]});
+		assert.sameValue(t.ab.byteLength, 2);
+		assert.sameValue(ab.byteLength, 0);
+	}
+
+	assert.sameValue(require("buffer").Blob, Blob);
+	results;
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := vm.Get("results").Export()
+	if s := fmt.Sprint(res); s != "[bcd 7 7 false true]" {
+		t.Fatal(s)
+	}
+}
+
+func TestBuffer_interop(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	data := []byte("hello")
+	vm.Set("shared", WrapBytes(vm, data))
+	_, err := vm.RunString(`shared[0] = 0x48;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "Hello" {
+		t.Fatalf("WrapBytes copied the data: %q", data)
+	}
+
+	v, err := vm.RunString(`new DataView(shared.buffer, 1, 3)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, ok := SourceBytes(vm, v)
+	if !ok || string(view) != "ell" {
+		t.Fatalf("SourceBytes: %q, %v", view, ok)
+	}
+	view[0] = 'E'
+	if string(data) != "HEllo" || string(Bytes(vm, v)) != "Ell" {
+		t.Fatalf("SourceBytes copied the data: %q", data)
+	}
+	if _, ok := SourceBytes(vm, vm.ToValue("str")); ok {
+		t.Fatal("SourceBytes accepted a string")
+	}
+
+	// The view properties a script redefines are not trusted.
+	vm.Set("sourceBytes", func(v goja.Value) int {
+		data, _ := SourceBytes(vm, v)
+		return len(data)
+	})
+	b, err := vm.RunString(`
+	{
+		const assert = require("../assert.js");
+		const b = Buffer.from([1, 2, 3, 4]);
+		Object.defineProperty(b, "byteLength", {get() { return 1e6; }});
+		assert.throwsNodeError(() => b.readUInt8(100), RangeError, "ERR_OUT_OF_RANGE");
+		assert.sameValue(b.readUInt8(3), 4);
+		const i16 = new Int16Array(2);
+		Object.defineProperty(i16, "byteLength", {get() { return 1e6; }});
+		assert.throwsNodeError(() => sourceBytes(i16), RangeError, "ERR_BUFFER_OUT_OF_BOUNDS");
+		b
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view, ok := SourceBytes(vm, b); !ok || len(view) != 4 {
+		t.Fatalf("SourceBytes: %v, %v", view, ok)
+	}
+
+	var out bytes.Buffer
+	if _, err := io.Copy(&out, NewReader(vm, v)); err != nil || out.String() != "Ell" {
+		t.Fatalf("NewReader: %q, %v", out.String(), err)
+	}
+
+	out.Reset()
+	vm.Set("reader", WrapReader(vm, strings.NewReader("abcdefgh"), 3))
+	vm.Set("bigReader", WrapReader(vm, strings.NewReader("abcde"), 3))
+	vm.Set("writer", WrapWriter(vm, &out))
+	_, err = vm.RunString(`
+	const assert = require("../assert.js");
+
+	const chunks = [];
+	for (const chunk of reader) {
+		assert.sameValue(Buffer.isBuffer(chunk), true);
+		chunks.push(chunk.toString());
+		writer.write(chunk);
+		if (chunks.length === 2) {
+			break;
+		}
+	}
+	assert.sameValue(chunks.join(), "abc,def");
+	assert.sameValue(reader.read(1).toString(), "g");
+	assert.sameValue(reader.readAll().toString(), "h");
+	assert.sameValue(reader.read(), null);
+	assert.sameValue(writer.write("€", "utf8"), 3);
+	assert.sameValue(writer.write(new Uint16Array([0x6968])), 2);
+	writer.close();
+	assert.throwsNodeError(() => reader.read(0), RangeError, "ERR_OUT_OF_RANGE");
+	assert.throwsNodeError(() => reader.read(-1), RangeError, "ERR_OUT_OF_RANGE");
+	assert.throwsNodeError(() => reader.read(1.5), RangeError, "ERR_OUT_OF_RANGE");
+	assert.throwsNodeError(() => reader.read(NaN), RangeError, "ERR_OUT_OF_RANGE");
+	assert.throwsNodeError(() => reader.read("1"), TypeError, "ERR_INVALID_ARG_TYPE");
+	assert.sameValue(bigReader.read(1e10).toString(), "abc");
+	assert.sameValue(bigReader.read(2).toString(), "de");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := out.String(); s != "abcdef€hi" {
+		t.Fatalf("WrapWriter: %q", s)
+	}
+}
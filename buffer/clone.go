@@ -0,0 +1,220 @@
+package buffer
+
+import (
+	"reflect"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+var (
+	reflectTypeMap     = reflect.TypeOf([][2]interface{}(nil))
+	reflectTypeSet     = reflect.TypeOf([]interface{}(nil))
+	reflectTypeProxy   = reflect.TypeOf(goja.Proxy{})
+	reflectTypePromise = reflect.TypeOf((*goja.Promise)(nil))
+)
+
+var errorCtorNames = map[string]bool{
+	"Error":          true,
+	"EvalError":      true,
+	"RangeError":     true,
+	"ReferenceError": true,
+	"SyntaxError":    true,
+	"TypeError":      true,
+	"URIError":       true,
+}
+
+type cloner struct {
+	b    *Buffer
+	r    *goja.Runtime
+	memo map[*goja.Object]*goja.Object
+
+	objectToString goja.Callable
+}
+
+func (c *cloner) dataCloneError(what string) *goja.Object {
+	return errors.NewDOMException(c.r, "DataCloneError", "%s could not be cloned.", what)
+}
+
+func (c *cloner) toStringTag(o *goja.Object) string {
+	res, err := c.objectToString(o)
+	if err != nil {
+		panic(err)
+	}
+	s := res.String()
+	return s[len("[object ") : len(s)-1]
+}
+
+func (c *cloner) construct(name string, args ...goja.Value) *goja.Object {
+	o, err := c.r.New(c.r.Get(name), args...)
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+func (c *cloner) clone(v goja.Value) goja.Value {
+	o, ok := v.(*goja.Object)
+	if !ok {
+		if _, isSym := v.(*goja.Symbol); isSym {
+			panic(c.dataCloneError(v.String()))
+		}
+		return v
+	}
+	if res := c.memo[o]; res != nil {
+		return res
+	}
+	if _, isFunc := goja.AssertFunction(o); isFunc {
+		panic(c.dataCloneError(o.String()))
+	}
+
+	var res *goja.Object
+	switch o.ClassName() {
+	case "Array":
+		res = c.r.NewArray()
+		c.memo[o] = res
+		res.Set("length", o.Get("length"))
+		c.copyProperties(o, res)
+		return res
+	case "Date":
+		res = c.construct("Date", o.ToNumber())
+	case "RegExp":
+		res = c.construct("RegExp", o.Get("source"), o.Get("flags"))
+	case "Error":
+		name := o.Get("name").String()
+		if !errorCtorNames[name] {
+			name = "Error"
+		}
+		res = c.construct(name, o.Get("message"))
+		if stack := o.Get("stack"); stack != nil && !goja.IsUndefined(stack) {
+			res.DefineDataProperty("stack", c.r.ToValue(stack.String()), goja.FLAG_TRUE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+		}
+	case "Number":
+		res = c.r.ToValue(o.ToNumber()).ToObject(c.r)
+	case "Boolean":
+		res = c.r.ToValue(o.Export()).ToObject(c.r)
+	case "String":
+		res = c.r.ToValue(o.String()).ToObject(c.r)
+	}
+	if res != nil {
+		c.memo[o] = res
+		return res
+	}
+
+	switch o.ExportType() {
+	case reflectTypeArrayBuffer:
+		data := o.Export().(goja.ArrayBuffer).Bytes()
+		res = c.r.ToValue(c.r.NewArrayBuffer(append([]byte(nil), data...))).(*goja.Object)
+		c.memo[o] = res
+		return res
+	case reflectTypeBlob:
+		res = c.b.wrapBlob(o.Export().(*blob))
+		c.memo[o] = res
+		return res
+	case reflectTypeProxy, reflectTypePromise:
+		panic(c.dataCloneError("#<" + c.toStringTag(o) + ">"))
+	}
+
+	tag := c.toStringTag(o)
+	switch {
+	case o.ExportType() == reflectTypeMap && tag == "Map":
+		res = c.construct("Map")
+		c.memo[o] = res
+		set, _ := goja.AssertFunction(res.Get("set"))
+		c.forEach(o, func(value, key goja.Value) {
+			if _, err := set(res, c.clone(key), c.clone(value)); err != nil {
+				panic(err)
+			}
+		})
+		return res
+	case o.ExportType() == reflectTypeSet && tag == "Set":
+		res = c.construct("Set")
+		c.memo[o] = res
+		add, _ := goja.AssertFunction(res.Get("add"))
+		c.forEach(o, func(value, _ goja.Value) {
+			if _, err := add(res, c.clone(value)); err != nil {
+				panic(err)
+			}
+		})
+		return res
+	}
+
+	if _, isView := SourceBytes(c.r, o); isView {
+		buf := c.clone(o.Get("buffer"))
+		length := o.Get("length")
+		if tag == "DataView" {
+			length = o.Get("byteLength")
+		}
+		res = c.construct(tag, buf, o.Get("byteOffset"), length)
+		c.memo[o] = res
+		return res
+	}
+
+	if tag != "Object" {
+		panic(c.dataCloneError("#<" + tag + ">"))
+	}
+	res = c.r.NewObject()
+	c.memo[o] = res
+	c.copyProperties(o, res)
+	return res
+}
+
+func (c *cloner) copyProperties(src, dst *goja.Object) {
+	for _, key := range src.Keys() {
+		dst.Set(key, c.clone(src.Get(key)))
+	}
+}
+
+func (c *cloner) forEach(o *goja.Object, f func(value, key goja.Value)) {
+	forEach, _ := goja.AssertFunction(o.Get("forEach"))
+	_, err := forEach(o, c.r.ToValue(func(call goja.FunctionCall) goja.Value {
+		f(call.Argument(0), call.Argument(1))
+		return goja.Undefined()
+	}))
+	if err != nil {
+		panic(err)
+	}
+}
+
+// structuredClone implements the structuredClone() global. Buffers are cloned as Uint8Arrays, which matches
+// nodejs. ArrayBuffers listed in the transfer option are moved to the clone and detached.
+func (b *Buffer) structuredClone(call goja.FunctionCall) goja.Value {
+	r := b.r
+	if len(call.Arguments) == 0 {
+		panic(errors.NewTypeError(r, errors.ErrCodeMissingArgs, `The "value" argument must be specified`))
+	}
+	objProto := r.Get("Object").ToObject(r).Get("prototype").ToObject(r)
+	toString, _ := goja.AssertFunction(objProto.Get("toString"))
+	c := &cloner{
+		b:              b,
+		r:              r,
+		memo:           make(map[*goja.Object]*goja.Object),
+		objectToString: toString,
+	}
+
+	var transfer []goja.ArrayBuffer
+	if t := getOption(b.optionsArg(call.Argument(1)), "transfer"); !goja.IsUndefined(t) {
+		r.ForOf(t, func(v goja.Value) bool {
+			o, ok := v.(*goja.Object)
+			if !ok || o.ExportType() != reflectTypeArrayBuffer {
+				panic(errors.NewDOMException(r, "DataCloneError", "Value not transferable"))
+			}
+			if c.memo[o] != nil {
+				panic(errors.NewDOMException(r, "DataCloneError", "ArrayBuffer occurs more than once in the transfer list"))
+			}
+			ab := o.Export().(goja.ArrayBuffer)
+			if ab.Detached() {
+				panic(errors.NewDOMException(r, "DataCloneError", "An ArrayBuffer is detached and could not be cloned."))
+			}
+			c.memo[o] = r.ToValue(r.NewArrayBuffer(ab.Bytes())).(*goja.Object)
+			transfer = append(transfer, ab)
+			return true
+		})
+	}
+
+	res := c.clone(call.Argument(0))
+	for _, ab := range transfer {
+		ab.Detach()
+	}
+	return res
+}
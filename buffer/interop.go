@@ -0,0 +1,182 @@
+package buffer
+
+import (
+	"bytes"
+	"io"
+	"math"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// DefaultChunkSize is the chunk size used by WrapReader when a non-positive size is given.
+const DefaultChunkSize = 65536
+
+// SourceBytes returns the bytes of an ArrayBuffer or an ArrayBuffer view (i.e. a Buffer, a TypedArray or
+// a DataView). The returned slice shares memory with the underlying ArrayBuffer: no data is copied, and changes
+// made through the slice are visible to JS code. The slice must not be accessed concurrently with the runtime,
+// and it must not be used after the ArrayBuffer is detached. If the value is neither, ok is false. Panics with
+// a RangeError if the byteOffset and byteLength properties of a view, which a script can redefine, are outside
+// of its ArrayBuffer.
+func SourceBytes(r *goja.Runtime, v goja.Value) (data []byte, ok bool) {
+	switch v.ExportType() {
+	case reflectTypeArrayBuffer:
+		return v.Export().(goja.ArrayBuffer).Bytes(), true
+	case reflectTypeBytes:
+		// A Buffer, a Uint8Array or a Uint8ClampedArray, whose exported slice is exactly the view, unlike a wrapped
+		// Go slice, whose class is "Array".
+		if o, isObj := v.(*goja.Object); isObj && o.ClassName() != "Array" {
+			return o.Export().([]byte), true
+		}
+	}
+	o, isObj := v.(*goja.Object)
+	if !isObj {
+		return nil, false
+	}
+	if ctor, isCtor := r.Get("ArrayBuffer").(*goja.Object); isCtor {
+		if isView, isFunc := goja.AssertFunction(ctor.Get("isView")); isFunc {
+			if res, err := isView(ctor, o); err != nil || !res.ToBoolean() {
+				return nil, false
+			}
+		}
+	}
+	buf, isAB := o.Get("buffer").Export().(goja.ArrayBuffer)
+	if !isAB {
+		return nil, false
+	}
+	data = buf.Bytes()
+	offset := o.Get("byteOffset").ToInteger()
+	length := o.Get("byteLength").ToInteger()
+	if offset < 0 || length < 0 || offset > int64(len(data)) || length > int64(len(data))-offset {
+		panic(errors.NewBufferOutOfBoundsError(r, ""))
+	}
+	return data[offset : offset+length], true
+}
+
+// NewReader returns an io.Reader over the bytes of a Buffer, a TypedArray, a DataView or an ArrayBuffer. The data
+// is not copied, so the same restrictions as for SourceBytes apply. Panics with a TypeError if the value is of
+// a different type.
+func NewReader(r *goja.Runtime, v goja.Value) *bytes.Reader {
+	data, ok := SourceBytes(r, v)
+	if !ok {
+		panic(errors.NewArgTypeError(r, "data", "an instance of Buffer, TypedArray, DataView or ArrayBuffer", v))
+	}
+	return bytes.NewReader(data)
+}
+
+// WrapReader returns a JS object that reads from rd lazily, chunk by chunk. The object has the following methods:
+//
+//	read([size]) - reads up to size bytes, at most chunkSize (the default), and returns them as a Buffer, or null
+//	at EOF;
+//	readAll() - reads the rest of the data and returns it as a single Buffer;
+//	[Symbol.iterator]() - allows iterating over the Buffer chunks with for...of.
+//
+// Each chunk is a Buffer that wraps the slice the data was read into, no extra copies are made. Read errors other
+// than io.EOF are thrown as GoErrors. The methods must only be called while the runtime is running
+// (i.e. from JS code).
+func WrapReader(r *goja.Runtime, rd io.Reader, chunkSize int) *goja.Object {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	eof := false
+	read := func(size int) goja.Value {
+		if eof {
+			return goja.Null()
+		}
+		buf := make([]byte, size)
+		for {
+			n, err := rd.Read(buf)
+			if n > 0 {
+				return WrapBytes(r, buf[:n:n])
+			}
+			if err == io.EOF {
+				eof = true
+				return goja.Null()
+			}
+			if err != nil {
+				panic(r.NewGoError(err))
+			}
+		}
+	}
+
+	o := r.NewObject()
+	o.Set("read", func(call goja.FunctionCall) goja.Value {
+		size := chunkSize
+		if arg := call.Argument(0); !goja.IsUndefined(arg) {
+			if !isNumber(arg) {
+				panic(errors.NewArgTypeError(r, "size", "of type number", arg))
+			}
+			f := arg.ToFloat()
+			if f != math.Trunc(f) {
+				panic(errors.NewOutOfRangeError(r, "size", "an integer", arg))
+			}
+			if f <= 0 {
+				panic(errors.NewOutOfRangeError(r, "size", "> 0", arg))
+			}
+			// The size is only an upper bound, so the script can't make the host allocate more than a chunk.
+			if f < float64(chunkSize) {
+				size = int(f)
+			}
+		}
+		return read(size)
+	})
+	o.Set("readAll", func(call goja.FunctionCall) goja.Value {
+		var data []byte
+		if !eof {
+			var err error
+			data, err = io.ReadAll(rd)
+			if err != nil {
+				panic(r.NewGoError(err))
+			}
+			eof = true
+		}
+		return WrapBytes(r, data)
+	})
+	o.SetSymbol(goja.SymIterator, func(call goja.FunctionCall) goja.Value {
+		iter := r.NewObject()
+		iter.Set("next", func(call goja.FunctionCall) goja.Value {
+			res := r.NewObject()
+			chunk := read(chunkSize)
+			res.Set("done", goja.IsNull(chunk))
+			if goja.IsNull(chunk) {
+				chunk = goja.Undefined()
+			}
+			res.Set("value", chunk)
+			return res
+		})
+		return iter
+	})
+	return o
+}
+
+// WrapWriter returns a JS object that writes to w. The object has the following methods:
+//
+//	write(data[, encoding]) - writes a string (in the specified encoding, "utf8" by default), a Buffer,
+//	a TypedArray, a DataView or an ArrayBuffer and returns the number of bytes written;
+//	close() - closes the writer if it implements io.Closer.
+//
+// Binary data is passed to w without copying, so w must not retain the slice after Write returns (as required by
+// the io.Writer contract). Write errors are thrown as GoErrors.
+func WrapWriter(r *goja.Runtime, w io.Writer) *goja.Object {
+	o := r.NewObject()
+	o.Set("write", func(call goja.FunctionCall) goja.Value {
+		data, ok := SourceBytes(r, call.Argument(0))
+		if !ok {
+			data = DecodeBytes(r, call.Argument(0), call.Argument(1))
+		}
+		n, err := w.Write(data)
+		if err != nil {
+			panic(r.NewGoError(err))
+		}
+		return r.ToValue(n)
+	})
+	o.Set("close", func(call goja.FunctionCall) goja.Value {
+		if c, ok := w.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				panic(r.NewGoError(err))
+			}
+		}
+		return goja.Undefined()
+	})
+	return o
+}
@@ -0,0 +1,135 @@
+package console
+
+import (
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Stream is the standard stream nodejs writes a console message to.
+type Stream int
+
+const (
+	Stdout Stream = iota
+	Stderr
+)
+
+func (s Stream) String() string {
+	if s == Stderr {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+// Entry is a console message recorded by a CapturePrinter.
+type Entry struct {
+	Time   time.Time
+	Stream Stream
+	Level  Level
+
+	// Text is the message indented by the group level, as nodejs prints it. If the message was longer than
+	// MaxEntrySize, it is cut and ends with a truncation marker.
+	Text string
+
+	// Truncated reports whether the text was cut or, for the marker entry added when the MaxSize was reached,
+	// whether messages were dropped.
+	Truncated bool
+}
+
+// CapturePrinter implements the console.StructuredPrinter interface
+// that records the messages in memory until they are drained.
+//
+// The printer is safe for concurrent use, so the messages can be drained from a goroutine other than the one
+// running the runtime. Use one printer per runtime to keep the output of concurrent runtimes apart.
+type CapturePrinter struct {
+	// MaxEntrySize is the maximum size in bytes of the text of an entry, zero means no limit.
+	MaxEntrySize int
+
+	// MaxSize is the maximum total size in bytes of the texts of the entries between two drains, zero means
+	// no limit. Once it is reached the following messages are dropped and Drain returns a marker entry in
+	// their place.
+	MaxSize int
+
+	// Tee also prints the messages to the standard output and error of the process.
+	Tee bool
+
+	mu           sync.Mutex
+	entries      []Entry
+	size         int
+	dropped      int
+	droppedBytes int
+	droppedAt    time.Time
+}
+
+// NewCapturePrinter returns a CapturePrinter with the given limits.
+func NewCapturePrinter(maxEntrySize, maxSize int) *CapturePrinter {
+	return &CapturePrinter{MaxEntrySize: maxEntrySize, MaxSize: maxSize}
+}
+
+// truncate cuts the text to at most max bytes, without splitting a character, and appends the marker.
+func truncate(text string, max int) string {
+	cut := max
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "... <" + strconv.Itoa(len(text)-cut) + " bytes truncated>"
+}
+
+func (p *CapturePrinter) Print(m Message) {
+	if p.Tee {
+		printerAdapter{defaultStdPrinter}.Print(m)
+	}
+	e := Entry{Time: m.Time, Level: m.Level, Text: m.Line()}
+	if m.Level.isStderr() {
+		e.Stream = Stderr
+	}
+	if p.MaxEntrySize > 0 && len(e.Text) > p.MaxEntrySize {
+		e.Text = truncate(e.Text, p.MaxEntrySize)
+		e.Truncated = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Once a message is dropped the following ones are dropped too, so the output never has holes.
+	if p.dropped > 0 || p.MaxSize > 0 && p.size+len(e.Text) > p.MaxSize {
+		if p.dropped == 0 {
+			p.droppedAt = e.Time
+		}
+		p.dropped++
+		p.droppedBytes += len(e.Text)
+		return
+	}
+	p.entries = append(p.entries, e)
+	p.size += len(e.Text)
+}
+
+// Drain returns the entries recorded since the last drain, in the order they were printed, and clears them.
+// If messages were dropped because of MaxSize, the last entry is a truncation marker on the standard error.
+func (p *CapturePrinter) Drain() []Entry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entries := p.entries
+	if p.dropped > 0 {
+		messages := " messages"
+		if p.dropped == 1 {
+			messages = " message"
+		}
+		entries = append(entries, Entry{
+			Time:      p.droppedAt,
+			Stream:    Stderr,
+			Level:     LevelWarn,
+			Text:      "... <" + strconv.Itoa(p.dropped) + messages + " (" + strconv.Itoa(p.droppedBytes) + " bytes) truncated>",
+			Truncated: true,
+		})
+	}
+	p.entries, p.size, p.dropped, p.droppedBytes, p.droppedAt = nil, 0, 0, 0, time.Time{}
+	return entries
+}
+
+// Len returns the number of entries recorded since the last drain, not counting the dropped messages.
+func (p *CapturePrinter) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
@@ -0,0 +1,185 @@
+package console
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+	"github.com/nuvolaris/goja_nodejs/internal/hooks"
+	"github.com/nuvolaris/goja_nodejs/util"
+)
+
+// stream is an output of a console.Console instance: either a writable stream object (anything with a write()
+// method) or a Go io.Writer passed to the runtime.
+type stream struct {
+	obj    *goja.Object
+	write  goja.Callable
+	writer io.Writer
+}
+
+var reflectTypeWriter = reflect.TypeOf((*io.Writer)(nil)).Elem()
+
+func asStream(v goja.Value) (*stream, bool) {
+	o, ok := v.(*goja.Object)
+	if !ok {
+		return nil, false
+	}
+	// Exporting a script object would copy it, only a wrapped Go value is exported.
+	if t := o.ExportType(); t != nil && t.Implements(reflectTypeWriter) {
+		return &stream{writer: o.Export().(io.Writer)}, true
+	}
+	if write, ok := goja.AssertFunction(o.Get("write")); ok {
+		return &stream{obj: o, write: write}, true
+	}
+	return nil, false
+}
+
+func (s *stream) writeString(r *goja.Runtime, str string, ignoreErrors bool) {
+	if s.writer != nil {
+		if _, err := io.WriteString(s.writer, str); err != nil && !ignoreErrors {
+			panic(r.NewGoError(err))
+		}
+		return
+	}
+	if _, err := s.write(s.obj, r.ToValue(str)); err != nil && !ignoreErrors {
+		panic(err)
+	}
+}
+
+// streamPrinter writes the messages of a console.Console instance to its streams, one line per message.
+type streamPrinter struct {
+	runtime        *goja.Runtime
+	stdout, stderr *stream
+	ignoreErrors   bool
+}
+
+func (p *streamPrinter) Print(m Message) {
+	s := p.stdout
+	if m.Level.isStderr() {
+		s = p.stderr
+	}
+	s.writeString(p.runtime, m.Line()+"\n", p.ignoreErrors)
+}
+
+// consoleClass returns the console.Console constructor.
+func (c *Console) consoleClass() goja.Value {
+	r := c.runtime
+	// Like in nodejs, Console() can also be called without new: goja constructs the instance in that case.
+	ctor := r.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		c.newInstance(call.Arguments).setMethods(call.This)
+		return nil
+	}).(*goja.Object)
+	ctor.DefineDataProperty("name", r.ToValue("Console"), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	return ctor
+}
+
+// newInstance creates the Console for new console.Console(options) or new console.Console(stdout[, stderr
+// [, ignoreErrors]]).
+func (c *Console) newInstance(args []goja.Value) *Console {
+	r := c.runtime
+	arg := func(i int) goja.Value {
+		if i < len(args) {
+			return args[i]
+		}
+		return goja.Undefined()
+	}
+	options, ok := arg(0).(*goja.Object)
+	if _, isStream := asStream(arg(0)); !ok || isStream {
+		options = r.NewObject()
+		options.Set("stdout", arg(0))
+		options.Set("stderr", arg(1))
+		options.Set("ignoreErrors", arg(2))
+	}
+	get := func(name string) goja.Value {
+		if v := options.Get(name); v != nil {
+			return v
+		}
+		return goja.Undefined()
+	}
+
+	stdout, ok := asStream(get("stdout"))
+	if !ok {
+		panic(errors.NewTypeError(r, errors.ErrCodeConsoleWritableStream, "Console expects a writable stream instance for stdout"))
+	}
+	stderr := stdout
+	if v := get("stderr"); !goja.IsUndefined(v) {
+		if stderr, ok = asStream(v); !ok {
+			panic(errors.NewTypeError(r, errors.ErrCodeConsoleWritableStream, "Console expects a writable stream instance for stderr"))
+		}
+	}
+	printer := &streamPrinter{runtime: r, stdout: stdout, stderr: stderr, ignoreErrors: true}
+	if v := get("ignoreErrors"); !goja.IsUndefined(v) {
+		printer.ignoreErrors = v.ToBoolean()
+	}
+
+	colorMode := get("colorMode")
+	switch {
+	case goja.IsUndefined(colorMode):
+	case colorMode.StrictEquals(r.ToValue("auto")), colorMode.StrictEquals(r.ToValue(true)), colorMode.StrictEquals(r.ToValue(false)):
+	default:
+		panic(errors.NewArgValueError(r, "colorMode", "is invalid", util.New(r).Inspect(colorMode)))
+	}
+
+	var inspectOptions *goja.Object
+	if v := get("inspectOptions"); !goja.IsUndefined(v) {
+		o, ok := v.(*goja.Object)
+		if !ok || isFunction(o) || o.ClassName() == "Array" {
+			panic(errors.NewArgTypeError(r, "options.inspectOptions", "of type object", v))
+		}
+		if colors := o.Get("colors"); colors != nil && !goja.IsUndefined(colors) && !goja.IsUndefined(colorMode) {
+			panic(errors.NewTypeError(r, errors.ErrCodeIncompatibleOptionPair,
+				"Option %q cannot be used in combination with option %q", "options.inspectOptions.color", "colorMode"))
+		}
+		inspectOptions = o
+	}
+
+	inst := newConsole(r, printer)
+	if v := get("groupIndentation"); !goja.IsUndefined(v) {
+		if !isNumber(v) {
+			panic(errors.NewArgTypeError(r, "groupIndentation", "of type number", v))
+		}
+		f := v.ToFloat()
+		if f != float64(v.ToInteger()) {
+			panic(errors.NewOutOfRangeError(r, "groupIndentation", "an integer", v))
+		}
+		if f < 0 || f > 1000 {
+			panic(errors.NewOutOfRangeError(r, "groupIndentation", ">= 0 && <= 1000", v))
+		}
+		inst.groupIndentation = int(f)
+	}
+
+	inst.getInspectOptions = func(isStderr bool) *goja.Object {
+		s := stdout
+		if isStderr {
+			s = stderr
+		}
+		var colors bool
+		if goja.IsUndefined(colorMode) || colorMode.StrictEquals(r.ToValue("auto")) {
+			// Only the streams of the runtime can be terminals, Go writers are never colored automatically.
+			colors = s.obj != nil && hooks.ShouldColorize(r, s.obj)
+		} else {
+			colors = colorMode.ToBoolean()
+		}
+		opts := r.NewObject()
+		if inspectOptions != nil {
+			assign(opts, inspectOptions)
+			if v := inspectOptions.Get("colors"); v == nil || goja.IsUndefined(v) {
+				opts.Set("colors", colors)
+			}
+		} else if colors {
+			opts.Set("colors", true)
+		}
+		return opts
+	}
+	return inst
+}
+
+func isNumber(v goja.Value) bool {
+	switch v.Export().(type) {
+	case int64, float64:
+		_, isObject := v.(*goja.Object)
+		return !isObject
+	}
+	return false
+}
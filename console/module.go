@@ -1,9 +1,18 @@
 package console
 
 import (
+	"bytes"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
 	"github.com/nuvolaris/goja_nodejs/require"
 	"github.com/nuvolaris/goja_nodejs/util"
+	"github.com/nuvolaris/goja_nodejs/util/types"
 )
 
 const ModuleName = "console"
@@ -11,55 +20,382 @@ const ModuleName = "console"
 type Console struct {
 	runtime *goja.Runtime
 	util    *goja.Object
-	printer Printer
+	printer StructuredPrinter
+	types   *types.Types
+
+	// getInspectOptions returns the util.inspect() options for the output to stdout or stderr, it's nil if
+	// the defaults are used.
+	getInspectOptions func(stderr bool) *goja.Object
+	groupIndentation  int
+
+	groupLevel int
+	counts     map[string]int
+	timers     map[string]time.Time
 }
 
+// Printer receives the formatted console output, already indented for console.group().
 type Printer interface {
 	Log(string)
 	Warn(string)
 	Error(string)
 }
 
-func (c *Console) log(p func(string)) func(goja.FunctionCall) goja.Value {
-	return func(call goja.FunctionCall) goja.Value {
-		if format, ok := goja.AssertFunction(c.util.Get("format")); ok {
-			ret, err := format(c.util, call.Arguments...)
+// caller returns the innermost frame of the call stack that is not a native function, or nil if there is none.
+func (c *Console) caller() *goja.StackFrame {
+	for _, frame := range c.runtime.CaptureCallStack(4, nil) {
+		if frame.SrcName() != "<native>" {
+			return &frame
+		}
+	}
+	return nil
+}
+
+// write sends the message to the printer.
+func (c *Console) write(level Level, args []goja.Value, text string) {
+	c.printer.Print(Message{
+		Time:             time.Now(),
+		Level:            level,
+		Text:             text,
+		Args:             args,
+		Group:            c.groupLevel,
+		GroupIndentation: c.groupIndentation,
+		Caller:           c.caller(),
+	})
+}
+
+// inspectOptions returns a new object with the util.inspect() options for the output to stdout or stderr.
+func (c *Console) inspectOptions(stderr bool) *goja.Object {
+	opts := c.runtime.NewObject()
+	if c.getInspectOptions != nil {
+		assign(opts, c.getInspectOptions(stderr))
+	}
+	return opts
+}
+
+// assign copies the own enumerable properties of src to dst.
+func assign(dst, src *goja.Object) {
+	if src == nil {
+		return
+	}
+	for _, key := range src.Keys() {
+		dst.Set(key, src.Get(key))
+	}
+}
+
+func (c *Console) format(stderr bool, args ...goja.Value) string {
+	if c.getInspectOptions != nil {
+		if format, ok := goja.AssertFunction(c.util.Get("formatWithOptions")); ok {
+			ret, err := format(c.util, append([]goja.Value{c.inspectOptions(stderr)}, args...)...)
 			if err != nil {
 				panic(err)
 			}
+			return ret.String()
+		}
+		panic(c.runtime.NewTypeError("util.formatWithOptions is not a function"))
+	}
+	if format, ok := goja.AssertFunction(c.util.Get("format")); ok {
+		ret, err := format(c.util, args...)
+		if err != nil {
+			panic(err)
+		}
+		return ret.String()
+	}
+	panic(c.runtime.NewTypeError("util.format is not a function"))
+}
+
+func (c *Console) inspect(v goja.Value, opts *goja.Object) string {
+	if inspect, ok := goja.AssertFunction(c.util.Get("inspect")); ok {
+		ret, err := inspect(c.util, v, opts)
+		if err != nil {
+			panic(err)
+		}
+		return ret.String()
+	}
+	panic(c.runtime.NewTypeError("util.inspect is not a function"))
+}
+
+// emitWarning emits the warning using process.emitWarning() if it's available, otherwise it prints it
+// to the printer's error stream the same way nodejs does by default.
+func (c *Console) emitWarning(msg string) {
+	if proc, ok := c.runtime.Get("process").(*goja.Object); ok {
+		if emit, ok := goja.AssertFunction(proc.Get("emitWarning")); ok {
+			if _, err := emit(proc, c.runtime.ToValue(msg)); err != nil {
+				panic(err)
+			}
+			return
+		}
+	}
+	c.printer.Print(Message{
+		Time:   time.Now(),
+		Level:  LevelWarn,
+		Text:   "(node:" + strconv.Itoa(os.Getpid()) + ") Warning: " + msg,
+		Args:   []goja.Value{c.runtime.ToValue(msg)},
+		Caller: c.caller(),
+	})
+}
+
+// label returns the label argument converted to a string, or "default" if it's undefined.
+func label(call goja.FunctionCall) string {
+	if l := call.Argument(0); !goja.IsUndefined(l) {
+		return l.String()
+	}
+	return "default"
+}
+
+func (c *Console) log(level Level) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		c.write(level, call.Arguments, c.format(level.isStderr(), call.Arguments...))
+		return nil
+	}
+}
+
+func (c *Console) assert(call goja.FunctionCall) goja.Value {
+	if call.Argument(0).ToBoolean() {
+		return nil
+	}
+	args := append([]goja.Value(nil), call.Arguments...)
+	if len(args) > 1 {
+		args = args[1:]
+	} else {
+		args = nil
+	}
+	if len(args) > 0 {
+		// Like in nodejs, the first argument is converted to a string and then used as the format string.
+		if _, ok := args[0].(*goja.Symbol); ok {
+			panic(c.runtime.NewTypeError("Cannot convert a Symbol value to a string"))
+		}
+		args[0] = c.runtime.ToValue("Assertion failed: " + args[0].String())
+	} else {
+		args = []goja.Value{c.runtime.ToValue("Assertion failed")}
+	}
+	c.write(LevelWarn, call.Arguments, c.format(true, args...))
+	return nil
+}
+
+func (c *Console) count(call goja.FunctionCall) goja.Value {
+	l := label(call)
+	c.counts[l]++
+	c.write(LevelLog, call.Arguments, l+": "+strconv.Itoa(c.counts[l]))
+	return nil
+}
+
+func (c *Console) countReset(call goja.FunctionCall) goja.Value {
+	l := label(call)
+	if _, ok := c.counts[l]; !ok {
+		c.emitWarning("Count for '" + l + "' does not exist")
+		return nil
+	}
+	delete(c.counts, l)
+	return nil
+}
 
-			p(ret.String())
-		} else {
-			panic(c.runtime.NewTypeError("util.format is not a function"))
+func (c *Console) group(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) > 0 {
+		c.write(LevelLog, call.Arguments, c.format(false, call.Arguments...))
+	}
+	c.groupLevel++
+	return nil
+}
+
+func (c *Console) groupEnd(goja.FunctionCall) goja.Value {
+	if c.groupLevel > 0 {
+		c.groupLevel--
+	}
+	return nil
+}
+
+// formatTime formats the duration the same way console.timeEnd() does in nodejs.
+func formatTime(d time.Duration) string {
+	ms := float64(d) / float64(time.Millisecond)
+	hours, minutes, seconds := 0, 0, 0.0
+	if ms >= 1000 {
+		if ms >= 60000 {
+			if ms >= 3600000 {
+				hours = int(ms / 3600000)
+				ms = math.Mod(ms, 3600000)
+			}
+			minutes = int(ms / 60000)
+			ms = math.Mod(ms, 60000)
 		}
+		seconds = ms / 1000
+	}
+	if hours != 0 || minutes != 0 {
+		parts := strings.SplitN(strconv.FormatFloat(seconds, 'f', 3, 64), ".", 2)
+		res := strconv.Itoa(minutes)
+		unit := "m:ss.mmm"
+		if hours != 0 {
+			res = strconv.Itoa(hours) + ":" + pad2(strconv.Itoa(minutes))
+			unit = "h:mm:ss.mmm"
+		}
+		return res + ":" + pad2(parts[0]) + "." + parts[1] + " (" + unit + ")"
+	}
+	if seconds != 0 {
+		return strconv.FormatFloat(seconds, 'f', 3, 64) + "s"
+	}
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(ms, 'f', 3, 64), 64)
+	return strconv.FormatFloat(v, 'f', -1, 64) + "ms"
+}
+
+func pad2(s string) string {
+	if len(s) < 2 {
+		return "0" + s
+	}
+	return s
+}
+
+func (c *Console) time(call goja.FunctionCall) goja.Value {
+	l := label(call)
+	if _, ok := c.timers[l]; ok {
+		c.emitWarning("Label '" + l + "' already exists for console.time()")
+		return nil
+	}
+	c.timers[l] = time.Now()
+	return nil
+}
+
+func (c *Console) timeLogImpl(name string, call goja.FunctionCall) bool {
+	l := label(call)
+	start, ok := c.timers[l]
+	if !ok {
+		c.emitWarning("No such label '" + l + "' for console." + name + "()")
+		return false
+	}
+	r := c.runtime
+	args := []goja.Value{r.ToValue("%s: %s"), r.ToValue(l), r.ToValue(formatTime(time.Since(start)))}
+	if len(call.Arguments) > 1 {
+		args = append(args, call.Arguments[1:]...)
+	}
+	c.write(LevelLog, call.Arguments, c.format(false, args...))
+	return true
+}
+
+func (c *Console) timeLog(call goja.FunctionCall) goja.Value {
+	c.timeLogImpl("timeLog", call)
+	return nil
+}
+
+func (c *Console) timeEnd(call goja.FunctionCall) goja.Value {
+	args := call.Arguments
+	if len(args) > 1 {
+		// Unlike timeLog(), timeEnd() does not print the extra arguments.
+		args = args[:1]
+	}
+	if c.timeLogImpl("timeEnd", goja.FunctionCall{This: call.This, Arguments: args}) {
+		delete(c.timers, label(call))
+	}
+	return nil
+}
 
+func (c *Console) trace(call goja.FunctionCall) goja.Value {
+	var b bytes.Buffer
+	b.WriteString("Trace")
+	if msg := c.format(true, call.Arguments...); msg != "" {
+		b.WriteString(": ")
+		b.WriteString(msg)
+	}
+	stack := c.runtime.CaptureCallStack(0, nil)
+	if len(stack) > 0 && stack[0].SrcName() == "<native>" {
+		// Skip the frame of console.trace() itself.
+		stack = stack[1:]
+	}
+	for _, frame := range stack {
+		b.WriteString("\n\tat ")
+		frame.Write(&b)
+	}
+	c.write(LevelTrace, call.Arguments, b.String())
+	return nil
+}
+
+func (c *Console) dir(call goja.FunctionCall) goja.Value {
+	opts := c.runtime.NewObject()
+	opts.Set("customInspect", false)
+	assign(opts, c.inspectOptions(false))
+	if o, ok := call.Argument(1).(*goja.Object); ok {
+		assign(opts, o)
+	}
+	c.write(LevelLog, call.Arguments, c.inspect(call.Argument(0), opts))
+	return nil
+}
+
+func (c *Console) table(call goja.FunctionCall) goja.Value {
+	r := c.runtime
+	data, properties := call.Argument(0), call.Argument(1)
+	var props []string
+	if !goja.IsUndefined(properties) {
+		o, ok := properties.(*goja.Object)
+		if !ok || o.ClassName() != "Array" {
+			panic(errors.NewArgTypeError(r, "properties", "an instance of Array", properties))
+		}
+		props = []string{}
+		r.ForOf(o, func(v goja.Value) bool {
+			props = append(props, v.String())
+			return true
+		})
+	}
+	o, ok := data.(*goja.Object)
+	if !ok || isFunction(o) {
+		c.write(LevelLog, call.Arguments, c.format(false, data))
 		return nil
 	}
+	c.write(LevelLog, call.Arguments, c.renderTable(o, props))
+	return nil
 }
 
 func Require(runtime *goja.Runtime, module *goja.Object) {
-	requireWithPrinter(defaultStdPrinter)(runtime, module)
+	RequireWithPrinter(defaultStdPrinter)(runtime, module)
 }
 
 func RequireWithPrinter(printer Printer) require.ModuleLoader {
+	return requireWithPrinter(printerAdapter{printer})
+}
+
+// RequireWithStructuredPrinter returns a module loader that sends every console message with its metadata
+// to the printer.
+func RequireWithStructuredPrinter(printer StructuredPrinter) require.ModuleLoader {
 	return requireWithPrinter(printer)
 }
 
-func requireWithPrinter(printer Printer) require.ModuleLoader {
-	return func(runtime *goja.Runtime, module *goja.Object) {
-		c := &Console{
-			runtime: runtime,
-			printer: printer,
-		}
+// newConsole returns a Console that sends the messages to the printer.
+func newConsole(runtime *goja.Runtime, printer StructuredPrinter) *Console {
+	return &Console{
+		runtime:          runtime,
+		util:             require.Require(runtime, util.ModuleName).(*goja.Object),
+		printer:          printer,
+		types:            types.New(runtime),
+		groupIndentation: defaultGroupIndentation,
+		counts:           make(map[string]int),
+		timers:           make(map[string]time.Time),
+	}
+}
 
-		c.util = require.Require(runtime, util.ModuleName).(*goja.Object)
+// setMethods adds the console methods to the object.
+func (c *Console) setMethods(o *goja.Object) {
+	o.Set("log", c.log(LevelLog))
+	o.Set("error", c.log(LevelError))
+	o.Set("warn", c.log(LevelWarn))
+	o.Set("info", c.log(LevelInfo))
+	o.Set("debug", c.log(LevelDebug))
+	o.Set("dirxml", c.log(LevelLog))
+	o.Set("assert", c.assert)
+	o.Set("count", c.count)
+	o.Set("countReset", c.countReset)
+	o.Set("dir", c.dir)
+	o.Set("group", c.group)
+	o.Set("groupCollapsed", c.group)
+	o.Set("groupEnd", c.groupEnd)
+	o.Set("table", c.table)
+	o.Set("time", c.time)
+	o.Set("timeEnd", c.timeEnd)
+	o.Set("timeLog", c.timeLog)
+	o.Set("trace", c.trace)
+}
 
+func requireWithPrinter(printer StructuredPrinter) require.ModuleLoader {
+	return func(runtime *goja.Runtime, module *goja.Object) {
+		c := newConsole(runtime, printer)
 		o := module.Get("exports").(*goja.Object)
-		o.Set("log", c.log(c.printer.Log))
-		o.Set("error", c.log(c.printer.Error))
-		o.Set("warn", c.log(c.printer.Warn))
-		o.Set("info", c.log(c.printer.Log))
-		o.Set("debug", c.log(c.printer.Log))
+		c.setMethods(o)
+		o.Set("Console", c.consoleClass())
 	}
 }
 
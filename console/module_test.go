@@ -1,7 +1,13 @@
 package console
 
 import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nuvolaris/goja"
 	"github.com/nuvolaris/goja_nodejs/require"
@@ -76,3 +82,500 @@ func TestConsoleWithPrinter(t *testing.T) {
 		t.Fatalf("Unexpected stderr output: got %q, want %q", stderrStr, want)
 	}
 }
+
+type linePrinter struct {
+	stdout, stderr []string
+}
+
+func (p *linePrinter) Log(s string)   { p.stdout = append(p.stdout, s) }
+func (p *linePrinter) Warn(s string)  { p.stderr = append(p.stderr, s) }
+func (p *linePrinter) Error(s string) { p.stderr = append(p.stderr, s) }
+
+func runWithLinePrinter(t *testing.T, src string) *linePrinter {
+	t.Helper()
+	printer := &linePrinter{}
+
+	vm := goja.New()
+	registry := new(require.Registry)
+	registry.Enable(vm)
+	registry.RegisterNativeModule(ModuleName, RequireWithPrinter(printer))
+	Enable(vm)
+
+	if _, err := vm.RunScript("test.js", src); err != nil {
+		t.Fatal(err)
+	}
+	return printer
+}
+
+func TestConsoleGroup(t *testing.T) {
+	p := runWithLinePrinter(t, `
+		console.log('a')
+		console.group('b')
+		console.log('c\nd')
+		console.groupCollapsed()
+		console.error('e')
+		console.groupEnd()
+		console.groupEnd()
+		console.groupEnd()
+		console.log('f')
+	`)
+	if want := []string{"a", "b", "  c\n  d", "f"}; !reflect.DeepEqual(p.stdout, want) {
+		t.Fatalf("Unexpected stdout output: got %q, want %q", p.stdout, want)
+	}
+	if want := []string{"    e"}; !reflect.DeepEqual(p.stderr, want) {
+		t.Fatalf("Unexpected stderr output: got %q, want %q", p.stderr, want)
+	}
+}
+
+func TestConsoleCountAssert(t *testing.T) {
+	p := runWithLinePrinter(t, `
+		console.count()
+		console.count('x')
+		console.count()
+		console.countReset()
+		console.count()
+		console.countReset('nope')
+		console.assert(true, 'not printed')
+		console.assert(false)
+		console.assert(0, 'value %d', 42)
+		console.assert(null, {a: 1})
+		console.assert(false, {a: 1}, 'x', {b: 2})
+		console.assert(false, [1, 2], '%d')
+		try {
+			console.assert(false, Symbol('s'))
+		} catch (e) {
+			console.log(e instanceof TypeError)
+		}
+	`)
+	if want := []string{"default: 1", "x: 1", "default: 2", "default: 1", "true"}; !reflect.DeepEqual(p.stdout, want) {
+		t.Fatalf("Unexpected stdout output: got %q, want %q", p.stdout, want)
+	}
+	if len(p.stderr) != 6 || !strings.HasSuffix(p.stderr[0], ") Warning: Count for 'nope' does not exist") {
+		t.Fatalf("Unexpected stderr output: %q", p.stderr)
+	}
+	if want := []string{"Assertion failed", "Assertion failed: value 42", "Assertion failed: [object Object]",
+		"Assertion failed: [object Object] x { b: 2 }", "Assertion failed: 1,2 %d"}; !reflect.DeepEqual(p.stderr[1:], want) {
+		t.Fatalf("Unexpected stderr output: got %q, want %q", p.stderr[1:], want)
+	}
+}
+
+func TestConsoleTime(t *testing.T) {
+	p := runWithLinePrinter(t, `
+		var warnings = [];
+		var process = {emitWarning: function(msg) { warnings.push(msg) }};
+		console.time()
+		console.time()
+		console.timeLog(undefined, 'extra', {a: 1})
+		console.timeEnd(undefined, 'ignored')
+		console.timeEnd()
+		console.timeLog('x')
+		console.log(warnings.join('|'))
+	`)
+	if len(p.stdout) != 3 {
+		t.Fatalf("Unexpected stdout output: %q", p.stdout)
+	}
+	if !regexp.MustCompile(`^default: \d+(\.\d+)?ms extra { a: 1 }$`).MatchString(p.stdout[0]) {
+		t.Fatalf("Unexpected timeLog output: %q", p.stdout[0])
+	}
+	if !regexp.MustCompile(`^default: \d+(\.\d+)?ms$`).MatchString(p.stdout[1]) {
+		t.Fatalf("Unexpected timeEnd output: %q", p.stdout[1])
+	}
+	if want := "Label 'default' already exists for console.time()|No such label 'default' for console.timeEnd()|" +
+		"No such label 'x' for console.timeLog()"; p.stdout[2] != want {
+		t.Fatalf("Unexpected warnings: got %q, want %q", p.stdout[2], want)
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	for _, tc := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{1500 * time.Microsecond, "1.5ms"},
+		{123456 * time.Nanosecond, "0.123ms"},
+		{2345 * time.Millisecond, "2.345s"},
+		{125500 * time.Millisecond, "2:05.500 (m:ss.mmm)"},
+		{3723004 * time.Millisecond, "1:02:03.004 (h:mm:ss.mmm)"},
+	} {
+		if got := formatTime(tc.d); got != tc.want {
+			t.Errorf("formatTime(%v): got %q, want %q", tc.d, got, tc.want)
+		}
+	}
+}
+
+func TestConsoleTrace(t *testing.T) {
+	p := runWithLinePrinter(t, `
+		function f() {
+			console.trace('here %s', 'now')
+		}
+		f()
+	`)
+	if len(p.stderr) != 1 {
+		t.Fatalf("Unexpected stderr output: %q", p.stderr)
+	}
+	if want := "Trace: here now\n\tat f (test.js:3:"; !strings.HasPrefix(p.stderr[0], want) {
+		t.Fatalf("Unexpected trace: got %q, want prefix %q", p.stderr[0], want)
+	}
+	if !strings.Contains(p.stderr[0], "\n\tat test.js:5:") {
+		t.Fatalf("Missing caller frame: %q", p.stderr[0])
+	}
+}
+
+func TestConsoleDir(t *testing.T) {
+	p := runWithLinePrinter(t, `
+		const o = {a: {b: {c: {d: 1}}}, [Symbol.for('nodejs.util.inspect.custom')]() { return 'custom' }};
+		console.dir(o)
+		console.dir(o, {depth: 0})
+		console.dir('100%')
+	`)
+	want := []string{
+		"{\n  a: { b: { c: [Object] } },\n  [Symbol(nodejs.util.inspect.custom)]: [Function: [nodejs.util.inspect.custom]]\n}",
+		"{\n  a: [Object],\n  [Symbol(nodejs.util.inspect.custom)]: [Function: [nodejs.util.inspect.custom]]\n}",
+		"'100%'",
+	}
+	if !reflect.DeepEqual(p.stdout, want) {
+		t.Fatalf("Unexpected stdout output: got %q, want %q", p.stdout, want)
+	}
+}
+
+func TestConsoleTable(t *testing.T) {
+	p := runWithLinePrinter(t, `
+		console.table(42)
+		console.table([{a: 1, b: 'Y'}, {a: 'Z', b: 2}])
+		console.table([{a: 1, b: 'Y'}, {a: 'Z', b: 2}], ['a'])
+		console.table({x: [1, 2, 3, 4], y: 'str', z: {p: 1, q: 2, r: 3}})
+		console.table([{o: {a: 1, b: 2, c: 3}, arr: [1, 2, 3, 4]}])
+		console.table(new Map([['k', 'v']]))
+		console.table(new Set(['漢字']))
+		console.table([{1: 'one', b: 'bee', 0: 'zero'}])
+	`)
+	want := []string{
+		"42",
+		"┌─────────┬─────┬─────┐\n" +
+			"│ (index) │ a   │ b   │\n" +
+			"├─────────┼─────┼─────┤\n" +
+			"│ 0       │ 1   │ 'Y' │\n" +
+			"│ 1       │ 'Z' │ 2   │\n" +
+			"└─────────┴─────┴─────┘",
+		"┌─────────┬─────┐\n" +
+			"│ (index) │ a   │\n" +
+			"├─────────┼─────┤\n" +
+			"│ 0       │ 1   │\n" +
+			"│ 1       │ 'Z' │\n" +
+			"└─────────┴─────┘",
+		"┌─────────┬───┬───┬───┬───┬───┬───┬───┬────────┐\n" +
+			"│ (index) │ 0 │ 1 │ 2 │ 3 │ p │ q │ r │ Values │\n" +
+			"├─────────┼───┼───┼───┼───┼───┼───┼───┼────────┤\n" +
+			"│ x       │ 1 │ 2 │ 3 │ 4 │   │   │   │        │\n" +
+			"│ y       │   │   │   │   │   │   │   │ 'str'  │\n" +
+			"│ z       │   │   │   │   │ 1 │ 2 │ 3 │        │\n" +
+			"└─────────┴───┴───┴───┴───┴───┴───┴───┴────────┘",
+		"┌─────────┬──────────┬──────────────────────────────┐\n" +
+			"│ (index) │ o        │ arr                          │\n" +
+			"├─────────┼──────────┼──────────────────────────────┤\n" +
+			"│ 0       │ [Object] │ [ 1, 2, 3, ... 1 more item ] │\n" +
+			"└─────────┴──────────┴──────────────────────────────┘",
+		"┌───────────────────┬─────┬────────┐\n" +
+			"│ (iteration index) │ Key │ Values │\n" +
+			"├───────────────────┼─────┼────────┤\n" +
+			"│ 0                 │ 'k' │ 'v'    │\n" +
+			"└───────────────────┴─────┴────────┘",
+		"┌───────────────────┬────────┐\n" +
+			"│ (iteration index) │ Values │\n" +
+			"├───────────────────┼────────┤\n" +
+			"│ 0                 │ '漢字' │\n" +
+			"└───────────────────┴────────┘",
+		"┌─────────┬────────┬───────┬───────┐\n" +
+			"│ (index) │ 0      │ 1     │ b     │\n" +
+			"├─────────┼────────┼───────┼───────┤\n" +
+			"│ 0       │ 'zero' │ 'one' │ 'bee' │\n" +
+			"└─────────┴────────┴───────┴───────┘",
+	}
+	if len(p.stdout) != len(want) {
+		t.Fatalf("Unexpected stdout output: %q", p.stdout)
+	}
+	for i := range want {
+		if p.stdout[i] != want[i] {
+			t.Errorf("Unexpected table %d:\n%s\nwant:\n%s", i, p.stdout[i], want[i])
+		}
+	}
+}
+
+type messagePrinter struct {
+	messages []Message
+}
+
+func (p *messagePrinter) Print(m Message) {
+	p.messages = append(p.messages, m)
+}
+
+func TestConsoleWithStructuredPrinter(t *testing.T) {
+	printer := &messagePrinter{}
+
+	vm := goja.New()
+	registry := new(require.Registry)
+	registry.Enable(vm)
+	registry.RegisterNativeModule(ModuleName, RequireWithStructuredPrinter(printer))
+	Enable(vm)
+
+	_, err := vm.RunScript("test.js", `
+		console.log('a %d', 1)
+		console.info('b')
+		console.group()
+		console.debug('c\nd')
+		console.groupEnd()
+		console.warn('e')
+		console.error('f')
+		;[1].forEach(function g() { console.trace() })
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		level Level
+		text  string
+		group int
+		args  int
+		line  int
+	}{
+		{LevelLog, "a 1", 0, 2, 2},
+		{LevelInfo, "b", 0, 1, 3},
+		{LevelDebug, "c\nd", 1, 1, 5},
+		{LevelWarn, "e", 0, 1, 7},
+		{LevelError, "f", 0, 1, 8},
+		{LevelTrace, "Trace", 0, 0, 9},
+	}
+	if len(printer.messages) != len(want) {
+		t.Fatalf("Unexpected messages: %v", printer.messages)
+	}
+	for i, w := range want {
+		m := printer.messages[i]
+		text := m.Text
+		if m.Level == LevelTrace {
+			text, _, _ = strings.Cut(text, "\n")
+		}
+		if m.Level != w.level || text != w.text || m.Group != w.group || len(m.Args) != w.args {
+			t.Errorf("Unexpected message %d: %v", i, m)
+		}
+		if m.Caller == nil || m.Caller.SrcName() != "test.js" || m.Caller.Position().Line != w.line {
+			t.Errorf("Unexpected caller of message %d: %v", i, m.Caller)
+		}
+		if m.Time.IsZero() {
+			t.Errorf("Message %d has no time", i)
+		}
+	}
+	if line := printer.messages[2].Line(); line != "  c\n  d" {
+		t.Errorf("Unexpected line: %q", line)
+	}
+	if name := printer.messages[5].Caller.FuncName(); name != "g" {
+		t.Errorf("Unexpected trace caller: %q", name)
+	}
+}
+
+func TestConsoleWithSlogPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+
+	vm := goja.New()
+	registry := new(require.Registry)
+	registry.Enable(vm)
+	registry.RegisterNativeModule(ModuleName, RequireWithStructuredPrinter(NewSlogPrinter(handler)))
+	Enable(vm)
+
+	_, err := vm.RunScript("test.js", `
+		console.debug('not enabled')
+		console.info('%s is %d', 'x', 42, {a: [1]}, function f() {}, Symbol('s'), null)
+		function g() {
+			console.error('failed')
+		}
+		g()
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"level":"INFO","msg":"x is 42 { a: [ 1 ] } [Function: f] Symbol(s) null","console":"info",` +
+		`"source":{"function":"<anonymous>","file":"test.js","line":3},` +
+		`"args":{"0":"%s is %d","1":"x","2":42,"3":{"a":[1]},"4":"[Function: f]","5":"Symbol(s)","6":null}}` + "\n" +
+		`{"level":"ERROR","msg":"failed","console":"error","source":{"function":"g","file":"test.js","line":5},` +
+		`"args":{"0":"failed"}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("Unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConsoleWithCapturePrinter(t *testing.T) {
+	printer := NewCapturePrinter(10, 38)
+
+	vm := goja.New()
+	registry := new(require.Registry)
+	registry.Enable(vm)
+	registry.RegisterNativeModule(ModuleName, RequireWithStructuredPrinter(printer))
+	Enable(vm)
+
+	start := time.Now()
+	_, err := vm.RunScript("test.js", `
+		console.log('a')
+		console.group()
+		console.error('b')
+		console.groupEnd()
+		console.info('0123456789abcdef€')
+		console.log('c')
+		console.log('d')
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := printer.Len(); n != 4 {
+		t.Fatalf("Unexpected length: %d", n)
+	}
+
+	type entry struct {
+		stream    Stream
+		level     Level
+		text      string
+		truncated bool
+	}
+	want := []entry{
+		{Stdout, LevelLog, "a", false},
+		{Stderr, LevelError, "  b", false},
+		{Stdout, LevelInfo, "0123456789... <9 bytes truncated>", true},
+		{Stdout, LevelLog, "c", false},
+		{Stderr, LevelWarn, "... <1 message (1 bytes) truncated>", true},
+	}
+	entries := printer.Drain()
+	var got []entry
+	for _, e := range entries {
+		if e.Time.Before(start) || e.Time.After(time.Now()) {
+			t.Fatalf("Unexpected time: %v", e.Time)
+		}
+		got = append(got, entry{e.Stream, e.Level, e.Text, e.Truncated})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected entries: %v", got)
+	}
+
+	if entries := printer.Drain(); len(entries) != 0 {
+		t.Fatalf("Unexpected entries after drain: %v", entries)
+	}
+	if _, err := vm.RunScript("test2.js", `console.warn('e')`); err != nil {
+		t.Fatal(err)
+	}
+	entries = printer.Drain()
+	if len(entries) != 1 || entries[0].Text != "e" || entries[0].Stream != Stderr {
+		t.Fatalf("Unexpected entries: %v", entries)
+	}
+}
+
+func TestCapturePrinterTruncate(t *testing.T) {
+	// The cut never splits a multi-byte character.
+	if got := truncate("ab€", 3); got != "ab... <3 bytes truncated>" {
+		t.Fatalf("Unexpected text: %q", got)
+	}
+}
+
+func TestConsoleClass(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	var buf bytes.Buffer
+	vm.Set("goWriter", &buf)
+
+	_, err := vm.RunScript("test.js", `
+	const assert = require("../assert.js");
+	const { Console } = require("console");
+
+	function memoryStream() {
+		return {
+			data: "",
+			write(s) { this.data += s; return true; },
+		};
+	}
+
+	{
+		const out = memoryStream(), err = memoryStream();
+		const c = new Console(out, err);
+		assert.sameValue(c instanceof Console, true);
+		assert.sameValue(Console(out) instanceof Console, true);
+		c.log("a %d", 1);
+		c.group("g");
+		c.info("b\nc");
+		c.groupEnd();
+		c.error("e");
+		c.warn({x: 1});
+		c.count();
+		c.table([{a: 1}]);
+		assert.sameValue(out.data, "a 1\ng\n  b\n  c\ndefault: 1\n" +
+			"┌─────────┬───┐\n│ (index) │ a │\n├─────────┼───┤\n│ 0       │ 1 │\n└─────────┴───┘\n");
+		assert.sameValue(err.data, "e\n{ x: 1 }\n");
+	}
+
+	{
+		// The stream is not exported, its getters don't run.
+		const out = memoryStream();
+		Object.defineProperty(out, "boom", { get() { throw new Error("exported"); }, enumerable: true });
+		new Console(out).log("ok");
+		assert.sameValue(out.data, "ok\n");
+	}
+
+	{
+		const out = memoryStream();
+		const c = new Console({stdout: out, groupIndentation: 4, inspectOptions: {depth: 0}});
+		c.group();
+		c.log({a: {b: 1}});
+		c.error("to stdout");
+		c.dir({a: {b: 1}});
+		assert.sameValue(out.data, "    { a: [Object] }\n    to stdout\n    { a: [Object] }\n");
+	}
+
+	{
+		const out = memoryStream();
+		const c = new Console({stdout: out, colorMode: true});
+		c.log(1, "s");
+		assert.sameValue(out.data, "\u001b[33m1\u001b[39m s\n");
+
+		out.data = "";
+		new Console({stdout: out, colorMode: "auto"}).log(1);
+		assert.sameValue(out.data, "1\n");
+	}
+
+	{
+		const c = new Console({stdout: goWriter});
+		c.log("to go");
+		c.warn("warning");
+	}
+
+	{
+		const failing = { write() { throw new Error("write failed"); } };
+		new Console(failing).log("ignored");
+		assert.throws(() => new Console({stdout: failing, ignoreErrors: false}).log("x"), Error);
+	}
+
+	assert.throwsNodeError(() => new Console(), TypeError, "ERR_CONSOLE_WRITABLE_STREAM");
+	assert.throwsNodeError(() => new Console({stdout: {}}), TypeError, "ERR_CONSOLE_WRITABLE_STREAM");
+	assert.throwsNodeError(() => new Console(memoryStream(), {}), TypeError, "ERR_CONSOLE_WRITABLE_STREAM");
+	assert.throwsNodeError(() => new Console({stdout: memoryStream(), colorMode: "yes"}), TypeError, "ERR_INVALID_ARG_VALUE");
+	assert.throwsNodeError(() => new Console({stdout: memoryStream(), inspectOptions: 1}), TypeError, "ERR_INVALID_ARG_TYPE");
+	assert.throwsNodeError(() => new Console({stdout: memoryStream(), colorMode: true, inspectOptions: {colors: true}}),
+		TypeError, "ERR_INCOMPATIBLE_OPTION_PAIR");
+	assert.throwsNodeError(() => new Console({stdout: memoryStream(), groupIndentation: "1"}), TypeError, "ERR_INVALID_ARG_TYPE");
+	assert.throwsNodeError(() => new Console({stdout: memoryStream(), groupIndentation: 1.5}), RangeError, "ERR_OUT_OF_RANGE");
+	assert.throwsNodeError(() => new Console({stdout: memoryStream(), groupIndentation: 1001}), RangeError, "ERR_OUT_OF_RANGE");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "to go\nwarning\n"; buf.String() != want {
+		t.Fatalf("Unexpected output: got %q, want %q", buf.String(), want)
+	}
+}
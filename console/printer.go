@@ -0,0 +1,100 @@
+package console
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nuvolaris/goja"
+)
+
+// defaultGroupIndentation is the number of spaces console.group() adds to the indentation by default.
+const defaultGroupIndentation = 2
+
+// Level is the severity of a console message.
+type Level int
+
+const (
+	LevelTrace Level = iota // console.trace()
+	LevelDebug              // console.debug()
+	LevelLog                // console.log() and the other methods that print to the standard output
+	LevelInfo               // console.info()
+	LevelWarn               // console.warn(), console.assert() and the console warnings
+	LevelError              // console.error()
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelTrace:
+		return "trace"
+	case LevelDebug:
+		return "debug"
+	case LevelLog:
+		return "log"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// isStderr reports whether nodejs writes the messages of the level to the standard error.
+func (l Level) isStderr() bool {
+	return l == LevelTrace || l == LevelWarn || l == LevelError
+}
+
+// Message is a message written by one of the console methods.
+type Message struct {
+	Time  time.Time
+	Level Level
+
+	// Text is the formatted message, without the group indentation.
+	Text string
+
+	// Args are the arguments the console method was called with. They must not be used outside
+	// the goroutine running the runtime.
+	Args []goja.Value
+
+	// Group is the nesting level of console.group().
+	Group int
+
+	// GroupIndentation is the number of spaces each group level adds to the indentation.
+	GroupIndentation int
+
+	// Caller is the innermost script frame of the call stack, or nil if the console method was not called
+	// from a script.
+	Caller *goja.StackFrame
+}
+
+// Line returns the text indented by the group level, as nodejs prints it.
+func (m *Message) Line() string {
+	if m.Group == 0 {
+		return m.Text
+	}
+	indent := strings.Repeat(" ", m.Group*m.GroupIndentation)
+	return indent + strings.ReplaceAll(m.Text, "\n", "\n"+indent)
+}
+
+// StructuredPrinter receives the console messages with their metadata.
+type StructuredPrinter interface {
+	Print(Message)
+}
+
+// printerAdapter sends the messages to a Printer: trace and error messages go to Error, warnings to Warn and
+// everything else to Log.
+type printerAdapter struct {
+	printer Printer
+}
+
+func (p printerAdapter) Print(m Message) {
+	switch m.Level {
+	case LevelTrace, LevelError:
+		p.printer.Error(m.Line())
+	case LevelWarn:
+		p.printer.Warn(m.Line())
+	default:
+		p.printer.Log(m.Line())
+	}
+}
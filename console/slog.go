@@ -0,0 +1,92 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+
+	"github.com/nuvolaris/goja"
+)
+
+// LevelTraceSlog is the slog level of the console.trace() messages.
+const LevelTraceSlog = slog.LevelDebug - 4
+
+// SlogPrinter implements the console.StructuredPrinter interface
+// that sends the messages to a slog.Handler.
+//
+// The record message is the formatted text. The console level is mapped to the closest slog level and is also
+// added as the "console" attribute, so console.log() and console.info() can be told apart. The caller frame is
+// added as the slog.SourceKey attribute and the arguments as the "args" group, with the values exported to Go.
+// Functions, symbols and objects that can't be encoded as JSON are added as strings.
+type SlogPrinter struct {
+	Handler slog.Handler
+}
+
+// NewSlogPrinter returns a SlogPrinter that sends the messages to the handler.
+func NewSlogPrinter(h slog.Handler) *SlogPrinter {
+	return &SlogPrinter{Handler: h}
+}
+
+func slogLevel(l Level) slog.Level {
+	switch l {
+	case LevelTrace:
+		return LevelTraceSlog
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// slogValue converts a script value into a value that any handler can encode.
+func slogValue(v goja.Value) slog.Value {
+	switch v := v.(type) {
+	case nil:
+		return slog.AnyValue(nil)
+	case *goja.Symbol:
+		return slog.StringValue("Symbol(" + v.String() + ")")
+	case *goja.Object:
+		if isFunction(v) {
+			return slog.StringValue("[Function: " + v.Get("name").String() + "]")
+		}
+		exported := v.Export()
+		if _, err := json.Marshal(exported); err != nil {
+			return slog.StringValue(v.String())
+		}
+		return slog.AnyValue(exported)
+	}
+	return slog.AnyValue(v.Export())
+}
+
+func (p *SlogPrinter) Print(m Message) {
+	ctx := context.Background()
+	level := slogLevel(m.Level)
+	if !p.Handler.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(m.Time, level, m.Text, 0)
+	r.AddAttrs(slog.String("console", m.Level.String()))
+	if m.Caller != nil {
+		pos := m.Caller.Position()
+		r.AddAttrs(slog.Any(slog.SourceKey, &slog.Source{
+			Function: m.Caller.FuncName(),
+			File:     pos.Filename,
+			Line:     pos.Line,
+		}))
+	}
+	if len(m.Args) > 0 {
+		args := make([]any, len(m.Args))
+		for i, arg := range m.Args {
+			args[i] = slog.Attr{Key: strconv.Itoa(i), Value: slogValue(arg)}
+		}
+		r.AddAttrs(slog.Group("args", args...))
+	}
+	if m.Group > 0 {
+		r.AddAttrs(slog.Int("group", m.Group))
+	}
+	_ = p.Handler.Handle(ctx, r)
+}
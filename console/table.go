@@ -0,0 +1,234 @@
+package console
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/nuvolaris/goja"
+	"golang.org/x/text/width"
+)
+
+const (
+	indexKey  = "(index)"
+	iterKey   = "(iteration index)"
+	keyKey    = "Key"
+	valuesKey = "Values"
+)
+
+func isFunction(v goja.Value) bool {
+	_, ok := goja.AssertFunction(v)
+	return ok
+}
+
+// stringWidth returns the number of columns the string takes on a terminal.
+func stringWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		switch {
+		case unicode.IsControl(r) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		case width.LookupRune(r).Kind() == width.EastAsianWide || width.LookupRune(r).Kind() == width.EastAsianFullwidth:
+			w += 2
+		default:
+			w++
+		}
+	}
+	return w
+}
+
+// tableColumns holds the columns of a table in the order Object.keys() would return them for an object with
+// the column names as keys.
+type tableColumns struct {
+	names []string
+	cells map[string][]string
+}
+
+func (t *tableColumns) set(name string, row int, cell string) {
+	if t.cells == nil {
+		t.cells = make(map[string][]string)
+	}
+	col, ok := t.cells[name]
+	if !ok {
+		t.names = append(t.names, name)
+	}
+	for len(col) <= row {
+		col = append(col, "")
+	}
+	col[row] = cell
+	t.cells[name] = col
+}
+
+func arrayIndex(s string) (uint32, bool) {
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n == math.MaxUint32 || strconv.FormatUint(n, 10) != s {
+		return 0, false
+	}
+	return uint32(n), true
+}
+
+func (t *tableColumns) keys() []string {
+	sort.SliceStable(t.names, func(i, j int) bool {
+		a, aok := arrayIndex(t.names[i])
+		b, bok := arrayIndex(t.names[j])
+		if aok && bok {
+			return a < b
+		}
+		return aok && !bok
+	})
+	return t.names
+}
+
+// inspectCell formats a value of the table the same way nodejs does: objects with more than two keys are
+// abbreviated and arrays show at most three elements.
+func (c *Console) inspectCell(v goja.Value) string {
+	r := c.runtime
+	depth := 0
+	if o, ok := v.(*goja.Object); ok && !isFunction(o) && o.ClassName() != "Array" && len(o.Keys()) > 2 {
+		depth = -1
+	}
+	opts := r.NewObject()
+	opts.Set("depth", depth)
+	opts.Set("maxArrayLength", 3)
+	opts.Set("breakLength", math.Inf(1))
+	assign(opts, c.inspectOptions(false))
+	return c.inspect(v, opts)
+}
+
+func (c *Console) indexColumn(length int) []string {
+	col := make([]string, length)
+	for i := range col {
+		col[i] = c.inspectCell(c.runtime.ToValue(i))
+	}
+	return col
+}
+
+func (c *Console) hasOwnProperty(o *goja.Object, key string) bool {
+	r := c.runtime
+	proto := r.Get("Object").ToObject(r).Get("prototype").ToObject(r)
+	hasOwn, _ := goja.AssertFunction(proto.Get("hasOwnProperty"))
+	res, err := hasOwn(o, r.ToValue(key))
+	if err != nil {
+		panic(err)
+	}
+	return res.ToBoolean()
+}
+
+// renderTable builds the output of console.table(). Unlike nodejs, map and set iterators are not previewed
+// because that would consume them, so they are shown like any other object.
+func (c *Console) renderTable(data *goja.Object, properties []string) string {
+	r := c.runtime
+	if c.types.IsMap(data) {
+		var keys, values []string
+		r.ForOf(data, func(entry goja.Value) bool {
+			e := entry.(*goja.Object)
+			keys = append(keys, c.inspectCell(e.Get("0")))
+			values = append(values, c.inspectCell(e.Get("1")))
+			return true
+		})
+		return cliTable([]string{iterKey, keyKey, valuesKey}, [][]string{c.indexColumn(len(keys)), keys, values})
+	}
+
+	if c.types.IsSet(data) {
+		var values []string
+		r.ForOf(data, func(v goja.Value) bool {
+			values = append(values, c.inspectCell(v))
+			return true
+		})
+		return cliTable([]string{iterKey, valuesKey}, [][]string{c.indexColumn(len(values)), values})
+	}
+
+	var columns tableColumns
+	var primitives []string
+	hasPrimitives := false
+	indexKeys := data.Keys()
+	for i, key := range indexKeys {
+		item := data.Get(key)
+		o, isObject := item.(*goja.Object)
+		if properties == nil && !isObject {
+			hasPrimitives = true
+			for len(primitives) <= i {
+				primitives = append(primitives, "")
+			}
+			primitives[i] = c.inspectCell(item)
+			continue
+		}
+		keys := properties
+		if keys == nil {
+			keys = o.Keys()
+		}
+		for _, k := range keys {
+			if !isObject || !c.hasOwnProperty(o, k) {
+				columns.set(k, i, "")
+			} else {
+				columns.set(k, i, c.inspectCell(o.Get(k)))
+			}
+		}
+	}
+
+	head := append([]string{indexKey}, columns.keys()...)
+	cols := [][]string{indexKeys}
+	for _, name := range head[1:] {
+		cols = append(cols, columns.cells[name])
+	}
+	if hasPrimitives {
+		head = append(head, valuesKey)
+		cols = append(cols, primitives)
+	}
+	return cliTable(head, cols)
+}
+
+func renderRow(row []string, columnWidths []int) string {
+	var b strings.Builder
+	b.WriteString("│ ")
+	for i, cell := range row {
+		b.WriteString(cell)
+		b.WriteString(strings.Repeat(" ", columnWidths[i]-stringWidth(cell)))
+		if i != len(row)-1 {
+			b.WriteString(" │ ")
+		}
+	}
+	b.WriteString(" │")
+	return b.String()
+}
+
+// cliTable renders the columns as a table with box-drawing characters, like nodejs' internal/cli_table.
+func cliTable(head []string, columns [][]string) string {
+	columnWidths := make([]int, len(head))
+	longestColumn := 0
+	for i, h := range head {
+		columnWidths[i] = stringWidth(h)
+		if l := len(columns[i]); l > longestColumn {
+			longestColumn = l
+		}
+	}
+
+	rows := make([][]string, longestColumn)
+	for j := range rows {
+		rows[j] = make([]string, len(head))
+		for i, column := range columns {
+			if j < len(column) {
+				rows[j][i] = column[j]
+			}
+			if w := stringWidth(rows[j][i]); w > columnWidths[i] {
+				columnWidths[i] = w
+			}
+		}
+	}
+
+	divider := make([]string, len(columnWidths))
+	for i, w := range columnWidths {
+		divider[i] = strings.Repeat("─", w+2)
+	}
+
+	var b strings.Builder
+	b.WriteString("┌" + strings.Join(divider, "┬") + "┐\n")
+	b.WriteString(renderRow(head, columnWidths) + "\n")
+	b.WriteString("├" + strings.Join(divider, "┼") + "┤\n")
+	for _, row := range rows {
+		b.WriteString(renderRow(row, columnWidths) + "\n")
+	}
+	b.WriteString("└" + strings.Join(divider, "┴") + "┘")
+	return b.String()
+}
@@ -2,14 +2,41 @@ package errors
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/nuvolaris/goja"
 )
 
 const (
-	ErrCodeInvalidArgType = "ERR_INVALID_ARG_TYPE"
-	ErrCodeInvalidThis    = "ERR_INVALID_THIS"
-	ErrCodeMissingArgs    = "ERR_MISSING_ARGS"
+	ErrCodeInvalidArgType    = "ERR_INVALID_ARG_TYPE"
+	ErrCodeInvalidArgValue   = "ERR_INVALID_ARG_VALUE"
+	ErrCodeInvalidThis       = "ERR_INVALID_THIS"
+	ErrCodeMissingArgs       = "ERR_MISSING_ARGS"
+	ErrCodeOutOfRange        = "ERR_OUT_OF_RANGE"
+	ErrCodeBufferOutOfBounds = "ERR_BUFFER_OUT_OF_BOUNDS"
+	ErrCodeUnknownEncoding   = "ERR_UNKNOWN_ENCODING"
+
+	ErrCodeFalsyValueRejection = "ERR_FALSY_VALUE_REJECTION"
+
+	ErrCodeUnhandledError = "ERR_UNHANDLED_ERROR"
+
+	ErrCodeConsoleWritableStream  = "ERR_CONSOLE_WRITABLE_STREAM"
+	ErrCodeIncompatibleOptionPair = "ERR_INCOMPATIBLE_OPTION_PAIR"
+
+	ErrCodeParseArgsInvalidOptionValue   = "ERR_PARSE_ARGS_INVALID_OPTION_VALUE"
+	ErrCodeParseArgsUnknownOption        = "ERR_PARSE_ARGS_UNKNOWN_OPTION"
+	ErrCodeParseArgsUnexpectedPositional = "ERR_PARSE_ARGS_UNEXPECTED_POSITIONAL"
+
+	ErrCodeEncodingNotSupported = "ERR_ENCODING_NOT_SUPPORTED"
+	ErrCodeEncodingInvalidData  = "ERR_ENCODING_INVALID_ENCODED_DATA"
+
+	ErrCodeInvalidFileURLHost = "ERR_INVALID_FILE_URL_HOST"
+	ErrCodeInvalidFileURLPath = "ERR_INVALID_FILE_URL_PATH"
+	ErrCodeInvalidURLScheme   = "ERR_INVALID_URL_SCHEME"
+	ErrCodeInvalidURI         = "ERR_INVALID_URI"
+	ErrCodeInvalidURLPattern  = "ERR_INVALID_URL_PATTERN"
 )
 
 func error_toString(call goja.FunctionCall, r *goja.Runtime) goja.Value {
@@ -68,3 +95,157 @@ func NewError(r *goja.Runtime, ctor *goja.Object, code string, args ...interface
 	addProps(r, o, code)
 	return o
 }
+
+func NewRangeError(r *goja.Runtime, code string, params ...interface{}) *goja.Object {
+	ctor, _ := r.Get("RangeError").(*goja.Object)
+	return NewError(r, ctor, code, params...)
+}
+
+// NewArgTypeError creates an ERR_INVALID_ARG_TYPE TypeError for the argument with the given name.
+// The expected parameter should complete the sentence 'The "name" argument must be ...', e.g. "of type number".
+func NewArgTypeError(r *goja.Runtime, name, expected string, actual goja.Value) *goja.Object {
+	what := "argument"
+	if strings.IndexByte(name, '.') != -1 {
+		what = "property"
+	}
+	return NewTypeError(r, ErrCodeInvalidArgType, "The %q %s must be %s. Received %s", name, what, expected, DescribeReceived(r, actual))
+}
+
+// NewArgValueError creates an ERR_INVALID_ARG_VALUE TypeError for the argument with the given name.
+// The reason should complete the sentence "The argument 'name' ...", e.g. "must be a single character",
+// and received is the inspected value.
+func NewArgValueError(r *goja.Runtime, name, reason, received string) *goja.Object {
+	what := "argument"
+	if strings.IndexByte(name, '.') != -1 {
+		what = "property"
+	}
+	if len(received) > 128 {
+		received = received[:128] + "..."
+	}
+	return NewTypeError(r, ErrCodeInvalidArgValue, "The %s '%s' %s. Received %s", what, name, reason, received)
+}
+
+// NewOutOfRangeError creates an ERR_OUT_OF_RANGE RangeError. The rng parameter should complete the sentence
+// 'It must be ...', e.g. ">= 0 and <= 255".
+func NewOutOfRangeError(r *goja.Runtime, name, rng string, received goja.Value) *goja.Object {
+	var s string
+	switch {
+	case isNumber(received):
+		if f := received.ToFloat(); f == math.Trunc(f) && math.Abs(f) > 1<<32 {
+			s = addNumericalSeparator(received.String())
+		} else {
+			s = received.String()
+		}
+	case isString(received):
+		s = "'" + received.String() + "'"
+	default:
+		s = received.String()
+	}
+	return NewRangeError(r, ErrCodeOutOfRange, "The value of %q is out of range. It must be %s. Received %s", name, rng, s)
+}
+
+// Legacy codes of the DOMException names, see https://webidl.spec.whatwg.org/#dfn-error-names-table
+var domExceptionCodes = map[string]int{
+	"IndexSizeError":        1,
+	"InvalidCharacterError": 5,
+	"NotFoundError":         8,
+	"NotSupportedError":     9,
+	"InvalidStateError":     11,
+	"SyntaxError":           12,
+	"AbortError":            20,
+	"DataCloneError":        25,
+}
+
+// NewDOMException creates an error that mimics a DOMException with the given name. The runtime does not
+// provide the DOMException class, so the result is an Error with the name and the legacy code set.
+func NewDOMException(r *goja.Runtime, name string, format string, args ...interface{}) *goja.Object {
+	ctor, _ := r.Get("Error").(*goja.Object)
+	o, err := r.New(ctor, r.ToValue(fmt.Sprintf(format, args...)))
+	if err != nil {
+		panic(err)
+	}
+	o.DefineDataProperty("name", r.ToValue(name), goja.FLAG_TRUE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	o.DefineDataProperty("code", r.ToValue(domExceptionCodes[name]), goja.FLAG_TRUE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	return o
+}
+
+func NewBufferOutOfBoundsError(r *goja.Runtime, name string) *goja.Object {
+	if name != "" {
+		return NewRangeError(r, ErrCodeBufferOutOfBounds, "%q is outside of buffer bounds", name)
+	}
+	return NewRangeError(r, ErrCodeBufferOutOfBounds, "Attempt to access memory outside buffer bounds")
+}
+
+func isNumber(v goja.Value) bool {
+	switch v.Export().(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+func isString(v goja.Value) bool {
+	if _, ok := v.(*goja.Symbol); ok {
+		return false
+	}
+	_, ok := v.Export().(string)
+	return ok
+}
+
+func addNumericalSeparator(s string) string {
+	start := 0
+	if strings.HasPrefix(s, "-") {
+		start = 1
+	}
+	var sb strings.Builder
+	sb.WriteString(s[:start])
+	digits := s[start:]
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte('_')
+		}
+		sb.WriteByte(digits[i])
+	}
+	return sb.String()
+}
+
+// DescribeReceived returns the description of a value used in the "Received ..." part of nodejs error messages.
+func DescribeReceived(r *goja.Runtime, v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined"
+	}
+	if goja.IsNull(v) {
+		return "null"
+	}
+	if o, ok := v.(*goja.Object); ok {
+		if _, ok := goja.AssertFunction(o); ok {
+			if name := o.Get("name"); name != nil && name.String() != "" {
+				return "function " + name.String()
+			}
+			return "function "
+		}
+		if ctor, ok := o.Get("constructor").(*goja.Object); ok {
+			if name := ctor.Get("name"); name != nil && name.String() != "" {
+				return "an instance of " + name.String()
+			}
+		}
+		return "[Object: null prototype]"
+	}
+	var typ, inspected string
+	switch val := v.Export().(type) {
+	case string:
+		if _, isSym := v.(*goja.Symbol); isSym {
+			typ, inspected = "symbol", v.String()
+		} else {
+			typ, inspected = "string", "'"+val+"'"
+		}
+	case bool:
+		typ, inspected = "boolean", strconv.FormatBool(val)
+	default:
+		typ, inspected = "number", v.String()
+	}
+	if len(inspected) > 28 {
+		inspected = inspected[:25] + "..."
+	}
+	return fmt.Sprintf("type %s (%s)", typ, inspected)
+}
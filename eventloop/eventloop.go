@@ -1,12 +1,17 @@
 package eventloop
 
 import (
+	"errors"
+	"os"
+	"os/signal"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/nuvolaris/goja"
 	"github.com/nuvolaris/goja_nodejs/console"
+	"github.com/nuvolaris/goja_nodejs/internal/hooks"
+	"github.com/nuvolaris/goja_nodejs/process"
 	"github.com/nuvolaris/goja_nodejs/require"
 )
 
@@ -46,7 +51,17 @@ type EventLoop struct {
 	running  bool
 
 	enableConsole bool
+	consoleLoader require.ModuleLoader
+	processLoader require.ModuleLoader
 	registry      *require.Registry
+
+	process  *process.Process
+	signals  []os.Signal
+	sigChan  chan os.Signal
+	exitChan chan struct{}
+	exited   bool
+	exitCode int
+	err      error
 }
 
 func NewEventLoop(opts ...Option) *EventLoop {
@@ -56,6 +71,7 @@ func NewEventLoop(opts ...Option) *EventLoop {
 		vm:            vm,
 		jobChan:       make(chan func()),
 		wakeupChan:    make(chan struct{}, 1),
+		exitChan:      make(chan struct{}),
 		enableConsole: true,
 	}
 	loop.stopCond = sync.NewCond(&loop.stopLock)
@@ -66,10 +82,29 @@ func NewEventLoop(opts ...Option) *EventLoop {
 	if loop.registry == nil {
 		loop.registry = new(require.Registry)
 	}
-	loop.registry.Enable(vm)
+	req := loop.registry.Enable(vm)
+	if loop.consoleLoader != nil {
+		hooks.RegisterNativeModule(req, console.ModuleName, loop.consoleLoader)
+	}
+	// The "process" module is only loaded when the script requires it, then the loop handles its events. The
+	// signals can't be forwarded without it.
+	processLoader := loop.processLoader
+	if processLoader == nil {
+		processLoader = hooks.NativeModuleLoader(req, process.ModuleName)
+	}
+	hooks.RegisterNativeModule(req, process.ModuleName, func(runtime *goja.Runtime, module *goja.Object) {
+		processLoader(runtime, module)
+		if loop.process = process.GetApi(runtime); loop.process != nil {
+			loop.process.SetExitHandler(loop.exit)
+			loop.process.SetCallbackRegistrar(loop.registerCallback)
+		}
+	})
 	if loop.enableConsole {
 		console.Enable(vm)
 	}
+	if len(loop.signals) > 0 {
+		process.GetApi(vm)
+	}
 	vm.Set("setTimeout", loop.setTimeout)
 	vm.Set("setInterval", loop.setInterval)
 	vm.Set("setImmediate", loop.setImmediate)
@@ -92,6 +127,39 @@ func EnableConsole(enableConsole bool) Option {
 	}
 }
 
+// WithConsolePrinter sets the printer of the "console" module of the runtime used by the loop, overriding
+// the one of the registry. Unlike Registry.RegisterNativeModule, it only affects this loop, so the loops
+// sharing a registry can capture their output separately.
+func WithConsolePrinter(printer console.Printer) Option {
+	return func(loop *EventLoop) {
+		loop.consoleLoader = console.RequireWithPrinter(printer)
+	}
+}
+
+// WithConsoleStructuredPrinter is like WithConsolePrinter but for a console.StructuredPrinter.
+func WithConsoleStructuredPrinter(printer console.StructuredPrinter) Option {
+	return func(loop *EventLoop) {
+		loop.consoleLoader = console.RequireWithStructuredPrinter(printer)
+	}
+}
+
+// WithProcessOptions configures the "process" module of the runtime used by the loop, overriding the one of
+// the registry, so that each loop can have its own environment, arguments and working directory.
+func WithProcessOptions(opts ...process.Option) Option {
+	return func(loop *EventLoop) {
+		loop.processLoader = process.RequireWithOptions(opts...)
+	}
+}
+
+// WithSignals forwards the signals the Go process receives while the loop is running to the script, as the
+// process events such as 'SIGINT'. Like in nodejs, a signal the script doesn't listen to stops the loop with
+// the exit code 128 + the number of the signal, see ExitCode(). By default no signal is forwarded.
+func WithSignals(signals ...os.Signal) Option {
+	return func(loop *EventLoop) {
+		loop.signals = signals
+	}
+}
+
 func WithRegistry(registry *require.Registry) Option {
 	return func(loop *EventLoop) {
 		loop.registry = registry
@@ -190,10 +258,17 @@ func (loop *EventLoop) setRunning() {
 	}
 	loop.running = true
 	atomic.StoreInt32(&loop.canRun, 1)
+	loop.err = nil
+	if len(loop.signals) > 0 && loop.process != nil {
+		loop.sigChan = make(chan os.Signal, 1)
+		signal.Notify(loop.sigChan, loop.signals...)
+	}
 }
 
 // Run calls the specified function, starts the event loop and waits until there are no more delayed jobs to run
-// after which it stops the loop and returns.
+// after which it stops the loop and returns. It also returns when the script calls process.exit(), see ExitCode().
+// The error thrown by a listener of the 'beforeExit' or 'exit' events of the process is returned by Err().
+// After process.exit() the loop is single-use: a later Run only calls the function.
 // The instance of goja.Runtime that is passed to the function and any Values derived from it must not be used
 // outside the function.
 // Do NOT use this function while the loop is already running. Use RunOnLoop() instead.
@@ -204,7 +279,8 @@ func (loop *EventLoop) Run(fn func(*goja.Runtime)) {
 	loop.run(false)
 }
 
-// Start the event loop in the background. The loop continues to run until Stop() is called.
+// Start the event loop in the background. The loop continues to run until Stop() is called or the script
+// calls process.exit(). The error thrown by a listener of the process events is returned by Err().
 // If the loop is already started it will panic.
 func (loop *EventLoop) Start() {
 	loop.setRunning()
@@ -249,31 +325,107 @@ func (loop *EventLoop) RunOnLoop(fn func(*goja.Runtime)) {
 	loop.addAuxJob(func() { fn(loop.vm) })
 }
 
+// registerCallback keeps the loop running until the returned function is called, from any goroutine, to run
+// the callback on the loop. It must be called on the loop.
+func (loop *EventLoop) registerCallback() func(func()) {
+	loop.jobCount++
+	return func(fn func()) {
+		loop.addAuxJob(func() {
+			loop.jobCount--
+			fn()
+		})
+	}
+}
+
+// exit is called by process.exit() on the loop: the jobs that did not run yet are discarded and the loop stops.
+// The timers and the intervals stop sending their jobs.
+func (loop *EventLoop) exit(code int) {
+	if !loop.exited {
+		close(loop.exitChan)
+	}
+	loop.exited, loop.exitCode = true, code
+	atomic.StoreInt32(&loop.canRun, 0)
+	loop.wakeup()
+}
+
+// ExitCode returns the exit code of the loop and whether the script called process.exit(). The code is the
+// one passed to process.exit(), otherwise the value of process.exitCode after the 'exit' listeners ran.
+// Like a nodejs process, a loop is done after process.exit(): it can be run again to call a function with
+// the runtime, but it does not run any more jobs. It must not be called while the loop is running.
+func (loop *EventLoop) ExitCode() (code int, exited bool) {
+	return loop.exitCode, loop.exited
+}
+
+// Err returns the error thrown by a listener of the 'beforeExit' or 'exit' events of the process during the
+// last run. It must not be called while the loop is running.
+func (loop *EventLoop) Err() error {
+	return loop.err
+}
+
+// listenerError records the error thrown by a listener of the process events. A listener calling process.exit()
+// interrupts the script, that is an exit and not an error.
+func (loop *EventLoop) listenerError(err error) {
+	var exit *process.ExitError
+	if err != nil && !errors.As(err, &exit) && loop.err == nil {
+		loop.err = err
+	}
+}
+
 func (loop *EventLoop) runAux() {
 	loop.auxJobsLock.Lock()
 	jobs := loop.auxJobs
 	loop.auxJobs = loop.auxJobsSpare
 	loop.auxJobsLock.Unlock()
 	for i, job := range jobs {
-		job()
+		if !loop.exited {
+			job()
+		}
 		jobs[i] = nil
 	}
 	loop.auxJobsSpare = jobs[:0]
 }
 
+// signal emits the process event of the signal, the loop exits if the script doesn't listen to it.
+func (loop *EventLoop) signal(sig os.Signal) {
+	if handled, _ := loop.process.EmitSignal(sig); !handled {
+		loop.exit(process.SignalExitCode(sig))
+	}
+}
+
 func (loop *EventLoop) run(inBackground bool) {
 	loop.runAux()
 	if inBackground {
 		loop.jobCount++
 	}
+	stopped := false
 LOOP:
-	for loop.jobCount > 0 {
+	for !loop.exited {
+		if loop.jobCount == 0 {
+			if loop.process == nil {
+				break
+			}
+			// Like in nodejs, the listeners of beforeExit can schedule more work.
+			if err := loop.process.EmitBeforeExit(); err != nil {
+				loop.listenerError(err)
+				if loop.err != nil {
+					break
+				}
+			}
+			loop.runAux()
+			if loop.jobCount == 0 {
+				break
+			}
+			continue
+		}
 		select {
 		case job := <-loop.jobChan:
 			job()
+		case sig := <-loop.sigChan:
+			loop.signal(sig)
 		case <-loop.wakeupChan:
 			loop.runAux()
 			if atomic.LoadInt32(&loop.canRun) == 0 {
+				stopped = true
 				break LOOP
 			}
 		}
@@ -281,6 +433,17 @@ LOOP:
 	if inBackground {
 		loop.jobCount--
 	}
+	if loop.sigChan != nil {
+		signal.Stop(loop.sigChan)
+		loop.sigChan = nil
+	}
+	if loop.process != nil && !loop.exited && (stopped || !inBackground) {
+		code, err := loop.process.EmitExit()
+		loop.listenerError(err)
+		if !loop.exited {
+			loop.exitCode = code
+		}
+	}
 
 	loop.stopLock.Lock()
 	loop.running = false
@@ -307,8 +470,11 @@ func (loop *EventLoop) addTimeout(f func(), timeout time.Duration) *Timer {
 		job: job{fn: f},
 	}
 	t.timer = time.AfterFunc(timeout, func() {
-		loop.jobChan <- func() {
+		select {
+		case loop.jobChan <- func() {
 			loop.doTimeout(t)
+		}:
+		case <-loop.exitChan:
 		}
 	})
 
@@ -393,9 +559,17 @@ L:
 		case <-i.stopChan:
 			i.ticker.Stop()
 			break L
+		case <-loop.exitChan:
+			i.ticker.Stop()
+			break L
 		case <-i.ticker.C:
-			loop.jobChan <- func() {
+			select {
+			case loop.jobChan <- func() {
 				loop.doInterval(i)
+			}:
+			case <-loop.exitChan:
+				i.ticker.Stop()
+				break L
 			}
 		}
 	}
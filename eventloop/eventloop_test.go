@@ -1,12 +1,19 @@
 package eventloop
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"runtime"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/console"
+	"github.com/nuvolaris/goja_nodejs/process"
+	"github.com/nuvolaris/goja_nodejs/require"
 )
 
 func TestRun(t *testing.T) {
@@ -165,6 +172,143 @@ func TestRunNoConsole(t *testing.T) {
 	}
 }
 
+func TestRunWithConsolePrinter(t *testing.T) {
+	t.Parallel()
+	const SCRIPT = `
+	console.log("from %s", name);
+	require("node:console").error("error");
+	`
+
+	registry := new(require.Registry)
+	var out [2]strings.Builder
+	for i := range out {
+		printer := console.StdPrinter{
+			StdoutPrint: func(s string) { out[i].WriteString(s + "\n") },
+			StderrPrint: func(s string) { out[i].WriteString("E " + s + "\n") },
+		}
+		loop := NewEventLoop(WithRegistry(registry), WithConsolePrinter(printer))
+		var err error
+		loop.Run(func(vm *goja.Runtime) {
+			vm.Set("name", fmt.Sprintf("loop%d", i))
+			_, err = vm.RunString(SCRIPT)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range out {
+		if want := fmt.Sprintf("from loop%d\nE error\n", i); out[i].String() != want {
+			t.Fatalf("Unexpected output of loop %d: got %q, want %q", i, out[i].String(), want)
+		}
+	}
+}
+
+func TestRunWithProcessOptions(t *testing.T) {
+	t.Parallel()
+	registry := new(require.Registry)
+	for i := 0; i < 2; i++ {
+		name := fmt.Sprintf("tenant%d", i)
+		loop := NewEventLoop(WithRegistry(registry), WithProcessOptions(
+			process.WithEnv(map[string]string{"NAME": name}),
+			process.WithArgv("node", name+".js"),
+		))
+		var v goja.Value
+		var err error
+		loop.Run(func(vm *goja.Runtime) {
+			v, err = vm.RunString(`const process = require("process"); process.env.NAME + " " + process.argv[1]`)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := name + " " + name + ".js"; v.String() != want {
+			t.Fatalf("Unexpected result: got %q, want %q", v, want)
+		}
+	}
+}
+
+func TestProcessExit(t *testing.T) {
+	t.Parallel()
+	const SCRIPT = `
+	var ticks = 0;
+	setInterval(function() { ticks++; }, 1);
+	setTimeout(function() {
+		Promise.resolve().then(function() {
+			process.exitCode = 2;
+			process.exit();
+			ticks = -1;
+		});
+	}, 20);
+	`
+
+	loop := NewEventLoop()
+	var err error
+	loop.Run(func(vm *goja.Runtime) {
+		process.Enable(vm)
+		_, err = vm.RunString(SCRIPT)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, exited := loop.ExitCode(); !exited || code != 2 {
+		t.Fatalf("Unexpected exit: %d, %v", code, exited)
+	}
+	loop.Run(func(vm *goja.Runtime) {
+		if ticks := vm.Get("ticks").ToInteger(); ticks <= 0 {
+			t.Fatalf("Unexpected ticks: %d", ticks)
+		}
+	})
+}
+
+func TestProcessExitInScript(t *testing.T) {
+	t.Parallel()
+	loop := NewEventLoop()
+	var err error
+	loop.Run(func(vm *goja.Runtime) {
+		vm.Set("later", func(goja.FunctionCall) goja.Value {
+			t.Error("a job ran after process.exit()")
+			return nil
+		})
+		_, err = vm.RunString(`
+		setTimeout(later, 0);
+		try {
+			require("process").exit(3);
+		} catch (e) {
+		}
+		later();
+		`)
+	})
+	var exit *process.ExitError
+	if !errors.As(err, &exit) || exit.Code != 3 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if code, exited := loop.ExitCode(); !exited || code != 3 {
+		t.Fatalf("Unexpected exit: %d, %v", code, exited)
+	}
+}
+
+func TestProcessLoadedOnRequire(t *testing.T) {
+	t.Parallel()
+	loaded := false
+	registry := new(require.Registry)
+	registry.RegisterNativeModule(process.ModuleName, func(runtime *goja.Runtime, module *goja.Object) {
+		loaded = true
+		process.Require(runtime, module)
+	})
+	loop := NewEventLoop(WithRegistry(registry))
+	loop.Run(func(vm *goja.Runtime) {
+		if loaded {
+			t.Fatal("the process module was loaded before it was required")
+		}
+		vm.RunString(`setTimeout(() => require("process").exit(4), 0)`)
+	})
+	if !loaded {
+		t.Fatal("the process module of the registry was not used")
+	}
+	if code, exited := loop.ExitCode(); !exited || code != 4 {
+		t.Fatalf("Unexpected exit: %d, %v", code, exited)
+	}
+}
+
 func TestClearIntervalRace(t *testing.T) {
 	t.Parallel()
 	const SCRIPT = `
@@ -444,3 +588,152 @@ func TestEventLoop_StopNoWait(t *testing.T) {
 		t.Fatal("ran != 0")
 	}
 }
+
+func TestProcessBeforeExitAndExit(t *testing.T) {
+	t.Parallel()
+	loop := NewEventLoop()
+	var v goja.Value
+	loop.Run(func(vm *goja.Runtime) {
+		_, err := vm.RunString(`
+		const process = require("process");
+		var calls = [];
+		let more = true;
+		process.on("beforeExit", (code) => {
+			calls.push("beforeExit " + code);
+			if (more) {
+				more = false;
+				setTimeout(() => calls.push("timeout"), 0);
+			}
+		});
+		process.on("exit", (code) => calls.push("exit " + code));
+		`)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	loop.Run(func(vm *goja.Runtime) {
+		v, _ = vm.RunString(`calls.join()`)
+	})
+	if want := "beforeExit 0,timeout,beforeExit 0,exit 0"; v.String() != want {
+		t.Fatalf("Unexpected calls: got %s, want %s", v, want)
+	}
+	if _, exited := loop.ExitCode(); exited {
+		t.Fatal("the loop exited")
+	}
+}
+
+func TestProcessExitCode(t *testing.T) {
+	t.Parallel()
+	loop := NewEventLoop()
+	loop.Run(func(vm *goja.Runtime) {
+		vm.RunString(`
+		const process = require("process");
+		process.exitCode = 3;
+		process.on("exit", (code) => { process.exitCode = code + 1; });
+		`)
+	})
+	if err := loop.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if code, exited := loop.ExitCode(); exited || code != 4 {
+		t.Fatalf("Unexpected exit: %d, %v", code, exited)
+	}
+}
+
+func TestProcessExitListenerError(t *testing.T) {
+	t.Parallel()
+	loop := NewEventLoop()
+	loop.Run(func(vm *goja.Runtime) {
+		vm.RunString(`require("process").on("exit", () => { throw new Error("in exit"); })`)
+	})
+	var ex *goja.Exception
+	if err := loop.Err(); !errors.As(err, &ex) || ex.Value().ToObject(nil).Get("message").String() != "in exit" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	loop = NewEventLoop()
+	loop.Run(func(vm *goja.Runtime) {
+		vm.RunString(`
+		const process = require("process");
+		process.on("beforeExit", () => { throw new Error("in beforeExit"); });
+		process.on("exit", () => process.exit(5));
+		`)
+	})
+	if err := loop.Err(); !errors.As(err, &ex) || ex.Value().ToObject(nil).Get("message").String() != "in beforeExit" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if code, exited := loop.ExitCode(); !exited || code != 5 {
+		t.Fatalf("Unexpected exit: %d, %v", code, exited)
+	}
+}
+
+func TestProcessExitStopsTimers(t *testing.T) {
+	// Not parallel, it counts the goroutines.
+	before := runtime.NumGoroutine()
+	loop := NewEventLoop()
+	loop.Run(func(vm *goja.Runtime) {
+		vm.RunString(`
+		setInterval(() => {}, 1);
+		setTimeout(() => {}, 50);
+		setTimeout(() => require("process").exit(), 20);
+		`)
+	})
+	if _, exited := loop.ExitCode(); !exited {
+		t.Fatal("the loop did not exit")
+	}
+	// The timeout fires after the exit, it must not block either.
+	time.Sleep(100 * time.Millisecond)
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines were left after the exit", runtime.NumGoroutine()-before)
+		}
+	}
+}
+
+func TestProcessExitEventOnStop(t *testing.T) {
+	t.Parallel()
+	loop := NewEventLoop()
+	exited := make(chan struct{})
+	loop.Start()
+	loop.RunOnLoop(func(vm *goja.Runtime) {
+		vm.Set("done", func() { close(exited) })
+		vm.RunString(`require("process").on("exit", done)`)
+	})
+	loop.Stop()
+	select {
+	case <-exited:
+	default:
+		t.Fatal("the exit event was not emitted")
+	}
+}
+
+func TestProcessStdin(t *testing.T) {
+	t.Parallel()
+	r, w := io.Pipe()
+	var stdout strings.Builder
+	loop := NewEventLoop(WithProcessOptions(process.WithStdin(r), process.WithStdout(&stdout)))
+	go func() {
+		w.Write([]byte("one "))
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("two"))
+		w.Close()
+	}()
+	loop.Run(func(vm *goja.Runtime) {
+		_, err := vm.RunString(`
+		const process = require("process");
+		(async () => {
+			const it = process.stdin.iterator();
+			for (let res = await it.next(); !res.done; res = await it.next()) {
+				process.stdout.write(res.value.toString().toUpperCase());
+			}
+			process.stdout.write("!");
+		})();
+		`)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	if want := "ONE TWO!"; stdout.String() != want {
+		t.Fatalf("Unexpected stdout: got %q, want %q", stdout.String(), want)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build unix
+
+package eventloop
+
+import (
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nuvolaris/goja"
+)
+
+func TestSignals(t *testing.T) {
+	loop := NewEventLoop(WithSignals(syscall.SIGUSR1, syscall.SIGUSR2))
+	var v goja.Value
+	loop.Run(func(vm *goja.Runtime) {
+		vm.Set("kill", func() {
+			syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+		})
+		_, err := vm.RunString(`
+		var got;
+		const keepAlive = setInterval(() => {}, 10);
+		require("process").once("SIGUSR1", (name, num) => {
+			got = name + " " + num;
+			clearInterval(keepAlive);
+		});
+		kill();
+		`)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	loop.Run(func(vm *goja.Runtime) {
+		v = vm.Get("got")
+	})
+	if want := "SIGUSR1 " + strconv.Itoa(int(syscall.SIGUSR1)); v == nil || v.String() != want {
+		t.Fatalf("Unexpected signal event: %v", v)
+	}
+
+	// Without a listener the loop exits, like nodejs does.
+	start := time.Now()
+	loop.Run(func(vm *goja.Runtime) {
+		vm.Set("kill", func() {
+			syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+		})
+		vm.RunString(`setTimeout(() => {}, 5000); kill()`)
+	})
+	if code, exited := loop.ExitCode(); !exited || code != 128+int(syscall.SIGUSR2) {
+		t.Fatalf("Unexpected exit: %d, %v", code, exited)
+	}
+	if time.Since(start) > 4*time.Second {
+		t.Fatal("the loop didn't exit on the signal")
+	}
+}
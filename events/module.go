@@ -0,0 +1,482 @@
+package events
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+	"github.com/nuvolaris/goja_nodejs/require"
+	"github.com/nuvolaris/goja_nodejs/util"
+)
+
+const ModuleName = "events"
+
+const defaultMaxListeners = 10
+
+var symState = goja.NewSymbol("events")
+
+// key identifies an event: the names are property keys, so the numbers are the same as their strings.
+type key struct {
+	name string
+	sym  *goja.Symbol
+}
+
+type listener struct {
+	fn    *goja.Object
+	once  bool
+	fired bool
+
+	// wrapper is the function returned by rawListeners() for a once listener.
+	wrapper *goja.Object
+}
+
+// emitter is the state of an EventEmitter, kept in a hidden property of the object.
+type emitter struct {
+	listeners map[key][]*listener
+
+	// names are the event names with listeners in the order they were added, for eventNames().
+	names []goja.Value
+
+	maxListeners goja.Value
+	warned       map[key]bool
+}
+
+type eventsModule struct {
+	r    *goja.Runtime
+	ctor *goja.Object
+
+	errorMonitor *goja.Symbol
+}
+
+func (m *eventsModule) key(name goja.Value) key {
+	if sym, ok := name.(*goja.Symbol); ok {
+		return key{sym: sym}
+	}
+	return key{name: name.String()}
+}
+
+// state returns the state of the emitter, creating it if the object was not initialized by the constructor,
+// e.g. because it was created with Object.create(EventEmitter.prototype).
+func (m *eventsModule) state(this goja.Value) (*goja.Object, *emitter) {
+	o := this.ToObject(m.r)
+	if v := o.GetSymbol(symState); v != nil {
+		if e, ok := v.Export().(*emitter); ok {
+			return o, e
+		}
+	}
+	return o, m.init(o)
+}
+
+func (m *eventsModule) init(o *goja.Object) *emitter {
+	e := &emitter{
+		listeners:    make(map[key][]*listener),
+		maxListeners: goja.Undefined(),
+		warned:       make(map[key]bool),
+	}
+	o.DefineDataPropertySymbol(symState, m.r.ToValue(e), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	return e
+}
+
+func (m *eventsModule) validateListener(v goja.Value) *goja.Object {
+	if _, ok := goja.AssertFunction(v); !ok {
+		panic(errors.NewArgTypeError(m.r, "listener", "of type function", v))
+	}
+	return v.(*goja.Object)
+}
+
+func (m *eventsModule) call(fn goja.Value, this goja.Value, args ...goja.Value) goja.Value {
+	f, _ := goja.AssertFunction(fn)
+	res, err := f(this, args...)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (m *eventsModule) maxListeners(e *emitter) int {
+	v := e.maxListeners
+	if goja.IsUndefined(v) {
+		v = m.ctor.Get("defaultMaxListeners")
+	}
+	return int(v.ToInteger())
+}
+
+func (m *eventsModule) addListener(this, name, fn goja.Value, prepend, once bool) goja.Value {
+	l := &listener{fn: m.validateListener(fn), once: once}
+	o, e := m.state(this)
+	k := m.key(name)
+	if _, ok := e.listeners[key{name: "newListener"}]; ok {
+		m.emit(o, m.r.ToValue("newListener"), name, fn)
+	}
+	list, ok := e.listeners[k]
+	if !ok {
+		e.names = append(e.names, name)
+	}
+	if prepend {
+		list = append([]*listener{l}, list...)
+	} else {
+		list = append(list, l)
+	}
+	e.listeners[k] = list
+
+	if max := m.maxListeners(e); max > 0 && len(list) > max && !e.warned[k] {
+		e.warned[k] = true
+		m.warnMaxListeners(o, name, len(list), max)
+	}
+	return o
+}
+
+// warnMaxListeners emits a MaxListenersExceededWarning using process.emitWarning() if it's available,
+// otherwise it prints it to stderr the same way nodejs does by default.
+func (m *eventsModule) warnMaxListeners(o *goja.Object, name goja.Value, count, max int) {
+	r := m.r
+	target := "Object"
+	if ctor, ok := o.Get("constructor").(*goja.Object); ok {
+		if n := ctor.Get("name"); n != nil && n.String() != "" {
+			target = n.String()
+		}
+	}
+	event := name.String()
+	if _, ok := name.(*goja.Symbol); ok {
+		event = "Symbol(" + event + ")"
+	}
+	msg := "Possible EventEmitter memory leak detected. " + strconv.Itoa(count) + " " + event +
+		" listeners added to [" + target + "]. MaxListeners is " + strconv.Itoa(max) +
+		". Use emitter.setMaxListeners() to increase limit"
+	if proc, ok := r.Get("process").(*goja.Object); ok {
+		if emitWarning, ok := goja.AssertFunction(proc.Get("emitWarning")); ok {
+			w, err := r.New(r.Get("Error"), r.ToValue(msg))
+			if err != nil {
+				panic(err)
+			}
+			w.Set("name", "MaxListenersExceededWarning")
+			w.Set("emitter", o)
+			w.Set("type", name)
+			w.Set("count", count)
+			if _, err := emitWarning(proc, w); err != nil {
+				panic(err)
+			}
+			return
+		}
+	}
+	os.Stderr.WriteString("(node:" + strconv.Itoa(os.Getpid()) + ") MaxListenersExceededWarning: " + msg + "\n")
+}
+
+func (m *eventsModule) removeListener(this, name, fn goja.Value) goja.Value {
+	m.validateListener(fn)
+	o, e := m.state(this)
+	k := m.key(name)
+	list := e.listeners[k]
+	for i := len(list) - 1; i >= 0; i-- {
+		if l := list[i]; l.fn.SameAs(fn) || l.wrapper != nil && l.wrapper.SameAs(fn) {
+			m.removeAt(o, e, name, i)
+			break
+		}
+	}
+	return o
+}
+
+// removeAt removes the listener at the index i from the list of the event and emits removeListener.
+func (m *eventsModule) removeAt(o *goja.Object, e *emitter, name goja.Value, i int) {
+	k := m.key(name)
+	list := e.listeners[k]
+	l := list[i]
+	if len(list) == 1 {
+		delete(e.listeners, k)
+		for j, n := range e.names {
+			if m.key(n) == k {
+				e.names = append(e.names[:j:j], e.names[j+1:]...)
+				break
+			}
+		}
+	} else {
+		e.listeners[k] = append(list[:i:i], list[i+1:]...)
+	}
+	if _, ok := e.listeners[key{name: "removeListener"}]; ok {
+		m.emit(o, m.r.ToValue("removeListener"), name, l.fn)
+	}
+}
+
+// removeAll removes the listeners of the event, the last added first, emitting removeListener for each.
+func (m *eventsModule) removeAll(o *goja.Object, e *emitter, name goja.Value) {
+	k := m.key(name)
+	for list := e.listeners[k]; len(list) > 0; list = e.listeners[k] {
+		m.removeAt(o, e, name, len(list)-1)
+	}
+}
+
+func (m *eventsModule) emit(o *goja.Object, name goja.Value, args ...goja.Value) bool {
+	_, e := m.state(o)
+	k := m.key(name)
+	if k == (key{name: "error"}) {
+		if _, ok := e.listeners[key{sym: m.errorMonitor}]; ok {
+			m.callListeners(o, e, m.errorMonitor, args)
+		}
+		if _, ok := e.listeners[k]; !ok {
+			m.throwUnhandled(args)
+		}
+	}
+	if _, ok := e.listeners[k]; !ok {
+		return false
+	}
+	m.callListeners(o, e, name, args)
+	return true
+}
+
+// callListeners calls the listeners the event has when it's emitted, even if one of them removes another.
+func (m *eventsModule) callListeners(o *goja.Object, e *emitter, name goja.Value, args []goja.Value) {
+	list := append([]*listener(nil), e.listeners[m.key(name)]...)
+	for _, l := range list {
+		if l.once && !m.fire(o, e, name, l) {
+			continue
+		}
+		m.call(l.fn, o, args...)
+	}
+}
+
+// fire removes a once listener before it's called and reports whether it must be called.
+func (m *eventsModule) fire(o *goja.Object, e *emitter, name goja.Value, l *listener) bool {
+	if l.fired {
+		return false
+	}
+	l.fired = true
+	for i, cur := range e.listeners[m.key(name)] {
+		if cur == l {
+			m.removeAt(o, e, name, i)
+			break
+		}
+	}
+	return true
+}
+
+func (m *eventsModule) throwUnhandled(args []goja.Value) {
+	r := m.r
+	er := goja.Undefined()
+	if len(args) > 0 {
+		er = args[0]
+	}
+	if o, ok := er.(*goja.Object); ok {
+		if errCtor, ok := r.Get("Error").(*goja.Object); ok {
+			if proto, ok := errCtor.Get("prototype").(*goja.Object); ok && isPrototypeOf(proto, o) {
+				panic(er)
+			}
+		}
+	}
+	e := errors.NewError(r, nil, errors.ErrCodeUnhandledError, "Unhandled error. (%s)", util.New(r).Inspect(er))
+	e.Set("context", er)
+	panic(e)
+}
+
+func isPrototypeOf(proto, o *goja.Object) bool {
+	for p := o.Prototype(); p != nil; p = p.Prototype() {
+		if p.SameAs(proto) {
+			return true
+		}
+	}
+	return false
+}
+
+// rawListener returns the function that rawListeners() reports for the listener: for a once listener, a
+// function that removes it and calls it, with the original function in its listener property.
+func (m *eventsModule) rawListener(o *goja.Object, name goja.Value, l *listener) *goja.Object {
+	if !l.once {
+		return l.fn
+	}
+	if l.wrapper == nil {
+		l.wrapper = m.r.ToValue(func(call goja.FunctionCall) goja.Value {
+			_, e := m.state(o)
+			if !m.fire(o, e, name, l) {
+				return goja.Undefined()
+			}
+			return m.call(l.fn, call.This, call.Arguments...)
+		}).(*goja.Object)
+		l.wrapper.Set("listener", l.fn)
+	}
+	return l.wrapper
+}
+
+func (m *eventsModule) listeners(call goja.FunctionCall, raw bool) goja.Value {
+	o, e := m.state(call.This)
+	name := call.Argument(0)
+	list := e.listeners[m.key(name)]
+	res := make([]interface{}, len(list))
+	for i, l := range list {
+		if raw {
+			res[i] = m.rawListener(o, name, l)
+		} else {
+			res[i] = l.fn
+		}
+	}
+	return m.r.NewArray(res...)
+}
+
+func (m *eventsModule) createConstructor() *goja.Object {
+	r := m.r
+	ctor := r.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		m.init(call.This)
+		return nil
+	}).(*goja.Object)
+	ctor.DefineDataProperty("name", r.ToValue("EventEmitter"), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	m.ctor = ctor
+
+	proto := r.NewObject()
+	on := r.ToValue(func(call goja.FunctionCall) goja.Value {
+		return m.addListener(call.This, call.Argument(0), call.Argument(1), false, false)
+	})
+	proto.Set("addListener", on)
+	proto.Set("on", on)
+	proto.Set("prependListener", func(call goja.FunctionCall) goja.Value {
+		return m.addListener(call.This, call.Argument(0), call.Argument(1), true, false)
+	})
+	proto.Set("once", func(call goja.FunctionCall) goja.Value {
+		return m.addListener(call.This, call.Argument(0), call.Argument(1), false, true)
+	})
+	proto.Set("prependOnceListener", func(call goja.FunctionCall) goja.Value {
+		return m.addListener(call.This, call.Argument(0), call.Argument(1), true, true)
+	})
+	off := r.ToValue(func(call goja.FunctionCall) goja.Value {
+		return m.removeListener(call.This, call.Argument(0), call.Argument(1))
+	})
+	proto.Set("removeListener", off)
+	proto.Set("off", off)
+	proto.Set("removeAllListeners", func(call goja.FunctionCall) goja.Value {
+		o, e := m.state(call.This)
+		if name := call.Argument(0); !goja.IsUndefined(name) {
+			m.removeAll(o, e, name)
+			return o
+		}
+		// The listeners of removeListener are removed last, so they see the removal of the others.
+		for _, name := range append([]goja.Value(nil), e.names...) {
+			if m.key(name) != (key{name: "removeListener"}) {
+				m.removeAll(o, e, name)
+			}
+		}
+		m.removeAll(o, e, r.ToValue("removeListener"))
+		return o
+	})
+	proto.Set("setMaxListeners", func(call goja.FunctionCall) goja.Value {
+		o, e := m.state(call.This)
+		n := call.Argument(0)
+		if !isNumber(n) {
+			panic(errors.NewArgTypeError(r, "setMaxListeners", "of type number", n))
+		}
+		if f := n.ToFloat(); f < 0 || f != f {
+			panic(errors.NewOutOfRangeError(r, "setMaxListeners", ">= 0", n))
+		}
+		e.maxListeners = n
+		return o
+	})
+	proto.Set("getMaxListeners", func(call goja.FunctionCall) goja.Value {
+		_, e := m.state(call.This)
+		return r.ToValue(m.maxListeners(e))
+	})
+	proto.Set("emit", func(call goja.FunctionCall) goja.Value {
+		o, _ := m.state(call.This)
+		var args []goja.Value
+		if len(call.Arguments) > 1 {
+			args = call.Arguments[1:]
+		}
+		return r.ToValue(m.emit(o, call.Argument(0), args...))
+	})
+	proto.Set("listeners", func(call goja.FunctionCall) goja.Value {
+		return m.listeners(call, false)
+	})
+	proto.Set("rawListeners", func(call goja.FunctionCall) goja.Value {
+		return m.listeners(call, true)
+	})
+	proto.Set("listenerCount", func(call goja.FunctionCall) goja.Value {
+		_, e := m.state(call.This)
+		list := e.listeners[m.key(call.Argument(0))]
+		if fn := call.Argument(1); !goja.IsUndefined(fn) {
+			n := 0
+			for _, l := range list {
+				if l.fn.SameAs(fn) || l.wrapper != nil && l.wrapper.SameAs(fn) {
+					n++
+				}
+			}
+			return r.ToValue(n)
+		}
+		return r.ToValue(len(list))
+	})
+	proto.Set("eventNames", func(call goja.FunctionCall) goja.Value {
+		_, e := m.state(call.This)
+		names := make([]interface{}, len(e.names))
+		for i, name := range e.names {
+			names[i] = name
+		}
+		return r.NewArray(names...)
+	})
+	proto.DefineDataProperty("constructor", ctor, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	ctor.Set("prototype", proto)
+
+	ctor.Set("EventEmitter", ctor)
+	ctor.Set("defaultMaxListeners", defaultMaxListeners)
+	ctor.Set("errorMonitor", m.errorMonitor)
+	ctor.Set("once", m.once)
+	ctor.Set("listenerCount", func(call goja.FunctionCall) goja.Value {
+		_, e := m.state(call.Argument(0))
+		return r.ToValue(len(e.listeners[m.key(call.Argument(1))]))
+	})
+	return ctor
+}
+
+// once implements events.once(emitter, name): the promise is resolved with the arguments of the event, or
+// rejected if the emitter emits an error first.
+func (m *eventsModule) once(call goja.FunctionCall) goja.Value {
+	r := m.r
+	o, _ := m.state(call.Argument(0))
+	name := call.Argument(1)
+	p, resolve, reject := r.NewPromise()
+	var onEvent, onError goja.Value
+	onEvent = r.ToValue(func(call goja.FunctionCall) goja.Value {
+		if onError != nil {
+			m.removeListener(o, r.ToValue("error"), onError)
+		}
+		args := make([]interface{}, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			args[i] = arg
+		}
+		resolve(r.NewArray(args...))
+		return goja.Undefined()
+	})
+	m.addListener(o, name, onEvent, false, true)
+	if k := m.key(name); k != (key{name: "error"}) {
+		onError = r.ToValue(func(call goja.FunctionCall) goja.Value {
+			m.removeListener(o, name, onEvent)
+			reject(call.Argument(0))
+			return goja.Undefined()
+		})
+		m.addListener(o, r.ToValue("error"), onError, false, true)
+	}
+	return r.ToValue(p)
+}
+
+func isNumber(v goja.Value) bool {
+	switch v.Export().(type) {
+	case int64, float64:
+		_, isObject := v.(*goja.Object)
+		return !isObject
+	}
+	return false
+}
+
+// Init makes the object an EventEmitter, like EventEmitter.call(o) after setting its prototype to
+// EventEmitter.prototype does in a script.
+func Init(runtime *goja.Runtime, o *goja.Object) {
+	ctor := require.Require(runtime, ModuleName).(*goja.Object)
+	o.SetPrototype(ctor.Get("prototype").(*goja.Object))
+	construct, _ := goja.AssertFunction(ctor)
+	if _, err := construct(o); err != nil {
+		panic(err)
+	}
+}
+
+func Require(runtime *goja.Runtime, module *goja.Object) {
+	m := &eventsModule{r: runtime, errorMonitor: goja.NewSymbol("events.errorMonitor")}
+	module.Set("exports", m.createConstructor())
+}
+
+func init() {
+	require.RegisterCoreModule(ModuleName, Require)
+}
@@ -0,0 +1,193 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/require"
+)
+
+func TestEventEmitter(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunString(`
+	const assert = require("../assert.js");
+	const EventEmitter = require("node:events");
+
+	assert.sameValue(EventEmitter.EventEmitter, EventEmitter);
+	assert.sameValue(EventEmitter.defaultMaxListeners, 10);
+
+	{
+		const e = new EventEmitter();
+		const calls = [];
+		const a = (x, y) => calls.push("a" + x + y);
+		const b = function() { calls.push("b"); assert.sameValue(this, e); };
+		assert.sameValue(e.on("ev", a), e);
+		e.prependListener("ev", b);
+		e.once("ev", () => calls.push("once"));
+		e.prependOnceListener("ev", () => calls.push("ponce"));
+		assert.sameValue(e.listenerCount("ev"), 4);
+		assert.sameValue(e.emit("ev", 1, 2), true);
+		assert.sameValue(e.emit("ev", 3, 4), true);
+		assert.sameValue(calls.join(), "ponce,b,a12,once,b,a34");
+		assert.sameValue(e.emit("nope"), false);
+
+		e.off("ev", b);
+		assert.sameValue(e.listeners("ev").length, 1);
+		assert.sameValue(e.listeners("ev")[0], a);
+		e.removeListener("ev", a);
+		assert.sameValue(e.eventNames().length, 0);
+	}
+
+	{
+		// A listener removed by a previous one is still called for the event being emitted.
+		const e = new EventEmitter();
+		const calls = [];
+		const b = () => calls.push("b");
+		e.on("ev", () => { calls.push("a"); e.off("ev", b); });
+		e.on("ev", b);
+		e.emit("ev");
+		e.emit("ev");
+		assert.sameValue(calls.join(), "a,b,a");
+	}
+
+	{
+		const e = new EventEmitter();
+		const events = [];
+		const f = () => {};
+		e.on("newListener", (name, fn) => events.push("new " + String(name)));
+		e.on("removeListener", (name, fn) => events.push("remove " + String(name)));
+		const sym = Symbol("s");
+		e.on(sym, f);
+		e.once(1, f);
+		assert.sameValue(e.eventNames().map(String).join(), "newListener,removeListener,Symbol(s),1");
+		assert.sameValue(e.rawListeners("1")[0].listener, f);
+		e.rawListeners(1)[0]();
+		assert.sameValue(e.listenerCount(1), 0);
+		e.on("x", f);
+		e.removeAllListeners();
+		assert.sameValue(events.join(),
+			"new removeListener,new Symbol(s),new 1,remove 1,new x,remove newListener,remove Symbol(s),remove x");
+		assert.sameValue(e.eventNames().length, 0);
+	}
+
+	{
+		const e = new EventEmitter();
+		const err = new TypeError("boom");
+		assert.throws(() => e.emit("error", err), TypeError);
+		assert.throwsNodeError(() => e.emit("error", "str"), Error, "ERR_UNHANDLED_ERROR");
+		try {
+			e.emit("error", "str");
+		} catch (ex) {
+			assert.sameValue(ex.message, "Unhandled error. ('str')");
+			assert.sameValue(ex.context, "str");
+		}
+		const seen = [];
+		e.on(EventEmitter.errorMonitor, (er) => seen.push("monitor"));
+		e.on("error", (er) => seen.push(er.message));
+		e.emit("error", err);
+		assert.sameValue(seen.join(), "monitor,boom");
+	}
+
+	{
+		const e = new EventEmitter();
+		assert.throwsNodeError(() => e.on("x", 1), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.throwsNodeError(() => e.setMaxListeners(-1), RangeError, "ERR_OUT_OF_RANGE");
+		assert.throwsNodeError(() => e.setMaxListeners("1"), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.sameValue(e.setMaxListeners(1), e);
+		assert.sameValue(e.getMaxListeners(), 1);
+	}
+
+	{
+		class Sub extends EventEmitter {
+			constructor() {
+				super();
+				this.x = 1;
+			}
+		}
+		const s = new Sub();
+		let got;
+		s.on("ev", (v) => got = v);
+		s.emit("ev", 42);
+		assert.sameValue(got, 42);
+		assert.sameValue(s instanceof EventEmitter, true);
+
+		function Old() {
+			EventEmitter.call(this);
+		}
+		Object.setPrototypeOf(Old.prototype, EventEmitter.prototype);
+		const o = new Old();
+		o.once("ev", (v) => got = v);
+		o.emit("ev", 43);
+		assert.sameValue(got, 43);
+
+		const plain = Object.create(EventEmitter.prototype);
+		plain.on("ev", (v) => got = v);
+		plain.emit("ev", 44);
+		assert.sameValue(got, 44);
+	}
+
+	var result;
+	{
+		const e = new EventEmitter();
+		EventEmitter.once(e, "ready").then((args) => { result = args.join(); });
+		e.emit("ready", 1, 2);
+		EventEmitter.once(e, "ready").catch((err) => { result += " " + err.message; });
+		e.emit("error", new Error("failed"));
+		assert.sameValue(e.listenerCount("ready"), 0);
+		assert.sameValue(e.listenerCount("error"), 0);
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := vm.Get("result").String(); res != "1,2 failed" {
+		t.Fatalf("Unexpected result: %s", res)
+	}
+}
+
+func TestEventEmitterMaxListenersWarning(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	v, err := vm.RunString(`
+	const EventEmitter = require("events");
+	var warnings = [];
+	var process = {emitWarning: (w) => warnings.push(w)};
+	const e = new EventEmitter();
+	e.setMaxListeners(2);
+	for (let i = 0; i < 4; i++) {
+		e.on("ev", () => {});
+	}
+	warnings.length + " " + warnings[0].name + ": " + warnings[0].message + " " + warnings[0].count
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1 MaxListenersExceededWarning: Possible EventEmitter memory leak detected. 3 ev listeners added to " +
+		"[EventEmitter]. MaxListeners is 2. Use emitter.setMaxListeners() to increase limit 3"
+	if v.String() != want {
+		t.Fatalf("Unexpected result: %s", v)
+	}
+}
+
+func TestInit(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	o := vm.NewObject()
+	Init(vm, o)
+	vm.Set("o", o)
+	v, err := vm.RunString(`
+	let got;
+	o.on("ev", (v) => got = v);
+	o.emit("ev", 1) && got === 1 && o instanceof require("events")
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.ToBoolean() {
+		t.Fatal("the object is not an EventEmitter")
+	}
+}
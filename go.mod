@@ -1,6 +1,6 @@
 module github.com/nuvolaris/goja_nodejs
 
-go 1.20
+go 1.21
 
 require (
 	github.com/nuvolaris/goja v0.0.0-20230825100449-967811910c6d
@@ -0,0 +1,20 @@
+// Package hooks shares functions between the packages of the module without making them part of their public API.
+// Each function is set by the init() of the package that implements it, so it can be used by the packages that
+// import that one.
+package hooks
+
+import (
+	"github.com/nuvolaris/goja"
+)
+
+// ShouldColorize reports whether the ANSI escape codes should be written to the stream of the runtime, like the
+// colorMode 'auto' of console.Console. It's set by the util package.
+var ShouldColorize func(runtime *goja.Runtime, stream *goja.Object) bool
+
+// RegisterNativeModule registers a native module for the runtime of req, a *require.RequireModule, only. It's set
+// by the require package.
+var RegisterNativeModule func(req interface{}, name string, loader func(*goja.Runtime, *goja.Object))
+
+// NativeModuleLoader returns the loader a require() of the native or core module would use for the runtime of req,
+// or nil if there is none. It's set by the require package.
+var NativeModuleLoader func(req interface{}, name string) func(*goja.Runtime, *goja.Object)
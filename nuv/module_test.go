@@ -259,7 +259,7 @@ func TestNuvWithScanner(t *testing.T) {
 		},
 		{
 			name:     "readDir",
-			expected: "nuv_test.js,sample.txt,sample2.txt,subfolder",
+			expected: "[ 'nuv_test.js', 'sample.txt', 'sample2.txt', 'subfolder' ]",
 		},
 	}
 
@@ -0,0 +1,47 @@
+package process
+
+import (
+	"sort"
+
+	"github.com/nuvolaris/goja"
+)
+
+// env is the handler of process.env: like in nodejs, the assigned values are converted to strings.
+type env struct {
+	runtime *goja.Runtime
+	vars    map[string]string
+}
+
+func (e *env) Get(key string) goja.Value {
+	if v, ok := e.vars[key]; ok {
+		return e.runtime.ToValue(v)
+	}
+	return nil
+}
+
+func (e *env) Set(key string, val goja.Value) bool {
+	if _, ok := val.(*goja.Symbol); ok {
+		panic(e.runtime.NewTypeError("Cannot convert a Symbol value to a string"))
+	}
+	e.vars[key] = val.ToString().String()
+	return true
+}
+
+func (e *env) Has(key string) bool {
+	_, ok := e.vars[key]
+	return ok
+}
+
+func (e *env) Delete(key string) bool {
+	delete(e.vars, key)
+	return true
+}
+
+func (e *env) Keys() []string {
+	keys := make([]string, 0, len(e.vars))
+	for k := range e.vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
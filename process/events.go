@@ -0,0 +1,219 @@
+package process
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// emit emits the event on the process object and reports whether it had listeners.
+func (p *Process) emit(name string, args ...goja.Value) (bool, error) {
+	emit, ok := goja.AssertFunction(p.obj.Get("emit"))
+	if !ok {
+		return false, nil
+	}
+	res, err := emit(p.obj, append([]goja.Value{p.runtime.ToValue(name)}, args...)...)
+	if err != nil {
+		return false, err
+	}
+	return res.ToBoolean(), nil
+}
+
+// EmitBeforeExit emits the 'beforeExit' event with the exit code. The host calls it when the runtime has no
+// more work to do: the listeners can schedule more, then the host must keep running the runtime.
+func (p *Process) EmitBeforeExit() error {
+	if p.exiting {
+		return nil
+	}
+	_, err := p.emit("beforeExit", p.runtime.ToValue(p.ExitCode()))
+	return err
+}
+
+// EmitExit emits the 'exit' event with the exit code, unless it was already emitted, and returns the exit code,
+// which the listeners can change. The host calls it when it stops running the runtime.
+func (p *Process) EmitExit() (int, error) {
+	if !p.exiting {
+		p.exiting = true
+		if _, err := p.emit("exit", p.runtime.ToValue(p.ExitCode())); err != nil {
+			return p.ExitCode(), err
+		}
+	}
+	return p.ExitCode(), nil
+}
+
+// EmitSignal emits the event of the signal, e.g. 'SIGINT', and reports whether the script listens to it.
+// If it doesn't, the host should terminate the runtime with the exit code SignalExitCode(sig), like nodejs does.
+func (p *Process) EmitSignal(sig os.Signal) (bool, error) {
+	name, num, ok := signalName(sig)
+	if !ok {
+		return false, nil
+	}
+	return p.emit(name, p.runtime.ToValue(name), p.runtime.ToValue(num))
+}
+
+// SignalExitCode returns the exit code of a process terminated by the signal: 128 + the number of the signal.
+func SignalExitCode(sig os.Signal) int {
+	return 128 + signalNumber(sig)
+}
+
+// isError reports whether the value is an instance of Error.
+func (p *Process) isError(v goja.Value) bool {
+	o, ok := v.(*goja.Object)
+	if !ok {
+		return false
+	}
+	errCtor, ok := p.runtime.Get("Error").(*goja.Object)
+	if !ok {
+		return false
+	}
+	proto := errCtor.Get("prototype")
+	for o = o.Prototype(); o != nil; o = o.Prototype() {
+		if o.SameAs(proto) {
+			return true
+		}
+	}
+	return false
+}
+
+func isFunction(v goja.Value) bool {
+	_, ok := goja.AssertFunction(v)
+	return ok
+}
+
+func (p *Process) js_emitWarning(call goja.FunctionCall) goja.Value {
+	r := p.runtime
+	warning, typ, code := call.Argument(0), call.Argument(1), call.Argument(2)
+	var detail goja.Value
+	if o, ok := typ.(*goja.Object); ok && !isFunction(o) && o.ClassName() != "Array" {
+		code = o.Get("code")
+		if d := o.Get("detail"); d != nil && isString(d) {
+			detail = d
+		}
+		typ = o.Get("type")
+		if typ == nil || !typ.ToBoolean() {
+			typ = r.ToValue("Warning")
+		}
+	} else if isFunction(typ) {
+		typ, code = r.ToValue("Warning"), goja.Undefined()
+	}
+	if code == nil || isFunction(code) {
+		code = goja.Undefined()
+	}
+	if !goja.IsUndefined(typ) && !isString(typ) {
+		panic(errors.NewArgTypeError(r, "type", "of type string", typ))
+	}
+	if !goja.IsUndefined(code) && !isString(code) {
+		panic(errors.NewArgTypeError(r, "code", "of type string", code))
+	}
+
+	if isString(warning) {
+		w, err := r.New(r.Get("Error"), warning)
+		if err != nil {
+			panic(err)
+		}
+		if typ.ToBoolean() {
+			w.Set("name", typ.String())
+		} else {
+			w.Set("name", "Warning")
+		}
+		if !goja.IsUndefined(code) {
+			w.Set("code", code)
+		}
+		if detail != nil {
+			w.Set("detail", detail)
+		}
+		warning = w
+	} else if !p.isError(warning) {
+		panic(errors.NewArgTypeError(r, "warning", "of type string or an instance of Error", warning))
+	}
+
+	o := warning.(*goja.Object)
+	if name := o.Get("name"); name != nil && name.String() == "DeprecationWarning" && p.flag("noDeprecation") {
+		return goja.Undefined()
+	}
+	p.nextTick(func() {
+		if _, err := p.emit("warning", warning); err != nil {
+			panic(err)
+		}
+	})
+	return goja.Undefined()
+}
+
+// flag reports whether the property of the process object, such as noDeprecation, is true.
+func (p *Process) flag(name string) bool {
+	v := p.obj.Get(name)
+	return v != nil && v.ToBoolean()
+}
+
+// onWarning is the default listener of the 'warning' event, it prints the warning to stderr.
+func (p *Process) onWarning(call goja.FunctionCall) goja.Value {
+	warning, ok := call.Argument(0).(*goja.Object)
+	if !ok || p.env["NODE_NO_WARNINGS"] == "1" || p.flag("noProcessWarnings") {
+		return goja.Undefined()
+	}
+	if name := warning.Get("name"); name != nil && name.String() == "DeprecationWarning" && p.flag("noDeprecation") {
+		return goja.Undefined()
+	}
+	var b strings.Builder
+	b.WriteString("(node:" + strconv.Itoa(os.Getpid()) + ") ")
+	if code := warning.Get("code"); code != nil && code.ToBoolean() {
+		b.WriteString("[" + code.String() + "] ")
+	}
+	b.WriteString(warning.String())
+	if detail := warning.Get("detail"); detail != nil && isString(detail) {
+		b.WriteString("\n" + detail.String())
+	}
+	b.WriteString("\n")
+	p.writeStderr(b.String())
+	return goja.Undefined()
+}
+
+// writeStderr writes the string using process.stderr.write() if it's available, otherwise it writes to the
+// standard error of the host.
+func (p *Process) writeStderr(s string) {
+	if stderr, ok := p.obj.Get("stderr").(*goja.Object); ok {
+		if write, ok := goja.AssertFunction(stderr.Get("write")); ok {
+			if _, err := write(stderr, p.runtime.ToValue(s)); err != nil {
+				panic(err)
+			}
+			return
+		}
+	}
+	io.WriteString(p.stderr, s)
+}
+
+// nextTick queues fn to run after the current operation, before the other promise jobs that are queued
+// later.
+func (p *Process) nextTick(fn func()) {
+	r := p.runtime
+	promise, resolve, _ := r.NewPromise()
+	then, _ := goja.AssertFunction(r.ToValue(promise).(*goja.Object).Get("then"))
+	if _, err := then(r.ToValue(promise), r.ToValue(func(goja.FunctionCall) goja.Value {
+		fn()
+		return goja.Undefined()
+	})); err != nil {
+		panic(err)
+	}
+	resolve(nil)
+}
+
+func (p *Process) js_nextTick(call goja.FunctionCall) goja.Value {
+	fn, ok := goja.AssertFunction(call.Argument(0))
+	if !ok {
+		panic(errors.NewArgTypeError(p.runtime, "callback", "of type function", call.Argument(0)))
+	}
+	var args []goja.Value
+	if len(call.Arguments) > 1 {
+		args = append(args, call.Arguments[1:]...)
+	}
+	p.nextTick(func() {
+		if _, err := fn(goja.Undefined(), args...); err != nil {
+			panic(err)
+		}
+	})
+	return goja.Undefined()
+}
@@ -1,34 +1,463 @@
+// Package process implements the nodejs process module.
+//
+// The runtime doesn't support async iteration, so process.stdin can't be consumed with for await...of. Use the
+// async iterator returned by process.stdin.iterator() instead, calling its next() method directly.
 package process
 
 import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
 	"os"
+	"path/filepath"
+	goruntime "runtime"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+	"github.com/nuvolaris/goja_nodejs/events"
 	"github.com/nuvolaris/goja_nodejs/require"
 )
 
 const ModuleName = "process"
 
+// Version is the version of nodejs whose API the modules follow, reported as process.version.
+const Version = "v20.18.0"
+
+var (
+	symApi = goja.NewSymbol("api")
+
+	// startTime is the origin of process.hrtime() and process.uptime().
+	startTime = time.Now()
+)
+
+// ExitError is the error a script is interrupted with when it calls process.exit(). The Go call that ran
+// the script returns it wrapped in a *goja.InterruptedError, use errors.As to get it.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Process is the state of the "process" module of a runtime.
 type Process struct {
-	env  map[string]string
-	argv []string
+	runtime *goja.Runtime
+	obj     *goja.Object
+	stderr  io.Writer
+
+	env      map[string]string
+	argv     []string
+	execArgv []string
+	cwd      string
+	title    string
+
+	exitCode    goja.Value
+	exitHandler func(code int)
+	exiting     bool
+
+	registerCallback func() func(func())
+}
+
+type options struct {
+	env          map[string]string
+	envAllowlist []string
+	argv         []string
+	execArgv     []string
+	cwd          string
+	title        string
+	stdin        io.Reader
+	stdout       io.Writer
+	stderr       io.Writer
+}
+
+type Option func(*options)
+
+// WithEnv sets the variables of process.env instead of the environment of the Go process. The map is copied,
+// the changes made by the script are not visible to the host.
+func WithEnv(env map[string]string) Option {
+	return func(o *options) {
+		o.env = env
+	}
+}
+
+// WithEnvAllowlist restricts process.env to the named variables, taken from the environment of the Go
+// process or from the map passed to WithEnv. The script can still add other variables.
+func WithEnvAllowlist(names ...string) Option {
+	return func(o *options) {
+		o.envAllowlist = names
+	}
+}
+
+// WithArgv sets process.argv instead of the arguments of the Go process.
+func WithArgv(argv ...string) Option {
+	return func(o *options) {
+		o.argv = argv
+	}
+}
+
+// WithExecArgv sets process.execArgv, which is empty by default.
+func WithExecArgv(execArgv ...string) Option {
+	return func(o *options) {
+		o.execArgv = execArgv
+	}
+}
+
+// WithCwd sets the initial working directory of the runtime instead of the one of the Go process.
+// A relative directory is resolved against the working directory of the Go process.
+func WithCwd(dir string) Option {
+	return func(o *options) {
+		o.cwd = dir
+	}
+}
+
+// WithTitle sets process.title, which is "node" by default.
+func WithTitle(title string) Option {
+	return func(o *options) {
+		o.title = title
+	}
+}
+
+// WithStdin sets the reader of process.stdin instead of the standard input of the Go process.
+func WithStdin(r io.Reader) Option {
+	return func(o *options) {
+		o.stdin = r
+	}
+}
+
+// WithStdout sets the writer of process.stdout instead of the standard output of the Go process.
+func WithStdout(w io.Writer) Option {
+	return func(o *options) {
+		o.stdout = w
+	}
+}
+
+// WithStderr sets the writer of process.stderr, and of the warnings, instead of the standard error of
+// the Go process.
+func WithStderr(w io.Writer) Option {
+	return func(o *options) {
+		o.stderr = w
+	}
 }
 
 func Require(runtime *goja.Runtime, module *goja.Object) {
+	RequireWithOptions()(runtime, module)
+}
+
+// RequireWithOptions returns a module loader for the "process" module configured by the options. Register it
+// with require.Registry.RegisterNativeModule to isolate the scripts from the environment of the host.
+func RequireWithOptions(opts ...Option) require.ModuleLoader {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(runtime *goja.Runtime, module *goja.Object) {
+		requireWithOptions(runtime, module, &o)
+	}
+}
+
+func requireWithOptions(runtime *goja.Runtime, module *goja.Object, opts *options) {
 	p := &Process{
-		env:  make(map[string]string),
-		argv: os.Args,
+		runtime:  runtime,
+		env:      make(map[string]string),
+		argv:     append([]string{}, os.Args...),
+		execArgv: append([]string{}, opts.execArgv...),
+		title:    "node",
+		stderr:   os.Stderr,
+		exitCode: goja.Undefined(),
 	}
 
-	for _, e := range os.Environ() {
-		envKeyValue := strings.SplitN(e, "=", 2)
-		p.env[envKeyValue[0]] = envKeyValue[1]
+	if opts.env != nil {
+		for k, v := range opts.env {
+			p.env[k] = v
+		}
+	} else {
+		for _, e := range os.Environ() {
+			envKeyValue := strings.SplitN(e, "=", 2)
+			p.env[envKeyValue[0]] = envKeyValue[1]
+		}
+	}
+	if opts.envAllowlist != nil {
+		allowed := make(map[string]string, len(opts.envAllowlist))
+		for _, name := range opts.envAllowlist {
+			if v, ok := p.env[name]; ok {
+				allowed[name] = v
+			}
+		}
+		p.env = allowed
+	}
+	if opts.argv != nil {
+		p.argv = append([]string{}, opts.argv...)
+	}
+	p.cwd, _ = os.Getwd()
+	if filepath.IsAbs(opts.cwd) {
+		p.cwd = filepath.Clean(opts.cwd)
+	} else if opts.cwd != "" {
+		p.cwd = filepath.Join(p.cwd, opts.cwd)
+	}
+	if opts.title != "" {
+		p.title = opts.title
+	}
+	if opts.stderr != nil {
+		p.stderr = opts.stderr
+	}
+	var stdin io.Reader = os.Stdin
+	if opts.stdin != nil {
+		stdin = opts.stdin
+	}
+	var stdout io.Writer = os.Stdout
+	if opts.stdout != nil {
+		stdout = opts.stdout
 	}
 
 	o := module.Get("exports").(*goja.Object)
-	o.Set("env", p.env)
+	p.obj = o
+	events.Init(runtime, o)
+	o.Set("env", runtime.NewDynamicObject(&env{runtime: runtime, vars: p.env}))
 	o.Set("argv", p.argv)
+	o.Set("execArgv", p.execArgv)
+	o.DefineAccessorProperty("title", runtime.ToValue(func() string {
+		return p.title
+	}), runtime.ToValue(func(v goja.Value) {
+		p.title = v.String()
+	}), goja.FLAG_TRUE, goja.FLAG_TRUE)
+	o.Set("pid", os.Getpid())
+	o.Set("ppid", os.Getppid())
+	o.Set("platform", platform())
+	o.Set("arch", arch())
+	o.Set("version", Version)
+	versions := runtime.NewObject()
+	versions.Set("node", strings.TrimPrefix(Version, "v"))
+	versions.Set("go", strings.TrimPrefix(goruntime.Version(), "go"))
+	o.Set("versions", versions)
+	o.Set("stdin", p.newStdin(stdin))
+	o.Set("stdout", p.newWritable(stdout, 1))
+	o.Set("stderr", p.newWritable(p.stderr, 2))
+
+	o.Set("cwd", p.js_cwd)
+	o.Set("chdir", p.js_chdir)
+	o.Set("uptime", p.js_uptime)
+	hrtime := runtime.ToValue(p.js_hrtime).(*goja.Object)
+	hrtime.Set("bigint", p.js_hrtimeBigint)
+	o.Set("hrtime", hrtime)
+	memoryUsage := runtime.ToValue(p.js_memoryUsage).(*goja.Object)
+	memoryUsage.Set("rss", p.js_memoryUsageRss)
+	o.Set("memoryUsage", memoryUsage)
+
+	o.Set("exit", p.js_exit)
+	o.Set("emitWarning", p.js_emitWarning)
+	o.Set("nextTick", p.js_nextTick)
+	if on, ok := goja.AssertFunction(o.Get("on")); ok {
+		if _, err := on(o, runtime.ToValue("warning"), runtime.ToValue(p.onWarning)); err != nil {
+			panic(err)
+		}
+	}
+	o.DefineAccessorProperty("exitCode", runtime.ToValue(func() goja.Value {
+		return p.exitCode
+	}), runtime.ToValue(func(v goja.Value) {
+		p.validateExitCode(v)
+		p.exitCode = v
+	}), goja.FLAG_TRUE, goja.FLAG_TRUE)
+
+	o.DefineDataPropertySymbol(symApi, runtime.ToValue(p), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+}
+
+// GetApi returns the Process of the runtime, or nil if the "process" module is not the one of this package.
+func GetApi(runtime *goja.Runtime) *Process {
+	mod, ok := require.Require(runtime, ModuleName).(*goja.Object)
+	if !ok {
+		return nil
+	}
+	if s := mod.GetSymbol(symApi); s != nil {
+		p, _ := s.Export().(*Process)
+		return p
+	}
+	return nil
+}
+
+// SetExitHandler sets the function process.exit() calls with the exit code before it interrupts the script,
+// so the host can stop running the jobs of the runtime. It replaces the previous handler.
+func (p *Process) SetExitHandler(handler func(code int)) {
+	p.exitHandler = handler
+}
+
+// ExitCode returns the value of process.exitCode as an integer, or 0 if it is not set.
+func (p *Process) ExitCode() int {
+	if goja.IsUndefined(p.exitCode) || goja.IsNull(p.exitCode) {
+		return 0
+	}
+	return int(p.exitCode.ToNumber().ToInteger())
+}
+
+func platform() string {
+	switch goruntime.GOOS {
+	case "windows":
+		return "win32"
+	case "solaris", "illumos":
+		return "sunos"
+	}
+	return goruntime.GOOS
+}
+
+func arch() string {
+	switch goruntime.GOARCH {
+	case "amd64":
+		return "x64"
+	case "386":
+		return "ia32"
+	case "ppc64le":
+		return "ppc64"
+	case "mipsle":
+		return "mipsel"
+	}
+	return goruntime.GOARCH
+}
+
+func isNumber(v goja.Value) bool {
+	switch v.Export().(type) {
+	case int64, float64:
+		_, isObject := v.(*goja.Object)
+		return !isObject
+	}
+	return false
+}
+
+func isString(v goja.Value) bool {
+	_, ok := v.Export().(string)
+	_, isObject := v.(*goja.Object)
+	return ok && !isObject
+}
+
+// validateExitCode accepts undefined, null, an integer or a string of an integer, like nodejs.
+func (p *Process) validateExitCode(v goja.Value) {
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		return
+	}
+	if isString(v) && v.String() != "" {
+		if f := v.ToFloat(); f == math.Trunc(f) {
+			return
+		}
+	}
+	if !isNumber(v) {
+		panic(errors.NewArgTypeError(p.runtime, "code", "of type number", v))
+	}
+	if f := v.ToFloat(); f != math.Trunc(f) {
+		panic(errors.NewOutOfRangeError(p.runtime, "code", "an integer", v))
+	} else if math.Abs(f) > 1<<53-1 {
+		panic(errors.NewOutOfRangeError(p.runtime, "code", ">= -9007199254740991 && <= 9007199254740991", v))
+	}
+}
+
+func (p *Process) js_exit(call goja.FunctionCall) goja.Value {
+	if code := call.Argument(0); !goja.IsUndefined(code) {
+		p.validateExitCode(code)
+		p.exitCode = code
+	}
+	// Like in nodejs, a listener of the exit event can call process.exit() again: the nested call doesn't emit
+	// the event and interrupts the script with its own exit code.
+	if _, err := p.EmitExit(); err != nil {
+		panic(err)
+	}
+	code := p.ExitCode()
+	if p.exitHandler != nil {
+		p.exitHandler(code)
+	}
+	// The interrupt can't be caught by the script, it unwinds the stack as soon as this function returns.
+	p.runtime.Interrupt(&ExitError{Code: code})
+	return goja.Undefined()
+}
+
+func (p *Process) js_cwd(goja.FunctionCall) goja.Value {
+	return p.runtime.ToValue(p.cwd)
+}
+
+// js_chdir changes the working directory of the runtime only: it is used to resolve the relative paths passed
+// to process.chdir(), but the working directory of the Go process is left alone.
+func (p *Process) js_chdir(call goja.FunctionCall) goja.Value {
+	r := p.runtime
+	arg := call.Argument(0)
+	if !isString(arg) {
+		panic(errors.NewArgTypeError(r, "directory", "of type string", arg))
+	}
+	dir := arg.String()
+	path := dir
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.cwd, path)
+	}
+	fi, err := os.Stat(path)
+	if err == nil && !fi.IsDir() {
+		err = syscall.ENOTDIR
+	}
+	if err != nil {
+		code, desc := "UNKNOWN", err.Error()
+		switch {
+		case stderrors.Is(err, fs.ErrNotExist):
+			code, desc = "ENOENT", "no such file or directory"
+		case stderrors.Is(err, syscall.ENOTDIR):
+			code, desc = "ENOTDIR", "not a directory"
+		case stderrors.Is(err, fs.ErrPermission):
+			code, desc = "EACCES", "permission denied"
+		}
+		e := errors.NewError(r, nil, code, "%s: %s, chdir '%s' -> '%s'", code, desc, p.cwd, dir)
+		e.Set("syscall", "chdir")
+		e.Set("path", p.cwd)
+		e.Set("dest", dir)
+		panic(e)
+	}
+	p.cwd = filepath.Clean(path)
+	return goja.Undefined()
+}
+
+func (p *Process) js_uptime(goja.FunctionCall) goja.Value {
+	return p.runtime.ToValue(time.Since(startTime).Seconds())
+}
+
+func (p *Process) js_hrtime(call goja.FunctionCall) goja.Value {
+	r := p.runtime
+	ns := time.Since(startTime).Nanoseconds()
+	if prev := call.Argument(0); !goja.IsUndefined(prev) {
+		o, ok := prev.(*goja.Object)
+		if !ok || o.ClassName() != "Array" {
+			panic(errors.NewArgTypeError(r, "time", "an instance of Array", prev))
+		}
+		if l := o.Get("length"); l.ToInteger() != 2 {
+			panic(errors.NewOutOfRangeError(r, "time", "2", l))
+		}
+		ns -= o.Get("0").ToInteger()*int64(time.Second) + o.Get("1").ToInteger()
+	}
+	return r.NewArray(ns/int64(time.Second), ns%int64(time.Second))
+}
+
+// js_hrtimeBigint returns the time in nanoseconds as a number because the runtime doesn't support BigInt.
+// Since the origin is the start of the Go process, the number is exact for more than 100 days.
+func (p *Process) js_hrtimeBigint(goja.FunctionCall) goja.Value {
+	return p.runtime.ToValue(time.Since(startTime).Nanoseconds())
+}
+
+// js_memoryUsage reports the memory statistics of the Go runtime: rss is the memory obtained from the OS,
+// heapTotal and heapUsed are the ones of the Go heap. The values of the runtime share the Go heap, so
+// external and arrayBuffers are always 0.
+func (p *Process) js_memoryUsage(goja.FunctionCall) goja.Value {
+	var m goruntime.MemStats
+	goruntime.ReadMemStats(&m)
+	o := p.runtime.NewObject()
+	o.Set("rss", m.Sys)
+	o.Set("heapTotal", m.HeapSys)
+	o.Set("heapUsed", m.HeapAlloc)
+	o.Set("external", 0)
+	o.Set("arrayBuffers", 0)
+	return o
+}
+
+func (p *Process) js_memoryUsageRss(goja.FunctionCall) goja.Value {
+	var m goruntime.MemStats
+	goruntime.ReadMemStats(&m)
+	return p.runtime.ToValue(m.Sys)
 }
 
 func Enable(runtime *goja.Runtime) {
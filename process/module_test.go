@@ -1,10 +1,15 @@
 package process
 
 import (
+	stderrors "errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/nuvolaris/goja"
 	"github.com/nuvolaris/goja_nodejs/require"
@@ -134,3 +139,376 @@ func TestProcessEnvValuesBrackets(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessProperties(t *testing.T) {
+	vm := goja.New()
+
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	for expr, want := range map[string]interface{}{
+		"process.pid":     int64(os.Getpid()),
+		"process.version": Version,
+		"process.versions.node === process.version.slice(1)": true,
+		"typeof process.platform":                            "string",
+		"typeof process.arch":                                "string",
+		"process.cwd() === process.cwd()":                    true,
+		"process.uptime() > 0":                               true,
+		"process.hrtime().length":                            int64(2),
+		"process.hrtime(process.hrtime())[0]":                int64(0),
+		"process.hrtime.bigint() <= process.hrtime.bigint()": true,
+		"process.memoryUsage().heapUsed > 0":                 true,
+		"process.memoryUsage.rss() > 0":                      true,
+		"process.exitCode":                                   nil,
+	} {
+		v, err := vm.RunString(expr)
+		if err != nil {
+			t.Fatalf("Error executing %s: %s", expr, err)
+		}
+		if got := v.Export(); got != want {
+			t.Fatalf("Error executing %s: got %v but expected %v", expr, got, want)
+		}
+	}
+}
+
+func TestProcessChdir(t *testing.T) {
+	vm := goja.New()
+
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	vm.Set("dir", dir)
+	v, err := vm.RunString(`
+		process.chdir(dir);
+		process.chdir('sub');
+		var cwd = process.cwd();
+		var codes = [], message;
+		for (const d of ['nope', '../../` + filepath.Base(dir) + `/sub/..', 42]) {
+			try {
+				process.chdir(d);
+			} catch (e) {
+				codes.push(e.code);
+				message = message || e.message;
+			}
+		}
+		[cwd, process.cwd(), codes.join(), message]
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{filepath.Join(dir, "sub"), dir, "ENOENT,ERR_INVALID_ARG_TYPE",
+		"ENOENT: no such file or directory, chdir '" + filepath.Join(dir, "sub") + "' -> 'nope'"}
+	if got := v.Export(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected result: got %v, want %v", got, want)
+	}
+	if cwd, _ := os.Getwd(); cwd != wd {
+		t.Fatalf("The working directory of the process changed to %s", cwd)
+	}
+}
+
+func TestProcessExit(t *testing.T) {
+	vm := goja.New()
+
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	var handled []int
+	GetApi(vm).SetExitHandler(func(code int) {
+		handled = append(handled, code)
+	})
+
+	_, err := vm.RunString(`
+		var errors = [];
+		for (const code of ['x', 1.5, {}]) {
+			try {
+				process.exitCode = code;
+			} catch (e) {
+				errors.push(e.code);
+			}
+		}
+		process.exitCode = '4';
+		try {
+			process.exit();
+		} finally {
+			errors.push('finally');
+		}
+	`)
+	var exit *ExitError
+	if !stderrors.As(err, &exit) || exit.Code != 4 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(handled, []int{4}) {
+		t.Fatalf("Unexpected exit handler calls: %v", handled)
+	}
+	if code := GetApi(vm).ExitCode(); code != 4 {
+		t.Fatalf("Unexpected exit code: %d", code)
+	}
+
+	v, err := vm.RunString(`errors.join()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ERR_INVALID_ARG_TYPE,ERR_OUT_OF_RANGE,ERR_INVALID_ARG_TYPE"; v.String() != want {
+		t.Fatalf("Unexpected errors: got %s, want %s", v, want)
+	}
+
+	_, err = vm.RunString(`process.exit(0)`)
+	if !stderrors.As(err, &exit) || exit.Code != 0 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestProcessRequireWithOptions(t *testing.T) {
+	os.Setenv("GOJA_IS_AWESOME", "true")
+	defer os.Unsetenv("GOJA_IS_AWESOME")
+
+	vm := goja.New()
+
+	registry := new(require.Registry)
+	registry.RegisterNativeModule(ModuleName, RequireWithOptions(
+		WithEnvAllowlist("GOJA_IS_AWESOME", "GOJA_MISSING"),
+		WithArgv("node", "main.js", "-x"),
+		WithExecArgv("--inspect"),
+		WithCwd("/tmp/../srv"),
+		WithTitle("action"),
+	))
+	registry.Enable(vm)
+	Enable(vm)
+
+	v, err := vm.RunString(`
+		[Object.keys(process.env).join(), process.argv.slice(1).join(), process.execArgv.join(), process.cwd(),
+			process.title]
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"GOJA_IS_AWESOME", "main.js,-x", "--inspect", filepath.Clean("/srv"), "action"}
+	if got := v.Export(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected result: got %v, want %v", got, want)
+	}
+}
+
+func TestProcessEnvCoercion(t *testing.T) {
+	env := map[string]string{"A": "a", "B": "b"}
+
+	vm := goja.New()
+
+	registry := new(require.Registry)
+	registry.RegisterNativeModule(ModuleName, RequireWithOptions(WithEnv(env)))
+	registry.Enable(vm)
+	Enable(vm)
+
+	v, err := vm.RunString(`
+		const env = process.env;
+		env.N = 42;
+		env.U = undefined;
+		env.O = {toString() { return 'o' }};
+		delete env.A;
+		var threw = false;
+		try {
+			env.S = Symbol('s');
+		} catch (e) {
+			threw = e instanceof TypeError;
+		}
+		[typeof env.N, env.N, env.U, env.O, 'A' in env, env.A, Object.keys(env).join(), JSON.stringify(env), threw]
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"string", "42", "undefined", "o", false, nil, "B,N,O,U",
+		`{"B":"b","N":"42","O":"o","U":"undefined"}`, true}
+	if got := v.Export(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected result: got %v, want %v", got, want)
+	}
+	if want := map[string]string{"A": "a", "B": "b"}; !reflect.DeepEqual(env, want) {
+		t.Fatalf("The map passed to WithEnv changed: %v", env)
+	}
+}
+
+func TestProcessEvents(t *testing.T) {
+	vm := goja.New()
+
+	var stderr strings.Builder
+	registry := new(require.Registry)
+	registry.RegisterNativeModule(ModuleName, RequireWithOptions(WithStderr(&stderr)))
+	registry.Enable(vm)
+	Enable(vm)
+	p := GetApi(vm)
+
+	_, err := vm.RunString(`
+		const assert = require("../assert.js");
+		assert.sameValue(process instanceof require("events"), true);
+
+		var calls = [];
+		process.nextTick((a, b) => calls.push("tick " + a + b), 1, 2);
+		Promise.resolve().then(() => calls.push("promise"));
+		calls.push("sync");
+		assert.throwsNodeError(() => process.nextTick(1), TypeError, "ERR_INVALID_ARG_TYPE");
+
+		process.on("warning", (w) => calls.push(w.name + " " + w.code + " " + w.message));
+		process.emitWarning("something", "CustomWarning", "WARN001");
+		process.emitWarning("with detail", {code: "WARN002", detail: "more info"});
+		process.noDeprecation = true;
+		process.emitWarning("old", "DeprecationWarning");
+		assert.throwsNodeError(() => process.emitWarning(1), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.throwsNodeError(() => process.emitWarning("x", 1), TypeError, "ERR_INVALID_ARG_TYPE");
+
+		process.on("beforeExit", (code) => calls.push("beforeExit " + code));
+		process.on("exit", (code) => { calls.push("exit " + code); process.exitCode = 2; });
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.EmitBeforeExit(); err != nil {
+		t.Fatal(err)
+	}
+	if code, err := p.EmitExit(); err != nil || code != 2 {
+		t.Fatalf("Unexpected exit: %d, %v", code, err)
+	}
+	if code, err := p.EmitExit(); err != nil || code != 2 {
+		t.Fatalf("Unexpected exit: %d, %v", code, err)
+	}
+
+	v, err := vm.RunString(`calls.join()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "sync,tick 12,promise,CustomWarning WARN001 something,Warning WARN002 with detail,beforeExit 0,exit 0"
+	if v.String() != want {
+		t.Fatalf("Unexpected calls: got %s, want %s", v, want)
+	}
+	pid := fmt.Sprintf("(node:%d) ", os.Getpid())
+	want = pid + "[WARN001] CustomWarning: something\n" + pid + "[WARN002] Warning: with detail\nmore info\n"
+	if stderr.String() != want {
+		t.Fatalf("Unexpected stderr: got %q, want %q", stderr.String(), want)
+	}
+}
+
+func TestProcessExitEvent(t *testing.T) {
+	vm := goja.New()
+
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	_, err := vm.RunString(`
+		var codes = [];
+		process.on("exit", (code) => {
+			codes.push(code);
+			process.exit(5);
+		});
+		process.exit(1);
+	`)
+	var exit *ExitError
+	if !stderrors.As(err, &exit) || exit.Code != 5 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if v := vm.Get("codes").Export(); !reflect.DeepEqual(v, []interface{}{int64(1)}) {
+		t.Fatalf("Unexpected exit events: %v", v)
+	}
+}
+
+func TestProcessStdio(t *testing.T) {
+	vm := goja.New()
+
+	var stdout, stderr strings.Builder
+	registry := new(require.Registry)
+	registry.RegisterNativeModule(ModuleName, RequireWithOptions(
+		WithStdin(iotest.OneByteReader(strings.NewReader("héllo\nworld"))),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+	))
+	registry.Enable(vm)
+	Enable(vm)
+
+	_, err := vm.RunString(`
+		const assert = require("../assert.js");
+		var calls = [];
+
+		assert.sameValue(process.stdout.write("a"), true);
+		process.stdout.write("62", "hex", (err) => calls.push("written " + err));
+		process.stdout.write(new Uint8Array([99, 10]));
+		process.stderr.write("err", () => calls.push("written stderr"));
+		assert.throwsNodeError(() => process.stdout.write(1), TypeError, "ERR_INVALID_ARG_TYPE");
+		assert.throwsNodeError(() => process.stdout.write("x", "nope"), TypeError, "ERR_UNKNOWN_ENCODING");
+		assert.sameValue(process.stdout.fd, 1);
+		assert.sameValue(process.stdout.isTTY, undefined);
+		assert.sameValue(process.stdin.isTTY, undefined);
+
+		assert.throwsNodeError(() => process.stdin.setEncoding("nope"), TypeError, "ERR_UNKNOWN_ENCODING");
+		process.stdin.setEncoding("utf8");
+		var input = "";
+		process.stdin.on("data", (chunk) => {
+			assert.sameValue(typeof chunk, "string");
+			input += chunk;
+		});
+		process.stdin.on("end", () => calls.push("end " + JSON.stringify(input)));
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "abc\n"; stdout.String() != want {
+		t.Fatalf("Unexpected stdout: got %q, want %q", stdout.String(), want)
+	}
+	if want := "err"; stderr.String() != want {
+		t.Fatalf("Unexpected stderr: got %q, want %q", stderr.String(), want)
+	}
+	v, err := vm.RunString(`calls.join()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `written undefined,written stderr,end "héllo\nworld"`; v.String() != want {
+		t.Fatalf("Unexpected calls: got %s, want %s", v, want)
+	}
+}
+
+func TestProcessStdinIterator(t *testing.T) {
+	for _, tc := range []struct {
+		stdin io.Reader
+		want  string
+	}{
+		{iotest.OneByteReader(strings.NewReader("ab")), "a,b,done,done"},
+		{strings.NewReader(""), "done,done"},
+		{io.MultiReader(strings.NewReader("a"), iotest.ErrReader(stderrors.New("broken"))), "a,error broken,error broken"},
+	} {
+		vm := goja.New()
+		registry := new(require.Registry)
+		registry.RegisterNativeModule(ModuleName, RequireWithOptions(WithStdin(tc.stdin)))
+		registry.Enable(vm)
+
+		_, err := vm.RunString(`
+		var result;
+		const it = require("process").stdin.iterator();
+		const out = [];
+		function next() {
+			return it.next().then((res) => {
+				if (res.done) {
+					out.push("done");
+					// The iterator stays done.
+					return it.next().then((res) => { out.push(res.done ? "done" : "more"); });
+				}
+				out.push(res.value.toString());
+				return next();
+			}, (err) => {
+				out.push("error " + err.message);
+				// The error is kept.
+				return it.next().catch((err) => { out.push("error " + err.message); });
+			});
+		}
+		next().then(() => { result = out.join(); });
+		`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := vm.Get("result"); v == nil || v.String() != tc.want {
+			t.Fatalf("Unexpected result: got %v, want %s", v, tc.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+//go:build unix || windows
+
+package process
+
+import (
+	"os"
+	"syscall"
+)
+
+// signalNames maps the signals to the names of their process events. The signals that are defined both on unix
+// and on windows are here, the others are added by signals_unix.go.
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGTRAP: "SIGTRAP",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGPIPE: "SIGPIPE",
+	syscall.SIGALRM: "SIGALRM",
+	syscall.SIGTERM: "SIGTERM",
+}
+
+// signalName returns the name and the number of the signal, or false if it's not a known signal.
+func signalName(sig os.Signal) (string, int, bool) {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return "", 0, false
+	}
+	name, ok := signalNames[s]
+	return name, int(s), ok
+}
+
+// signalNumber returns the number of the signal, or 0 if it has none.
+func signalNumber(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return int(s)
+	}
+	return 0
+}
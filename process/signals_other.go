@@ -0,0 +1,15 @@
+//go:build !(unix || windows)
+
+package process
+
+import "os"
+
+// signalName returns false: the signals are not forwarded to the scripts on this platform.
+func signalName(os.Signal) (string, int, bool) {
+	return "", 0, false
+}
+
+// signalNumber returns 0: the signals have no number on this platform.
+func signalNumber(os.Signal) int {
+	return 0
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package process
+
+import "syscall"
+
+func init() {
+	for sig, name := range map[syscall.Signal]string{
+		syscall.SIGUSR1:   "SIGUSR1",
+		syscall.SIGUSR2:   "SIGUSR2",
+		syscall.SIGCHLD:   "SIGCHLD",
+		syscall.SIGCONT:   "SIGCONT",
+		syscall.SIGSTOP:   "SIGSTOP",
+		syscall.SIGTSTP:   "SIGTSTP",
+		syscall.SIGTTIN:   "SIGTTIN",
+		syscall.SIGTTOU:   "SIGTTOU",
+		syscall.SIGURG:    "SIGURG",
+		syscall.SIGXCPU:   "SIGXCPU",
+		syscall.SIGXFSZ:   "SIGXFSZ",
+		syscall.SIGVTALRM: "SIGVTALRM",
+		syscall.SIGPROF:   "SIGPROF",
+		syscall.SIGWINCH:  "SIGWINCH",
+		syscall.SIGIO:     "SIGIO",
+		syscall.SIGSYS:    "SIGSYS",
+	} {
+		signalNames[sig] = name
+	}
+}
@@ -0,0 +1,334 @@
+package process
+
+import (
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/buffer"
+	"github.com/nuvolaris/goja_nodejs/errors"
+	"github.com/nuvolaris/goja_nodejs/events"
+	"github.com/nuvolaris/goja_nodejs/stringdecoder"
+)
+
+// readChunkSize is the maximum size of the chunks process.stdin emits.
+const readChunkSize = 65536
+
+var reflectTypeArrayBuffer = reflect.TypeOf(goja.ArrayBuffer{})
+
+// SetCallbackRegistrar sets the function the process uses to deliver the data it reads from stdin. It is
+// called on the goroutine of the runtime and returns a function that is called once, from any goroutine,
+// to run the callback on the runtime: the host must keep running the runtime until then. Without it the reads
+// block the runtime. It replaces the previous registrar.
+func (p *Process) SetCallbackRegistrar(register func() func(func())) {
+	p.registerCallback = register
+}
+
+// setTTY sets isTTY, and for writable streams the size of the terminal, if f is a terminal.
+func setTTY(o *goja.Object, w interface{}, writable bool) {
+	f, ok := w.(*os.File)
+	if !ok {
+		return
+	}
+	cols, rows, tty := terminalSize(f)
+	if !tty {
+		return
+	}
+	o.Set("isTTY", true)
+	if writable && cols > 0 {
+		o.Set("columns", cols)
+		o.Set("rows", rows)
+	}
+}
+
+// newWritable returns the object of process.stdout or process.stderr. Unlike in nodejs the writes are always
+// synchronous.
+func (p *Process) newWritable(w io.Writer, fd int) *goja.Object {
+	r := p.runtime
+	o := r.NewObject()
+	events.Init(r, o)
+	o.Set("fd", fd)
+	o.Set("writable", true)
+	setTTY(o, w, true)
+	o.Set("write", func(call goja.FunctionCall) goja.Value {
+		chunk, enc, cb := call.Argument(0), call.Argument(1), call.Argument(2)
+		if isFunction(enc) {
+			enc, cb = goja.Undefined(), enc
+		}
+		if !goja.IsUndefined(cb) && !isFunction(cb) {
+			panic(errors.NewArgTypeError(r, "cb", "of type function", cb))
+		}
+		data, ok := buffer.SourceBytes(r, chunk)
+		if ok && chunk.ExportType() == reflectTypeArrayBuffer {
+			ok = false
+		}
+		if !ok {
+			if !isString(chunk) {
+				panic(errors.NewArgTypeError(r, "chunk", "of type string or an instance of Buffer, TypedArray, or DataView", chunk))
+			}
+			codec := buffer.StringCodecByName("utf8")
+			if !goja.IsUndefined(enc) && !goja.IsNull(enc) {
+				if codec = buffer.StringCodecByName(enc.String()); codec == nil {
+					panic(errors.NewTypeError(r, errors.ErrCodeUnknownEncoding, "Unknown encoding: %s", enc))
+				}
+			}
+			data = codec.DecodeAppend(chunk.String(), nil)
+		}
+
+		var errValue goja.Value = goja.Undefined()
+		if _, err := w.Write(data); err != nil {
+			errValue = r.NewGoError(err)
+			if goja.IsUndefined(cb) {
+				// Like an uncaught 'error' event in nodejs, it's thrown if there is no listener.
+				p.emitOn(o, "error", errValue)
+				return r.ToValue(false)
+			}
+		}
+		if fn, ok := goja.AssertFunction(cb); ok {
+			p.nextTick(func() {
+				if _, err := fn(goja.Undefined(), errValue); err != nil {
+					panic(err)
+				}
+			})
+		}
+		return r.ToValue(goja.IsUndefined(errValue))
+	})
+	return o
+}
+
+// emitOn emits the event on the emitter, panicking with the error a listener throws.
+func (p *Process) emitOn(o *goja.Object, name string, args ...goja.Value) bool {
+	emit, ok := goja.AssertFunction(o.Get("emit"))
+	if !ok {
+		return false
+	}
+	res, err := emit(o, append([]goja.Value{p.runtime.ToValue(name)}, args...)...)
+	if err != nil {
+		panic(err)
+	}
+	return res.ToBoolean()
+}
+
+// stdin is the state of process.stdin. Like in nodejs, the stream starts flowing when a 'data' listener is
+// added or resume() is called, and the chunks read while it's paused are kept until it flows again.
+type stdin struct {
+	p   *Process
+	r   io.Reader
+	obj *goja.Object
+
+	decoder *stringdecoder.Decoder
+	flowing bool
+	paused  bool
+	reading bool
+	ended   bool
+	closed  bool
+	err     goja.Value
+
+	chunks  []goja.Value
+	waiters []func(value goja.Value, done bool, err goja.Value)
+}
+
+func (p *Process) newStdin(rd io.Reader) *goja.Object {
+	r := p.runtime
+	s := &stdin{p: p, r: rd, obj: r.NewObject()}
+	o := s.obj
+	events.Init(r, o)
+	o.Set("fd", 0)
+	o.Set("readable", true)
+	setTTY(o, rd, false)
+
+	for _, name := range []string{"on", "addListener", "once", "prependListener", "prependOnceListener"} {
+		add, _ := goja.AssertFunction(o.Get(name))
+		o.Set(name, func(call goja.FunctionCall) goja.Value {
+			if _, err := add(o, call.Arguments...); err != nil {
+				panic(err)
+			}
+			if call.Argument(0).String() == "data" && !s.paused {
+				s.resume()
+			}
+			return o
+		})
+	}
+	o.Set("setEncoding", func(call goja.FunctionCall) goja.Value {
+		enc := call.Argument(0)
+		name := "utf8"
+		if !goja.IsUndefined(enc) && !goja.IsNull(enc) {
+			name = enc.String()
+		}
+		d := stringdecoder.NewDecoder(name)
+		if d == nil {
+			panic(errors.NewTypeError(r, errors.ErrCodeUnknownEncoding, "Unknown encoding: %s", name))
+		}
+		s.decoder = d
+		return o
+	})
+	o.Set("pause", func(call goja.FunctionCall) goja.Value {
+		s.paused, s.flowing = true, false
+		return o
+	})
+	o.Set("resume", func(call goja.FunctionCall) goja.Value {
+		s.paused = false
+		s.resume()
+		return o
+	})
+	o.Set("isPaused", func(call goja.FunctionCall) goja.Value {
+		return r.ToValue(s.paused)
+	})
+	iterator := r.ToValue(s.iterator)
+	o.Set("iterator", iterator)
+	// The runtime might not support async iteration, then iterator() can be used directly, see the package doc.
+	if sym, ok := r.Get("Symbol").(*goja.Object).Get("asyncIterator").(*goja.Symbol); ok {
+		o.SetSymbol(sym, iterator)
+	}
+	return o
+}
+
+func (s *stdin) resume() {
+	s.flowing = true
+	for s.flowing && len(s.chunks) > 0 {
+		chunk := s.chunks[0]
+		s.chunks = s.chunks[1:]
+		s.p.emitOn(s.obj, "data", chunk)
+	}
+	if s.flowing && s.ended {
+		s.end()
+	}
+	s.read()
+}
+
+// read starts reading the next chunk, unless a read is in progress or nobody wants the data.
+func (s *stdin) read() {
+	if s.reading || s.ended || s.err != nil || (!s.flowing && len(s.waiters) == 0) {
+		return
+	}
+	s.reading = true
+	buf := make([]byte, readChunkSize)
+	if s.p.registerCallback == nil {
+		s.p.nextTick(func() {
+			n, err := s.r.Read(buf)
+			s.deliver(buf[:n:n], err)
+		})
+		return
+	}
+	callback := s.p.registerCallback()
+	go func() {
+		n, err := s.r.Read(buf)
+		callback(func() {
+			s.deliver(buf[:n:n], err)
+		})
+	}()
+}
+
+// deliver passes the result of a read to onRead. Like for the callbacks of the timers, the errors thrown by
+// the listeners are dropped.
+func (s *stdin) deliver(data []byte, err error) {
+	onRead, _ := goja.AssertFunction(s.p.runtime.ToValue(func(goja.FunctionCall) goja.Value {
+		s.onRead(data, err)
+		return goja.Undefined()
+	}))
+	onRead(goja.Undefined())
+}
+
+func (s *stdin) onRead(data []byte, err error) {
+	s.reading = false
+	if len(data) > 0 {
+		if s.decoder != nil {
+			if str := s.decoder.Write(data); str != "" {
+				s.push(s.p.runtime.ToValue(str))
+			}
+		} else {
+			s.push(buffer.WrapBytes(s.p.runtime, data))
+		}
+	}
+	switch {
+	case err == io.EOF:
+		if s.decoder != nil {
+			if str := s.decoder.End(nil); str != "" {
+				s.push(s.p.runtime.ToValue(str))
+			}
+		}
+		s.ended = true
+		for _, w := range s.waiters {
+			w(goja.Undefined(), true, nil)
+		}
+		s.waiters = nil
+		if s.flowing && len(s.chunks) == 0 {
+			s.end()
+		}
+	case err != nil:
+		s.err = s.p.runtime.NewGoError(err)
+		for _, w := range s.waiters {
+			w(nil, false, s.err)
+		}
+		s.waiters = nil
+		s.p.emitOn(s.obj, "error", s.err)
+	default:
+		s.read()
+	}
+}
+
+// push hands the chunk to the first iterator waiting for data, or emits it if the stream is flowing.
+func (s *stdin) push(chunk goja.Value) {
+	if len(s.waiters) > 0 {
+		w := s.waiters[0]
+		s.waiters = s.waiters[1:]
+		w(chunk, false, nil)
+		return
+	}
+	if s.flowing {
+		s.p.emitOn(s.obj, "data", chunk)
+		return
+	}
+	s.chunks = append(s.chunks, chunk)
+}
+
+func (s *stdin) end() {
+	if s.closed {
+		return
+	}
+	s.flowing, s.closed = false, true
+	s.p.emitOn(s.obj, "end")
+	s.p.emitOn(s.obj, "close")
+}
+
+// iterator implements readable.iterator(): it returns an async iterator over the chunks.
+func (s *stdin) iterator(call goja.FunctionCall) goja.Value {
+	r := s.p.runtime
+	result := func(value goja.Value, done bool) goja.Value {
+		res := r.NewObject()
+		res.Set("value", value)
+		res.Set("done", done)
+		return res
+	}
+	it := r.NewObject()
+	it.Set("next", func(call goja.FunctionCall) goja.Value {
+		promise, resolve, reject := r.NewPromise()
+		switch {
+		case len(s.chunks) > 0:
+			chunk := s.chunks[0]
+			s.chunks = s.chunks[1:]
+			resolve(result(chunk, false))
+		case s.err != nil:
+			reject(s.err)
+		case s.ended:
+			resolve(result(goja.Undefined(), true))
+		default:
+			s.waiters = append(s.waiters, func(value goja.Value, done bool, err goja.Value) {
+				if err != nil {
+					reject(err)
+				} else {
+					resolve(result(value, done))
+				}
+			})
+			s.read()
+		}
+		return r.ToValue(promise)
+	})
+	it.Set("return", func(call goja.FunctionCall) goja.Value {
+		promise, resolve, _ := r.NewPromise()
+		resolve(result(goja.Undefined(), true))
+		return r.ToValue(promise)
+	})
+	return it
+}
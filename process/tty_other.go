@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package process
+
+import "os"
+
+// terminalSize reports whether f is a character device, the size of the terminal is not available.
+func terminalSize(f *os.File) (cols, rows int, ok bool) {
+	fi, err := f.Stat()
+	return 0, 0, err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,27 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package process
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalSize returns the size of the terminal f refers to, ok is false if f is not a terminal.
+func terminalSize(f *os.File) (cols, rows int, ok bool) {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return 0, 0, false
+	}
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	}); err != nil || errno != 0 {
+		return 0, 0, false
+	}
+	return int(ws.Col), int(ws.Row), true
+}
@@ -0,0 +1,258 @@
+package require
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	js "github.com/nuvolaris/goja"
+)
+
+// Conditions matched by the "exports" field resolution, see
+// https://nodejs.org/api/packages.html#conditional-exports
+var exportsConditions = map[string]bool{
+	"default": true,
+	"require": true,
+	"node":    true,
+}
+
+type packageJSON struct {
+	Name    string          `json:"name"`
+	Main    string          `json:"main"`
+	Exports json.RawMessage `json:"exports"`
+}
+
+type jsonObjectEntry struct {
+	key   string
+	value json.RawMessage
+}
+
+func (r *RequireModule) readPackageJSON(dir string) *packageJSON {
+	buf, err := r.r.getSource(path.Join(dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(buf, &pkg); err != nil {
+		return nil
+	}
+	if bytes.Equal(pkg.Exports, []byte("null")) {
+		pkg.Exports = nil
+	}
+	return &pkg
+}
+
+// findPackageScope returns the closest directory containing a package.json, starting from start and going up
+// until a node_modules folder or the root is reached.
+func (r *RequireModule) findPackageScope(start string) (string, *packageJSON) {
+	for {
+		if path.Base(start) == "node_modules" {
+			break
+		}
+		if pkg := r.readPackageJSON(start); pkg != nil {
+			return start, pkg
+		}
+		if start == ".." {
+			break
+		}
+		parent := path.Dir(start)
+		if parent == start {
+			break
+		}
+		start = parent
+	}
+	return "", nil
+}
+
+// loadPackageSelf implements the LOAD_PACKAGE_SELF step of the resolution algorithm, i.e. it allows a package
+// with the "exports" field to require itself by name.
+func (r *RequireModule) loadPackageSelf(modpath, start string) (*js.Object, error) {
+	dir, pkg := r.findPackageScope(start)
+	if pkg == nil || pkg.Name == "" || len(pkg.Exports) == 0 {
+		return nil, nil
+	}
+	if modpath != pkg.Name && !strings.HasPrefix(modpath, pkg.Name+"/") {
+		return nil, nil
+	}
+	return r.loadPackageExports(dir, "."+modpath[len(pkg.Name):], pkg.Exports)
+}
+
+func (r *RequireModule) loadPackageExports(dir, subpath string, exports json.RawMessage) (*js.Object, error) {
+	p, err := resolvePackageExports(dir, subpath, exports)
+	if err != nil {
+		return nil, err
+	}
+	return r.loadModule(p)
+}
+
+// splitPackageName splits a module path such as "@scope/name/sub/path" into the package name and the subpath
+// relative to the package ("./sub/path").
+func splitPackageName(modpath string) (name, subpath string, ok bool) {
+	sep := strings.IndexByte(modpath, '/')
+	if strings.HasPrefix(modpath, "@") {
+		if sep == -1 {
+			return "", "", false
+		}
+		next := strings.IndexByte(modpath[sep+1:], '/')
+		if next == -1 {
+			sep = -1
+		} else {
+			sep += next + 1
+		}
+	}
+	if sep == -1 {
+		name = modpath
+	} else {
+		name = modpath[:sep]
+	}
+	if name == "" || strings.HasPrefix(name, ".") {
+		return "", "", false
+	}
+	return name, "." + modpath[len(name):], true
+}
+
+func parseJSONObject(data json.RawMessage) ([]jsonObjectEntry, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, false
+	}
+	var entries []jsonObjectEntry
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, _ := t.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		entries = append(entries, jsonObjectEntry{key: key, value: value})
+	}
+	return entries, true
+}
+
+// resolvePackageExports implements PACKAGE_EXPORTS_RESOLVE as described by
+// https://nodejs.org/api/esm.html#resolution-algorithm-specification
+func resolvePackageExports(dir, subpath string, exports json.RawMessage) (string, error) {
+	entries, isObject := parseJSONObject(exports)
+	hasSubpaths := false
+	for i, e := range entries {
+		isSubpath := strings.HasPrefix(e.key, ".")
+		if i > 0 && isSubpath != hasSubpaths {
+			return "", fmt.Errorf("%w: %s", InvalidPackageConfigError, path.Join(dir, "package.json"))
+		}
+		hasSubpaths = isSubpath
+	}
+
+	var target json.RawMessage
+	var patternMatch string
+	isPattern := false
+	if !isObject || !hasSubpaths {
+		if subpath == "." {
+			target = exports
+		}
+	} else {
+		bestKey := ""
+		for _, e := range entries {
+			if e.key == subpath && !strings.Contains(e.key, "*") {
+				target, bestKey = e.value, ""
+				isPattern = false
+				break
+			}
+			star := strings.IndexByte(e.key, '*')
+			if star == -1 || strings.IndexByte(e.key[star+1:], '*') != -1 {
+				continue
+			}
+			prefix, suffix := e.key[:star], e.key[star+1:]
+			if !strings.HasPrefix(subpath, prefix) || subpath == prefix {
+				continue
+			}
+			if len(subpath) < len(e.key) || !strings.HasSuffix(subpath, suffix) {
+				continue
+			}
+			if bestKey == "" || patternKeyLess(e.key, bestKey) {
+				bestKey = e.key
+				target = e.value
+				isPattern = true
+				patternMatch = subpath[len(prefix) : len(subpath)-len(suffix)]
+			}
+		}
+	}
+
+	if target != nil {
+		resolved, err := resolvePackageTarget(dir, target, patternMatch, isPattern)
+		if err != nil {
+			return "", err
+		}
+		if resolved != "" {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("%w: '%s' is not defined by \"exports\" in %s", PackagePathNotExportedError, subpath, path.Join(dir, "package.json"))
+}
+
+// patternKeyLess reports whether the pattern key a should take precedence over b (PATTERN_KEY_COMPARE).
+func patternKeyLess(a, b string) bool {
+	aBase, bBase := strings.IndexByte(a, '*'), strings.IndexByte(b, '*')
+	if aBase != bBase {
+		return aBase > bBase
+	}
+	return len(a) > len(b)
+}
+
+func resolvePackageTarget(dir string, target json.RawMessage, patternMatch string, isPattern bool) (string, error) {
+	target = bytes.TrimSpace(target)
+	if len(target) == 0 {
+		return "", nil
+	}
+	switch target[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(target, &s); err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(s, "./") {
+			return "", fmt.Errorf("%w: %q in %s", InvalidPackageTargetError, s, path.Join(dir, "package.json"))
+		}
+		if isPattern {
+			s = strings.ReplaceAll(s, "*", patternMatch)
+		}
+		resolved := path.Join(dir, s)
+		if dir != "." && !strings.HasPrefix(resolved, dir+"/") && resolved != dir {
+			return "", fmt.Errorf("%w: %q in %s", InvalidPackageTargetError, s, path.Join(dir, "package.json"))
+		}
+		return resolved, nil
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(target, &items); err != nil {
+			return "", err
+		}
+		var lastErr error
+		for _, item := range items {
+			resolved, err := resolvePackageTarget(dir, item, patternMatch, isPattern)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if resolved != "" {
+				return resolved, nil
+			}
+		}
+		return "", lastErr
+	case '{':
+		entries, _ := parseJSONObject(target)
+		for _, e := range entries {
+			if !exportsConditions[e.key] {
+				continue
+			}
+			resolved, err := resolvePackageTarget(dir, e.value, patternMatch, isPattern)
+			if err != nil || resolved != "" {
+				return resolved, err
+			}
+		}
+	}
+	return "", nil
+}
@@ -14,6 +14,7 @@ import (
 
 	js "github.com/nuvolaris/goja"
 	"github.com/nuvolaris/goja/parser"
+	"github.com/nuvolaris/goja_nodejs/internal/hooks"
 )
 
 type ModuleLoader func(*js.Runtime, *js.Object)
@@ -23,11 +24,20 @@ type ModuleLoader func(*js.Runtime, *js.Object)
 // This error will be ignored by the resolver and the search will continue. Any other errors will be propagated.
 type SourceLoader func(path string) ([]byte, error)
 
+// RealPathFunc represents a function that returns the canonical path of a module file, i.e. the path with all
+// symbolic links resolved. It is used to determine the identity of a module, so that the same file reached through
+// different links is only loaded once, and its own dependencies are looked up relative to its real location.
+// The function should return ModuleFileDoesNotExistError if the path does not exist.
+type RealPathFunc func(path string) (string, error)
+
 var (
 	InvalidModuleError          = errors.New("Invalid module")
 	IllegalModuleNameError      = errors.New("Illegal module name")
 	NoSuchBuiltInModuleError    = errors.New("No such built-in module")
 	ModuleFileDoesNotExistError = errors.New("module file does not exist")
+	PackagePathNotExportedError = errors.New("Package subpath is not exported")
+	InvalidPackageTargetError   = errors.New("Invalid package target")
+	InvalidPackageConfigError   = errors.New("Invalid package config")
 )
 
 var native, builtin map[string]ModuleLoader
@@ -38,13 +48,17 @@ type Registry struct {
 	native   map[string]ModuleLoader
 	compiled map[string]*js.Program
 
-	srcLoader     SourceLoader
-	globalFolders []string
+	srcLoader        SourceLoader
+	realPath         RealPathFunc
+	globalFolders    []string
+	preserveSymlinks bool
+	packageExports   bool
 }
 
 type RequireModule struct {
 	r           *Registry
 	runtime     *js.Runtime
+	native      map[string]ModuleLoader
 	modules     map[string]*js.Object
 	nodeModules map[string]*js.Object
 }
@@ -88,6 +102,32 @@ func WithGlobalFolders(globalFolders ...string) Option {
 	}
 }
 
+// WithRealPath sets a function which will be used to resolve module paths to their canonical form (see RealPathFunc).
+// If not set, DefaultRealPath is used with the default source loader, and paths are used as is when a custom
+// loader has been set with WithLoader().
+func WithRealPath(realPath RealPathFunc) Option {
+	return func(r *Registry) {
+		r.realPath = realPath
+	}
+}
+
+// WithPreserveSymlinks instructs the registry to identify modules by the path they were requested with rather than
+// by their real path. This is the equivalent of the --preserve-symlinks nodejs flag.
+func WithPreserveSymlinks(preserve bool) Option {
+	return func(r *Registry) {
+		r.preserveSymlinks = preserve
+	}
+}
+
+// WithPackageExports instructs the registry to resolve the packages under node_modules through the "exports" field
+// of their package.json, like nodejs does: the subpaths a package doesn't export can't be required. By default the
+// "exports" field is only used when a package requires itself by name.
+func WithPackageExports(enable bool) Option {
+	return func(r *Registry) {
+		r.packageExports = enable
+	}
+}
+
 // Enable adds the require() function to the specified runtime.
 func (r *Registry) Enable(runtime *js.Runtime) *RequireModule {
 	rrt := &RequireModule{
@@ -101,6 +141,32 @@ func (r *Registry) Enable(runtime *js.Runtime) *RequireModule {
 	return rrt
 }
 
+// registerNativeModule registers a native module for this runtime only. It takes precedence over the modules
+// registered with Registry.RegisterNativeModule and the global functions, and if the name is the name of a core
+// module it is also used for the "node:" alias. It has no effect if the module has already been loaded.
+func (r *RequireModule) registerNativeModule(name string, loader ModuleLoader) {
+	if r.native == nil {
+		r.native = make(map[string]ModuleLoader)
+	}
+	r.native[filepathClean(name)] = loader
+}
+
+func init() {
+	hooks.RegisterNativeModule = func(req interface{}, name string, loader func(*js.Runtime, *js.Object)) {
+		req.(*RequireModule).registerNativeModule(name, loader)
+	}
+	hooks.NativeModuleLoader = func(req interface{}, name string) func(*js.Runtime, *js.Object) {
+		return req.(*RequireModule).nativeModuleLoader(name)
+	}
+}
+
+// nativeModuleLoader returns the loader a require() of the native or core module would use for this runtime,
+// or nil if there is none. It can be used to wrap the loader with registerNativeModule.
+func (r *RequireModule) nativeModuleLoader(name string) ModuleLoader {
+	ldr, _, _ := r.nativeLoader(filepathClean(name))
+	return ldr
+}
+
 func (r *Registry) RegisterNativeModule(name string, loader ModuleLoader) {
 	r.Lock()
 	defer r.Unlock()
@@ -140,6 +206,34 @@ func DefaultSourceLoader(filename string) ([]byte, error) {
 	return io.ReadAll(f)
 }
 
+// DefaultRealPath is used if no RealPathFunc was set (see WithRealPath()) and the default source loader is in use.
+// It resolves symbolic links using the host's filesystem.
+func DefaultRealPath(p string) (string, error) {
+	rp, err := filepath.EvalSymlinks(filepath.FromSlash(p))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", ModuleFileDoesNotExistError
+		}
+		// Leave it to the source loader to report the error, if any.
+		return p, nil
+	}
+	return filepath.ToSlash(rp), nil
+}
+
+func (r *Registry) getRealPath(p string) (string, error) {
+	if r.preserveSymlinks {
+		return p, nil
+	}
+	realPath := r.realPath
+	if realPath == nil {
+		if r.srcLoader != nil {
+			return p, nil
+		}
+		realPath = DefaultRealPath
+	}
+	return realPath(p)
+}
+
 func (r *Registry) getSource(p string) ([]byte, error) {
 	srcLoader := r.srcLoader
 	if srcLoader == nil {
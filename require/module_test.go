@@ -9,6 +9,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	js "github.com/nuvolaris/goja"
@@ -183,6 +184,48 @@ func TestRequireRegistryNativeModule(t *testing.T) {
 	}
 }
 
+func TestRequireRuntimeNativeModule(t *testing.T) {
+	exportName := func(name string) ModuleLoader {
+		return func(vm *js.Runtime, module *js.Object) {
+			module.Get("exports").(*js.Object).Set("name", name)
+		}
+	}
+
+	RegisterCoreModule("test/rtcore", exportName("core"))
+
+	registry := new(Registry)
+	registry.RegisterNativeModule("test/rtnative", exportName("registry"))
+
+	vm1 := js.New()
+	req1 := registry.Enable(vm1)
+	req1.registerNativeModule("test/rtnative", exportName("vm1"))
+	req1.registerNativeModule("test/rtcore", exportName("vm1 core"))
+
+	vm2 := js.New()
+	registry.Enable(vm2)
+
+	res, err := vm1.RunString(`
+	if (require("node:test/rtcore") !== require("test/rtcore")) {
+		throw new Error("Modules are not equal");
+	}
+	[require("test/rtnative").name, require("node:test/rtcore").name].join()
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != "vm1,vm1 core" {
+		t.Fatalf("vm1: Unexpected result: %q", s)
+	}
+
+	res, err = vm2.RunString(`[require("test/rtnative").name, require("test/rtcore").name].join()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != "registry,core" {
+		t.Fatalf("vm2: Unexpected result: %q", s)
+	}
+}
+
 func TestRequire(t *testing.T) {
 	absPath, err := filepath.Abs("./testdata/m.js")
 	if err != nil {
@@ -532,3 +575,177 @@ func TestDefaultModuleLoader(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRealPath(t *testing.T) {
+	links := map[string]string{
+		"/home/src/app/node_modules/lib": "/home/src/packages/lib",
+	}
+	realPath := func(p string) (string, error) {
+		for link, target := range links {
+			if p == link || strings.HasPrefix(p, link+"/") {
+				p = target + p[len(link):]
+			}
+		}
+		return p, nil
+	}
+	fs := map[string]string{
+		"/home/src/app/app.js":                   `exports.a = require('lib'); exports.b = require('../packages/lib/index.js');`,
+		"/home/src/packages/lib/index.js":        `exports.dep = require('dep').name;`,
+		"/home/src/packages/node_modules/dep.js": `exports.name = "real";`,
+		"/home/src/app/node_modules/dep.js":      `exports.name = "link";`,
+	}
+
+	for _, tc := range []struct {
+		preserve bool
+		dep      string
+		same     bool
+	}{
+		{false, "real", true},
+		{true, "link", false},
+	} {
+		vm := js.New()
+		r := NewRegistry(WithLoader(func(p string) ([]byte, error) {
+			// like a real filesystem, the loader follows the links
+			rp, _ := realPath(p)
+			return mapFileSystemSourceLoader(fs)(rp)
+		}), WithRealPath(func(p string) (string, error) {
+			rp, _ := realPath(p)
+			if _, exists := fs[rp]; !exists {
+				return "", ModuleFileDoesNotExistError
+			}
+			return rp, nil
+		}), WithPreserveSymlinks(tc.preserve))
+		r.Enable(vm)
+		vm.Set("dep", tc.dep)
+		vm.Set("same", tc.same)
+		_, err := vm.RunScript("/home/src/test.js", `
+		var app = require('./app/app.js');
+		if (app.a.dep !== dep) {
+			throw new Error("Unexpected dependency: " + app.a.dep);
+		}
+		if ((app.a === app.b) !== same) {
+			throw new Error("Unexpected module identity");
+		}
+		`)
+		if err != nil {
+			t.Fatalf("preserve=%v: %v", tc.preserve, err)
+		}
+	}
+}
+
+func TestDefaultRealPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on windows")
+	}
+	dir, err := os.MkdirTemp("", "goja-nodejs-require-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"packages/lib/index.js":            `exports.dep = require('dep').name;`,
+		"packages/lib/node_modules/dep.js": `exports.name = "real";`,
+		"app/node_modules/dep.js":          `exports.name = "link";`,
+	}
+	for name, src := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "packages", "lib"), filepath.Join(dir, "app", "node_modules", "lib")); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := js.New()
+	NewRegistry().Enable(vm)
+	res, err := vm.RunScript(path.Join(filepath.ToSlash(dir), "app", "main.js"), `
+	var lib = require('lib');
+	lib === require('../packages/lib') ? lib.dep : "not the same module";
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != "real" {
+		t.Fatalf("Unexpected result: %q", s)
+	}
+}
+
+func TestPackageExports(t *testing.T) {
+	fs := map[string]string{
+		"/src/pkg/package.json": `{
+			"name": "@scope/pkg",
+			"exports": {
+				".": {"import": "./esm/index.mjs", "require": "./lib/index.js"},
+				"./util": "./lib/util.js",
+				"./features/*.js": "./lib/features/*.js",
+				"./features/private/*": null
+			}
+		}`,
+		"/src/pkg/lib/index.js":                         `exports.name = "index"; exports.self = function() { return require('@scope/pkg/util').name; };`,
+		"/src/pkg/lib/util.js":                          `exports.name = "util";`,
+		"/src/pkg/lib/features/a.js":                    `exports.name = "feature a";`,
+		"/src/pkg/lib/features/private/b.js":            `exports.name = "feature b";`,
+		"/src/pkg/lib/hidden.js":                        `exports.name = "hidden";`,
+		"/src/app/node_modules/sugar/package.json":      `{"exports": "./main.js"}`,
+		"/src/app/node_modules/sugar/main.js":           `exports.name = "sugar";`,
+		"/src/app/node_modules/@scope/pkg/package.json": `{"name": "@scope/pkg", "exports": {".": "./index.js"}}`,
+		"/src/app/node_modules/@scope/pkg/index.js":     `exports.name = "dependency";`,
+	}
+
+	for i, tc := range []struct {
+		exports bool
+		src     string
+		path    string
+		ok      bool
+		value   string
+	}{
+		{false, "/src/pkg/lib", "@scope/pkg", true, "index"},
+		{false, "/src/pkg/lib", "@scope/pkg/util", true, "util"},
+		{false, "/src/pkg/lib/features", "@scope/pkg/features/a.js", true, "feature a"},
+		{false, "/src/pkg", "@scope/pkg/features/private/b.js", false, ""},
+		{false, "/src/pkg", "@scope/pkg/lib/hidden.js", false, ""},
+		{false, "/src/app", "@scope/pkg", true, "dependency"},
+		{false, "/src/app", "sugar", false, ""},
+		{false, "/src/app", "sugar/main.js", true, "sugar"},
+		{true, "/src/app", "@scope/pkg", true, "dependency"},
+		{true, "/src/app", "@scope/pkg/util", false, ""},
+		{true, "/src/app", "sugar", true, "sugar"},
+		{true, "/src/app", "sugar/main.js", false, ""},
+	} {
+		vm := js.New()
+		NewRegistry(WithLoader(mapFileSystemSourceLoader(fs)), WithPackageExports(tc.exports)).Enable(vm)
+		res, err := vm.RunScript(path.Join(tc.src, "test.js"), fmt.Sprintf("require('%s').name", tc.path))
+		if err != nil {
+			if tc.ok {
+				t.Errorf("%d: require() failed: %v", i, err)
+			}
+			continue
+		}
+		if !tc.ok {
+			t.Errorf("%d: expected to fail, but did not", i)
+			continue
+		}
+		if s := res.String(); s != tc.value {
+			t.Errorf("%d: got %q expected %q", i, s, tc.value)
+		}
+	}
+
+	vm := js.New()
+	NewRegistry(WithLoader(mapFileSystemSourceLoader(fs))).Enable(vm)
+	res, err := vm.RunScript("/src/pkg/test.js", `require('./lib/index.js').self()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != "util" {
+		t.Fatalf("Unexpected result: %q", s)
+	}
+}
@@ -1,7 +1,6 @@
 package require
 
 import (
-	"encoding/json"
 	"errors"
 	"path"
 	"path/filepath"
@@ -52,7 +51,10 @@ func (r *RequireModule) resolve(modpath string) (module *js.Object, err error) {
 		if module = r.nodeModules[p]; module != nil {
 			return
 		}
-		module, err = r.loadNodeModules(modpath, start)
+		module, err = r.loadPackageSelf(modpath, start)
+		if module == nil && err == nil {
+			module, err = r.loadNodeModules(modpath, start)
+		}
 		if err == nil && module != nil {
 			r.nodeModules[p] = module
 		}
@@ -70,22 +72,9 @@ func (r *RequireModule) loadNative(path string) (*js.Object, error) {
 		return module, nil
 	}
 
-	var ldr ModuleLoader
-	if ldr = r.r.native[path]; ldr == nil {
-		ldr = native[path]
-	}
-
-	var isBuiltIn, withPrefix bool
-	if ldr == nil {
-		ldr = builtin[path]
-		if ldr == nil && strings.HasPrefix(path, NodePrefix) {
-			ldr = builtin[path[len(NodePrefix):]]
-			if ldr == nil {
-				return nil, NoSuchBuiltInModuleError
-			}
-			withPrefix = true
-		}
-		isBuiltIn = true
+	ldr, isBuiltIn, withPrefix := r.nativeLoader(path)
+	if ldr == nil && strings.HasPrefix(path, NodePrefix) {
+		return nil, NoSuchBuiltInModuleError
 	}
 
 	if ldr != nil {
@@ -107,6 +96,40 @@ func (r *RequireModule) loadNative(path string) (*js.Object, error) {
 	return nil, InvalidModuleError
 }
 
+// nativeLoader returns the loader of the native or core module of the path, in the order of precedence.
+func (r *RequireModule) nativeLoader(path string) (ldr ModuleLoader, isBuiltIn, withPrefix bool) {
+	ldr, isBuiltIn, withPrefix = r.runtimeNative(path)
+	if ldr == nil {
+		if ldr = r.r.native[path]; ldr == nil {
+			ldr = native[path]
+		}
+	}
+
+	if ldr == nil {
+		ldr = builtin[path]
+		if ldr == nil && strings.HasPrefix(path, NodePrefix) {
+			ldr = builtin[path[len(NodePrefix):]]
+			withPrefix = true
+		}
+		isBuiltIn = true
+	}
+	return
+}
+
+// runtimeNative returns the loader registered with RequireModule.RegisterNativeModule for the path. The
+// loaders of core modules are also used for the "node:" alias.
+func (r *RequireModule) runtimeNative(path string) (ldr ModuleLoader, isBuiltIn, withPrefix bool) {
+	if ldr = r.native[path]; ldr != nil {
+		return ldr, builtin[path] != nil, false
+	}
+	if name := strings.TrimPrefix(path, NodePrefix); name != path && builtin[name] != nil {
+		if ldr = r.native[name]; ldr != nil {
+			return ldr, true, true
+		}
+	}
+	return nil, false, false
+}
+
 func (r *RequireModule) loadAsFileOrDirectory(path string) (module *js.Object, err error) {
 	if module, err = r.loadAsFile(path); module != nil || err != nil {
 		return
@@ -140,16 +163,8 @@ func (r *RequireModule) loadIndex(modpath string) (module *js.Object, err error)
 }
 
 func (r *RequireModule) loadAsDirectory(modpath string) (module *js.Object, err error) {
-	p := path.Join(modpath, "package.json")
-	buf, err := r.r.getSource(p)
-	if err != nil {
-		return r.loadIndex(modpath)
-	}
-	var pkg struct {
-		Main string
-	}
-	err = json.Unmarshal(buf, &pkg)
-	if err != nil || len(pkg.Main) == 0 {
+	pkg := r.readPackageJSON(modpath)
+	if pkg == nil || len(pkg.Main) == 0 {
 		return r.loadIndex(modpath)
 	}
 
@@ -162,6 +177,12 @@ func (r *RequireModule) loadAsDirectory(modpath string) (module *js.Object, err
 }
 
 func (r *RequireModule) loadNodeModule(modpath, start string) (*js.Object, error) {
+	if name, subpath, ok := splitPackageName(modpath); ok && r.r.packageExports {
+		dir := path.Join(start, name)
+		if pkg := r.readPackageJSON(dir); pkg != nil && len(pkg.Exports) > 0 {
+			return r.loadPackageExports(dir, subpath, pkg.Exports)
+		}
+	}
 	return r.loadAsFileOrDirectory(path.Join(start, modpath))
 }
 
@@ -211,18 +232,35 @@ func (r *RequireModule) createModuleObject() *js.Object {
 
 func (r *RequireModule) loadModule(path string) (*js.Object, error) {
 	module := r.modules[path]
-	if module == nil {
-		module = r.createModuleObject()
-		r.modules[path] = module
-		err := r.loadModuleFile(path, module)
-		if err != nil {
-			module = nil
-			delete(r.modules, path)
-			if errors.Is(err, ModuleFileDoesNotExistError) {
-				err = nil
-			}
+	if module != nil {
+		return module, nil
+	}
+	realPath, err := r.r.getRealPath(path)
+	if err != nil {
+		if errors.Is(err, ModuleFileDoesNotExistError) {
+			err = nil
 		}
-		return module, err
+		return nil, err
+	}
+	if realPath != path {
+		if module = r.modules[realPath]; module != nil {
+			r.modules[path] = module
+			return module, nil
+		}
+	}
+	module = r.createModuleObject()
+	r.modules[realPath] = module
+	err = r.loadModuleFile(realPath, module)
+	if err != nil {
+		module = nil
+		delete(r.modules, realPath)
+		if errors.Is(err, ModuleFileDoesNotExistError) {
+			err = nil
+		}
+		return nil, err
+	}
+	if realPath != path {
+		r.modules[path] = module
 	}
 	return module, nil
 }
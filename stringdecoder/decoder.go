@@ -0,0 +1,195 @@
+package stringdecoder
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/nuvolaris/goja_nodejs/buffer"
+)
+
+// ErrInvalidData is returned by the strict decoding methods when the data is not valid for the encoding.
+var ErrInvalidData = errors.New("the encoded data was not valid")
+
+// Decoder decodes a stream of bytes into strings. Unlike buffer.StringCodec it keeps incomplete
+// multibyte characters (or, for base64, incomplete groups) between writes, so that characters split across
+// chunks are decoded correctly.
+type Decoder struct {
+	encoding string
+	codec    buffer.StringCodec
+	pending  []byte
+}
+
+// NormalizeEncoding returns the canonical nodejs name of the encoding or an empty string if the encoding
+// is not supported. An empty name means "utf8".
+func NormalizeEncoding(enc string) string {
+	switch strings.ToLower(enc) {
+	case "", "utf8", "utf-8":
+		return "utf8"
+	case "ucs2", "ucs-2", "utf16le", "utf-16le":
+		return "utf16le"
+	case "latin1", "binary":
+		return "latin1"
+	case "base64", "base64url", "hex", "ascii":
+		return strings.ToLower(enc)
+	}
+	return ""
+}
+
+// NewDecoder returns a Decoder for the given encoding or nil if the encoding is not supported.
+func NewDecoder(encoding string) *Decoder {
+	enc := NormalizeEncoding(encoding)
+	if enc == "" {
+		return nil
+	}
+	return &Decoder{
+		encoding: enc,
+		codec:    buffer.StringCodecByName(enc),
+	}
+}
+
+// Encoding returns the canonical name of the decoder's encoding.
+func (d *Decoder) Encoding() string {
+	return d.encoding
+}
+
+// Pending returns the number of bytes held back by the decoder waiting for the rest of a character.
+func (d *Decoder) Pending() int {
+	return len(d.pending)
+}
+
+func (d *Decoder) Write(data []byte) string {
+	return d.codec.Encode(d.split(data))
+}
+
+// End decodes the data (if any) and flushes the decoder. Incomplete characters are replaced by U+FFFD
+// (or encoded as is for base64).
+func (d *Decoder) End(data []byte) string {
+	s := d.Write(data)
+	if len(d.pending) > 0 {
+		switch d.encoding {
+		case "utf8":
+			s += "\uFFFD"
+		case "utf16le":
+			if len(d.pending) >= 2 {
+				s += "\uFFFD"
+			}
+		default:
+			s += d.codec.Encode(d.pending)
+		}
+		d.pending = nil
+	}
+	return s
+}
+
+// WriteStrict is like Write, but instead of replacing invalid sequences with U+FFFD it returns ErrInvalidData
+// and resets the decoder. Only utf8 and utf16le can have invalid sequences.
+func (d *Decoder) WriteStrict(data []byte) (string, error) {
+	complete := d.split(data)
+	if !d.valid(complete) {
+		d.pending = nil
+		return "", ErrInvalidData
+	}
+	return d.codec.Encode(complete), nil
+}
+
+// EndStrict is like End, but returns ErrInvalidData if the data is not valid or ends with an incomplete
+// character.
+func (d *Decoder) EndStrict(data []byte) (string, error) {
+	s, err := d.WriteStrict(data)
+	if err != nil {
+		return "", err
+	}
+	if len(d.pending) > 0 {
+		switch d.encoding {
+		case "utf8", "utf16le":
+			d.pending = nil
+			return "", ErrInvalidData
+		}
+	}
+	return s + d.End(nil), nil
+}
+
+// split prepends the pending bytes to data and returns the part of the result that can be decoded,
+// keeping the rest pending.
+func (d *Decoder) split(data []byte) []byte {
+	if len(d.pending) > 0 {
+		data = append(d.pending[:len(d.pending):len(d.pending)], data...)
+		d.pending = nil
+	}
+	n := len(data) - d.incompleteTail(data)
+	if n < len(data) {
+		d.pending = append([]byte(nil), data[n:]...)
+	}
+	return data[:n]
+}
+
+func (d *Decoder) valid(data []byte) bool {
+	switch d.encoding {
+	case "utf8":
+		return utf8.Valid(data)
+	case "utf16le":
+		for i := 0; i+1 < len(data); i += 2 {
+			u := rune(data[i]) | rune(data[i+1])<<8
+			if utf16.IsSurrogate(u) {
+				if u >= 0xDC00 || i+3 >= len(data) {
+					return false
+				}
+				if u2 := rune(data[i+2]) | rune(data[i+3])<<8; u2 < 0xDC00 || u2 > 0xDFFF {
+					return false
+				}
+				i += 2
+			}
+		}
+	}
+	return true
+}
+
+// Reset discards any pending bytes.
+func (d *Decoder) Reset() {
+	d.pending = nil
+}
+
+func (d *Decoder) incompleteTail(data []byte) int {
+	switch d.encoding {
+	case "utf8":
+		return incompleteUTF8Tail(data)
+	case "utf16le":
+		n := len(data) & 1
+		if l := len(data) - n; l >= 2 {
+			if u := uint16(data[l-2]) | uint16(data[l-1])<<8; u >= 0xD800 && u <= 0xDBFF {
+				n += 2
+			}
+		}
+		return n
+	case "base64", "base64url":
+		return len(data) % 3
+	}
+	return 0
+}
+
+// incompleteUTF8Tail returns the length of a trailing sequence that is a valid, but incomplete UTF-8 character.
+func incompleteUTF8Tail(data []byte) int {
+	for i := 1; i <= 3 && i <= len(data); i++ {
+		c := data[len(data)-i]
+		if utf8.RuneStart(c) {
+			var need int
+			switch {
+			case c&0xE0 == 0xC0:
+				need = 2
+			case c&0xF0 == 0xE0:
+				need = 3
+			case c&0xF8 == 0xF0:
+				need = 4
+			default:
+				return 0
+			}
+			if need > i {
+				return i
+			}
+			return 0
+		}
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package stringdecoder
+
+import (
+	"reflect"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/buffer"
+	"github.com/nuvolaris/goja_nodejs/errors"
+	"github.com/nuvolaris/goja_nodejs/require"
+)
+
+const ModuleName = "string_decoder"
+
+type stringDecoder struct {
+	d *Decoder
+}
+
+type stringDecoderModule struct {
+	r *goja.Runtime
+}
+
+var (
+	reflectTypeStringDecoder = reflect.TypeOf((*stringDecoder)(nil))
+	reflectTypeArrayBuffer   = reflect.TypeOf(goja.ArrayBuffer{})
+)
+
+func (m *stringDecoderModule) toDecoder(v goja.Value) *Decoder {
+	if v.ExportType() == reflectTypeStringDecoder {
+		if sd := v.Export().(*stringDecoder); sd != nil {
+			return sd.d
+		}
+	}
+	panic(errors.NewTypeError(m.r, errors.ErrCodeInvalidThis, `Value of "this" must be of type StringDecoder`))
+}
+
+func (m *stringDecoderModule) bytesArg(v goja.Value) []byte {
+	if data, ok := buffer.SourceBytes(m.r, v); ok && v.ExportType() != reflectTypeArrayBuffer {
+		return data
+	}
+	panic(errors.NewArgTypeError(m.r, "buf", "an instance of Buffer, TypedArray, or DataView", v))
+}
+
+func (m *stringDecoderModule) write(call goja.FunctionCall) goja.Value {
+	d := m.toDecoder(call.This)
+	arg := call.Argument(0)
+	if s, ok := arg.Export().(string); ok {
+		return m.r.ToValue(s)
+	}
+	return m.r.ToValue(d.Write(m.bytesArg(arg)))
+}
+
+func (m *stringDecoderModule) end(call goja.FunctionCall) goja.Value {
+	d := m.toDecoder(call.This)
+	var s string
+	if arg := call.Argument(0); !goja.IsUndefined(arg) {
+		if str, ok := arg.Export().(string); ok {
+			s = str
+		} else {
+			s = d.Write(m.bytesArg(arg))
+		}
+	}
+	return m.r.ToValue(s + d.End(nil))
+}
+
+func (m *stringDecoderModule) createConstructor() *goja.Object {
+	r := m.r
+	ctor := r.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		var enc string
+		if arg := call.Argument(0); !goja.IsUndefined(arg) && !goja.IsNull(arg) {
+			enc = arg.String()
+		}
+		d := NewDecoder(enc)
+		if d == nil {
+			panic(errors.NewTypeError(r, errors.ErrCodeUnknownEncoding, "Unknown encoding: %s", enc))
+		}
+		res := r.ToValue(&stringDecoder{d: d}).(*goja.Object)
+		res.SetPrototype(call.This.Prototype())
+		return res
+	}).(*goja.Object)
+	ctor.DefineDataProperty("name", r.ToValue("StringDecoder"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+
+	proto := r.NewObject()
+	proto.Set("write", m.write)
+	proto.Set("end", m.end)
+	proto.DefineAccessorProperty("encoding", r.ToValue(func(call goja.FunctionCall) goja.Value {
+		return r.ToValue(m.toDecoder(call.This).Encoding())
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	proto.DefineDataProperty("constructor", ctor, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	ctor.Set("prototype", proto)
+	return ctor
+}
+
+func Require(runtime *goja.Runtime, module *goja.Object) {
+	m := &stringDecoderModule{r: runtime}
+	exports := module.Get("exports").(*goja.Object)
+	exports.Set("StringDecoder", m.createConstructor())
+}
+
+func init() {
+	require.RegisterCoreModule(ModuleName, Require)
+}
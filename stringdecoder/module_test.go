@@ -0,0 +1,64 @@
+package stringdecoder
+
+import (
+	"testing"
+
+	"github.com/nuvolaris/goja"
+	_ "github.com/nuvolaris/goja_nodejs/buffer"
+	"github.com/nuvolaris/goja_nodejs/require"
+)
+
+func TestStringDecoder(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunString(`
+	const assert = require("../assert.js");
+	const { Buffer } = require("node:buffer");
+	const { StringDecoder } = require("node:string_decoder");
+
+	{
+		const d = new StringDecoder("utf8");
+		assert.sameValue(d.encoding, "utf8");
+		const euro = Buffer.from("€");
+		assert.sameValue(d.write(euro.subarray(0, 1)), "");
+		assert.sameValue(d.write(euro.subarray(1, 2)), "");
+		assert.sameValue(d.write(Buffer.concat([euro.subarray(2), Buffer.from("ab")])), "€ab");
+		assert.sameValue(d.write(Buffer.from([0xF0, 0x9F])), "");
+		assert.sameValue(d.end(), "�");
+		assert.sameValue(d.end(Buffer.from("x")), "x");
+		assert.sameValue(d.write("str"), "str");
+	}
+
+	{
+		const d = new StringDecoder("ucs2");
+		assert.sameValue(d.encoding, "utf16le");
+		const b = Buffer.from("a💩", "utf16le");
+		assert.sameValue(d.write(b.subarray(0, 3)), "a");
+		assert.sameValue(d.write(b.subarray(3, 5)), "");
+		assert.sameValue(d.end(b.subarray(5)), "💩");
+	}
+
+	{
+		const d = new StringDecoder("base64");
+		assert.sameValue(d.write(Buffer.from("ab")), "");
+		assert.sameValue(d.write(Buffer.from("cd")), "YWJj");
+		assert.sameValue(d.end(), "ZA==");
+	}
+
+	{
+		const d = new StringDecoder("hex");
+		assert.sameValue(d.write(new Uint8Array([1, 255])), "01ff");
+		assert.sameValue(new StringDecoder().encoding, "utf8");
+		assert.sameValue(new StringDecoder("binary").encoding, "latin1");
+	}
+
+	assert.throwsNodeError(() => new StringDecoder("nope"), TypeError, "ERR_UNKNOWN_ENCODING");
+	assert.throwsNodeError(() => new StringDecoder().write(42), TypeError, "ERR_INVALID_ARG_TYPE");
+	assert.throwsNodeError(() => StringDecoder.prototype.write.call({}, Buffer.alloc(1)), TypeError, "ERR_INVALID_THIS");
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,183 @@
+package url
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// windowsOption returns the value of the 'windows' option, which defaults to true when running on Windows.
+func (m *urlModule) windowsOption(options goja.Value) bool {
+	if goja.IsUndefined(options) || goja.IsNull(options) {
+		return runtime.GOOS == "windows"
+	}
+	opts, ok := options.(*goja.Object)
+	if !ok {
+		panic(errors.NewArgTypeError(m.r, "options", "of type object", options))
+	}
+	if v := opts.Get("windows"); v != nil && !goja.IsUndefined(v) {
+		return v.ToBoolean()
+	}
+	return runtime.GOOS == "windows"
+}
+
+func (m *urlModule) fileURLToPath(call goja.FunctionCall) goja.Value {
+	arg := call.Argument(0)
+	var u *urlRecord
+	if _, ok := arg.Export().(string); ok {
+		u = m.parseURL(arg, goja.Undefined())
+	} else if arg.ExportType() == reflectTypeURL {
+		u = arg.Export().(*nodeURL).url
+	} else {
+		panic(errors.NewArgTypeError(m.r, "path", "of type string or an instance of URL", arg))
+	}
+	if u.scheme != "file" {
+		panic(errors.NewTypeError(m.r, errors.ErrCodeInvalidURLScheme, "The URL must be of scheme file"))
+	}
+	hostname := u.hostString()
+	pathname := u.pathname()
+	lp := strings.ToLower(pathname)
+
+	if m.windowsOption(call.Argument(1)) {
+		if strings.Contains(lp, "%2f") || strings.Contains(lp, "%5c") {
+			panic(errors.NewTypeError(m.r, errors.ErrCodeInvalidFileURLPath, "File URL path must not include encoded \\ or / characters"))
+		}
+		pathname = strings.ReplaceAll(m.decodeURIComponent(pathname), "/", "\\")
+		if hostname != "" {
+			// Pass the hostname through domainToUnicode() in case it had been converted to punycode.
+			return m.r.ToValue("\\\\" + idnaToUnicode(hostname) + pathname)
+		}
+		// Otherwise, it's a local path that requires a drive letter.
+		if len(pathname) < 3 || pathname[2] != ':' {
+			panic(errors.NewTypeError(m.r, errors.ErrCodeInvalidFileURLPath, "File URL path must be absolute"))
+		}
+		if letter := pathname[1] | 0x20; letter < 'a' || letter > 'z' {
+			panic(errors.NewTypeError(m.r, errors.ErrCodeInvalidFileURLPath, "File URL path must be absolute"))
+		}
+		return m.r.ToValue(pathname[1:])
+	}
+
+	if hostname != "" {
+		panic(errors.NewTypeError(m.r, errors.ErrCodeInvalidFileURLHost, `File URL host must be "localhost" or empty on %s`, runtime.GOOS))
+	}
+	if strings.Contains(lp, "%2f") {
+		panic(errors.NewTypeError(m.r, errors.ErrCodeInvalidFileURLPath, "File URL path must not include encoded / characters"))
+	}
+	return m.r.ToValue(m.decodeURIComponent(pathname))
+}
+
+// resolveFilePath makes the path absolute using the current working directory, i.e. the same way the relative paths
+// passed to require() are resolved by the default source loader. The result uses forward slashes as separators.
+func resolveFilePath(p string, windows bool) string {
+	if windows {
+		p = strings.ReplaceAll(p, "\\", "/")
+		if strings.HasPrefix(p, "//") {
+			return "//" + path.Clean(p[2:])
+		}
+		if len(p) >= 2 && p[1] == ':' {
+			return p[:2] + path.Clean("/"+p[2:])
+		}
+	} else if path.IsAbs(p) {
+		return path.Clean(p)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = "/"
+	}
+	cwd = filepath.ToSlash(cwd)
+	if windows && len(cwd) >= 2 && cwd[1] == ':' {
+		return cwd[:2] + path.Join("/"+cwd[2:], p)
+	}
+	return path.Join(cwd, p)
+}
+
+func (m *urlModule) pathToFileURL(call goja.FunctionCall) goja.Value {
+	arg := call.Argument(0)
+	p, ok := arg.Export().(string)
+	if !ok {
+		panic(errors.NewArgTypeError(m.r, "path", "of type string", arg))
+	}
+	windows := m.windowsOption(call.Argument(1))
+	resolved := resolveFilePath(p, windows)
+
+	// Resolving strips the trailing slashes, so they must be added back.
+	if (strings.HasSuffix(p, "/") || (windows && strings.HasSuffix(p, "\\"))) && !strings.HasSuffix(resolved, "/") {
+		resolved += "/"
+	}
+
+	var s string
+	if windows && strings.HasPrefix(resolved, "//") {
+		// UNC path: \\server\share\resource
+		rest := resolved[2:]
+		hostname, pathname := rest, "/"
+		if i := strings.IndexByte(rest, '/'); i != -1 {
+			hostname, pathname = rest[:i], rest[i:]
+		}
+		if hostname == "" {
+			panic(errors.NewTypeError(m.r, errors.ErrCodeInvalidArgValue, "The argument 'path' must be a valid UNC path. Received '%s'", p))
+		}
+		s = "file://" + hostname + escape(pathname, &tblEscapeFilePath, false)
+	} else {
+		if windows {
+			resolved = "/" + resolved
+		}
+		s = "file://" + escape(resolved, &tblEscapeFilePath, false)
+	}
+	return m.newURL(m.parseURL(m.r.ToValue(s), goja.Undefined()), m.urlPrototype)
+}
+
+func (m *urlModule) urlToHttpOptions(call goja.FunctionCall) goja.Value {
+	r := m.r
+	u := call.Argument(0).ToObject(r)
+	get := func(name string) goja.Value {
+		if v := u.Get(name); v != nil {
+			return v
+		}
+		return goja.Undefined()
+	}
+
+	options := r.CreateObject(nil)
+	// In case the url object was extended by the user. The methods of the Go value behind the url are not
+	// properties of a url in nodejs.
+	t := u.ExportType()
+	for _, key := range u.Keys() {
+		if t != nil {
+			if _, ok := t.MethodByName(key); ok {
+				continue
+			}
+		}
+		options.Set(key, u.Get(key))
+	}
+	options.Set("protocol", get("protocol"))
+	hostname := get("hostname")
+	if s, ok := hostname.Export().(string); ok && strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		hostname = r.ToValue(s[1 : len(s)-1])
+	}
+	options.Set("hostname", hostname)
+	options.Set("hash", get("hash"))
+	pathname, search := get("pathname"), get("search")
+	options.Set("search", search)
+	options.Set("pathname", pathname)
+	var pathStr string
+	if pathname.ToBoolean() {
+		pathStr = pathname.String()
+	}
+	if search.ToBoolean() {
+		pathStr += search.String()
+	}
+	options.Set("path", pathStr)
+	options.Set("href", get("href"))
+	if port := get("port"); port.String() != "" {
+		options.Set("port", port.ToNumber())
+	}
+	username, password := get("username"), get("password")
+	if username.ToBoolean() || password.ToBoolean() {
+		options.Set("auth", m.decodeURIComponent(username.String())+":"+m.decodeURIComponent(password.String()))
+	}
+	return options
+}
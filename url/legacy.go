@@ -0,0 +1,912 @@
+package url
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+
+	"golang.org/x/net/idna"
+)
+
+// This is a port of the legacy url API from nodejs lib/url.js
+
+const legacyHostnameMaxLen = 255
+
+var (
+	legacyUnsafeProtocol   = map[string]bool{"javascript": true, "javascript:": true}
+	legacyHostlessProtocol = map[string]bool{"javascript": true, "javascript:": true}
+	legacySlashedProtocol  = map[string]bool{
+		"http": true, "http:": true,
+		"https": true, "https:": true,
+		"ftp": true, "ftp:": true,
+		"gopher": true, "gopher:": true,
+		"file": true, "file:": true,
+		"ws": true, "ws:": true,
+		"wss": true, "wss:": true,
+	}
+)
+
+// legacyURL holds the properties of a legacy Url object. A nil pointer corresponds to null.
+type legacyURL struct {
+	protocol *string
+	slashes  *bool
+	auth     *string
+	host     *string
+	port     *string
+	hostname *string
+	hash     *string
+	search   *string
+	query    goja.Value // null, a string or an object
+	pathname *string
+	path     *string
+	href     *string
+}
+
+func str(s string) *string {
+	return &s
+}
+
+func strVal(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+// truthy returns true if the value is a non-empty string, i.e. what the value would evaluate to in JS.
+func truthy(p *string) bool {
+	return p != nil && *p != ""
+}
+
+func strEqual(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func isLegacyURLSpace(c rune) bool {
+	return c < 33 || c == 0xA0 || c == 0xFEFF
+}
+
+func isJSSpace(c rune) bool {
+	switch c {
+	case '\t', '\n', '\v', '\f', '\r', ' ', 0xA0, 0x1680, 0x2028, 0x2029, 0x202F, 0x205F, 0x3000, 0xFEFF:
+		return true
+	}
+	return c >= 0x2000 && c <= 0x200A
+}
+
+// matchLegacyProtocol returns the length of the match of /^[a-z0-9.+-]+:/i
+func matchLegacyProtocol(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '+', c == '-':
+		case c == ':' && i > 0:
+			return i + 1
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+// matchLegacyHost tests the string against /^\/\/[^@/]+@[^@/]+/
+func matchLegacyHost(s string) bool {
+	if !strings.HasPrefix(s, "//") {
+		return false
+	}
+	s = s[2:]
+	at := strings.IndexAny(s, "@/")
+	if at <= 0 || s[at] != '@' {
+		return false
+	}
+	return len(s) > at+1 && s[at+1] != '@' && s[at+1] != '/'
+}
+
+// matchLegacySimplePath tests the string against /^(\/\/?(?!\/)[^?\s]*)(\?[^\s]*)?$/ and returns the path
+// and the search parts.
+func matchLegacySimplePath(s string) (pathname, search string, ok bool) {
+	if !strings.HasPrefix(s, "/") || strings.HasPrefix(s, "///") || strings.IndexFunc(s, isJSSpace) != -1 {
+		return "", "", false
+	}
+	if q := strings.IndexByte(s, '?'); q != -1 {
+		return s[:q], s[q:], true
+	}
+	return s, "", true
+}
+
+func isIPv6Hostname(hostname string) bool {
+	return strings.HasPrefix(hostname, "[") && strings.HasSuffix(hostname, "]")
+}
+
+func (m *urlModule) decodeURIComponent(s string) string {
+	res, err := url.PathUnescape(s)
+	if err != nil || !utf8.ValidString(res) {
+		ctor, _ := m.r.Get("URIError").(*goja.Object)
+		e, _ := m.r.New(ctor, m.r.ToValue("URI malformed"))
+		panic(e)
+	}
+	return res
+}
+
+func (m *urlModule) parseLegacyURL(u *legacyURL, input string, parseQueryString, slashesDenoteHost bool) {
+	// Trim whitespace and convert backslashes before the query string or the hash to forward slashes.
+	rest := strings.TrimFunc(input, isLegacyURLSpace)
+	hasHash, hasAt := false, false
+	if i := strings.IndexAny(rest, "#?"); i != -1 {
+		hasAt = strings.IndexByte(rest[:i], '@') != -1
+		hasHash = strings.IndexByte(rest[i:], '#') != -1
+		rest = strings.ReplaceAll(rest[:i], "\\", "/") + rest[i:]
+	} else {
+		hasAt = strings.IndexByte(rest, '@') != -1
+		rest = strings.ReplaceAll(rest, "\\", "/")
+	}
+
+	if !slashesDenoteHost && !hasHash && !hasAt {
+		if pathname, search, ok := matchLegacySimplePath(rest); ok {
+			u.path = str(rest)
+			u.href = str(rest)
+			u.pathname = str(pathname)
+			if search != "" {
+				u.search = str(search)
+				if parseQueryString {
+					u.query = qsParse(m.r, search[1:], "&", "=", qsDefaultMaxKeys, nil)
+				} else {
+					u.query = m.r.ToValue(search[1:])
+				}
+			} else if parseQueryString {
+				u.search = nil
+				u.query = qsParse(m.r, "", "&", "=", qsDefaultMaxKeys, nil)
+			}
+			return
+		}
+	}
+
+	var lowerProto string
+	proto := ""
+	if n := matchLegacyProtocol(rest); n > 0 {
+		proto = rest[:n]
+		lowerProto = strings.ToLower(proto)
+		u.protocol = str(lowerProto)
+		rest = rest[n:]
+	}
+
+	// Figure out if it's got a host. user@server is *always* interpreted as a hostname, and url resolution will treat
+	// //foo/bar as host=foo,path=bar because that's how the browser resolves relative URLs.
+	slashes := false
+	if slashesDenoteHost || proto != "" || matchLegacyHost(rest) {
+		slashes = strings.HasPrefix(rest, "//")
+		if slashes && !(proto != "" && legacyHostlessProtocol[lowerProto]) {
+			rest = rest[2:]
+			u.slashes = new(bool)
+			*u.slashes = true
+		}
+	}
+
+	if !legacyHostlessProtocol[lowerProto] && (slashes || (proto != "" && !legacySlashedProtocol[proto])) {
+		// There's a hostname. The first instance of /, ?, ;, or # ends the host. If there is an @ in the hostname,
+		// then non-host chars *are* allowed to the left of the last @ sign, unless some host-ending character comes
+		// *before* the @-sign.
+		atSign, nonHost := -1, -1
+	L:
+		for i := 0; i < len(rest); i++ {
+			switch rest[i] {
+			case '\t', '\n', '\r', ' ', '"', '%', '\'', ';', '<', '>', '\\', '^', '`', '{', '|', '}':
+				// Characters that are never ever allowed in a hostname from RFC 2396
+				if nonHost == -1 {
+					nonHost = i
+				}
+			case '#', '/', '?':
+				if nonHost == -1 {
+					nonHost = i
+				}
+				break L
+			case '@':
+				atSign = i
+				nonHost = -1
+			}
+		}
+		start := 0
+		if atSign != -1 {
+			u.auth = str(m.decodeURIComponent(rest[:atSign]))
+			start = atSign + 1
+		}
+		if nonHost == -1 {
+			u.host = str(rest[start:])
+			rest = ""
+		} else {
+			u.host = str(rest[start:nonHost])
+			rest = rest[nonHost:]
+		}
+
+		u.parseHost()
+
+		// We've indicated that there is a hostname, so even if it's empty, it has to be present.
+		if u.hostname == nil {
+			u.hostname = str("")
+		}
+		hostname := *u.hostname
+		ipv6Hostname := isIPv6Hostname(hostname)
+
+		if !ipv6Hostname {
+			for i := 0; i < len(hostname); i++ {
+				switch hostname[i] {
+				case '/', '\\', '#', '?', ':':
+					u.hostname = str(hostname[:i])
+					rest = "/" + hostname[i:] + rest
+				default:
+					continue
+				}
+				break
+			}
+		}
+
+		if utf8.RuneCountInString(*u.hostname) > legacyHostnameMaxLen {
+			u.hostname = str("")
+		} else {
+			u.hostname = str(strings.ToLower(*u.hostname))
+		}
+
+		if *u.hostname != "" {
+			if ipv6Hostname {
+				if strings.ContainsAny(*u.hostname, "\x00\t\n\r #%/<>?@\\^|") {
+					panic(m.newInvalidURLError(InvalidURL, input))
+				}
+			} else {
+				h, err := idna.Punycode.ToASCII(*u.hostname)
+				// Prevent hostname spoofing: forbidden characters could only have been introduced by the conversion.
+				if err != nil || h == "" || strings.ContainsAny(h, "\x00\t\n\r #%/:<>?@[\\]^|") {
+					panic(m.newInvalidURLError(InvalidURL, input))
+				}
+				u.hostname = str(h)
+			}
+		}
+
+		host := *u.hostname
+		if truthy(u.port) {
+			host += ":" + *u.port
+		}
+		u.host = str(host)
+
+		// Strip [ and ] from the hostname, the host field still retains them.
+		if ipv6Hostname {
+			u.hostname = str((*u.hostname)[1 : len(*u.hostname)-1])
+			if !strings.HasPrefix(rest, "/") {
+				rest = "/" + rest
+			}
+		}
+	}
+
+	if !legacyUnsafeProtocol[lowerProto] {
+		rest = escapeWith(rest, &tblAutoEscapeLegacy, false, false)
+	}
+
+	questionIdx, hashIdx := -1, -1
+	for i := 0; i < len(rest); i++ {
+		if c := rest[i]; c == '#' {
+			u.hash = str(rest[i:])
+			hashIdx = i
+			break
+		} else if c == '?' && questionIdx == -1 {
+			questionIdx = i
+		}
+	}
+
+	if questionIdx != -1 {
+		var query string
+		if hashIdx == -1 {
+			u.search = str(rest[questionIdx:])
+			query = rest[questionIdx+1:]
+		} else {
+			u.search = str(rest[questionIdx:hashIdx])
+			query = rest[questionIdx+1 : hashIdx]
+		}
+		if parseQueryString {
+			u.query = qsParse(m.r, query, "&", "=", qsDefaultMaxKeys, nil)
+		} else {
+			u.query = m.r.ToValue(query)
+		}
+	} else if parseQueryString {
+		u.search = nil
+		u.query = qsParse(m.r, "", "&", "=", qsDefaultMaxKeys, nil)
+	}
+
+	firstIdx := hashIdx
+	if questionIdx != -1 && (hashIdx == -1 || questionIdx < hashIdx) {
+		firstIdx = questionIdx
+	}
+	if firstIdx == -1 {
+		if len(rest) > 0 {
+			u.pathname = str(rest)
+		}
+	} else if firstIdx > 0 {
+		u.pathname = str(rest[:firstIdx])
+	}
+	if legacySlashedProtocol[lowerProto] && truthy(u.hostname) && !truthy(u.pathname) {
+		u.pathname = str("/")
+	}
+
+	if truthy(u.pathname) || truthy(u.search) {
+		u.path = str(strVal(u.pathname) + strVal(u.search))
+	}
+
+	u.href = str(u.format(m.r))
+}
+
+// parseHost extracts the port from the host.
+func (u *legacyURL) parseHost() {
+	host := strVal(u.host)
+	i := len(host)
+	for i > 0 && host[i-1] >= '0' && host[i-1] <= '9' {
+		i--
+	}
+	if i > 0 && host[i-1] == ':' {
+		if i < len(host) {
+			u.port = str(host[i:])
+		}
+		host = host[:i-1]
+	}
+	if host != "" {
+		u.hostname = str(host)
+	}
+}
+
+func (u *legacyURL) format(r *goja.Runtime) string {
+	auth := strVal(u.auth)
+	if auth != "" {
+		auth = escape(auth, &tblEscapeLegacyAuth, false) + "@"
+	}
+
+	protocol := strVal(u.protocol)
+	pathname := strVal(u.pathname)
+	hash := strVal(u.hash)
+	host := ""
+	query := ""
+
+	if truthy(u.host) {
+		host = auth + *u.host
+	} else if truthy(u.hostname) {
+		if strings.IndexByte(*u.hostname, ':') != -1 && !isIPv6Hostname(*u.hostname) {
+			host = auth + "[" + *u.hostname + "]"
+		} else {
+			host = auth + *u.hostname
+		}
+		if truthy(u.port) {
+			host += ":" + *u.port
+		}
+	}
+
+	if o, ok := u.query.(*goja.Object); ok {
+		query = qsStringify(r, o, "&", "=", nil)
+	}
+
+	search := strVal(u.search)
+	if search == "" && query != "" {
+		search = "?" + query
+	}
+
+	if protocol != "" && !strings.HasSuffix(protocol, ":") {
+		protocol += ":"
+	}
+
+	pathname = strings.NewReplacer("#", "%23", "?", "%3F").Replace(pathname)
+
+	// Only the slashedProtocols get the //. Not mailto:, xmpp:, etc. unless they had them to begin with.
+	slashes := u.slashes != nil && *u.slashes
+	if slashes || legacySlashedProtocol[protocol] {
+		if slashes || host != "" {
+			if pathname != "" && pathname[0] != '/' {
+				pathname = "/" + pathname
+			}
+			host = "//" + host
+		} else if strings.HasPrefix(protocol, "file") {
+			host = "//"
+		}
+	}
+
+	search = strings.ReplaceAll(search, "#", "%23")
+
+	if hash != "" && hash[0] != '#' {
+		hash = "#" + hash
+	}
+	if search != "" && search[0] != '?' {
+		search = "?" + search
+	}
+
+	return protocol + host + pathname + search + hash
+}
+
+func (m *urlModule) resolveLegacyURL(src, relative *legacyURL) *legacyURL {
+	result := *src
+	rel := *relative
+
+	// Hash is always overridden, no matter what, even href="" will remove it.
+	result.hash = rel.hash
+
+	// If the relative url is empty, then there's nothing left to do here.
+	if rel.href != nil && *rel.href == "" {
+		result.href = str(result.format(m.r))
+		return &result
+	}
+
+	// Hrefs like //foo/bar always cut to the protocol.
+	if rel.slashes != nil && *rel.slashes && !truthy(rel.protocol) {
+		protocol := result.protocol
+		result = rel
+		result.protocol = protocol
+
+		// parse() appends trailing / to urls like http://www.example.com
+		if result.protocol != nil && legacySlashedProtocol[*result.protocol] && truthy(result.hostname) && !truthy(result.pathname) {
+			result.pathname = str("/")
+			result.path = result.pathname
+		}
+		result.href = str(result.format(m.r))
+		return &result
+	}
+
+	if truthy(rel.protocol) && !strEqual(rel.protocol, result.protocol) {
+		// If it's a known url protocol, then changing the protocol does weird things. First, if it's not file:,
+		// then we MUST have a host, and if there was a path to begin with, then we MUST have a path. If it is file:,
+		// then the host is dropped, because that's known to be hostless. Anything else is assumed to be absolute.
+		if !legacySlashedProtocol[*rel.protocol] {
+			result = rel
+			result.href = str(result.format(m.r))
+			return &result
+		}
+
+		result.protocol = rel.protocol
+		if !truthy(rel.host) && *rel.protocol != "file" && *rel.protocol != "file:" && !legacyHostlessProtocol[*rel.protocol] {
+			relPath := strings.Split(strVal(rel.pathname), "/")
+			host := ""
+			for len(relPath) > 0 {
+				host, relPath = relPath[0], relPath[1:]
+				if host != "" {
+					break
+				}
+			}
+			rel.host = str(host)
+			if !truthy(rel.hostname) {
+				rel.hostname = str("")
+			}
+			if len(relPath) == 0 || relPath[0] != "" {
+				relPath = append([]string{""}, relPath...)
+			}
+			if len(relPath) < 2 {
+				relPath = append([]string{""}, relPath...)
+			}
+			result.pathname = str(strings.Join(relPath, "/"))
+		} else {
+			result.pathname = rel.pathname
+		}
+		result.search = rel.search
+		result.query = rel.query
+		result.host = str(strVal(rel.host))
+		result.auth = rel.auth
+		if truthy(rel.hostname) {
+			result.hostname = rel.hostname
+		} else {
+			result.hostname = rel.host
+		}
+		result.port = rel.port
+		if truthy(result.pathname) || truthy(result.search) {
+			result.path = str(strVal(result.pathname) + strVal(result.search))
+		}
+		if result.slashes == nil || !*result.slashes {
+			result.slashes = rel.slashes
+		}
+		result.href = str(result.format(m.r))
+		return &result
+	}
+
+	isSourceAbs := truthy(result.pathname) && (*result.pathname)[0] == '/'
+	isRelAbs := truthy(rel.host) || (truthy(rel.pathname) && (*rel.pathname)[0] == '/')
+	mustEndAbs := isRelAbs || isSourceAbs || (truthy(result.host) && truthy(rel.pathname))
+	removeAllDots := mustEndAbs
+	var srcPath, relPath []string
+	if truthy(result.pathname) {
+		srcPath = strings.Split(*result.pathname, "/")
+	}
+	if truthy(rel.pathname) {
+		relPath = strings.Split(*rel.pathname, "/")
+	}
+	noLeadingSlashes := truthy(result.protocol) && !legacySlashedProtocol[*result.protocol]
+
+	// If the url is a non-slashed url, then relative links like ../.. should be able to crawl up to the hostname,
+	// as well. This is strange. result.protocol has already been set by now. Later on, put the first path part into
+	// the host field.
+	if noLeadingSlashes {
+		result.hostname = str("")
+		result.port = nil
+		if truthy(result.host) {
+			if len(srcPath) > 0 && srcPath[0] == "" {
+				srcPath[0] = *result.host
+			} else {
+				srcPath = append([]string{*result.host}, srcPath...)
+			}
+		}
+		result.host = str("")
+		if truthy(rel.protocol) {
+			rel.hostname = nil
+			rel.port = nil
+			result.auth = nil
+			if truthy(rel.host) {
+				if len(relPath) > 0 && relPath[0] == "" {
+					relPath[0] = *rel.host
+				} else {
+					relPath = append([]string{*rel.host}, relPath...)
+				}
+			}
+			rel.host = nil
+		}
+		mustEndAbs = mustEndAbs && ((len(relPath) > 0 && relPath[0] == "") || (len(srcPath) > 0 && srcPath[0] == ""))
+	}
+
+	extractAuthFromHost := func() {
+		// Occasionally the auth can get stuck only in host. This especially happens in cases like
+		// url.resolveObject('mailto:local1@domain1', 'local2@domain2')
+		if truthy(result.host) && strings.IndexByte(*result.host, '@') > 0 {
+			parts := strings.Split(*result.host, "@")
+			result.auth = str(parts[0])
+			result.host = str(parts[1])
+			result.hostname = result.host
+		}
+	}
+
+	if isRelAbs {
+		// it's absolute.
+		if rel.host != nil {
+			if !strEqual(result.host, rel.host) {
+				result.auth = nil
+			}
+			result.host = rel.host
+			result.port = rel.port
+		}
+		if rel.hostname != nil {
+			if !strEqual(result.hostname, rel.hostname) {
+				result.auth = nil
+			}
+			result.hostname = rel.hostname
+		}
+		result.search = rel.search
+		result.query = rel.query
+		srcPath = relPath
+	} else if len(relPath) > 0 {
+		// it's relative, throw away the existing file, and take the new path instead.
+		if len(srcPath) > 0 {
+			srcPath = srcPath[:len(srcPath)-1]
+		}
+		srcPath = append(srcPath, relPath...)
+		result.search = rel.search
+		result.query = rel.query
+	} else if rel.search != nil {
+		// Just pull out the search, like href='?foo'.
+		if noLeadingSlashes {
+			if len(srcPath) > 0 {
+				result.host = str(srcPath[0])
+				srcPath = srcPath[1:]
+			} else {
+				result.host = nil
+			}
+			result.hostname = result.host
+			extractAuthFromHost()
+		}
+		result.search = rel.search
+		result.query = rel.query
+		if result.pathname != nil || result.search != nil {
+			result.path = str(strVal(result.pathname) + strVal(result.search))
+		}
+		result.href = str(result.format(m.r))
+		return &result
+	}
+
+	if len(srcPath) == 0 {
+		// No path at all. All other things were already handled above.
+		result.pathname = nil
+		if truthy(result.search) {
+			result.path = str("/" + *result.search)
+		} else {
+			result.path = nil
+		}
+		result.href = str(result.format(m.r))
+		return &result
+	}
+
+	// If a url ENDs in . or .., then it must get a trailing slash. However, if it ends in anything else non-slashy,
+	// then it must NOT get a trailing slash.
+	last := srcPath[len(srcPath)-1]
+	hasTrailingSlash := ((truthy(result.host) || truthy(rel.host) || len(srcPath) > 1) && (last == "." || last == "..")) || last == ""
+
+	// Strip single dots, resolve double dots to parent dir. If the path tries to go above the root, `up` ends up > 0
+	up := 0
+	for i := len(srcPath) - 1; i >= 0; i-- {
+		switch last = srcPath[i]; {
+		case last == ".":
+			srcPath = append(srcPath[:i], srcPath[i+1:]...)
+		case last == "..":
+			srcPath = append(srcPath[:i], srcPath[i+1:]...)
+			up++
+		case up > 0:
+			srcPath = append(srcPath[:i], srcPath[i+1:]...)
+			up--
+		}
+	}
+
+	// If the path is allowed to go above the root, restore leading ..s
+	if !mustEndAbs && !removeAllDots {
+		for ; up > 0; up-- {
+			srcPath = append([]string{".."}, srcPath...)
+		}
+	}
+
+	if mustEndAbs && (len(srcPath) == 0 || (srcPath[0] != "" && srcPath[0][0] != '/')) {
+		srcPath = append([]string{""}, srcPath...)
+	}
+
+	if hasTrailingSlash && !strings.HasSuffix(strings.Join(srcPath, "/"), "/") {
+		srcPath = append(srcPath, "")
+	}
+
+	isAbsolute := len(srcPath) > 0 && (srcPath[0] == "" || srcPath[0][0] == '/')
+
+	// put the host back
+	if noLeadingSlashes {
+		host := ""
+		if !isAbsolute && len(srcPath) > 0 {
+			host, srcPath = srcPath[0], srcPath[1:]
+		}
+		result.host = str(host)
+		result.hostname = result.host
+		extractAuthFromHost()
+	}
+
+	mustEndAbs = mustEndAbs || (truthy(result.host) && len(srcPath) > 0)
+
+	if mustEndAbs && !isAbsolute {
+		srcPath = append([]string{""}, srcPath...)
+	}
+
+	if len(srcPath) > 0 {
+		result.pathname = str(strings.Join(srcPath, "/"))
+	} else {
+		result.pathname = nil
+		result.path = nil
+	}
+
+	if result.pathname != nil || result.search != nil {
+		result.path = str(strVal(result.pathname) + strVal(result.search))
+	}
+	if truthy(rel.auth) {
+		result.auth = rel.auth
+	}
+	if result.slashes == nil || !*result.slashes {
+		result.slashes = rel.slashes
+	}
+	result.href = str(result.format(m.r))
+	return &result
+}
+
+func (m *urlModule) valueToLegacyString(v goja.Value) *string {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil
+	}
+	if s, ok := v.Export().(string); ok {
+		return &s
+	}
+	if !v.ToBoolean() {
+		return nil
+	}
+	return str(v.String())
+}
+
+// legacyURLFromObject reads the Url properties from an arbitrary object.
+func (m *urlModule) legacyURLFromObject(o *goja.Object) *legacyURL {
+	u := &legacyURL{
+		protocol: m.valueToLegacyString(o.Get("protocol")),
+		auth:     m.valueToLegacyString(o.Get("auth")),
+		host:     m.valueToLegacyString(o.Get("host")),
+		port:     m.valueToLegacyString(o.Get("port")),
+		hostname: m.valueToLegacyString(o.Get("hostname")),
+		hash:     m.valueToLegacyString(o.Get("hash")),
+		search:   m.valueToLegacyString(o.Get("search")),
+		pathname: m.valueToLegacyString(o.Get("pathname")),
+		path:     m.valueToLegacyString(o.Get("path")),
+		href:     m.valueToLegacyString(o.Get("href")),
+	}
+	if v := o.Get("slashes"); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+		u.slashes = new(bool)
+		*u.slashes = v.ToBoolean()
+	}
+	if v := o.Get("query"); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+		u.query = v
+	}
+	return u
+}
+
+func (m *urlModule) legacyStringValue(p *string) goja.Value {
+	if p == nil {
+		return goja.Null()
+	}
+	return m.r.ToValue(*p)
+}
+
+func (m *urlModule) setLegacyURLProperties(u *legacyURL, o *goja.Object) {
+	o.Set("protocol", m.legacyStringValue(u.protocol))
+	if u.slashes == nil {
+		o.Set("slashes", goja.Null())
+	} else {
+		o.Set("slashes", *u.slashes)
+	}
+	o.Set("auth", m.legacyStringValue(u.auth))
+	o.Set("host", m.legacyStringValue(u.host))
+	o.Set("port", m.legacyStringValue(u.port))
+	o.Set("hostname", m.legacyStringValue(u.hostname))
+	o.Set("hash", m.legacyStringValue(u.hash))
+	o.Set("search", m.legacyStringValue(u.search))
+	if u.query == nil {
+		o.Set("query", goja.Null())
+	} else {
+		o.Set("query", u.query)
+	}
+	o.Set("pathname", m.legacyStringValue(u.pathname))
+	o.Set("path", m.legacyStringValue(u.path))
+	o.Set("href", m.legacyStringValue(u.href))
+}
+
+func (m *urlModule) newLegacyURLObject(u *legacyURL) *goja.Object {
+	o := m.r.NewObject()
+	o.SetPrototype(m.legacyURLPrototype)
+	m.setLegacyURLProperties(u, o)
+	return o
+}
+
+func (m *urlModule) isLegacyURLObject(v goja.Value) bool {
+	o, ok := v.(*goja.Object)
+	if !ok {
+		return false
+	}
+	for p := o.Prototype(); p != nil; p = p.Prototype() {
+		if p == m.legacyURLPrototype {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *urlModule) legacyParse(v goja.Value, parseQueryString, slashesDenoteHost bool) *legacyURL {
+	s, ok := v.Export().(string)
+	if !ok {
+		panic(errors.NewArgTypeError(m.r, "url", "of type string", v))
+	}
+	u := &legacyURL{}
+	m.parseLegacyURL(u, s, parseQueryString, slashesDenoteHost)
+	return u
+}
+
+// toLegacyRelative converts the argument of resolveObject() into a legacyURL, parsing it if it's a string.
+func (m *urlModule) toLegacyRelative(v goja.Value) *legacyURL {
+	if _, ok := v.Export().(string); ok {
+		return m.legacyParse(v, false, true)
+	}
+	if o, ok := v.(*goja.Object); ok {
+		return m.legacyURLFromObject(o)
+	}
+	return &legacyURL{}
+}
+
+// formatWHATWGURL implements url.format() for URL instances.
+func (m *urlModule) formatWHATWGURL(nu *nodeURL, options goja.Value) string {
+	fragment, unicode, search, auth := true, false, true, true
+	if !goja.IsUndefined(options) && !goja.IsNull(options) {
+		opts, ok := options.(*goja.Object)
+		if !ok {
+			panic(errors.NewArgTypeError(m.r, "options", "of type object", options))
+		}
+		getBool := func(name string, def bool) bool {
+			if v := opts.Get(name); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+				return v.ToBoolean()
+			}
+			return def
+		}
+		fragment = getBool("fragment", fragment)
+		unicode = getBool("unicode", unicode)
+		search = getBool("search", search)
+		auth = getBool("auth", auth)
+	}
+
+	u := nu.url.clone()
+	if !auth {
+		u.username = ""
+		u.password = ""
+	}
+	if !fragment {
+		u.fragment = nil
+	}
+	if !search {
+		u.query = nil
+	}
+	if unicode && u.host != nil {
+		host := idnaToUnicode(*u.host)
+		u.host = &host
+	}
+	return u.String()
+}
+
+func (m *urlModule) createLegacyURLConstructor() *goja.Object {
+	r := m.r
+	ctor := r.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		m.setLegacyURLProperties(&legacyURL{}, call.This)
+		return nil
+	}).(*goja.Object)
+	ctor.DefineDataProperty("name", r.ToValue("Url"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+
+	proto := r.NewObject()
+	proto.Set("parse", func(call goja.FunctionCall) goja.Value {
+		u := m.legacyParse(call.Argument(0), call.Argument(1).ToBoolean(), call.Argument(2).ToBoolean())
+		this := call.This.ToObject(r)
+		m.setLegacyURLProperties(u, this)
+		return this
+	})
+	proto.Set("format", func(call goja.FunctionCall) goja.Value {
+		return r.ToValue(m.legacyURLFromObject(call.This.ToObject(r)).format(r))
+	})
+	proto.Set("resolve", func(call goja.FunctionCall) goja.Value {
+		src := m.legacyURLFromObject(call.This.ToObject(r))
+		return r.ToValue(m.resolveLegacyURL(src, m.legacyParse(call.Argument(0), false, true)).format(r))
+	})
+	proto.Set("resolveObject", func(call goja.FunctionCall) goja.Value {
+		src := m.legacyURLFromObject(call.This.ToObject(r))
+		return m.newLegacyURLObject(m.resolveLegacyURL(src, m.toLegacyRelative(call.Argument(0))))
+	})
+	proto.DefineDataProperty("constructor", ctor, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	ctor.Set("prototype", proto)
+	m.legacyURLPrototype = proto
+	return ctor
+}
+
+func (m *urlModule) urlParse(call goja.FunctionCall) goja.Value {
+	if m.isLegacyURLObject(call.Argument(0)) {
+		return call.Argument(0)
+	}
+	return m.newLegacyURLObject(m.legacyParse(call.Argument(0), call.Argument(1).ToBoolean(), call.Argument(2).ToBoolean()))
+}
+
+func (m *urlModule) urlFormat(call goja.FunctionCall) goja.Value {
+	arg := call.Argument(0)
+	if _, ok := arg.Export().(string); ok {
+		return m.r.ToValue(m.legacyParse(arg, false, false).format(m.r))
+	}
+	o, ok := arg.(*goja.Object)
+	if !ok {
+		panic(errors.NewArgTypeError(m.r, "urlObject", "of type string or an instance of Object", arg))
+	}
+	if o.ExportType() == reflectTypeURL {
+		if nu := o.Export().(*nodeURL); nu != nil {
+			return m.r.ToValue(m.formatWHATWGURL(nu, call.Argument(1)))
+		}
+	}
+	return m.r.ToValue(m.legacyURLFromObject(o).format(m.r))
+}
+
+func (m *urlModule) urlResolve(call goja.FunctionCall) goja.Value {
+	src := m.legacyParse(call.Argument(0), false, true)
+	return m.r.ToValue(m.resolveLegacyURL(src, m.legacyParse(call.Argument(1), false, true)).format(m.r))
+}
+
+func (m *urlModule) urlResolveObject(call goja.FunctionCall) goja.Value {
+	source := call.Argument(0)
+	if !source.ToBoolean() {
+		return call.Argument(1)
+	}
+	var src *legacyURL
+	if m.isLegacyURLObject(source) {
+		src = m.legacyURLFromObject(source.(*goja.Object))
+	} else {
+		src = m.legacyParse(source, false, true)
+	}
+	return m.newLegacyURLObject(m.resolveLegacyURL(src, m.toLegacyRelative(call.Argument(1))))
+}
@@ -10,8 +10,46 @@ const ModuleName = "url"
 type urlModule struct {
 	r *goja.Runtime
 
+	urlPrototype *goja.Object
+
 	URLSearchParamsPrototype         *goja.Object
 	URLSearchParamsIteratorPrototype *goja.Object
+
+	legacyURLPrototype *goja.Object
+
+	objectURLs map[string]*goja.Object
+}
+
+// customInspectSymbol returns Symbol.for('nodejs.util.inspect.custom').
+func customInspectSymbol(r *goja.Runtime) *goja.Symbol {
+	symFor, _ := goja.AssertFunction(r.Get("Symbol").ToObject(r).Get("for"))
+	sym, err := symFor(nil, r.ToValue("nodejs.util.inspect.custom"))
+	if err != nil {
+		panic(err)
+	}
+	return sym.(*goja.Symbol)
+}
+
+// inspectArg calls the inspect function passed to a custom inspect method.
+func inspectArg(call goja.FunctionCall, v goja.Value, opts goja.Value) string {
+	if inspect, ok := goja.AssertFunction(call.Argument(2)); ok {
+		res, err := inspect(goja.Undefined(), v, opts)
+		if err != nil {
+			panic(err)
+		}
+		return res.String()
+	}
+	return v.String()
+}
+
+// constructorName returns the name of the object's constructor.
+func constructorName(r *goja.Runtime, v goja.Value, def string) string {
+	if ctor, ok := v.ToObject(r).Get("constructor").(*goja.Object); ok {
+		if name := ctor.Get("name"); name != nil && name.String() != "" {
+			return name.String()
+		}
+	}
+	return def
 }
 
 func Require(runtime *goja.Runtime, module *goja.Object) {
@@ -21,14 +59,24 @@ func Require(runtime *goja.Runtime, module *goja.Object) {
 	}
 	exports.Set("URL", m.createURLConstructor())
 	exports.Set("URLSearchParams", m.createURLSearchParamsConstructor())
+	exports.Set("URLPattern", m.createURLPatternConstructor())
 	exports.Set("domainToASCII", m.domainToASCII)
 	exports.Set("domainToUnicode", m.domainToUnicode)
+	exports.Set("Url", m.createLegacyURLConstructor())
+	exports.Set("parse", m.urlParse)
+	exports.Set("format", m.urlFormat)
+	exports.Set("resolve", m.urlResolve)
+	exports.Set("resolveObject", m.urlResolveObject)
+	exports.Set("fileURLToPath", m.fileURLToPath)
+	exports.Set("pathToFileURL", m.pathToFileURL)
+	exports.Set("urlToHttpOptions", m.urlToHttpOptions)
 }
 
 func Enable(runtime *goja.Runtime) {
 	m := require.Require(runtime, ModuleName).ToObject(runtime)
 	runtime.Set("URL", m.Get("URL"))
 	runtime.Set("URLSearchParams", m.Get("URLSearchParams"))
+	runtime.Set("URLPattern", m.Get("URLPattern"))
 }
 
 func init() {
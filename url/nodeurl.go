@@ -1,7 +1,6 @@
 package url
 
 import (
-	"net/url"
 	"strings"
 )
 
@@ -63,22 +62,32 @@ func (s searchParams) String() string {
 }
 
 type nodeURL struct {
-	url          *url.URL
+	url          *urlRecord
 	searchParams searchParams
 }
 
 type urlSearchParams nodeURL
 
-// This methods ensures that the url.URL has the proper RawQuery based on the searchParam
-// structs. If a change is made to the searchParams we need to keep them in sync.
-func (nu *nodeURL) syncSearchParams() {
-	if nu.rawQueryUpdateNeeded() {
-		nu.url.RawQuery = nu.searchParams.Encode()
+// updateSearchParams re-creates the searchParams list from the URL's query.
+func (nu *nodeURL) updateSearchParams() {
+	var query string
+	if nu.url.query != nil {
+		query = *nu.url.query
+	}
+	nu.searchParams = parseSearchQuery(query)
+	if nu.searchParams == nil {
+		nu.searchParams = make(searchParams, 0)
 	}
 }
 
-func (nu *nodeURL) rawQueryUpdateNeeded() bool {
-	return len(nu.searchParams) > 0 && nu.url.RawQuery == ""
+// updateQuery sets the URL's query from the searchParams list. It should be called after the list is modified.
+func (nu *nodeURL) updateQuery() {
+	if query := nu.searchParams.Encode(); query != "" {
+		nu.url.query = &query
+	} else {
+		nu.url.query = nil
+		nu.url.stripTrailingSpacesFromOpaquePath()
+	}
 }
 
 func (nu *nodeURL) String() string {
@@ -129,8 +138,6 @@ func parseSearchQuery(query string) (ret searchParams) {
 		return
 	}
 
-	query = strings.TrimPrefix(query, "?")
-
 	for _, v := range strings.Split(query, "&") {
 		if v == "" {
 			continue
@@ -0,0 +1,50 @@
+package url
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/buffer"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+const objectURLPrefix = "blob:nodedata:"
+
+func randomUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0F | 0x40 // version 4
+	b[8] = b[8]&0x3F | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// createObjectURL implements URL.createObjectURL(). The Blob is kept alive until the URL is revoked.
+func (m *urlModule) createObjectURL(call goja.FunctionCall) goja.Value {
+	obj := call.Argument(0)
+	if !buffer.IsBlob(obj) {
+		panic(errors.NewArgTypeError(m.r, "obj", "an instance of Blob", obj))
+	}
+	id := randomUUID()
+	if m.objectURLs == nil {
+		m.objectURLs = make(map[string]*goja.Object)
+	}
+	m.objectURLs[id] = obj.(*goja.Object)
+	return m.r.ToValue(objectURLPrefix + id)
+}
+
+func (m *urlModule) revokeObjectURL(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) == 0 {
+		panic(errors.NewTypeError(m.r, errors.ErrCodeMissingArgs, `The "url" argument must be specified`))
+	}
+	u, ok := parseURLRecord(call.Argument(0).String(), nil)
+	if ok && u.scheme == "blob" {
+		if split := strings.Split(u.pathname(), ":"); len(split) == 2 {
+			delete(m.objectURLs, split[1])
+		}
+	}
+	return goja.Undefined()
+}
@@ -0,0 +1,1176 @@
+package url
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// This is an implementation of the URL parser and serializer as defined by the WHATWG URL Standard,
+// see https://url.spec.whatwg.org/
+
+type parserState int
+
+const (
+	stateNone parserState = iota
+	stateSchemeStart
+	stateScheme
+	stateNoScheme
+	stateSpecialRelativeOrAuthority
+	statePathOrAuthority
+	stateRelative
+	stateRelativeSlash
+	stateSpecialAuthoritySlashes
+	stateSpecialAuthorityIgnoreSlashes
+	stateAuthority
+	stateHost
+	stateHostname
+	statePort
+	stateFile
+	stateFileSlash
+	stateFileHost
+	statePathStart
+	statePath
+	stateOpaquePath
+	stateQuery
+	stateFragment
+)
+
+const eof = -1
+
+// Default ports of the special schemes, -1 means there is no default port.
+var specialSchemes = map[string]int{
+	"ftp":   21,
+	"file":  -1,
+	"http":  80,
+	"https": 443,
+	"ws":    80,
+	"wss":   443,
+}
+
+// urlRecord is a URL as defined by the standard. A nil pointer corresponds to null.
+type urlRecord struct {
+	scheme   string
+	username string
+	password string
+	host     *string
+	port     int // -1 if null
+	path     []string
+	// If opaquePath is set, the path is a single string stored in path[0].
+	opaquePath bool
+	query      *string
+	fragment   *string
+}
+
+// Profile used by the 'domain to ASCII' algorithm with beStrict set to false.
+var idnaProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.Transitional(false),
+	idna.CheckHyphens(false),
+	idna.StrictDomainName(false),
+	idna.VerifyDNSLength(false),
+)
+
+func (u *urlRecord) isSpecial() bool {
+	_, ok := specialSchemes[u.scheme]
+	return ok
+}
+
+func (u *urlRecord) includesCredentials() bool {
+	return u.username != "" || u.password != ""
+}
+
+func (u *urlRecord) cannotHaveCredentialsOrPort() bool {
+	return u.host == nil || *u.host == "" || u.scheme == "file"
+}
+
+func (u *urlRecord) clone() *urlRecord {
+	res := *u
+	res.path = append([]string(nil), u.path...)
+	return &res
+}
+
+func (u *urlRecord) shortenPath() {
+	if u.scheme == "file" && len(u.path) == 1 && isNormalizedWindowsDriveLetter(u.path[0]) {
+		return
+	}
+	if len(u.path) > 0 {
+		u.path = u.path[:len(u.path)-1]
+	}
+}
+
+// stripTrailingSpacesFromOpaquePath implements the 'potentially strip trailing spaces from an opaque path' steps.
+func (u *urlRecord) stripTrailingSpacesFromOpaquePath() {
+	if !u.opaquePath || u.fragment != nil || u.query != nil {
+		return
+	}
+	u.path[0] = strings.TrimRight(u.path[0], " ")
+}
+
+func (u *urlRecord) pathname() string {
+	if u.opaquePath {
+		return u.path[0]
+	}
+	var sb strings.Builder
+	for _, segment := range u.path {
+		sb.WriteByte('/')
+		sb.WriteString(segment)
+	}
+	return sb.String()
+}
+
+func (u *urlRecord) hostString() string {
+	if u.host == nil {
+		return ""
+	}
+	return *u.host
+}
+
+func (u *urlRecord) hostAndPort() string {
+	if u.host == nil {
+		return ""
+	}
+	if u.port == -1 {
+		return *u.host
+	}
+	return *u.host + ":" + strconv.Itoa(u.port)
+}
+
+func (u *urlRecord) serialize(excludeFragment bool) string {
+	var sb strings.Builder
+	sb.WriteString(u.scheme)
+	sb.WriteByte(':')
+	if u.host != nil {
+		sb.WriteString("//")
+		if u.includesCredentials() {
+			sb.WriteString(u.username)
+			if u.password != "" {
+				sb.WriteByte(':')
+				sb.WriteString(u.password)
+			}
+			sb.WriteByte('@')
+		}
+		sb.WriteString(u.hostAndPort())
+	} else if !u.opaquePath && len(u.path) > 1 && u.path[0] == "" {
+		sb.WriteString("/.")
+	}
+	sb.WriteString(u.pathname())
+	if u.query != nil {
+		sb.WriteByte('?')
+		sb.WriteString(*u.query)
+	}
+	if !excludeFragment && u.fragment != nil {
+		sb.WriteByte('#')
+		sb.WriteString(*u.fragment)
+	}
+	return sb.String()
+}
+
+func (u *urlRecord) String() string {
+	return u.serialize(false)
+}
+
+func (u *urlRecord) origin() string {
+	switch u.scheme {
+	case "blob":
+		if pathURL, ok := parseURLRecord(u.pathname(), nil); ok && (pathURL.scheme == "http" || pathURL.scheme == "https") {
+			return pathURL.origin()
+		}
+	case "ftp", "http", "https", "ws", "wss":
+		return u.scheme + "://" + u.hostAndPort()
+	}
+	return "null"
+}
+
+func isASCIIAlpha(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isASCIIDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isASCIIHexDigit(c rune) bool {
+	return isASCIIDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func isWindowsDriveLetter(s string) bool {
+	return len(s) == 2 && isASCIIAlpha(rune(s[0])) && (s[1] == ':' || s[1] == '|')
+}
+
+func isNormalizedWindowsDriveLetter(s string) bool {
+	return isWindowsDriveLetter(s) && s[1] == ':'
+}
+
+func startsWithWindowsDriveLetter(s []rune) bool {
+	if len(s) < 2 || !isASCIIAlpha(s[0]) || (s[1] != ':' && s[1] != '|') {
+		return false
+	}
+	if len(s) == 2 {
+		return true
+	}
+	switch s[2] {
+	case '/', '\\', '?', '#':
+		return true
+	}
+	return false
+}
+
+func isSingleDotSegment(s string) bool {
+	return s == "." || strings.EqualFold(s, "%2e")
+}
+
+func isDoubleDotSegment(s string) bool {
+	switch strings.ToLower(s) {
+	case "..", ".%2e", "%2e.", "%2e%2e":
+		return true
+	}
+	return false
+}
+
+// Percent-encode sets. Each function returns true if the ASCII character must be encoded, the code points
+// outside of the ASCII range are always encoded.
+
+func inC0ControlSet(c byte) bool {
+	return c < 0x20 || c > 0x7E
+}
+
+func inFragmentSet(c byte) bool {
+	switch c {
+	case ' ', '"', '<', '>', '`':
+		return true
+	}
+	return inC0ControlSet(c)
+}
+
+func inQuerySet(c byte) bool {
+	switch c {
+	case ' ', '"', '#', '<', '>':
+		return true
+	}
+	return inC0ControlSet(c)
+}
+
+func inSpecialQuerySet(c byte) bool {
+	return c == '\'' || inQuerySet(c)
+}
+
+func inPathSet(c byte) bool {
+	switch c {
+	case '?', '`', '{', '}':
+		return true
+	}
+	return inQuerySet(c)
+}
+
+func inUserinfoSet(c byte) bool {
+	switch c {
+	case '/', ':', ';', '=', '@', '[', '\\', ']', '^', '|':
+		return true
+	}
+	return inPathSet(c)
+}
+
+func inComponentSet(c byte) bool {
+	switch c {
+	case '$', '%', '&', '+', ',':
+		return true
+	}
+	return inUserinfoSet(c)
+}
+
+func appendPercentEncoded(sb *strings.Builder, c rune, set func(byte) bool) {
+	if c < utf8.RuneSelf && !set(byte(c)) {
+		sb.WriteRune(c)
+		return
+	}
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], c)
+	for _, b := range buf[:n] {
+		sb.WriteByte('%')
+		sb.WriteByte(upperhex[b>>4])
+		sb.WriteByte(upperhex[b&15])
+	}
+}
+
+func percentEncodeString(s string, set func(byte) bool) string {
+	var sb strings.Builder
+	for _, c := range s {
+		appendPercentEncoded(&sb, c, set)
+	}
+	return sb.String()
+}
+
+func percentDecode(s string) string {
+	if strings.IndexByte(s, '%') == -1 {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '%' && i+2 < len(s) && ishex(s[i+1]) && ishex(s[i+2]) {
+			sb.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+			i += 2
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// parseURLRecord implements the 'API URL parser'.
+func parseURLRecord(input string, base *urlRecord) (*urlRecord, bool) {
+	return basicParseURL(input, base, nil, stateNone)
+}
+
+// basicParseURL implements the 'basic URL parser'. If u is not nil, it is modified in place.
+// Returns false on failure.
+func basicParseURL(input string, base, u *urlRecord, stateOverride parserState) (*urlRecord, bool) {
+	if u == nil {
+		u = &urlRecord{port: -1}
+		input = strings.TrimFunc(input, func(c rune) bool {
+			return c <= 0x20
+		})
+	}
+	if strings.ContainsAny(input, "\t\n\r") {
+		input = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(input)
+	}
+	p := &urlParser{
+		input:    []rune(input),
+		base:     base,
+		url:      u,
+		override: stateOverride,
+	}
+	if !p.parse() {
+		return nil, false
+	}
+	return u, true
+}
+
+type urlParser struct {
+	input    []rune
+	pointer  int
+	base     *urlRecord
+	url      *urlRecord
+	override parserState
+
+	buffer            []rune
+	atSignSeen        bool
+	insideBrackets    bool
+	passwordTokenSeen bool
+}
+
+func (p *urlParser) c() rune {
+	if p.pointer >= len(p.input) || p.pointer < 0 {
+		return eof
+	}
+	return p.input[p.pointer]
+}
+
+func (p *urlParser) remaining() []rune {
+	if p.pointer+1 >= len(p.input) {
+		return nil
+	}
+	return p.input[p.pointer+1:]
+}
+
+func (p *urlParser) remainingStartsWith(c rune) bool {
+	r := p.remaining()
+	return len(r) > 0 && r[0] == c
+}
+
+func (p *urlParser) parse() bool {
+	u := p.url
+	state := p.override
+	if state == stateNone {
+		state = stateSchemeStart
+	}
+
+	for ; ; p.pointer++ {
+		c := p.c()
+		switch state {
+		case stateSchemeStart:
+			if isASCIIAlpha(c) {
+				p.buffer = append(p.buffer, toLowerASCII(c))
+				state = stateScheme
+			} else if p.override == stateNone {
+				state = stateNoScheme
+				p.pointer--
+			} else {
+				return false
+			}
+
+		case stateScheme:
+			if isASCIIAlpha(c) || isASCIIDigit(c) || c == '+' || c == '-' || c == '.' {
+				p.buffer = append(p.buffer, toLowerASCII(c))
+			} else if c == ':' {
+				scheme := string(p.buffer)
+				if p.override != stateNone {
+					_, newSpecial := specialSchemes[scheme]
+					if u.isSpecial() != newSpecial {
+						return true
+					}
+					if (u.includesCredentials() || u.port != -1) && scheme == "file" {
+						return true
+					}
+					if u.scheme == "file" && u.host != nil && *u.host == "" {
+						return true
+					}
+				}
+				u.scheme = scheme
+				if p.override != stateNone {
+					if defPort, ok := specialSchemes[u.scheme]; ok && u.port == defPort {
+						u.port = -1
+					}
+					return true
+				}
+				p.buffer = p.buffer[:0]
+				if u.scheme == "file" {
+					state = stateFile
+				} else if u.isSpecial() && p.base != nil && p.base.scheme == u.scheme {
+					state = stateSpecialRelativeOrAuthority
+				} else if u.isSpecial() {
+					state = stateSpecialAuthoritySlashes
+				} else if p.remainingStartsWith('/') {
+					state = statePathOrAuthority
+					p.pointer++
+				} else {
+					u.path = []string{""}
+					u.opaquePath = true
+					state = stateOpaquePath
+				}
+			} else if p.override == stateNone {
+				p.buffer = p.buffer[:0]
+				state = stateNoScheme
+				p.pointer = -1
+			} else {
+				return false
+			}
+
+		case stateNoScheme:
+			base := p.base
+			if base == nil || (base.opaquePath && c != '#') {
+				return false
+			} else if base.opaquePath && c == '#' {
+				u.scheme = base.scheme
+				u.path = append([]string(nil), base.path...)
+				u.opaquePath = true
+				u.query = base.query
+				u.fragment = new(string)
+				state = stateFragment
+			} else if base.scheme != "file" {
+				state = stateRelative
+				p.pointer--
+			} else {
+				state = stateFile
+				p.pointer--
+			}
+
+		case stateSpecialRelativeOrAuthority:
+			if c == '/' && p.remainingStartsWith('/') {
+				state = stateSpecialAuthorityIgnoreSlashes
+				p.pointer++
+			} else {
+				state = stateRelative
+				p.pointer--
+			}
+
+		case statePathOrAuthority:
+			if c == '/' {
+				state = stateAuthority
+			} else {
+				state = statePath
+				p.pointer--
+			}
+
+		case stateRelative:
+			base := p.base
+			u.scheme = base.scheme
+			if c == '/' || (u.isSpecial() && c == '\\') {
+				state = stateRelativeSlash
+			} else {
+				u.username = base.username
+				u.password = base.password
+				u.host = base.host
+				u.port = base.port
+				u.path = append([]string(nil), base.path...)
+				u.query = base.query
+				if c == '?' {
+					u.query = new(string)
+					state = stateQuery
+				} else if c == '#' {
+					u.fragment = new(string)
+					state = stateFragment
+				} else if c != eof {
+					u.query = nil
+					u.shortenPath()
+					state = statePath
+					p.pointer--
+				}
+			}
+
+		case stateRelativeSlash:
+			if u.isSpecial() && (c == '/' || c == '\\') {
+				state = stateSpecialAuthorityIgnoreSlashes
+			} else if c == '/' {
+				state = stateAuthority
+			} else {
+				u.username = p.base.username
+				u.password = p.base.password
+				u.host = p.base.host
+				u.port = p.base.port
+				state = statePath
+				p.pointer--
+			}
+
+		case stateSpecialAuthoritySlashes:
+			if c == '/' && p.remainingStartsWith('/') {
+				p.pointer++
+			} else {
+				p.pointer--
+			}
+			state = stateSpecialAuthorityIgnoreSlashes
+
+		case stateSpecialAuthorityIgnoreSlashes:
+			if c != '/' && c != '\\' {
+				state = stateAuthority
+				p.pointer--
+			}
+
+		case stateAuthority:
+			if c == '@' {
+				if p.atSignSeen {
+					p.buffer = append([]rune("%40"), p.buffer...)
+				}
+				p.atSignSeen = true
+				var username, password strings.Builder
+				username.WriteString(u.username)
+				password.WriteString(u.password)
+				for _, cp := range p.buffer {
+					if cp == ':' && !p.passwordTokenSeen {
+						p.passwordTokenSeen = true
+						continue
+					}
+					if p.passwordTokenSeen {
+						appendPercentEncoded(&password, cp, inUserinfoSet)
+					} else {
+						appendPercentEncoded(&username, cp, inUserinfoSet)
+					}
+				}
+				u.username = username.String()
+				u.password = password.String()
+				p.buffer = p.buffer[:0]
+			} else if c == eof || c == '/' || c == '?' || c == '#' || (u.isSpecial() && c == '\\') {
+				if p.atSignSeen && len(p.buffer) == 0 {
+					return false
+				}
+				p.pointer -= len(p.buffer) + 1
+				p.buffer = p.buffer[:0]
+				state = stateHost
+			} else {
+				p.buffer = append(p.buffer, c)
+			}
+
+		case stateHost, stateHostname:
+			if p.override != stateNone && u.scheme == "file" {
+				p.pointer--
+				state = stateFileHost
+			} else if c == ':' && !p.insideBrackets {
+				if len(p.buffer) == 0 {
+					return false
+				}
+				if p.override == stateHostname {
+					return false
+				}
+				host, ok := parseHost(string(p.buffer), !u.isSpecial())
+				if !ok {
+					return false
+				}
+				u.host = &host
+				p.buffer = p.buffer[:0]
+				state = statePort
+			} else if c == eof || c == '/' || c == '?' || c == '#' || (u.isSpecial() && c == '\\') {
+				p.pointer--
+				if u.isSpecial() && len(p.buffer) == 0 {
+					return false
+				} else if p.override != stateNone && len(p.buffer) == 0 && (u.includesCredentials() || u.port != -1) {
+					return false
+				}
+				host, ok := parseHost(string(p.buffer), !u.isSpecial())
+				if !ok {
+					return false
+				}
+				u.host = &host
+				p.buffer = p.buffer[:0]
+				state = statePathStart
+				if p.override != stateNone {
+					return true
+				}
+			} else {
+				if c == '[' {
+					p.insideBrackets = true
+				} else if c == ']' {
+					p.insideBrackets = false
+				}
+				p.buffer = append(p.buffer, c)
+			}
+
+		case statePort:
+			if isASCIIDigit(c) {
+				p.buffer = append(p.buffer, c)
+			} else if c == eof || c == '/' || c == '?' || c == '#' || (u.isSpecial() && c == '\\') || p.override != stateNone {
+				if len(p.buffer) != 0 {
+					port := 0
+					for _, d := range p.buffer {
+						port = port*10 + int(d-'0')
+						if port > math.MaxUint16 {
+							return false
+						}
+					}
+					if defPort, ok := specialSchemes[u.scheme]; ok && defPort == port {
+						u.port = -1
+					} else {
+						u.port = port
+					}
+					p.buffer = p.buffer[:0]
+				}
+				if p.override != stateNone {
+					return true
+				}
+				state = statePathStart
+				p.pointer--
+			} else {
+				return false
+			}
+
+		case stateFile:
+			u.scheme = "file"
+			u.host = new(string)
+			if c == '/' || c == '\\' {
+				state = stateFileSlash
+			} else if base := p.base; base != nil && base.scheme == "file" {
+				u.host = base.host
+				u.path = append([]string(nil), base.path...)
+				u.query = base.query
+				if c == '?' {
+					u.query = new(string)
+					state = stateQuery
+				} else if c == '#' {
+					u.fragment = new(string)
+					state = stateFragment
+				} else if c != eof {
+					u.query = nil
+					if !startsWithWindowsDriveLetter(p.input[p.pointer:]) {
+						u.shortenPath()
+					} else {
+						u.path = nil
+					}
+					state = statePath
+					p.pointer--
+				}
+			} else {
+				state = statePath
+				p.pointer--
+			}
+
+		case stateFileSlash:
+			if c == '/' || c == '\\' {
+				state = stateFileHost
+			} else {
+				if base := p.base; base != nil && base.scheme == "file" {
+					u.host = base.host
+					if !startsWithWindowsDriveLetter(p.input[p.pointer:]) && len(base.path) > 0 && isNormalizedWindowsDriveLetter(base.path[0]) {
+						u.path = append(u.path, base.path[0])
+					}
+				}
+				state = statePath
+				p.pointer--
+			}
+
+		case stateFileHost:
+			if c == eof || c == '/' || c == '\\' || c == '?' || c == '#' {
+				p.pointer--
+				if p.override == stateNone && isWindowsDriveLetter(string(p.buffer)) {
+					state = statePath
+				} else if len(p.buffer) == 0 {
+					u.host = new(string)
+					if p.override != stateNone {
+						return true
+					}
+					state = statePathStart
+				} else {
+					host, ok := parseHost(string(p.buffer), !u.isSpecial())
+					if !ok {
+						return false
+					}
+					if host == "localhost" {
+						host = ""
+					}
+					u.host = &host
+					if p.override != stateNone {
+						return true
+					}
+					p.buffer = p.buffer[:0]
+					state = statePathStart
+				}
+			} else {
+				p.buffer = append(p.buffer, c)
+			}
+
+		case statePathStart:
+			if u.isSpecial() {
+				state = statePath
+				if c != '/' && c != '\\' {
+					p.pointer--
+				}
+			} else if p.override == stateNone && c == '?' {
+				u.query = new(string)
+				state = stateQuery
+			} else if p.override == stateNone && c == '#' {
+				u.fragment = new(string)
+				state = stateFragment
+			} else if c != eof {
+				state = statePath
+				if c != '/' {
+					p.pointer--
+				}
+			} else if p.override != stateNone && u.host == nil {
+				u.path = append(u.path, "")
+			}
+
+		case statePath:
+			slash := c == '/' || (u.isSpecial() && c == '\\')
+			if c == eof || slash || (p.override == stateNone && (c == '?' || c == '#')) {
+				buffer := string(p.buffer)
+				if isDoubleDotSegment(buffer) {
+					u.shortenPath()
+					if !slash {
+						u.path = append(u.path, "")
+					}
+				} else if isSingleDotSegment(buffer) {
+					if !slash {
+						u.path = append(u.path, "")
+					}
+				} else {
+					if u.scheme == "file" && len(u.path) == 0 && isWindowsDriveLetter(buffer) {
+						buffer = buffer[:1] + ":"
+					}
+					u.path = append(u.path, buffer)
+				}
+				p.buffer = p.buffer[:0]
+				if c == '?' {
+					u.query = new(string)
+					state = stateQuery
+				} else if c == '#' {
+					u.fragment = new(string)
+					state = stateFragment
+				}
+			} else {
+				p.appendToBuffer(c, inPathSet)
+			}
+
+		case stateOpaquePath:
+			if c == '?' {
+				u.query = new(string)
+				state = stateQuery
+			} else if c == '#' {
+				u.fragment = new(string)
+				state = stateFragment
+			} else if c != eof {
+				var sb strings.Builder
+				sb.WriteString(u.path[0])
+				appendPercentEncoded(&sb, c, inC0ControlSet)
+				u.path[0] = sb.String()
+			}
+
+		case stateQuery:
+			if (p.override == stateNone && c == '#') || c == eof {
+				set := inQuerySet
+				if u.isSpecial() {
+					set = inSpecialQuerySet
+				}
+				var sb strings.Builder
+				if u.query != nil {
+					sb.WriteString(*u.query)
+				}
+				for _, cp := range p.buffer {
+					appendPercentEncoded(&sb, cp, set)
+				}
+				query := sb.String()
+				u.query = &query
+				p.buffer = p.buffer[:0]
+				if c == '#' {
+					u.fragment = new(string)
+					state = stateFragment
+				}
+			} else {
+				p.buffer = append(p.buffer, c)
+			}
+
+		case stateFragment:
+			if c != eof {
+				p.appendToBuffer(c, inFragmentSet)
+			} else {
+				var sb strings.Builder
+				if u.fragment != nil {
+					sb.WriteString(*u.fragment)
+				}
+				for _, cp := range p.buffer {
+					sb.WriteRune(cp)
+				}
+				fragment := sb.String()
+				u.fragment = &fragment
+				p.buffer = p.buffer[:0]
+			}
+		}
+
+		if p.pointer >= len(p.input) {
+			break
+		}
+	}
+	return true
+}
+
+// appendToBuffer appends the percent-encoded code point to the buffer.
+func (p *urlParser) appendToBuffer(c rune, set func(byte) bool) {
+	if c < utf8.RuneSelf && !set(byte(c)) {
+		p.buffer = append(p.buffer, c)
+		return
+	}
+	var sb strings.Builder
+	appendPercentEncoded(&sb, c, set)
+	p.buffer = append(p.buffer, []rune(sb.String())...)
+}
+
+func toLowerASCII(c rune) rune {
+	if c >= 'A' && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
+
+func isForbiddenHostCodePoint(c rune) bool {
+	switch c {
+	case 0, '\t', '\n', '\r', ' ', '#', '/', ':', '<', '>', '?', '@', '[', '\\', ']', '^', '|':
+		return true
+	}
+	return false
+}
+
+func isForbiddenDomainCodePoint(c rune) bool {
+	return isForbiddenHostCodePoint(c) || c <= 0x1F || c == '%' || c == 0x7F
+}
+
+// parseHost implements the 'host parser'. The result is the serialised host.
+func parseHost(input string, isOpaque bool) (string, bool) {
+	if strings.HasPrefix(input, "[") {
+		if !strings.HasSuffix(input, "]") {
+			return "", false
+		}
+		address, ok := parseIPv6([]rune(input[1 : len(input)-1]))
+		if !ok {
+			return "", false
+		}
+		return "[" + serializeIPv6(address) + "]", true
+	}
+	if isOpaque {
+		if strings.IndexFunc(input, isForbiddenHostCodePoint) != -1 {
+			return "", false
+		}
+		return percentEncodeString(input, inC0ControlSet), true
+	}
+	domain := strings.ToValidUTF8(percentDecode(input), "�")
+	asciiDomain, ok := idnaToASCII(domain)
+	if !ok || strings.IndexFunc(asciiDomain, isForbiddenDomainCodePoint) != -1 {
+		return "", false
+	}
+	if endsInANumber(asciiDomain) {
+		address, ok := parseIPv4(asciiDomain)
+		if !ok {
+			return "", false
+		}
+		return serializeIPv4(address), true
+	}
+	return asciiDomain, true
+}
+
+// idnaToASCII implements the 'domain to ASCII' algorithm with beStrict set to false.
+func idnaToASCII(domain string) (string, bool) {
+	if isASCIILowerDomain(domain) {
+		return domain, true
+	}
+	res, err := idnaProfile.ToASCII(domain)
+	if err != nil || res == "" {
+		return "", false
+	}
+	return res, true
+}
+
+// isASCIILowerDomain returns true if the domain can be returned by idnaToASCII() as is, i.e. it consists
+// of ASCII characters that are not mapped and none of its labels start with "xn--".
+func isASCIILowerDomain(domain string) bool {
+	if domain == "" {
+		return false
+	}
+	for i := 0; i < len(domain); i++ {
+		c := domain[i]
+		if c >= utf8.RuneSelf || c >= 'A' && c <= 'Z' {
+			return false
+		}
+		if (i == 0 || domain[i-1] == '.') && strings.HasPrefix(domain[i:], "xn--") {
+			return false
+		}
+	}
+	return true
+}
+
+func idnaToUnicode(domain string) string {
+	res, _ := idnaProfile.ToUnicode(domain)
+	return res
+}
+
+func endsInANumber(s string) bool {
+	parts := strings.Split(s, ".")
+	if parts[len(parts)-1] == "" {
+		if len(parts) == 1 {
+			return false
+		}
+		parts = parts[:len(parts)-1]
+	}
+	last := parts[len(parts)-1]
+	if last != "" && strings.IndexFunc(last, func(c rune) bool { return !isASCIIDigit(c) }) == -1 {
+		return true
+	}
+	_, ok := parseIPv4Number(last)
+	return ok
+}
+
+func parseIPv4Number(s string) (uint64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	base := 10
+	if len(s) >= 2 && (s[:2] == "0x" || s[:2] == "0X") {
+		s = s[2:]
+		base = 16
+	} else if len(s) >= 2 && s[0] == '0' {
+		s = s[1:]
+		base = 8
+	}
+	if s == "" {
+		return 0, true
+	}
+	var res uint64
+	for i := 0; i < len(s); i++ {
+		c := rune(s[i])
+		var d uint64
+		switch {
+		case base == 8 && c >= '0' && c <= '7', base == 10 && isASCIIDigit(c), base == 16 && isASCIIDigit(c):
+			d = uint64(c - '0')
+		case base == 16 && c >= 'a' && c <= 'f':
+			d = uint64(c-'a') + 10
+		case base == 16 && c >= 'A' && c <= 'F':
+			d = uint64(c-'A') + 10
+		default:
+			return 0, false
+		}
+		// Any value that does not fit into 40 bits is going to be rejected anyway.
+		if res < 1<<40 {
+			res = res*uint64(base) + d
+		}
+	}
+	return res, true
+}
+
+func parseIPv4(s string) (uint32, bool) {
+	parts := strings.Split(s, ".")
+	if parts[len(parts)-1] == "" && len(parts) > 1 {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) > 4 {
+		return 0, false
+	}
+	numbers := make([]uint64, len(parts))
+	for i, part := range parts {
+		n, ok := parseIPv4Number(part)
+		if !ok {
+			return 0, false
+		}
+		numbers[i] = n
+	}
+	for _, n := range numbers[:len(numbers)-1] {
+		if n > 255 {
+			return 0, false
+		}
+	}
+	last := numbers[len(numbers)-1]
+	if last >= 1<<(8*(5-len(numbers))) {
+		return 0, false
+	}
+	ipv4 := last
+	for i, n := range numbers[:len(numbers)-1] {
+		ipv4 += n << (8 * (3 - i))
+	}
+	return uint32(ipv4), true
+}
+
+func serializeIPv4(address uint32) string {
+	var sb strings.Builder
+	for i := 3; i >= 0; i-- {
+		sb.WriteString(strconv.Itoa(int(address >> (8 * i) & 0xFF)))
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+	}
+	return sb.String()
+}
+
+func parseIPv6(input []rune) ([8]uint16, bool) {
+	var address [8]uint16
+	pieceIndex, compress, pointer := 0, -1, 0
+	c := func() rune {
+		if pointer < len(input) {
+			return input[pointer]
+		}
+		return eof
+	}
+	if c() == ':' {
+		if len(input) < 2 || input[1] != ':' {
+			return address, false
+		}
+		pointer += 2
+		pieceIndex++
+		compress = pieceIndex
+	}
+	for c() != eof {
+		if pieceIndex == 8 {
+			return address, false
+		}
+		if c() == ':' {
+			if compress != -1 {
+				return address, false
+			}
+			pointer++
+			pieceIndex++
+			compress = pieceIndex
+			continue
+		}
+		value, length := 0, 0
+		for length < 4 && isASCIIHexDigit(c()) {
+			value = value*0x10 + int(unhex(byte(c())))
+			pointer++
+			length++
+		}
+		if c() == '.' {
+			if length == 0 {
+				return address, false
+			}
+			pointer -= length
+			if pieceIndex > 6 {
+				return address, false
+			}
+			numbersSeen := 0
+			for c() != eof {
+				ipv4Piece := -1
+				if numbersSeen > 0 {
+					if c() == '.' && numbersSeen < 4 {
+						pointer++
+					} else {
+						return address, false
+					}
+				}
+				if !isASCIIDigit(c()) {
+					return address, false
+				}
+				for isASCIIDigit(c()) {
+					number := int(c() - '0')
+					if ipv4Piece == -1 {
+						ipv4Piece = number
+					} else if ipv4Piece == 0 {
+						return address, false
+					} else {
+						ipv4Piece = ipv4Piece*10 + number
+					}
+					if ipv4Piece > 255 {
+						return address, false
+					}
+					pointer++
+				}
+				address[pieceIndex] = address[pieceIndex]*0x100 + uint16(ipv4Piece)
+				numbersSeen++
+				if numbersSeen == 2 || numbersSeen == 4 {
+					pieceIndex++
+				}
+			}
+			if numbersSeen != 4 {
+				return address, false
+			}
+			break
+		} else if c() == ':' {
+			pointer++
+			if c() == eof {
+				return address, false
+			}
+		} else if c() != eof {
+			return address, false
+		}
+		address[pieceIndex] = uint16(value)
+		pieceIndex++
+	}
+	if compress != -1 {
+		swaps := pieceIndex - compress
+		pieceIndex = 7
+		for pieceIndex != 0 && swaps > 0 {
+			address[pieceIndex], address[compress+swaps-1] = address[compress+swaps-1], address[pieceIndex]
+			pieceIndex--
+			swaps--
+		}
+	} else if pieceIndex != 8 {
+		return address, false
+	}
+	return address, true
+}
+
+func serializeIPv6(address [8]uint16) string {
+	// Find the first longest sequence of 2 or more zero pieces.
+	compress, longest := -1, 1
+	for i := 0; i < 8; {
+		if address[i] != 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < 8 && address[j] == 0 {
+			j++
+		}
+		if j-i > longest {
+			compress, longest = i, j-i
+		}
+		i = j
+	}
+	var sb strings.Builder
+	ignore0 := false
+	for i := 0; i < 8; i++ {
+		if ignore0 && address[i] == 0 {
+			continue
+		}
+		ignore0 = false
+		if compress == i {
+			if i == 0 {
+				sb.WriteString("::")
+			} else {
+				sb.WriteByte(':')
+			}
+			ignore0 = true
+			continue
+		}
+		sb.WriteString(strconv.FormatUint(uint64(address[i]), 16))
+		if i != 7 {
+			sb.WriteByte(':')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,372 @@
+package url
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/buffer"
+	"github.com/nuvolaris/goja_nodejs/errors"
+	"github.com/nuvolaris/goja_nodejs/require"
+)
+
+// This is a port of nodejs lib/querystring.js
+
+const QueryStringModuleName = "querystring"
+
+const qsDefaultMaxKeys = 1000
+
+type queryStringModule struct {
+	r       *goja.Runtime
+	exports *goja.Object
+
+	escapeFn, unescapeFn goja.Value
+}
+
+// isWellFormed returns false if the string contains lone surrogates.
+func isWellFormed(v goja.Value) bool {
+	s, ok := v.(goja.String)
+	if !ok {
+		return true
+	}
+	for i, l := 0, s.Length(); i < l; i++ {
+		c := s.CharAt(i)
+		if !utf16.IsSurrogate(rune(c)) {
+			continue
+		}
+		if c >= 0xDC00 || i+1 >= l {
+			return false
+		}
+		if next := s.CharAt(i + 1); next < 0xDC00 || next > 0xDFFF {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func newURIError(r *goja.Runtime) *goja.Object {
+	ctor, _ := r.Get("URIError").(*goja.Object)
+	return errors.NewError(r, ctor, errors.ErrCodeInvalidURI, "URI malformed")
+}
+
+// qsEscape converts the value to a string and escapes it the same way as encodeURIComponent() does.
+func qsEscape(r *goja.Runtime, v goja.Value) string {
+	v = v.ToString()
+	if !isWellFormed(v) {
+		panic(newURIError(r))
+	}
+	return escape(v.String(), &tblEscapeQueryString, false)
+}
+
+// decodeURIComponent returns false if decodeURIComponent() would throw.
+func decodeURIComponent(s string) (string, bool) {
+	if strings.IndexByte(s, '%') == -1 {
+		return s, true
+	}
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' {
+			if i+2 >= len(s) || !ishex(s[i+1]) || !ishex(s[i+2]) {
+				return "", false
+			}
+			c = unhex(s[i+1])<<4 | unhex(s[i+2])
+			i += 2
+		}
+		sb.WriteByte(c)
+	}
+	res := sb.String()
+	if !utf8.ValidString(res) {
+		return "", false
+	}
+	return res, true
+}
+
+// qsUnescapeBuffer decodes the percent-encoded octets leaving invalid sequences as is. Like in nodejs, characters
+// are truncated to their lower 8 bits.
+func qsUnescapeBuffer(s string, decodeSpaces bool) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, 0, len(units))
+	for i := 0; i < len(units); i++ {
+		c := units[i]
+		switch {
+		case c == '+' && decodeSpaces:
+			out = append(out, ' ')
+		case c == '%' && i+2 < len(units) && units[i+1] < 128 && units[i+2] < 128 &&
+			ishex(byte(units[i+1])) && ishex(byte(units[i+2])):
+			out = append(out, unhex(byte(units[i+1]))<<4|unhex(byte(units[i+2])))
+			i += 2
+		default:
+			out = append(out, byte(c))
+		}
+	}
+	return out
+}
+
+// qsUnescape implements querystring.unescape(): it's decodeURIComponent() that falls back to a lenient decoding
+// if the input is malformed.
+func qsUnescape(s string, decodeSpaces bool) string {
+	if res, ok := decodeURIComponent(s); ok {
+		return res
+	}
+	return buffer.StringCodecByName("utf8").Encode(qsUnescapeBuffer(s, decodeSpaces))
+}
+
+func hasPercentEscape(s string) bool {
+	for i := 0; i+2 < len(s); i++ {
+		if s[i] == '%' && ishex(s[i+1]) && ishex(s[i+2]) {
+			return true
+		}
+	}
+	return false
+}
+
+// qsParse implements querystring.parse(). If decode is nil, qsUnescape() is used.
+func qsParse(r *goja.Runtime, qs, sep, eq string, maxKeys int, decode func(string) goja.Value) *goja.Object {
+	res := r.CreateObject(nil)
+	if qs == "" {
+		return res
+	}
+	pairs := maxKeys
+	if pairs <= 0 {
+		pairs = -1
+	}
+
+	decodeStr := func(s string) goja.Value {
+		encoded := decode != nil
+		if strings.IndexByte(s, '+') != -1 {
+			s = strings.ReplaceAll(s, "+", "%20")
+			encoded = true
+		} else if !encoded {
+			encoded = hasPercentEscape(s)
+		}
+		if !encoded || s == "" {
+			return r.ToValue(s)
+		}
+		if decode == nil {
+			return r.ToValue(qsUnescape(s, false))
+		}
+		return decode(s)
+	}
+
+	for rest := qs; ; {
+		pair, tail, found := strings.Cut(rest, sep)
+		rest = tail
+		if pair != "" {
+			k, v, _ := strings.Cut(pair, eq)
+			key, value := decodeStr(k), decodeStr(v)
+			name := key.String()
+			if existing := res.Get(name); existing == nil || goja.IsUndefined(existing) {
+				res.Set(name, value)
+			} else if arr, ok := existing.(*goja.Object); ok && arr.ClassName() == "Array" {
+				arr.Set(strconv.FormatInt(arr.Get("length").ToInteger(), 10), value)
+			} else {
+				res.Set(name, r.NewArray(existing, value))
+			}
+		} else if !found {
+			break
+		}
+		if pairs--; pairs == 0 || !found {
+			break
+		}
+	}
+	return res
+}
+
+func isArray(v goja.Value) bool {
+	o, ok := v.(*goja.Object)
+	return ok && o.ClassName() == "Array"
+}
+
+// qsStringifyValue converts a value the way querystring.stringify() does. If encode is nil, qsEscape() is used.
+func qsStringifyValue(r *goja.Runtime, v goja.Value, encode func(goja.Value) string) string {
+	var s string
+	isNum := false
+	switch val := v.Export().(type) {
+	case string:
+		s = val
+	case int64:
+		s, isNum = v.String(), true
+	case float64:
+		if !math.IsInf(val, 0) && !math.IsNaN(val) {
+			s, isNum = v.String(), math.Abs(val) < 1e21
+		}
+	case bool:
+		s, isNum = v.String(), true
+	}
+	if encode != nil {
+		return encode(r.ToValue(s))
+	}
+	if isNum || s == "" {
+		return s
+	}
+	return qsEscape(r, v)
+}
+
+// qsStringify implements querystring.stringify(). If encode is nil, qsEscape() is used.
+func qsStringify(r *goja.Runtime, v goja.Value, sep, eq string, encode func(goja.Value) string) string {
+	o, ok := v.(*goja.Object)
+	if !ok {
+		return ""
+	}
+	var sb strings.Builder
+	for _, key := range o.Keys() {
+		ks := qsStringifyValue(r, r.ToValue(key), encode) + eq
+		val := o.Get(key)
+		if isArray(val) {
+			arr := val.(*goja.Object)
+			l := arr.Get("length").ToInteger()
+			for i := int64(0); i < l; i++ {
+				if sb.Len() > 0 {
+					sb.WriteString(sep)
+				}
+				sb.WriteString(ks)
+				sb.WriteString(qsStringifyValue(r, arr.Get(strconv.FormatInt(i, 10)), encode))
+			}
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(ks)
+		sb.WriteString(qsStringifyValue(r, val, encode))
+	}
+	return sb.String()
+}
+
+// separatorArg returns the string value of a sep or eq argument, or def if the value is falsy.
+func separatorArg(v goja.Value, def string) string {
+	if !v.ToBoolean() {
+		return def
+	}
+	return v.String()
+}
+
+// jsCallback wraps a JS function so that it can be used as a decode or encode callback.
+func (m *queryStringModule) jsCallback(fn goja.Callable) func(goja.Value) goja.Value {
+	return func(arg goja.Value) goja.Value {
+		res, err := fn(goja.Undefined(), arg)
+		if err != nil {
+			panic(err)
+		}
+		return res
+	}
+}
+
+func (m *queryStringModule) parse(call goja.FunctionCall) goja.Value {
+	qs, ok := call.Argument(0).Export().(string)
+	if !ok {
+		return m.r.CreateObject(nil)
+	}
+	sep := separatorArg(call.Argument(1), "&")
+	eq := separatorArg(call.Argument(2), "=")
+
+	maxKeys := qsDefaultMaxKeys
+	var decodeFn goja.Callable
+	if unescape := m.exports.Get("unescape"); unescape != nil && !unescape.SameAs(m.unescapeFn) {
+		decodeFn, _ = goja.AssertFunction(unescape)
+	}
+	if options, ok := call.Argument(3).(*goja.Object); ok {
+		if v := options.Get("maxKeys"); v != nil && isNumber(v) {
+			if f := v.ToFloat(); f > 0 {
+				maxKeys = int(math.Min(f, math.MaxInt32))
+			} else {
+				maxKeys = -1
+			}
+		}
+		if fn, ok := goja.AssertFunction(options.Get("decodeURIComponent")); ok {
+			decodeFn = fn
+		}
+	}
+
+	var decode func(string) goja.Value
+	if decodeFn != nil {
+		cb := m.jsCallback(decodeFn)
+		decode = func(s string) (res goja.Value) {
+			// Like nodejs, fall back to the default decoder if the custom one throws.
+			defer func() {
+				if x := recover(); x != nil {
+					if _, ok := x.(*goja.Exception); !ok {
+						panic(x)
+					}
+					res = m.r.ToValue(qsUnescape(s, true))
+				}
+			}()
+			return cb(m.r.ToValue(s))
+		}
+	}
+	return qsParse(m.r, qs, sep, eq, maxKeys, decode)
+}
+
+func (m *queryStringModule) stringify(call goja.FunctionCall) goja.Value {
+	sep := separatorArg(call.Argument(1), "&")
+	eq := separatorArg(call.Argument(2), "=")
+
+	var encodeFn goja.Callable
+	if escape := m.exports.Get("escape"); escape != nil && !escape.SameAs(m.escapeFn) {
+		encodeFn, _ = goja.AssertFunction(escape)
+	}
+	if options, ok := call.Argument(3).(*goja.Object); ok {
+		if fn, ok := goja.AssertFunction(options.Get("encodeURIComponent")); ok {
+			encodeFn = fn
+		}
+	}
+
+	var encode func(goja.Value) string
+	if encodeFn != nil {
+		cb := m.jsCallback(encodeFn)
+		encode = func(v goja.Value) string {
+			return cb(v).String()
+		}
+	}
+	return m.r.ToValue(qsStringify(m.r, call.Argument(0), sep, eq, encode))
+}
+
+func (m *queryStringModule) escape(call goja.FunctionCall) goja.Value {
+	return m.r.ToValue(qsEscape(m.r, call.Argument(0)))
+}
+
+func (m *queryStringModule) unescape(call goja.FunctionCall) goja.Value {
+	return m.r.ToValue(qsUnescape(call.Argument(0).String(), call.Argument(1).ToBoolean()))
+}
+
+func (m *queryStringModule) unescapeBuffer(call goja.FunctionCall) goja.Value {
+	return buffer.WrapBytes(m.r, qsUnescapeBuffer(call.Argument(0).String(), call.Argument(1).ToBoolean()))
+}
+
+func isNumber(v goja.Value) bool {
+	switch v.Export().(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+func RequireQueryString(runtime *goja.Runtime, module *goja.Object) {
+	exports := module.Get("exports").(*goja.Object)
+	m := &queryStringModule{
+		r:       runtime,
+		exports: exports,
+	}
+	m.escapeFn = runtime.ToValue(m.escape)
+	m.unescapeFn = runtime.ToValue(m.unescape)
+	parse := runtime.ToValue(m.parse)
+	stringify := runtime.ToValue(m.stringify)
+
+	exports.Set("unescapeBuffer", m.unescapeBuffer)
+	exports.Set("unescape", m.unescapeFn)
+	exports.Set("escape", m.escapeFn)
+	exports.Set("stringify", stringify)
+	exports.Set("encode", stringify)
+	exports.Set("parse", parse)
+	exports.Set("decode", parse)
+}
+
+func init() {
+	require.RegisterCoreModule(QueryStringModuleName, RequireQueryString)
+}
@@ -0,0 +1,25 @@
+package url
+
+import (
+	_ "embed"
+	"testing"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/require"
+)
+
+//go:embed testdata/querystring.js
+var queryStringTest string
+
+func TestQueryString(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunScript("testdata/querystring.js", queryStringTest)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			t.Fatal(ex.String())
+		}
+		t.Fatal("Failed to process querystring script.", err)
+	}
+}
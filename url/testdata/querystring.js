@@ -0,0 +1,111 @@
+"use strict";
+
+const assert = require("../../assert.js");
+const qs = require("querystring");
+
+function sameObject(actual, expected) {
+  assert.sameValue(JSON.stringify(actual), JSON.stringify(expected));
+}
+
+// parse
+sameObject(qs.parse("foo=bar"), { foo: "bar" });
+sameObject(qs.parse("foo=bar&foo=quux"), { foo: ["bar", "quux"] });
+sameObject(qs.parse("foo=1&bar=2&foo=3"), { foo: ["1", "3"], bar: "2" });
+sameObject(qs.parse("foo&bar=baz"), { foo: "", bar: "baz" });
+sameObject(qs.parse("a=b=c"), { a: "b=c" });
+sameObject(qs.parse("&&a=1&&"), { a: "1" });
+sameObject(qs.parse("a+b=c+d"), { "a b": "c d" });
+sameObject(qs.parse("a=%20%2B%26"), { a: " +&" });
+sameObject(qs.parse("a=%zz&b=%"), { a: "%zz", b: "%" });
+sameObject(qs.parse("a=%E2%82"), { a: "�" });
+sameObject(qs.parse("%E4%BD%A0=%E5%A5%BD"), { "你": "好" });
+sameObject(qs.parse("=x"), { "": "x" });
+sameObject(qs.parse(""), {});
+sameObject(qs.parse(undefined), {});
+sameObject(qs.parse(42), {});
+assert.sameValue(Object.getPrototypeOf(qs.parse("a=1")), null);
+assert.sameValue(qs.decode, qs.parse);
+
+// custom separators
+sameObject(qs.parse("a:1;b:2", ";", ":"), { a: "1", b: "2" });
+sameObject(qs.parse("a=>1, b=>2", ", ", "=>"), { a: "1", b: "2" });
+sameObject(qs.parse("a=1&b=2", "", ""), { a: "1", b: "2" });
+
+// maxKeys
+sameObject(qs.parse("a=1&b=2&c=3", null, null, { maxKeys: 2 }), { a: "1", b: "2" });
+sameObject(qs.parse("a=1&&b=2", null, null, { maxKeys: 2 }), { a: "1" });
+assert.sameValue(Object.keys(qs.parse("a=1&".repeat(1100))).length, 1);
+{
+  const big = Array.from({ length: 1100 }, (_, i) => "k" + i + "=v").join("&");
+  assert.sameValue(Object.keys(qs.parse(big)).length, 1000);
+  assert.sameValue(Object.keys(qs.parse(big, null, null, { maxKeys: 0 })).length, 1100);
+}
+
+// decodeURIComponent override
+{
+  const seen = [];
+  const res = qs.parse("a=b&c%41=d+e", null, null, {
+    decodeURIComponent(s) {
+      seen.push(s);
+      return s.toUpperCase();
+    },
+  });
+  sameObject(res, { A: "B", "C%41": "D%20E" });
+  sameObject(seen, ["a", "b", "c%41", "d%20e"]);
+
+  const fallback = qs.parse("a=%41", null, null, {
+    decodeURIComponent() {
+      throw new Error("boom");
+    },
+  });
+  sameObject(fallback, { a: "A" });
+}
+
+// stringify
+assert.sameValue(qs.stringify({ foo: "bar", baz: ["qux", "quux"], corge: "" }), "foo=bar&baz=qux&baz=quux&corge=");
+assert.sameValue(qs.stringify({ a: 1, b: true, c: null, d: undefined, e: {}, f: NaN, g: Infinity }), "a=1&b=true&c=&d=&e=&f=&g=");
+assert.sameValue(qs.stringify({ a: [] }), "");
+assert.sameValue(qs.stringify({ a: [], b: 1 }), "b=1");
+assert.sameValue(qs.stringify({ a: 1e21 }), "a=1e%2B21");
+assert.sameValue(qs.stringify({ "a b": "c d+e&f" }), "a%20b=c%20d%2Be%26f");
+assert.sameValue(qs.stringify({ w: "中文", foo: "bar" }), "w=%E4%B8%AD%E6%96%87&foo=bar");
+assert.sameValue(qs.stringify({ a: "!'()*-._~" }), "a=!'()*-._~");
+assert.sameValue(qs.stringify({ foo: "bar", baz: "qux" }, ";", ":"), "foo:bar;baz:qux");
+assert.sameValue(qs.stringify("foo"), "");
+assert.sameValue(qs.stringify(null), "");
+assert.sameValue(qs.encode, qs.stringify);
+assert.sameValue(
+  qs.stringify({ w: "中文", n: 1 }, null, null, { encodeURIComponent: (s) => "<" + s + ">" }),
+  "<w>=<中文>&<n>=<1>"
+);
+assert.throws(() => {
+  qs.stringify({ a: "\ud800" });
+}, URIError);
+
+// escape and unescape
+assert.sameValue(qs.escape("a b&c=d/é"), "a%20b%26c%3Dd%2F%C3%A9");
+assert.sameValue(qs.escape(5), "5");
+assert.sameValue(qs.escape({ toString: () => "x y" }), "x%20y");
+assert.throwsNodeError(() => {
+  qs.escape("\udc00");
+}, URIError, "ERR_INVALID_URI");
+assert.sameValue(qs.unescape("a%20b+c"), "a b+c");
+assert.sameValue(qs.unescape("a%zz+c", true), "a%zz c");
+assert.sameValue(qs.unescape("%E4%BD%A0"), "你");
+assert.sameValue(qs.unescapeBuffer("a%41%zz+").toString(), "aA%zz+");
+assert.sameValue(qs.unescapeBuffer("a+b", true).toString(), "a b");
+
+// overriding escape and unescape affects stringify and parse
+{
+  const { escape, unescape } = qs;
+  try {
+    qs.escape = (s) => s.toUpperCase();
+    qs.unescape = (s) => "[" + s + "]";
+    assert.sameValue(qs.stringify({ a: "b" }), "A=B");
+    sameObject(qs.parse("a=b"), { "[a]": "[b]" });
+  } finally {
+    qs.escape = escape;
+    qs.unescape = unescape;
+  }
+  assert.sameValue(qs.stringify({ a: "b c" }), "a=b%20c");
+}
@@ -0,0 +1,65 @@
+"use strict";
+
+const assert = require("../../assert.js");
+const url = require("url");
+
+const posix = { windows: false };
+const win = { windows: true };
+
+assert.sameValue(url.fileURLToPath("file:///etc/passwd", posix), "/etc/passwd");
+assert.sameValue(url.fileURLToPath("file://localhost/etc/passwd", posix), "/etc/passwd");
+assert.sameValue(url.fileURLToPath(new URL("file:///a%20b/c%25d/%E2%82%AC"), posix), "/a b/c%d/€");
+assert.sameValue(url.fileURLToPath("file:///C:/a%20b/c", win), "C:\\a b\\c");
+assert.sameValue(url.fileURLToPath("file://server/share/file", win), "\\\\server\\share\\file");
+
+assert.throwsNodeError(() => url.fileURLToPath("http://example.com/", posix), TypeError, "ERR_INVALID_URL_SCHEME");
+assert.throwsNodeError(() => url.fileURLToPath("file://host/a", posix), TypeError, "ERR_INVALID_FILE_URL_HOST");
+assert.throwsNodeError(() => url.fileURLToPath("file:///a%2Fb", posix), TypeError, "ERR_INVALID_FILE_URL_PATH");
+assert.throwsNodeError(() => url.fileURLToPath("file:///a%5Cb", win), TypeError, "ERR_INVALID_FILE_URL_PATH");
+assert.throwsNodeError(() => url.fileURLToPath("file:///a/b", win), TypeError, "ERR_INVALID_FILE_URL_PATH");
+assert.throwsNodeError(() => url.fileURLToPath("foo", posix), TypeError, "ERR_INVALID_URL");
+assert.throwsNodeError(() => url.fileURLToPath(5), TypeError, "ERR_INVALID_ARG_TYPE");
+
+function testPathToFileURL(p, expected, options) {
+  const u = url.pathToFileURL(p, options);
+  assert.sameValue(u instanceof URL, true);
+  assert.sameValue(u.href, expected, p);
+}
+
+testPathToFileURL("/foo/bar", "file:///foo/bar", posix);
+testPathToFileURL("/foo/bar/", "file:///foo/bar/", posix);
+testPathToFileURL("/foo/../bar/./baz", "file:///bar/baz", posix);
+testPathToFileURL("/a b/100%/#hash?q", "file:///a%20b/100%25/%23hash%3Fq", posix);
+testPathToFileURL("/back\\slash\n\t", "file:///back%5Cslash%0A%09", posix);
+testPathToFileURL("/€", "file:///%E2%82%AC", posix);
+testPathToFileURL("C:\\foo bar\\baz\\", "file:///C:/foo%20bar/baz/", win);
+testPathToFileURL("\\\\server\\share\\file", "file://server/share/file", win);
+assert.sameValue(url.pathToFileURL("rel/file.js", posix).href.endsWith("/rel/file.js"), true);
+
+for (const p of ["/a b/100%/#hash?q", "/€/x"]) {
+  assert.sameValue(url.fileURLToPath(url.pathToFileURL(p, posix), posix), p);
+}
+assert.throwsNodeError(() => url.pathToFileURL(5), TypeError, "ERR_INVALID_ARG_TYPE");
+
+let opts = url.urlToHttpOptions(new URL("https://a:b%20c@[::1]:8080/p?x=1#h"));
+assert.sameValue(opts.protocol, "https:");
+assert.sameValue(opts.hostname, "::1");
+assert.sameValue(opts.port, 8080);
+assert.sameValue(opts.path, "/p?x=1");
+assert.sameValue(opts.auth, "a:b c");
+assert.sameValue(opts.hash, "#h");
+
+opts = url.urlToHttpOptions(new URL("http://example.com"));
+assert.sameValue(opts.hostname, "example.com");
+assert.sameValue("port" in opts, false);
+assert.sameValue("auth" in opts, false);
+assert.sameValue(opts.path, "/");
+assert.sameValue(opts.href, "http://example.com/");
+assert.sameValue(Object.keys(url.urlToHttpOptions(new URL("http://u:p@a:8/p?q#h"))).join(),
+  "protocol,hostname,hash,search,pathname,path,href,port,auth");
+assert.sameValue(Object.keys(url.urlToHttpOptions(new URL("http://a/"))).join(),
+  "protocol,hostname,hash,search,pathname,path,href");
+// The keys of a url-like object are kept.
+assert.sameValue(Object.keys(url.urlToHttpOptions({ x: 1, protocol: "http:", hostname: "a", pathname: "/p",
+  search: "", hash: "", href: "http://a/p", port: "8", username: "u", password: "" })).join(),
+  "x,protocol,hostname,pathname,search,hash,href,port,username,password,path,auth");
@@ -0,0 +1,153 @@
+"use strict";
+
+const assert = require("../../assert.js");
+
+// Components and defaults
+{
+  const p = new URLPattern({ pathname: "/users/:id" });
+  assert.sameValue(p.protocol, "*");
+  assert.sameValue(p.hostname, "*");
+  assert.sameValue(p.pathname, "/users/:id");
+  assert.sameValue(p.search, "*");
+  assert.sameValue(p.hasRegExpGroups, false);
+  assert.sameValue(Object.prototype.toString.call(p), "[object URLPattern]");
+
+  assert.sameValue(p.test("https://example.com/users/42"), true);
+  assert.sameValue(p.test("https://example.com/users/42/posts"), false);
+  assert.sameValue(p.test({ pathname: "/users/7" }), true);
+  assert.sameValue(p.test("not a url"), false);
+
+  const res = p.exec("https://example.com/users/42?x=1");
+  assert.sameValue(res.inputs.length, 1);
+  assert.sameValue(res.inputs[0], "https://example.com/users/42?x=1");
+  assert.sameValue(res.pathname.input, "/users/42");
+  assert.sameValue(res.pathname.groups.id, "42");
+  assert.sameValue(res.hostname.input, "example.com");
+  assert.sameValue(res.hostname.groups["0"], "example.com");
+  assert.sameValue(res.search.input, "x=1");
+  assert.sameValue(p.exec("https://example.com/"), null);
+}
+
+// Constructor string
+{
+  const p = new URLPattern("https://:sub.example.com/books/:id(\\d+)/:rest*\\?q=:q#:h");
+  assert.sameValue(p.protocol, "https");
+  assert.sameValue(p.hostname, ":sub.example.com");
+  assert.sameValue(p.port, "");
+  assert.sameValue(p.pathname, "/books/:id(\\d+)/:rest*");
+  assert.sameValue(p.search, "q=:q");
+  assert.sameValue(p.hash, ":h");
+  assert.sameValue(p.hasRegExpGroups, true);
+
+  const res = p.exec("https://api.example.com/books/12/a/b?q=go#top");
+  assert.sameValue(res.hostname.groups.sub, "api");
+  assert.sameValue(res.pathname.groups.id, "12");
+  assert.sameValue(res.pathname.groups.rest, "a/b");
+  assert.sameValue(res.search.groups.q, "go");
+  assert.sameValue(res.hash.groups.h, "top");
+  assert.sameValue(p.test("https://api.example.com/books/ab?q=go#top"), false);
+  assert.sameValue(p.test("http://api.example.com/books/12?q=go#top"), false);
+
+  assert.sameValue(new URLPattern("https://example.com:443/").port, "");
+  assert.sameValue(new URLPattern("https://example.com").pathname, "*");
+
+  const mailto = new URLPattern("mailto\\::addr@:domain");
+  assert.sameValue(mailto.protocol, "mailto");
+  assert.sameValue(mailto.pathname, ":addr@:domain");
+  assert.sameValue(mailto.exec("mailto:joe@example.org").pathname.groups.domain, "example.org");
+}
+
+// Base URL
+{
+  const p = new URLPattern("/foo/:bar?", "https://example.com");
+  assert.sameValue(p.protocol, "https");
+  assert.sameValue(p.hostname, "example.com");
+  assert.sameValue(p.pathname, "/foo/:bar?");
+  assert.sameValue(p.test("https://example.com/foo"), true);
+  assert.sameValue(p.test("https://example.com/foo/x"), true);
+  assert.sameValue(p.test("https://example.org/foo"), false);
+
+  const res = new URLPattern({ pathname: "/a/:b" }).exec("/a/x", "https://h.com");
+  assert.sameValue(res.inputs.length, 2);
+  assert.sameValue(res.inputs[1], "https://h.com");
+  assert.sameValue(res.pathname.groups.b, "x");
+
+  const dictRes = new URLPattern({ pathname: "/a/:b" }).exec({ pathname: "c", baseURL: "https://h.com/a/" });
+  assert.sameValue(dictRes.pathname.input, "/a/c");
+  assert.sameValue(dictRes.hostname.input, "h.com");
+
+  assert.throwsNodeError(() => {
+    new URLPattern("/relative");
+  }, TypeError, "ERR_INVALID_URL_PATTERN");
+  assert.throws(() => {
+    new URLPattern({ pathname: "/a" }, "https://example.com");
+  }, TypeError);
+  assert.throws(() => {
+    new URLPattern({ pathname: "/a" }).test({ pathname: "/a" }, "https://example.com");
+  }, TypeError);
+}
+
+// Wildcards, modifiers and regexp groups
+{
+  const files = new URLPattern({ pathname: "/files/*.:ext" });
+  const res = files.exec({ pathname: "/files/a/b.txt" });
+  assert.sameValue(res.pathname.groups["0"], "a/b");
+  assert.sameValue(res.pathname.groups.ext, "txt");
+
+  const multi = new URLPattern({ pathname: "/(foo|bar)/:x+" });
+  assert.sameValue(multi.hasRegExpGroups, true);
+  assert.sameValue(multi.exec({ pathname: "/bar/1/2" }).pathname.groups.x, "1/2");
+  assert.sameValue(multi.test({ pathname: "/baz/1" }), false);
+  assert.sameValue(multi.test({ pathname: "/foo/" }), false);
+
+  const optional = new URLPattern({ pathname: "/:a/:b?" });
+  assert.sameValue(optional.exec({ pathname: "/x" }).pathname.groups.b, undefined);
+
+  const host = new URLPattern({ hostname: "{*.}?example.com" });
+  assert.sameValue(host.test({ hostname: "example.com" }), true);
+  assert.sameValue(host.test({ hostname: "a.b.example.com" }), true);
+  assert.sameValue(host.test({ hostname: "example.org" }), false);
+
+  const proto = new URLPattern({ protocol: "http{s}?" });
+  assert.sameValue(proto.test("https://x.com/"), true);
+  assert.sameValue(proto.test("http://x.com/"), true);
+  assert.sameValue(proto.test("ftp://x.com/"), false);
+}
+
+// Canonicalization
+{
+  assert.sameValue(new URLPattern({ pathname: "/café" }).pathname, "/caf%C3%A9");
+  assert.sameValue(new URLPattern({ pathname: "/café" }).test("https://example.com/café"), true);
+  assert.sameValue(new URLPattern({ hostname: "EXAMPLE.com" }).hostname, "example.com");
+  assert.sameValue(new URLPattern({ search: "a b" }).search, "a%20b");
+  assert.sameValue(new URLPattern({ hostname: "[\\:\\:AB]" }).hostname, "[\\:\\:ab]");
+  assert.sameValue(new URLPattern({ protocol: "https:" }).protocol, "https");
+  assert.sameValue(new URLPattern({ pathname: "/foo/../bar" }).test({ pathname: "/bar" }), true);
+  assert.throwsNodeError(() => {
+    new URLPattern({ hostname: "bad host" });
+  }, TypeError, "ERR_INVALID_URL_PATTERN");
+}
+
+// ignoreCase
+{
+  const p = new URLPattern({ pathname: "/FOO" }, { ignoreCase: true });
+  assert.sameValue(p.test({ pathname: "/foo" }), true);
+  assert.sameValue(new URLPattern({ pathname: "/FOO" }).test({ pathname: "/foo" }), false);
+  assert.sameValue(new URLPattern("/FOO", "https://example.com", { ignoreCase: true }).test("https://example.com/foo"), true);
+}
+
+// Invalid patterns
+assert.throwsNodeError(() => {
+  new URLPattern({ pathname: "/:id/:id" });
+}, TypeError, "ERR_INVALID_URL_PATTERN");
+assert.throwsNodeError(() => {
+  new URLPattern({ pathname: "/(a" });
+}, TypeError, "ERR_INVALID_URL_PATTERN");
+assert.throwsNodeError(() => {
+  new URLPattern({ pathname: "/(?:a)" });
+}, TypeError, "ERR_INVALID_URL_PATTERN");
+assert.throwsNodeError(() => {
+  new URLPattern({ pathname: "/([)" });
+}, TypeError, "ERR_INVALID_URL_PATTERN");
+
+assert.sameValue(require("url").URLPattern, URLPattern);
@@ -0,0 +1,69 @@
+"use strict";
+
+// Runs the web-platform-tests URL test data against the URL implementation. The test data is passed in
+// the urlTestData and settersTestData variables. Returns the list of failures.
+
+const failures = [];
+
+function check(desc, actual, expected) {
+  if (actual !== expected) {
+    failures.push(`${desc}: expected ${JSON.stringify(expected)}, got ${JSON.stringify(actual)}`);
+  }
+}
+
+for (const expected of urlTestData) {
+  if (typeof expected === "string") {
+    continue;
+  }
+  const base = expected.base !== null ? expected.base : undefined;
+  const desc = `Parsing <${expected.input}> against <${base}>`;
+
+  if (expected.failure) {
+    let thrown = false;
+    try {
+      new URL(expected.input, base);
+    } catch (e) {
+      thrown = e instanceof TypeError;
+    }
+    check(desc + " throws a TypeError", thrown, true);
+    check(desc + " URL.canParse()", URL.canParse(expected.input, base), false);
+    check(desc + " URL.parse()", URL.parse(expected.input, base), null);
+    continue;
+  }
+
+  let url;
+  try {
+    url = new URL(expected.input, base);
+  } catch (e) {
+    failures.push(`${desc}: ${e}`);
+    continue;
+  }
+  check(desc + " href", url.href, expected.href);
+  if ("origin" in expected) {
+    check(desc + " origin", url.origin, expected.origin);
+  }
+  for (const attr of ["protocol", "username", "password", "host", "hostname", "port", "pathname", "search", "hash"]) {
+    check(`${desc} ${attr}`, url[attr], expected[attr]);
+  }
+  if ("searchParams" in expected) {
+    check(desc + " searchParams", url.searchParams.toString(), expected.searchParams);
+  }
+  check(desc + " URL.canParse()", URL.canParse(expected.input, base), true);
+  check(desc + " URL.parse()", URL.parse(expected.input, base).href, expected.href);
+}
+
+for (const attr in settersTestData) {
+  if (attr === "comment") {
+    continue;
+  }
+  for (const testCase of settersTestData[attr]) {
+    const desc = `Setting <${testCase.href}>.${attr} = ${JSON.stringify(testCase.new_value)}`;
+    const url = new URL(testCase.href);
+    url[attr] = testCase.new_value;
+    for (const prop in testCase.expected) {
+      check(`${desc} ${prop}`, url[prop], testCase.expected[prop]);
+    }
+  }
+}
+
+failures;
@@ -1,16 +1,12 @@
 package url
 
 import (
-	"math"
-	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/nuvolaris/goja"
 	"github.com/nuvolaris/goja_nodejs/errors"
-
-	"golang.org/x/net/idna"
 )
 
 const (
@@ -22,7 +18,6 @@ const (
 
 var (
 	reflectTypeURL = reflect.TypeOf((*nodeURL)(nil))
-	reflectTypeInt = reflect.TypeOf(int64(0))
 )
 
 func toURL(r *goja.Runtime, v goja.Value) *nodeURL {
@@ -57,152 +52,37 @@ func (m *urlModule) defineURLAccessorProp(p *goja.Object, name string, getter fu
 	p.DefineAccessorProperty(name, getterVal, setterVal, goja.FLAG_FALSE, goja.FLAG_TRUE)
 }
 
-func valueToURLPort(v goja.Value) (portNum int, empty bool) {
-	portNum = -1
-	if et := v.ExportType(); et == reflectTypeInt {
-		num := v.ToInteger()
-		if num < 0 {
-			empty = true
-		} else if num <= math.MaxUint16 {
-			portNum = int(num)
-		}
-	} else {
-		s := v.String()
-		if s == "" {
-			return 0, true
-		}
-		firstDigitIdx := -1
-		for i := 0; i < len(s); i++ {
-			if c := s[i]; c >= '0' && c <= '9' {
-				firstDigitIdx = i
-				break
-			}
-		}
-
-		if firstDigitIdx == -1 {
-			return -1, false
-		}
-
-		if firstDigitIdx > 0 {
-			return 0, true
-		}
-
-		for i := 0; i < len(s); i++ {
-			if c := s[i]; c >= '0' && c <= '9' {
-				if portNum == -1 {
-					portNum = 0
-				}
-				portNum = portNum*10 + int(c-'0')
-				if portNum > math.MaxUint16 {
-					portNum = -1
-					break
-				}
-			} else {
-				break
-			}
-		}
-	}
-	return
-}
-
-func isDefaultURLPort(protocol string, port int) bool {
-	switch port {
-	case 21:
-		if protocol == "ftp" {
-			return true
+// parseURLArgs parses the url and the optional base arguments. Returns false if either of them is not a valid URL.
+func parseURLArgs(input, base goja.Value) (*urlRecord, bool) {
+	var baseURL *urlRecord
+	if !goja.IsUndefined(base) {
+		var ok bool
+		if baseURL, ok = parseURLRecord(base.String(), nil); !ok {
+			return nil, false
 		}
-	case 80:
-		if protocol == "http" || protocol == "ws" {
-			return true
-		}
-	case 443:
-		if protocol == "https" || protocol == "wss" {
-			return true
-		}
-	}
-	return false
-}
-
-func isSpecialProtocol(protocol string) bool {
-	switch protocol {
-	case "ftp", "file", "http", "https", "ws", "wss":
-		return true
-	}
-	return false
-}
-
-func clearURLPort(u *url.URL) {
-	u.Host = u.Hostname()
-}
-
-func setURLPort(nu *nodeURL, v goja.Value) {
-	u := nu.url
-	if u.Scheme == "file" {
-		return
-	}
-	portNum, empty := valueToURLPort(v)
-	if empty {
-		clearURLPort(u)
-		return
-	}
-	if portNum == -1 {
-		return
-	}
-	if isDefaultURLPort(u.Scheme, portNum) {
-		clearURLPort(u)
-	} else {
-		u.Host = u.Hostname() + ":" + strconv.Itoa(portNum)
 	}
+	return parseURLRecord(input.String(), baseURL)
 }
 
-func (m *urlModule) parseURL(s string, isBase bool) *url.URL {
-	u, err := url.Parse(s)
-	if err != nil {
-		if isBase {
-			panic(m.newInvalidURLError(InvalidBaseURL, s))
-		} else {
-			panic(m.newInvalidURLError(InvalidURL, s))
+// parseURL is the same as parseURLArgs, but it panics with ERR_INVALID_URL on failure.
+func (m *urlModule) parseURL(input, base goja.Value) *urlRecord {
+	u, ok := parseURLArgs(input, base)
+	if !ok {
+		e := m.newInvalidURLError(InvalidURL, input.String())
+		if !goja.IsUndefined(base) {
+			e.Set("base", base.String())
 		}
+		panic(e)
 	}
-	if isBase && !u.IsAbs() {
-		panic(m.newInvalidURLError(URLNotAbsolute, s))
-	}
-	if portStr := u.Port(); portStr != "" {
-		if port, err := strconv.Atoi(portStr); err != nil || isDefaultURLPort(u.Scheme, port) {
-			u.Host = u.Hostname() // Clear port
-		}
-	}
-	m.fixURL(u)
 	return u
 }
 
-func fixRawQuery(u *url.URL) {
-	if u.RawQuery != "" {
-		u.RawQuery = escape(u.RawQuery, &tblEscapeURLQuery, false)
-	}
-}
-
-func (m *urlModule) fixURL(u *url.URL) {
-	switch u.Scheme {
-	case "https", "http", "ftp", "wss", "ws":
-		if u.Path == "" {
-			u.Path = "/"
-		}
-		hostname := u.Hostname()
-		lh := strings.ToLower(hostname)
-		ch, err := idna.Punycode.ToASCII(lh)
-		if err != nil {
-			panic(m.newInvalidURLError(InvalidHostname, lh))
-		}
-		if ch != hostname {
-			if port := u.Port(); port != "" {
-				u.Host = ch + ":" + port
-			} else {
-				u.Host = ch
-			}
-		}
-	}
-	fixRawQuery(u)
+func (m *urlModule) newURL(u *urlRecord, proto *goja.Object) *goja.Object {
+	nu := &nodeURL{url: u}
+	nu.updateSearchParams()
+	res := m.r.ToValue(nu).(*goja.Object)
+	res.SetPrototype(proto)
+	return res
 }
 
 func (m *urlModule) createURLPrototype() *goja.Object {
@@ -210,196 +90,210 @@ func (m *urlModule) createURLPrototype() *goja.Object {
 
 	// host
 	m.defineURLAccessorProp(p, "host", func(u *nodeURL) interface{} {
-		return u.url.Host
+		return u.url.hostAndPort()
 	}, func(u *nodeURL, arg goja.Value) {
-		host := arg.String()
-		if _, err := url.ParseRequestURI(u.url.Scheme + "://" + host); err == nil {
-			u.url.Host = host
-			m.fixURL(u.url)
+		if !u.url.opaquePath {
+			basicParseURL(arg.String(), nil, u.url, stateHost)
 		}
 	})
 
 	// hash
 	m.defineURLAccessorProp(p, "hash", func(u *nodeURL) interface{} {
-		if u.url.Fragment != "" {
-			return "#" + u.url.EscapedFragment()
+		if u.url.fragment == nil || *u.url.fragment == "" {
+			return ""
 		}
-		return ""
+		return "#" + *u.url.fragment
 	}, func(u *nodeURL, arg goja.Value) {
 		h := arg.String()
-		if len(h) > 0 && h[0] == '#' {
-			h = h[1:]
+		if h == "" {
+			u.url.fragment = nil
+			u.url.stripTrailingSpacesFromOpaquePath()
+			return
 		}
-		u.url.Fragment = h
+		u.url.fragment = new(string)
+		basicParseURL(strings.TrimPrefix(h, "#"), nil, u.url, stateFragment)
 	})
 
 	// hostname
 	m.defineURLAccessorProp(p, "hostname", func(u *nodeURL) interface{} {
-		return strings.Split(u.url.Host, ":")[0]
+		return u.url.hostString()
 	}, func(u *nodeURL, arg goja.Value) {
-		h := arg.String()
-		if strings.IndexByte(h, ':') >= 0 {
-			return
-		}
-		if _, err := url.ParseRequestURI(u.url.Scheme + "://" + h); err == nil {
-			if port := u.url.Port(); port != "" {
-				u.url.Host = h + ":" + port
-			} else {
-				u.url.Host = h
-			}
-			m.fixURL(u.url)
+		if !u.url.opaquePath {
+			basicParseURL(arg.String(), nil, u.url, stateHostname)
 		}
 	})
 
 	// href
 	m.defineURLAccessorProp(p, "href", func(u *nodeURL) interface{} {
-		return u.String()
+		return u.url.String()
 	}, func(u *nodeURL, arg goja.Value) {
-		u.url = m.parseURL(arg.String(), true)
+		u.url = m.parseURL(arg, goja.Undefined())
+		u.updateSearchParams()
 	})
 
 	// pathname
 	m.defineURLAccessorProp(p, "pathname", func(u *nodeURL) interface{} {
-		return u.url.EscapedPath()
+		return u.url.pathname()
 	}, func(u *nodeURL, arg goja.Value) {
-		p := arg.String()
-		if _, err := url.Parse(p); err == nil {
-			switch u.url.Scheme {
-			case "https", "http", "ftp", "ws", "wss":
-				if !strings.HasPrefix(p, "/") {
-					p = "/" + p
-				}
-			}
-			u.url.Path = p
+		if !u.url.opaquePath {
+			u.url.path = nil
+			basicParseURL(arg.String(), nil, u.url, statePathStart)
 		}
 	})
 
 	// origin
 	m.defineURLAccessorProp(p, "origin", func(u *nodeURL) interface{} {
-		return u.url.Scheme + "://" + u.url.Hostname()
+		return u.url.origin()
 	}, nil)
 
 	// password
 	m.defineURLAccessorProp(p, "password", func(u *nodeURL) interface{} {
-		p, _ := u.url.User.Password()
-		return p
+		return u.url.password
 	}, func(u *nodeURL, arg goja.Value) {
-		user := u.url.User
-		u.url.User = url.UserPassword(user.Username(), arg.String())
+		if !u.url.cannotHaveCredentialsOrPort() {
+			u.url.password = percentEncodeString(arg.String(), inUserinfoSet)
+		}
 	})
 
 	// username
 	m.defineURLAccessorProp(p, "username", func(u *nodeURL) interface{} {
-		return u.url.User.Username()
+		return u.url.username
 	}, func(u *nodeURL, arg goja.Value) {
-		p, has := u.url.User.Password()
-		if !has {
-			u.url.User = url.User(arg.String())
-		} else {
-			u.url.User = url.UserPassword(arg.String(), p)
+		if !u.url.cannotHaveCredentialsOrPort() {
+			u.url.username = percentEncodeString(arg.String(), inUserinfoSet)
 		}
 	})
 
 	// port
 	m.defineURLAccessorProp(p, "port", func(u *nodeURL) interface{} {
-		return u.url.Port()
+		if u.url.port == -1 {
+			return ""
+		}
+		return strconv.Itoa(u.url.port)
 	}, func(u *nodeURL, arg goja.Value) {
-		setURLPort(u, arg)
+		if u.url.cannotHaveCredentialsOrPort() {
+			return
+		}
+		if port := arg.String(); port == "" {
+			u.url.port = -1
+		} else {
+			basicParseURL(port, nil, u.url, statePort)
+		}
 	})
 
 	// protocol
 	m.defineURLAccessorProp(p, "protocol", func(u *nodeURL) interface{} {
-		return u.url.Scheme + ":"
+		return u.url.scheme + ":"
 	}, func(u *nodeURL, arg goja.Value) {
-		s := arg.String()
-		pos := strings.IndexByte(s, ':')
-		if pos >= 0 {
-			s = s[:pos]
-		}
-		s = strings.ToLower(s)
-		if isSpecialProtocol(u.url.Scheme) == isSpecialProtocol(s) {
-			if _, err := url.ParseRequestURI(s + "://" + u.url.Host); err == nil {
-				u.url.Scheme = s
-			}
-		}
+		basicParseURL(arg.String()+":", nil, u.url, stateSchemeStart)
 	})
 
 	// Search
 	m.defineURLAccessorProp(p, "search", func(u *nodeURL) interface{} {
-		u.syncSearchParams()
-		if u.url.RawQuery != "" {
-			return "?" + u.url.RawQuery
+		if u.url.query == nil || *u.url.query == "" {
+			return ""
 		}
-		return ""
+		return "?" + *u.url.query
 	}, func(u *nodeURL, arg goja.Value) {
-		u.url.RawQuery = arg.String()
-		fixRawQuery(u.url)
-		if u.searchParams != nil {
-			u.searchParams = parseSearchQuery(u.url.RawQuery)
-			if u.searchParams == nil {
-				u.searchParams = make(searchParams, 0)
-			}
+		s := arg.String()
+		if s == "" {
+			u.url.query = nil
+			u.searchParams = make(searchParams, 0)
+			u.url.stripTrailingSpacesFromOpaquePath()
+			return
 		}
+		s = strings.TrimPrefix(s, "?")
+		u.url.query = new(string)
+		basicParseURL(s, nil, u.url, stateQuery)
+		u.searchParams = parseSearchQuery(s)
 	})
 
 	// search Params
 	m.defineURLAccessorProp(p, "searchParams", func(u *nodeURL) interface{} {
-		sp := parseSearchQuery(u.url.RawQuery)
-		if sp == nil {
-			sp = make(searchParams, 0)
-		}
-		u.searchParams = sp
 		return m.newURLSearchParams((*urlSearchParams)(u))
 	}, nil)
 
 	p.Set("toString", m.r.ToValue(func(call goja.FunctionCall) goja.Value {
 		u := toURL(m.r, call.This)
-		u.syncSearchParams()
 		return m.r.ToValue(u.url.String())
 	}))
 
 	p.Set("toJSON", m.r.ToValue(func(call goja.FunctionCall) goja.Value {
 		u := toURL(m.r, call.This)
-		u.syncSearchParams()
 		return m.r.ToValue(u.url.String())
 	}))
 
+	p.DefineDataPropertySymbol(goja.SymToStringTag, m.r.ToValue("URL"), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	p.SetSymbol(customInspectSymbol(m.r), m.r.ToValue(m.urlInspect))
+
 	return p
 }
 
+// urlInspect implements URL.prototype[util.inspect.custom].
+func (m *urlModule) urlInspect(call goja.FunctionCall) goja.Value {
+	u := toURL(m.r, call.This)
+	if depth := call.Argument(0); isNumber(depth) && depth.ToFloat() < 0 {
+		return call.This
+	}
+	obj := m.r.NewObject()
+	this := call.This.ToObject(m.r)
+	for _, name := range []string{"href", "origin", "protocol", "username", "password", "host", "hostname", "port",
+		"pathname", "search"} {
+		obj.Set(name, this.Get(name))
+	}
+	obj.Set("searchParams", m.newURLSearchParams((*urlSearchParams)(u)))
+	obj.Set("hash", this.Get("hash"))
+	return m.r.ToValue(constructorName(m.r, call.This, "URL") + " " + inspectArg(call, obj, call.Argument(1)))
+}
+
 func (m *urlModule) createURLConstructor() goja.Value {
 	f := m.r.ToValue(func(call goja.ConstructorCall) *goja.Object {
-		var u *url.URL
-		if baseArg := call.Argument(1); !goja.IsUndefined(baseArg) {
-			base := m.parseURL(baseArg.String(), true)
-			ref := m.parseURL(call.Argument(0).String(), false)
-			u = base.ResolveReference(ref)
-		} else {
-			u = m.parseURL(call.Argument(0).String(), true)
+		if len(call.Arguments) == 0 {
+			panic(errors.NewTypeError(m.r, errors.ErrCodeMissingArgs, `The "url" argument must be specified`))
 		}
-		res := m.r.ToValue(&nodeURL{url: u}).(*goja.Object)
-		res.SetPrototype(call.This.Prototype())
-		return res
+		return m.newURL(m.parseURL(call.Argument(0), call.Argument(1)), call.This.Prototype())
 	}).(*goja.Object)
+	f.DefineDataProperty("name", m.r.ToValue("URL"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
 
 	proto := m.createURLPrototype()
+	m.urlPrototype = proto
 	f.Set("prototype", proto)
 	proto.DefineDataProperty("constructor", f, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+
+	f.Set("canParse", m.r.ToValue(func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			panic(errors.NewTypeError(m.r, errors.ErrCodeMissingArgs, `The "url" argument must be specified`))
+		}
+		_, ok := parseURLArgs(call.Argument(0), call.Argument(1))
+		return m.r.ToValue(ok)
+	}))
+	f.Set("parse", m.r.ToValue(func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			panic(errors.NewTypeError(m.r, errors.ErrCodeMissingArgs, `The "url" argument must be specified`))
+		}
+		u, ok := parseURLArgs(call.Argument(0), call.Argument(1))
+		if !ok {
+			return goja.Null()
+		}
+		return m.newURL(u, proto)
+	}))
+	f.Set("createObjectURL", m.r.ToValue(m.createObjectURL))
+	f.Set("revokeObjectURL", m.r.ToValue(m.revokeObjectURL))
 	return f
 }
 
 func (m *urlModule) domainToASCII(domUnicode string) string {
-	res, err := idna.ToASCII(domUnicode)
-	if err != nil {
+	res, ok := parseHost(domUnicode, false)
+	if !ok {
 		return ""
 	}
 	return res
 }
 
 func (m *urlModule) domainToUnicode(domASCII string) string {
-	res, err := idna.ToUnicode(domASCII)
-	if err != nil {
+	res, ok := parseHost(domASCII, false)
+	if !ok {
 		return ""
 	}
-	return res
+	return idnaToUnicode(res)
 }
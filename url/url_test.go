@@ -66,7 +66,7 @@ func TestGetters(t *testing.T) {
 		},
 		{
 			prop:     "origin",
-			expected: "https://sub.example.com",
+			expected: "https://sub.example.com:8080",
 		},
 		{
 			prop:     "password",
@@ -120,3 +120,89 @@ func TestJs(t *testing.T) {
 		t.Fatal("Failed to process url script.", err)
 	}
 }
+
+//go:embed testdata/url_legacy.js
+var urlLegacyTest string
+
+func TestLegacy(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	_, err := vm.RunScript("testdata/url_legacy.js", urlLegacyTest)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			t.Fatal(ex.String())
+		}
+		t.Fatal("Failed to process url script.", err)
+	}
+}
+
+//go:embed testdata/url_file.js
+var urlFileTest string
+
+func TestFileURL(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	_, err := vm.RunScript("testdata/url_file.js", urlFileTest)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			t.Fatal(ex.String())
+		}
+		t.Fatal("Failed to process url script.", err)
+	}
+}
+
+var (
+	//go:embed testdata/urltestdata.json
+	wptURLTestData string
+	//go:embed testdata/setters_tests.json
+	wptSettersTestData string
+	//go:embed testdata/url_wpt.js
+	wptRunner string
+)
+
+func TestWPT(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	parse, _ := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("parse"))
+	for name, data := range map[string]string{"urlTestData": wptURLTestData, "settersTestData": wptSettersTestData} {
+		v, err := parse(nil, vm.ToValue(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm.Set(name, v)
+	}
+
+	res, err := vm.RunScript("testdata/url_wpt.js", wptRunner)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			t.Fatal(ex.String())
+		}
+		t.Fatal("Failed to process url script.", err)
+	}
+	for _, failure := range res.Export().([]interface{}) {
+		t.Error(failure)
+	}
+}
+
+//go:embed testdata/url_pattern.js
+var urlPatternTest string
+
+func TestURLPattern(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	_, err := vm.RunScript("testdata/url_pattern.js", urlPatternTest)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			t.Fatal(ex.String())
+		}
+		t.Fatal("Failed to process url script.", err)
+	}
+}
@@ -0,0 +1,660 @@
+package url
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// This file implements the URLPattern class as defined by the URL Pattern Standard.
+
+const (
+	compProtocol = iota
+	compUsername
+	compPassword
+	compHostname
+	compPort
+	compPathname
+	compSearch
+	compHash
+	numComponents
+)
+
+var componentNames = [numComponents]string{"protocol", "username", "password", "hostname", "port", "pathname", "search", "hash"}
+
+// The members of URLPatternInit in the order they are read from a JS object.
+var urlPatternInitMembers = []string{"baseURL", "hash", "hostname", "password", "pathname", "port", "protocol", "search", "username"}
+
+type patternComponent struct {
+	patternString   string
+	regexp          *goja.Object
+	groupNames      []string
+	hasRegexpGroups bool
+}
+
+type urlPattern struct {
+	components [numComponents]*patternComponent
+}
+
+var reflectTypeURLPattern = reflect.TypeOf((*urlPattern)(nil))
+
+func (init *urlPatternInit) member(name string) **string {
+	switch name {
+	case "protocol":
+		return &init.protocol
+	case "username":
+		return &init.username
+	case "password":
+		return &init.password
+	case "hostname":
+		return &init.hostname
+	case "port":
+		return &init.port
+	case "pathname":
+		return &init.pathname
+	case "search":
+		return &init.search
+	case "hash":
+		return &init.hash
+	case "baseURL":
+		return &init.baseURL
+	}
+	return nil
+}
+
+func isSpecialScheme(s string) bool {
+	_, ok := specialSchemes[s]
+	return ok
+}
+
+// createDummyURL returns the URL record used by the canonicalization algorithms.
+func createDummyURL() *urlRecord {
+	u, _ := parseURLRecord("https://dummy.invalid/", nil)
+	return u
+}
+
+func canonicalizeProtocol(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	u, ok := parseURLRecord(value+"://dummy.invalid/", nil)
+	if !ok {
+		return "", fmt.Errorf("invalid protocol %q", value)
+	}
+	return u.scheme, nil
+}
+
+func canonicalizeUserinfo(value string) (string, error) {
+	return percentEncodeString(value, inUserinfoSet), nil
+}
+
+func canonicalizeHostname(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	u := createDummyURL()
+	if _, ok := basicParseURL(value, nil, u, stateHostname); !ok {
+		return "", fmt.Errorf("invalid hostname %q", value)
+	}
+	return u.hostString(), nil
+}
+
+func canonicalizeIPv6Hostname(value string) (string, error) {
+	var sb strings.Builder
+	for _, c := range value {
+		if !isASCIIHexDigit(c) && c != '[' && c != ']' && c != ':' {
+			return "", fmt.Errorf("invalid IPv6 hostname %q", value)
+		}
+		sb.WriteRune(toLowerASCII(c))
+	}
+	return sb.String(), nil
+}
+
+func canonicalizePort(value string, protocol *string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	u := &urlRecord{port: -1}
+	if protocol != nil {
+		u.scheme = *protocol
+	}
+	if _, ok := basicParseURL(value, nil, u, statePort); !ok {
+		return "", fmt.Errorf("invalid port %q", value)
+	}
+	if u.port == -1 {
+		return "", nil
+	}
+	return strconv.Itoa(u.port), nil
+}
+
+func canonicalizePathname(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	leadingSlash := value[0] == '/'
+	if !leadingSlash {
+		value = "/-" + value
+	}
+	u := createDummyURL()
+	u.path = nil
+	if _, ok := basicParseURL(value, nil, u, statePathStart); !ok {
+		return "", fmt.Errorf("invalid pathname %q", value)
+	}
+	res := u.pathname()
+	if !leadingSlash {
+		res = res[2:]
+	}
+	return res, nil
+}
+
+func canonicalizeOpaquePathname(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	u := createDummyURL()
+	u.path = []string{""}
+	u.opaquePath = true
+	if _, ok := basicParseURL(value, nil, u, stateOpaquePath); !ok {
+		return "", fmt.Errorf("invalid pathname %q", value)
+	}
+	return u.pathname(), nil
+}
+
+func canonicalizeSearch(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	u := createDummyURL()
+	u.query = new(string)
+	if _, ok := basicParseURL(value, nil, u, stateQuery); !ok {
+		return "", fmt.Errorf("invalid search %q", value)
+	}
+	return *u.query, nil
+}
+
+func canonicalizeHash(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	u := createDummyURL()
+	u.fragment = new(string)
+	if _, ok := basicParseURL(value, nil, u, stateFragment); !ok {
+		return "", fmt.Errorf("invalid hash %q", value)
+	}
+	return *u.fragment, nil
+}
+
+func isAbsolutePathname(input string, isPattern bool) bool {
+	if input == "" {
+		return false
+	}
+	if input[0] == '/' {
+		return true
+	}
+	if !isPattern || len(input) < 2 {
+		return false
+	}
+	return (input[0] == '\\' || input[0] == '{') && input[1] == '/'
+}
+
+// processURLPatternInit implements 'process a URLPatternInit'. If isPattern is false the components are
+// canonicalized, otherwise they are kept as patterns.
+func processURLPatternInit(init *urlPatternInit, isPattern bool) (*urlPatternInit, error) {
+	result := &urlPatternInit{}
+	if !isPattern {
+		for _, name := range componentNames {
+			*result.member(name) = strPtr("")
+		}
+	}
+
+	processBaseURLString := func(s string) *string {
+		if isPattern {
+			s = escapePatternString(s)
+		}
+		return &s
+	}
+
+	var baseURL *urlRecord
+	if init.baseURL != nil {
+		var ok bool
+		if baseURL, ok = parseURLRecord(*init.baseURL, nil); !ok {
+			return nil, fmt.Errorf("invalid base URL %q", *init.baseURL)
+		}
+		if init.protocol == nil {
+			result.protocol = processBaseURLString(baseURL.scheme)
+		}
+		if !isPattern && init.protocol == nil && init.hostname == nil && init.port == nil && init.username == nil {
+			result.username = processBaseURLString(baseURL.username)
+		}
+		if !isPattern && init.protocol == nil && init.hostname == nil && init.port == nil && init.username == nil &&
+			init.password == nil {
+			result.password = processBaseURLString(baseURL.password)
+		}
+		if init.protocol == nil && init.hostname == nil {
+			result.hostname = processBaseURLString(baseURL.hostString())
+		}
+		if init.protocol == nil && init.hostname == nil && init.port == nil {
+			port := ""
+			if baseURL.port != -1 {
+				port = strconv.Itoa(baseURL.port)
+			}
+			result.port = &port
+		}
+		if init.protocol == nil && init.hostname == nil && init.port == nil && init.pathname == nil {
+			result.pathname = processBaseURLString(baseURL.pathname())
+		}
+		if init.protocol == nil && init.hostname == nil && init.port == nil && init.pathname == nil &&
+			init.search == nil {
+			query := ""
+			if baseURL.query != nil {
+				query = *baseURL.query
+			}
+			result.search = processBaseURLString(query)
+		}
+		if init.protocol == nil && init.hostname == nil && init.port == nil && init.pathname == nil &&
+			init.search == nil && init.hash == nil {
+			fragment := ""
+			if baseURL.fragment != nil {
+				fragment = *baseURL.fragment
+			}
+			result.hash = processBaseURLString(fragment)
+		}
+	}
+
+	process := func(value string, canonicalize func(string) (string, error)) (*string, error) {
+		if isPattern {
+			return &value, nil
+		}
+		res, err := canonicalize(value)
+		if err != nil {
+			return nil, err
+		}
+		return &res, nil
+	}
+
+	var err error
+	if init.protocol != nil {
+		if result.protocol, err = process(strings.TrimSuffix(*init.protocol, ":"), canonicalizeProtocol); err != nil {
+			return nil, err
+		}
+	}
+	if init.username != nil {
+		if result.username, err = process(*init.username, canonicalizeUserinfo); err != nil {
+			return nil, err
+		}
+	}
+	if init.password != nil {
+		if result.password, err = process(*init.password, canonicalizeUserinfo); err != nil {
+			return nil, err
+		}
+	}
+	if init.hostname != nil {
+		if result.hostname, err = process(*init.hostname, canonicalizeHostname); err != nil {
+			return nil, err
+		}
+	}
+	if init.port != nil {
+		if result.port, err = process(*init.port, func(s string) (string, error) {
+			return canonicalizePort(s, result.protocol)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if init.pathname != nil {
+		pathname := *init.pathname
+		if baseURL != nil && !baseURL.opaquePath && !isAbsolutePathname(pathname, isPattern) {
+			basePath := *processBaseURLString(baseURL.pathname())
+			if slashIndex := strings.LastIndexByte(basePath, '/'); slashIndex != -1 {
+				pathname = basePath[:slashIndex+1] + pathname
+			}
+		}
+		protocol := ""
+		if result.protocol != nil {
+			protocol = *result.protocol
+		}
+		canonicalize := canonicalizeOpaquePathname
+		if protocol == "" || isSpecialScheme(protocol) {
+			canonicalize = canonicalizePathname
+		}
+		if result.pathname, err = process(pathname, canonicalize); err != nil {
+			return nil, err
+		}
+	}
+	if init.search != nil {
+		if result.search, err = process(strings.TrimPrefix(*init.search, "?"), canonicalizeSearch); err != nil {
+			return nil, err
+		}
+	}
+	if init.hash != nil {
+		if result.hash, err = process(strings.TrimPrefix(*init.hash, "#"), canonicalizeHash); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func isIPv6HostnamePattern(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	return s[0] == '[' || ((s[0] == '{' || s[0] == '\\') && s[1] == '[')
+}
+
+func (m *urlModule) compileComponent(input string, encode encodingCallback, options patternOptions) (*patternComponent, error) {
+	parts, err := parsePatternString(input, options, encode)
+	if err != nil {
+		return nil, err
+	}
+	source, names := generateRegexp(parts, options)
+	flags := "u"
+	if options.ignoreCase {
+		flags = "ui"
+	}
+	re, err := m.r.New(m.r.Get("RegExp"), m.r.ToValue(source), m.r.ToValue(flags))
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q", source)
+	}
+	c := &patternComponent{
+		patternString: generatePatternString(parts, options),
+		regexp:        re,
+		groupNames:    names,
+	}
+	for i := range parts {
+		if parts[i].typ == partRegexp {
+			c.hasRegexpGroups = true
+			break
+		}
+	}
+	return c, nil
+}
+
+// exec runs the component's regular expression against the input. Returns null if there is no match.
+func (m *urlModule) execComponent(c *patternComponent, input string) goja.Value {
+	exec, _ := goja.AssertFunction(c.regexp.Get("exec"))
+	res, err := exec(c.regexp, m.r.ToValue(input))
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (m *urlModule) matchesSpecialScheme(c *patternComponent) bool {
+	for scheme := range specialSchemes {
+		if !goja.IsNull(m.execComponent(c, scheme)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *urlModule) newURLPatternError(err error) *goja.Object {
+	return errors.NewTypeError(m.r, errors.ErrCodeInvalidURLPattern, "Invalid URL pattern: %s", err.Error())
+}
+
+func (m *urlModule) createURLPattern(init *urlPatternInit, ignoreCase bool) (*urlPattern, error) {
+	processed, err := processURLPatternInit(init, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range componentNames {
+		if p := processed.member(name); *p == nil {
+			*p = strPtr("*")
+		}
+	}
+	if defPort, ok := specialSchemes[*processed.protocol]; ok && *processed.port == strconv.Itoa(defPort) {
+		*processed.port = ""
+	}
+
+	p := &urlPattern{}
+	compile := func(idx int, input string, encode encodingCallback, options patternOptions) error {
+		c, err := m.compileComponent(input, encode, options)
+		if err != nil {
+			return err
+		}
+		p.components[idx] = c
+		return nil
+	}
+	if err := compile(compProtocol, *processed.protocol, canonicalizeProtocol, defaultPatternOptions); err != nil {
+		return nil, err
+	}
+	if err := compile(compUsername, *processed.username, canonicalizeUserinfo, defaultPatternOptions); err != nil {
+		return nil, err
+	}
+	if err := compile(compPassword, *processed.password, canonicalizeUserinfo, defaultPatternOptions); err != nil {
+		return nil, err
+	}
+	hostnameEncode := canonicalizeHostname
+	if isIPv6HostnamePattern(*processed.hostname) {
+		hostnameEncode = canonicalizeIPv6Hostname
+	}
+	if err := compile(compHostname, *processed.hostname, hostnameEncode, hostnamePatternOptions); err != nil {
+		return nil, err
+	}
+	if err := compile(compPort, *processed.port, func(s string) (string, error) {
+		return canonicalizePort(s, nil)
+	}, defaultPatternOptions); err != nil {
+		return nil, err
+	}
+	compileOptions := defaultPatternOptions
+	compileOptions.ignoreCase = ignoreCase
+	if m.matchesSpecialScheme(p.components[compProtocol]) {
+		pathOptions := pathnamePatternOptions
+		pathOptions.ignoreCase = ignoreCase
+		if err := compile(compPathname, *processed.pathname, canonicalizePathname, pathOptions); err != nil {
+			return nil, err
+		}
+	} else if err := compile(compPathname, *processed.pathname, canonicalizeOpaquePathname, compileOptions); err != nil {
+		return nil, err
+	}
+	if err := compile(compSearch, *processed.search, canonicalizeSearch, compileOptions); err != nil {
+		return nil, err
+	}
+	if err := compile(compHash, *processed.hash, canonicalizeHash, compileOptions); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// toURLPatternInit converts a JS object to URLPatternInit.
+func toURLPatternInit(v goja.Value) *urlPatternInit {
+	init := &urlPatternInit{}
+	o, ok := v.(*goja.Object)
+	if !ok {
+		return init
+	}
+	for _, name := range urlPatternInitMembers {
+		if val := o.Get(name); val != nil && !goja.IsUndefined(val) {
+			*init.member(name) = strPtr(val.String())
+		}
+	}
+	return init
+}
+
+func isURLPatternInit(v goja.Value) bool {
+	_, isObj := v.(*goja.Object)
+	return isObj || goja.IsUndefined(v) || goja.IsNull(v)
+}
+
+func (m *urlModule) newURLPattern(input goja.Value, baseURL *string, ignoreCase bool) *urlPattern {
+	var init *urlPatternInit
+	if isURLPatternInit(input) {
+		if baseURL != nil {
+			panic(errors.NewTypeError(m.r, errors.ErrCodeInvalidArgValue, "A base URL must not be provided when the input is an object"))
+		}
+		init = toURLPatternInit(input)
+	} else {
+		var err error
+		init, err = parseConstructorString(input.String(), func(protocol string) (bool, error) {
+			c, err := m.compileComponent(protocol, canonicalizeProtocol, defaultPatternOptions)
+			if err != nil {
+				return false, err
+			}
+			return m.matchesSpecialScheme(c), nil
+		})
+		if err != nil {
+			panic(m.newURLPatternError(err))
+		}
+		if baseURL == nil && init.protocol == nil {
+			panic(m.newURLPatternError(fmt.Errorf("relative constructor string requires a base URL")))
+		}
+		init.baseURL = baseURL
+	}
+	p, err := m.createURLPattern(init, ignoreCase)
+	if err != nil {
+		panic(m.newURLPatternError(err))
+	}
+	return p
+}
+
+// match implements the 'match' algorithm. Returns null if the input does not match.
+func (m *urlModule) matchURLPattern(p *urlPattern, input, baseURLArg goja.Value) goja.Value {
+	var values [numComponents]string
+	var inputs []interface{}
+	if isURLPatternInit(input) {
+		if !goja.IsUndefined(baseURLArg) {
+			panic(errors.NewTypeError(m.r, errors.ErrCodeInvalidArgValue, "A base URL must not be provided when the input is an object"))
+		}
+		init := toURLPatternInit(input)
+		processed, err := processURLPatternInit(init, false)
+		if err != nil {
+			return goja.Null()
+		}
+		dict := m.r.NewObject()
+		for _, name := range urlPatternInitMembers {
+			if v := *init.member(name); v != nil {
+				dict.Set(name, *v)
+			}
+		}
+		inputs = append(inputs, dict)
+		for i, name := range componentNames {
+			values[i] = **processed.member(name)
+		}
+	} else {
+		s := input.String()
+		inputs = append(inputs, s)
+		var baseURL *urlRecord
+		if !goja.IsUndefined(baseURLArg) {
+			base := baseURLArg.String()
+			var ok bool
+			if baseURL, ok = parseURLRecord(base, nil); !ok {
+				return goja.Null()
+			}
+			inputs = append(inputs, base)
+		}
+		u, ok := parseURLRecord(s, baseURL)
+		if !ok {
+			return goja.Null()
+		}
+		values[compProtocol] = u.scheme
+		values[compUsername] = u.username
+		values[compPassword] = u.password
+		values[compHostname] = u.hostString()
+		if u.port != -1 {
+			values[compPort] = strconv.Itoa(u.port)
+		}
+		values[compPathname] = u.pathname()
+		if u.query != nil {
+			values[compSearch] = *u.query
+		}
+		if u.fragment != nil {
+			values[compHash] = *u.fragment
+		}
+	}
+
+	var execResults [numComponents]*goja.Object
+	for i, c := range p.components {
+		res, ok := m.execComponent(c, values[i]).(*goja.Object)
+		if !ok {
+			return goja.Null()
+		}
+		execResults[i] = res
+	}
+
+	result := m.r.NewObject()
+	result.Set("inputs", m.r.NewArray(inputs...))
+	for i, c := range p.components {
+		groups := m.r.NewObject()
+		execResult := execResults[i]
+		l := execResult.Get("length").ToInteger()
+		for idx := int64(1); idx < l; idx++ {
+			groups.Set(c.groupNames[idx-1], execResult.Get(strconv.FormatInt(idx, 10)))
+		}
+		componentResult := m.r.NewObject()
+		componentResult.Set("input", values[i])
+		componentResult.Set("groups", groups)
+		result.Set(componentNames[i], componentResult)
+	}
+	return result
+}
+
+func toURLPattern(r *goja.Runtime, v goja.Value) *urlPattern {
+	if v.ExportType() == reflectTypeURLPattern {
+		if p := v.Export().(*urlPattern); p != nil {
+			return p
+		}
+	}
+	panic(errors.NewTypeError(r, errors.ErrCodeInvalidThis, `Value of "this" must be of type URLPattern`))
+}
+
+func (m *urlModule) createURLPatternPrototype() *goja.Object {
+	p := m.r.NewObject()
+
+	for i, name := range componentNames {
+		idx := i
+		p.DefineAccessorProperty(name, m.r.ToValue(func(call goja.FunctionCall) goja.Value {
+			return m.r.ToValue(toURLPattern(m.r, call.This).components[idx].patternString)
+		}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	}
+
+	p.DefineAccessorProperty("hasRegExpGroups", m.r.ToValue(func(call goja.FunctionCall) goja.Value {
+		for _, c := range toURLPattern(m.r, call.This).components {
+			if c.hasRegexpGroups {
+				return m.r.ToValue(true)
+			}
+		}
+		return m.r.ToValue(false)
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+
+	p.Set("test", m.r.ToValue(func(call goja.FunctionCall) goja.Value {
+		res := m.matchURLPattern(toURLPattern(m.r, call.This), call.Argument(0), call.Argument(1))
+		return m.r.ToValue(!goja.IsNull(res))
+	}))
+
+	p.Set("exec", m.r.ToValue(func(call goja.FunctionCall) goja.Value {
+		return m.matchURLPattern(toURLPattern(m.r, call.This), call.Argument(0), call.Argument(1))
+	}))
+
+	p.DefineDataPropertySymbol(goja.SymToStringTag, m.r.ToValue("URLPattern"), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+
+	return p
+}
+
+func (m *urlModule) createURLPatternConstructor() goja.Value {
+	f := m.r.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		var baseURL *string
+		options := call.Argument(1)
+		if !isURLPatternInit(options) {
+			baseURL = strPtr(options.String())
+			options = call.Argument(2)
+		}
+		ignoreCase := false
+		if o, ok := options.(*goja.Object); ok {
+			if v := o.Get("ignoreCase"); v != nil {
+				ignoreCase = v.ToBoolean()
+			}
+		}
+		res := m.r.ToValue(m.newURLPattern(call.Argument(0), baseURL, ignoreCase)).(*goja.Object)
+		res.SetPrototype(call.This.Prototype())
+		return res
+	}).(*goja.Object)
+	f.DefineDataProperty("name", m.r.ToValue("URLPattern"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+
+	proto := m.createURLPatternPrototype()
+	f.Set("prototype", proto)
+	proto.DefineDataProperty("constructor", f, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+	return f
+}
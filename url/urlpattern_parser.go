@@ -0,0 +1,919 @@
+package url
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// This file implements the pattern string and the constructor string parsers of the URL Pattern Standard
+// (https://urlpattern.spec.whatwg.org/).
+
+type patternTokenType int
+
+const (
+	tokenOpen patternTokenType = iota
+	tokenClose
+	tokenRegexp
+	tokenName
+	tokenChar
+	tokenEscapedChar
+	tokenOtherModifier
+	tokenAsterisk
+	tokenEnd
+	tokenInvalidChar
+)
+
+type patternToken struct {
+	typ   patternTokenType
+	index int
+	value string
+}
+
+type tokenizePolicy int
+
+const (
+	policyStrict tokenizePolicy = iota
+	policyLenient
+)
+
+type patternTokenizer struct {
+	input     []rune
+	policy    tokenizePolicy
+	tokens    []patternToken
+	index     int
+	nextIndex int
+	c         rune
+}
+
+func isValidNameCodePoint(c rune, first bool) bool {
+	if c == '$' || c == '_' {
+		return true
+	}
+	idStart := unicode.In(c, unicode.L, unicode.Nl, unicode.Other_ID_Start) &&
+		!unicode.In(c, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+	if first {
+		return idStart
+	}
+	return idStart || c == '\u200C' || c == '\u200D' ||
+		unicode.In(c, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)
+}
+
+func (t *patternTokenizer) getNextCodePoint() {
+	t.c = t.input[t.nextIndex]
+	t.nextIndex++
+}
+
+func (t *patternTokenizer) seekAndGetNextCodePoint(index int) {
+	t.nextIndex = index
+	t.getNextCodePoint()
+}
+
+func (t *patternTokenizer) addToken(typ patternTokenType, nextPos, valuePos, valueLen int) {
+	t.tokens = append(t.tokens, patternToken{
+		typ:   typ,
+		index: t.index,
+		value: string(t.input[valuePos : valuePos+valueLen]),
+	})
+	t.index = nextPos
+}
+
+func (t *patternTokenizer) addTokenWithDefaultLength(typ patternTokenType, nextPos, valuePos int) {
+	t.addToken(typ, nextPos, valuePos, nextPos-valuePos)
+}
+
+func (t *patternTokenizer) addTokenWithDefaultPositionAndLength(typ patternTokenType) {
+	t.addTokenWithDefaultLength(typ, t.nextIndex, t.index)
+}
+
+func (t *patternTokenizer) processTokenizingError(nextPos, valuePos int) error {
+	if t.policy == policyStrict {
+		return errors.New("invalid pattern at position " + strconv.Itoa(valuePos))
+	}
+	t.addTokenWithDefaultLength(tokenInvalidChar, nextPos, valuePos)
+	return nil
+}
+
+func tokenizePattern(input string, policy tokenizePolicy) ([]patternToken, error) {
+	t := &patternTokenizer{
+		input:  []rune(input),
+		policy: policy,
+	}
+	l := len(t.input)
+	for t.index < l {
+		t.seekAndGetNextCodePoint(t.index)
+		switch t.c {
+		case '*':
+			t.addTokenWithDefaultPositionAndLength(tokenAsterisk)
+			continue
+		case '+', '?':
+			t.addTokenWithDefaultPositionAndLength(tokenOtherModifier)
+			continue
+		case '\\':
+			if t.index == l-1 {
+				if err := t.processTokenizingError(t.nextIndex, t.index); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			escapedIndex := t.nextIndex
+			t.getNextCodePoint()
+			t.addToken(tokenEscapedChar, t.nextIndex, escapedIndex, t.nextIndex-escapedIndex)
+			continue
+		case '{':
+			t.addTokenWithDefaultPositionAndLength(tokenOpen)
+			continue
+		case '}':
+			t.addTokenWithDefaultPositionAndLength(tokenClose)
+			continue
+		case ':':
+			namePos := t.nextIndex
+			nameStart := namePos
+			for namePos < l {
+				t.seekAndGetNextCodePoint(namePos)
+				if !isValidNameCodePoint(t.c, namePos == nameStart) {
+					break
+				}
+				namePos = t.nextIndex
+			}
+			if namePos <= nameStart {
+				if err := t.processTokenizingError(nameStart, t.index); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			t.addToken(tokenName, namePos, nameStart, namePos-nameStart)
+			continue
+		case '(':
+			depth := 1
+			regexpPos := t.nextIndex
+			regexpStart := regexpPos
+			failed := false
+			for regexpPos < l {
+				t.seekAndGetNextCodePoint(regexpPos)
+				if t.c > unicode.MaxASCII || (regexpPos == regexpStart && t.c == '?') {
+					failed = true
+					break
+				}
+				if t.c == '\\' {
+					if regexpPos == l-1 {
+						failed = true
+						break
+					}
+					t.getNextCodePoint()
+					if t.c > unicode.MaxASCII {
+						failed = true
+						break
+					}
+					regexpPos = t.nextIndex
+					continue
+				}
+				if t.c == ')' {
+					depth--
+					if depth == 0 {
+						regexpPos = t.nextIndex
+						break
+					}
+				} else if t.c == '(' {
+					depth++
+					if regexpPos == l-1 {
+						failed = true
+						break
+					}
+					tempPos := t.nextIndex
+					t.getNextCodePoint()
+					if t.c != '?' {
+						failed = true
+						break
+					}
+					t.nextIndex = tempPos
+				}
+				regexpPos = t.nextIndex
+			}
+			if !failed && depth != 0 {
+				failed = true
+			}
+			regexpLen := regexpPos - regexpStart - 1
+			if !failed && regexpLen == 0 {
+				failed = true
+			}
+			if failed {
+				if err := t.processTokenizingError(regexpStart, t.index); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			t.addToken(tokenRegexp, regexpPos, regexpStart, regexpLen)
+			continue
+		}
+		t.addTokenWithDefaultPositionAndLength(tokenChar)
+	}
+	t.addTokenWithDefaultLength(tokenEnd, t.index, t.index)
+	return t.tokens, nil
+}
+
+type partType int
+
+const (
+	partFixedText partType = iota
+	partRegexp
+	partSegmentWildcard
+	partFullWildcard
+)
+
+type partModifier int
+
+const (
+	modifierNone partModifier = iota
+	modifierOptional
+	modifierZeroOrMore
+	modifierOneOrMore
+)
+
+func (m partModifier) String() string {
+	switch m {
+	case modifierOptional:
+		return "?"
+	case modifierZeroOrMore:
+		return "*"
+	case modifierOneOrMore:
+		return "+"
+	}
+	return ""
+}
+
+type patternPart struct {
+	typ      partType
+	value    string
+	modifier partModifier
+	name     string
+	prefix   string
+	suffix   string
+}
+
+type patternOptions struct {
+	delimiter  string
+	prefix     string
+	ignoreCase bool
+}
+
+var (
+	defaultPatternOptions  = patternOptions{}
+	hostnamePatternOptions = patternOptions{delimiter: "."}
+	pathnamePatternOptions = patternOptions{delimiter: "/", prefix: "/"}
+)
+
+const fullWildcardRegexp = ".*"
+
+// encodingCallback canonicalizes the fixed text of a pattern.
+type encodingCallback func(string) (string, error)
+
+type patternParser struct {
+	tokens                []patternToken
+	encode                encodingCallback
+	segmentWildcardRegexp string
+	parts                 []patternPart
+	pendingFixedValue     strings.Builder
+	index                 int
+	nextNumericName       int
+}
+
+func escapeRegexpString(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(".+*?^${}()[]|/\\", c) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+func escapePatternString(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune("+*?:{}()\\", c) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+func generateSegmentWildcardRegexp(options patternOptions) string {
+	return "[^" + escapeRegexpString(options.delimiter) + "]+?"
+}
+
+// parsePatternString parses a pattern string into a list of parts.
+func parsePatternString(input string, options patternOptions, encode encodingCallback) ([]patternPart, error) {
+	tokens, err := tokenizePattern(input, policyStrict)
+	if err != nil {
+		return nil, err
+	}
+	p := &patternParser{
+		tokens:                tokens,
+		encode:                encode,
+		segmentWildcardRegexp: generateSegmentWildcardRegexp(options),
+	}
+	for p.index < len(p.tokens) {
+		charToken := p.tryConsumeToken(tokenChar)
+		nameToken := p.tryConsumeToken(tokenName)
+		regexpOrWildcardToken := p.tryConsumeRegexpOrWildcardToken(nameToken)
+		if nameToken != nil || regexpOrWildcardToken != nil {
+			prefix := ""
+			if charToken != nil {
+				prefix = charToken.value
+			}
+			if prefix != "" && prefix != options.prefix {
+				p.pendingFixedValue.WriteString(prefix)
+				prefix = ""
+			}
+			if err := p.maybeAddPartFromPendingFixedValue(); err != nil {
+				return nil, err
+			}
+			modifierToken := p.tryConsumeModifierToken()
+			if err := p.addPart(prefix, nameToken, regexpOrWildcardToken, "", modifierToken); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		fixedToken := charToken
+		if fixedToken == nil {
+			fixedToken = p.tryConsumeToken(tokenEscapedChar)
+		}
+		if fixedToken != nil {
+			p.pendingFixedValue.WriteString(fixedToken.value)
+			continue
+		}
+		if openToken := p.tryConsumeToken(tokenOpen); openToken != nil {
+			prefix := p.consumeText()
+			nameToken = p.tryConsumeToken(tokenName)
+			regexpOrWildcardToken = p.tryConsumeRegexpOrWildcardToken(nameToken)
+			suffix := p.consumeText()
+			if err := p.consumeRequiredToken(tokenClose); err != nil {
+				return nil, err
+			}
+			modifierToken := p.tryConsumeModifierToken()
+			if err := p.addPart(prefix, nameToken, regexpOrWildcardToken, suffix, modifierToken); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := p.maybeAddPartFromPendingFixedValue(); err != nil {
+			return nil, err
+		}
+		if err := p.consumeRequiredToken(tokenEnd); err != nil {
+			return nil, err
+		}
+	}
+	return p.parts, nil
+}
+
+func (p *patternParser) tryConsumeToken(typ patternTokenType) *patternToken {
+	if p.index >= len(p.tokens) {
+		return nil
+	}
+	token := &p.tokens[p.index]
+	if token.typ != typ {
+		return nil
+	}
+	p.index++
+	return token
+}
+
+func (p *patternParser) tryConsumeModifierToken() *patternToken {
+	if token := p.tryConsumeToken(tokenOtherModifier); token != nil {
+		return token
+	}
+	return p.tryConsumeToken(tokenAsterisk)
+}
+
+func (p *patternParser) tryConsumeRegexpOrWildcardToken(nameToken *patternToken) *patternToken {
+	token := p.tryConsumeToken(tokenRegexp)
+	if nameToken == nil && token == nil {
+		token = p.tryConsumeToken(tokenAsterisk)
+	}
+	return token
+}
+
+func (p *patternParser) consumeRequiredToken(typ patternTokenType) error {
+	if p.tryConsumeToken(typ) == nil {
+		pos := 0
+		if p.index < len(p.tokens) {
+			pos = p.tokens[p.index].index
+		}
+		return errors.New("unexpected token at position " + strconv.Itoa(pos))
+	}
+	return nil
+}
+
+func (p *patternParser) consumeText() string {
+	var sb strings.Builder
+	for {
+		token := p.tryConsumeToken(tokenChar)
+		if token == nil {
+			token = p.tryConsumeToken(tokenEscapedChar)
+		}
+		if token == nil {
+			break
+		}
+		sb.WriteString(token.value)
+	}
+	return sb.String()
+}
+
+func (p *patternParser) maybeAddPartFromPendingFixedValue() error {
+	if p.pendingFixedValue.Len() == 0 {
+		return nil
+	}
+	encoded, err := p.encode(p.pendingFixedValue.String())
+	if err != nil {
+		return err
+	}
+	p.pendingFixedValue.Reset()
+	p.parts = append(p.parts, patternPart{typ: partFixedText, value: encoded})
+	return nil
+}
+
+func (p *patternParser) isDuplicateName(name string) bool {
+	for i := range p.parts {
+		if p.parts[i].name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *patternParser) addPart(prefix string, nameToken, regexpOrWildcardToken *patternToken, suffix string, modifierToken *patternToken) error {
+	modifier := modifierNone
+	if modifierToken != nil {
+		switch modifierToken.value {
+		case "?":
+			modifier = modifierOptional
+		case "*":
+			modifier = modifierZeroOrMore
+		case "+":
+			modifier = modifierOneOrMore
+		}
+	}
+	if nameToken == nil && regexpOrWildcardToken == nil && modifier == modifierNone {
+		p.pendingFixedValue.WriteString(prefix)
+		return nil
+	}
+	if err := p.maybeAddPartFromPendingFixedValue(); err != nil {
+		return err
+	}
+	if nameToken == nil && regexpOrWildcardToken == nil {
+		if prefix == "" {
+			return nil
+		}
+		encoded, err := p.encode(prefix)
+		if err != nil {
+			return err
+		}
+		p.parts = append(p.parts, patternPart{typ: partFixedText, value: encoded, modifier: modifier})
+		return nil
+	}
+
+	var regexpValue string
+	switch {
+	case regexpOrWildcardToken == nil:
+		regexpValue = p.segmentWildcardRegexp
+	case regexpOrWildcardToken.typ == tokenAsterisk:
+		regexpValue = fullWildcardRegexp
+	default:
+		regexpValue = regexpOrWildcardToken.value
+	}
+	typ := partRegexp
+	if regexpValue == p.segmentWildcardRegexp {
+		typ = partSegmentWildcard
+		regexpValue = ""
+	} else if regexpValue == fullWildcardRegexp {
+		typ = partFullWildcard
+		regexpValue = ""
+	}
+
+	var name string
+	if nameToken != nil {
+		name = nameToken.value
+	} else {
+		name = strconv.Itoa(p.nextNumericName)
+		p.nextNumericName++
+	}
+	if p.isDuplicateName(name) {
+		return errors.New("duplicate group name '" + name + "'")
+	}
+	encodedPrefix, err := p.encode(prefix)
+	if err != nil {
+		return err
+	}
+	encodedSuffix, err := p.encode(suffix)
+	if err != nil {
+		return err
+	}
+	p.parts = append(p.parts, patternPart{
+		typ:      typ,
+		value:    regexpValue,
+		modifier: modifier,
+		name:     name,
+		prefix:   encodedPrefix,
+		suffix:   encodedSuffix,
+	})
+	return nil
+}
+
+// generateRegexp returns the regular expression source for the parts and the list of the group names.
+func generateRegexp(parts []patternPart, options patternOptions) (string, []string) {
+	var sb strings.Builder
+	var names []string
+	sb.WriteByte('^')
+	for i := range parts {
+		part := &parts[i]
+		if part.typ == partFixedText {
+			if part.modifier == modifierNone {
+				sb.WriteString(escapeRegexpString(part.value))
+			} else {
+				sb.WriteString("(?:" + escapeRegexpString(part.value) + ")" + part.modifier.String())
+			}
+			continue
+		}
+		names = append(names, part.name)
+		regexpValue := part.value
+		switch part.typ {
+		case partSegmentWildcard:
+			regexpValue = generateSegmentWildcardRegexp(options)
+		case partFullWildcard:
+			regexpValue = fullWildcardRegexp
+		}
+		single := part.modifier == modifierNone || part.modifier == modifierOptional
+		if part.prefix == "" && part.suffix == "" {
+			if single {
+				sb.WriteString("(" + regexpValue + ")" + part.modifier.String())
+			} else {
+				sb.WriteString("((?:" + regexpValue + ")" + part.modifier.String() + ")")
+			}
+			continue
+		}
+		prefix, suffix := escapeRegexpString(part.prefix), escapeRegexpString(part.suffix)
+		if single {
+			sb.WriteString("(?:" + prefix + "(" + regexpValue + ")" + suffix + ")" + part.modifier.String())
+			continue
+		}
+		sb.WriteString("(?:" + prefix + "((?:" + regexpValue + ")(?:" + suffix + prefix + "(?:" + regexpValue + "))*)" + suffix + ")")
+		if part.modifier == modifierZeroOrMore {
+			sb.WriteByte('?')
+		}
+	}
+	sb.WriteByte('$')
+	return sb.String(), names
+}
+
+func firstCodePoint(s string) rune {
+	for _, c := range s {
+		return c
+	}
+	return -1
+}
+
+func lastCodePoint(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return ""
+	}
+	return string(r[len(r)-1])
+}
+
+// generatePatternString returns the normalized pattern string for the parts.
+func generatePatternString(parts []patternPart, options patternOptions) string {
+	var sb strings.Builder
+	for i := range parts {
+		part := &parts[i]
+		var prev, next *patternPart
+		if i > 0 {
+			prev = &parts[i-1]
+		}
+		if i < len(parts)-1 {
+			next = &parts[i+1]
+		}
+		if part.typ == partFixedText {
+			if part.modifier == modifierNone {
+				sb.WriteString(escapePatternString(part.value))
+			} else {
+				sb.WriteString("{" + escapePatternString(part.value) + "}" + part.modifier.String())
+			}
+			continue
+		}
+		customName := !isASCIIDigit(firstCodePoint(part.name))
+		needsGrouping := part.suffix != "" || (part.prefix != "" && part.prefix != options.prefix)
+		if !needsGrouping && customName && part.typ == partSegmentWildcard && part.modifier == modifierNone &&
+			next != nil && next.prefix == "" && next.suffix == "" {
+			if next.typ == partFixedText {
+				needsGrouping = isValidNameCodePoint(firstCodePoint(next.value), false)
+			} else {
+				needsGrouping = isASCIIDigit(firstCodePoint(next.name))
+			}
+		}
+		if !needsGrouping && part.prefix == "" && prev != nil && prev.typ == partFixedText &&
+			options.prefix != "" && lastCodePoint(prev.value) == options.prefix {
+			needsGrouping = true
+		}
+		if needsGrouping {
+			sb.WriteByte('{')
+		}
+		sb.WriteString(escapePatternString(part.prefix))
+		if customName {
+			sb.WriteString(":" + part.name)
+		}
+		switch part.typ {
+		case partRegexp:
+			sb.WriteString("(" + part.value + ")")
+		case partSegmentWildcard:
+			if !customName {
+				sb.WriteString("(" + generateSegmentWildcardRegexp(options) + ")")
+			}
+		case partFullWildcard:
+			if !customName && (prev == nil || prev.typ == partFixedText || prev.modifier != modifierNone ||
+				needsGrouping || part.prefix != "") {
+				sb.WriteByte('*')
+			} else {
+				sb.WriteString("(" + fullWildcardRegexp + ")")
+			}
+		}
+		if part.typ == partSegmentWildcard && customName && part.suffix != "" &&
+			isValidNameCodePoint(firstCodePoint(part.suffix), false) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteString(escapePatternString(part.suffix))
+		if needsGrouping {
+			sb.WriteByte('}')
+		}
+		sb.WriteString(part.modifier.String())
+	}
+	return sb.String()
+}
+
+// Constructor string parser
+
+type constructorState int
+
+const (
+	ctorStateInit constructorState = iota
+	ctorStateProtocol
+	ctorStateAuthority
+	ctorStateUsername
+	ctorStatePassword
+	ctorStateHostname
+	ctorStatePort
+	ctorStatePathname
+	ctorStateSearch
+	ctorStateHash
+	ctorStateDone
+)
+
+// urlPatternInit is the URLPatternInit dictionary. A nil pointer means the member is not present.
+type urlPatternInit struct {
+	protocol, username, password, hostname, port, pathname, search, hash, baseURL *string
+}
+
+func (init *urlPatternInit) component(state constructorState) **string {
+	switch state {
+	case ctorStateProtocol:
+		return &init.protocol
+	case ctorStateUsername:
+		return &init.username
+	case ctorStatePassword:
+		return &init.password
+	case ctorStateHostname:
+		return &init.hostname
+	case ctorStatePort:
+		return &init.port
+	case ctorStatePathname:
+		return &init.pathname
+	case ctorStateSearch:
+		return &init.search
+	case ctorStateHash:
+		return &init.hash
+	}
+	return nil
+}
+
+type constructorStringParser struct {
+	input  []rune
+	tokens []patternToken
+	result urlPatternInit
+
+	componentStart           int
+	tokenIndex               int
+	tokenIncrement           int
+	groupDepth               int
+	hostnameIPv6BracketDepth int
+	protocolMatchesSpecial   bool
+	state                    constructorState
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// parseConstructorString parses a string passed to the URLPattern constructor. protocolMatchesSpecial is called
+// with the protocol component to find out if it matches one of the special schemes.
+func parseConstructorString(input string, protocolMatchesSpecial func(string) (bool, error)) (*urlPatternInit, error) {
+	tokens, err := tokenizePattern(input, policyLenient)
+	if err != nil {
+		return nil, err
+	}
+	p := &constructorStringParser{
+		input:  []rune(input),
+		tokens: tokens,
+	}
+	for p.tokenIndex < len(p.tokens) {
+		p.tokenIncrement = 1
+		if p.tokens[p.tokenIndex].typ == tokenEnd {
+			if p.state == ctorStateInit {
+				p.rewind()
+				if p.isHashPrefix() {
+					p.changeState(ctorStateHash, 1)
+				} else if p.isSearchPrefix() {
+					p.changeState(ctorStateSearch, 1)
+				} else {
+					p.changeState(ctorStatePathname, 0)
+				}
+				p.tokenIndex += p.tokenIncrement
+				continue
+			}
+			if p.state == ctorStateAuthority {
+				p.rewindAndSetState(ctorStateHostname)
+				p.tokenIndex += p.tokenIncrement
+				continue
+			}
+			p.changeState(ctorStateDone, 0)
+			break
+		}
+		if p.tokens[p.tokenIndex].typ == tokenOpen {
+			p.groupDepth++
+			p.tokenIndex += p.tokenIncrement
+			continue
+		}
+		if p.groupDepth > 0 {
+			if p.tokens[p.tokenIndex].typ == tokenClose {
+				p.groupDepth--
+			} else {
+				p.tokenIndex += p.tokenIncrement
+				continue
+			}
+		}
+		switch p.state {
+		case ctorStateInit:
+			if p.isPatternChar(p.tokenIndex, ":") {
+				p.rewindAndSetState(ctorStateProtocol)
+			}
+		case ctorStateProtocol:
+			if p.isPatternChar(p.tokenIndex, ":") {
+				special, err := protocolMatchesSpecial(p.makeComponentString())
+				if err != nil {
+					return nil, err
+				}
+				p.protocolMatchesSpecial = special
+				nextState, skip := ctorStatePathname, 1
+				if p.isPatternChar(p.tokenIndex+1, "/") && p.isPatternChar(p.tokenIndex+2, "/") {
+					nextState, skip = ctorStateAuthority, 3
+				} else if special {
+					nextState = ctorStateAuthority
+				}
+				p.changeState(nextState, skip)
+			}
+		case ctorStateAuthority:
+			if p.isPatternChar(p.tokenIndex, "@") {
+				p.rewindAndSetState(ctorStateUsername)
+			} else if p.isPatternChar(p.tokenIndex, "/") || p.isSearchPrefix() || p.isHashPrefix() {
+				p.rewindAndSetState(ctorStateHostname)
+			}
+		case ctorStateUsername:
+			if p.isPatternChar(p.tokenIndex, ":") {
+				p.changeState(ctorStatePassword, 1)
+			} else if p.isPatternChar(p.tokenIndex, "@") {
+				p.changeState(ctorStateHostname, 1)
+			}
+		case ctorStatePassword:
+			if p.isPatternChar(p.tokenIndex, "@") {
+				p.changeState(ctorStateHostname, 1)
+			}
+		case ctorStateHostname:
+			if p.isPatternChar(p.tokenIndex, "[") {
+				p.hostnameIPv6BracketDepth++
+			} else if p.isPatternChar(p.tokenIndex, "]") {
+				p.hostnameIPv6BracketDepth--
+			} else if p.isPatternChar(p.tokenIndex, ":") && p.hostnameIPv6BracketDepth == 0 {
+				p.changeState(ctorStatePort, 1)
+			} else if p.isPatternChar(p.tokenIndex, "/") {
+				p.changeState(ctorStatePathname, 0)
+			} else if p.isSearchPrefix() {
+				p.changeState(ctorStateSearch, 1)
+			} else if p.isHashPrefix() {
+				p.changeState(ctorStateHash, 1)
+			}
+		case ctorStatePort:
+			if p.isPatternChar(p.tokenIndex, "/") {
+				p.changeState(ctorStatePathname, 0)
+			} else if p.isSearchPrefix() {
+				p.changeState(ctorStateSearch, 1)
+			} else if p.isHashPrefix() {
+				p.changeState(ctorStateHash, 1)
+			}
+		case ctorStatePathname:
+			if p.isSearchPrefix() {
+				p.changeState(ctorStateSearch, 1)
+			} else if p.isHashPrefix() {
+				p.changeState(ctorStateHash, 1)
+			}
+		case ctorStateSearch:
+			if p.isHashPrefix() {
+				p.changeState(ctorStateHash, 1)
+			}
+		}
+		p.tokenIndex += p.tokenIncrement
+	}
+	if p.result.hostname != nil && p.result.port == nil {
+		p.result.port = strPtr("")
+	}
+	return &p.result, nil
+}
+
+func (p *constructorStringParser) changeState(newState constructorState, skip int) {
+	if p.state != ctorStateInit && p.state != ctorStateAuthority && p.state != ctorStateDone {
+		*p.result.component(p.state) = strPtr(p.makeComponentString())
+	}
+	if p.state != ctorStateInit && newState != ctorStateDone {
+		if p.state <= ctorStatePassword && newState >= ctorStatePort && p.result.hostname == nil {
+			p.result.hostname = strPtr("")
+		}
+		if p.state <= ctorStatePort && newState >= ctorStateSearch && p.result.pathname == nil {
+			if p.protocolMatchesSpecial {
+				p.result.pathname = strPtr("/")
+			} else {
+				p.result.pathname = strPtr("")
+			}
+		}
+		if p.state <= ctorStatePathname && newState == ctorStateHash && p.result.search == nil {
+			p.result.search = strPtr("")
+		}
+	}
+	p.state = newState
+	p.tokenIndex += skip
+	p.componentStart = p.tokenIndex
+	p.tokenIncrement = 0
+}
+
+func (p *constructorStringParser) rewind() {
+	p.tokenIndex = p.componentStart
+	p.tokenIncrement = 0
+}
+
+func (p *constructorStringParser) rewindAndSetState(state constructorState) {
+	p.rewind()
+	p.state = state
+}
+
+func (p *constructorStringParser) safeToken(index int) *patternToken {
+	if index < len(p.tokens) {
+		return &p.tokens[index]
+	}
+	return &p.tokens[len(p.tokens)-1]
+}
+
+// isPatternChar implements 'is a non-special pattern char'.
+func (p *constructorStringParser) isPatternChar(index int, value string) bool {
+	token := p.safeToken(index)
+	if token.value != value {
+		return false
+	}
+	return token.typ == tokenChar || token.typ == tokenEscapedChar || token.typ == tokenInvalidChar
+}
+
+func (p *constructorStringParser) isSearchPrefix() bool {
+	if p.isPatternChar(p.tokenIndex, "?") {
+		return true
+	}
+	if p.tokens[p.tokenIndex].value != "?" {
+		return false
+	}
+	if p.tokenIndex == 0 {
+		return true
+	}
+	switch p.safeToken(p.tokenIndex - 1).typ {
+	case tokenName, tokenRegexp, tokenClose, tokenAsterisk:
+		return false
+	}
+	return true
+}
+
+func (p *constructorStringParser) isHashPrefix() bool {
+	return p.isPatternChar(p.tokenIndex, "#")
+}
+
+func (p *constructorStringParser) makeComponentString() string {
+	start := p.safeToken(p.componentStart).index
+	end := p.tokens[p.tokenIndex].index
+	return string(p.input[start:end])
+}
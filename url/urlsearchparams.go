@@ -2,7 +2,9 @@ package url
 
 import (
 	"reflect"
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/nuvolaris/goja_nodejs/errors"
 
@@ -48,11 +50,12 @@ func (m *urlModule) createURLSearchParamsConstructor() goja.Value {
 		if o, ok := v.(*goja.Object); ok {
 			sp = m.buildParamsFromObject(o)
 		} else if !goja.IsUndefined(v) {
-			sp = parseSearchQuery(v.String())
+			sp = parseSearchQuery(strings.TrimPrefix(v.String(), "?"))
 		}
 
 		return m.newURLSearchParams(&urlSearchParams{searchParams: sp})
 	}).(*goja.Object)
+	f.DefineDataProperty("name", m.r.ToValue("URLSearchParams"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
 
 	m.URLSearchParamsPrototype = m.createURLSearchParamsPrototype()
 	f.Set("prototype", m.URLSearchParamsPrototype)
@@ -115,6 +118,60 @@ func (m *urlModule) buildParamsFromIterable(o *goja.Object) searchParams {
 	return query
 }
 
+// urlSearchParamsInspect implements URLSearchParams.prototype[util.inspect.custom].
+func (m *urlModule) urlSearchParamsInspect(call goja.FunctionCall) goja.Value {
+	u := toUrlSearchParams(m.r, call.This)
+	depth := call.Argument(0)
+	opts, _ := call.Argument(1).(*goja.Object)
+	if isNumber(depth) && depth.ToFloat() < 0 {
+		if opts != nil {
+			if stylize, ok := goja.AssertFunction(opts.Get("stylize")); ok {
+				res, err := stylize(opts, m.r.ToValue("[Object]"), m.r.ToValue("special"))
+				if err != nil {
+					panic(err)
+				}
+				return res
+			}
+		}
+		return m.r.ToValue("[Object]")
+	}
+	innerOpts := m.r.NewObject()
+	breakLength := 128.0
+	if opts != nil {
+		for _, key := range opts.Keys() {
+			innerOpts.Set(key, opts.Get(key))
+		}
+		if v := opts.Get("breakLength"); v != nil && isNumber(v) {
+			breakLength = v.ToFloat()
+		}
+	}
+	if !goja.IsNull(depth) {
+		innerOpts.Set("depth", depth.ToFloat()-1)
+	}
+	const separator = ", "
+	output := make([]string, 0, len(u.searchParams))
+	length := -len(separator)
+	for _, param := range u.searchParams {
+		entry := inspectArg(call, m.r.ToValue(param.name), innerOpts) + " => " + inspectArg(call, m.r.ToValue(param.value), innerOpts)
+		output = append(output, entry)
+		length += len(stripColors(entry)) + len(separator)
+	}
+	name := constructorName(m.r, call.This, "URLSearchParams")
+	switch {
+	case float64(length) > breakLength:
+		return m.r.ToValue(name + " {\n  " + strings.Join(output, ",\n  ") + " }")
+	case len(output) > 0:
+		return m.r.ToValue(name + " { " + strings.Join(output, separator) + " }")
+	}
+	return m.r.ToValue(name + " {}")
+}
+
+var colorRegExp = regexp.MustCompile("\x1b\\[\\d\\d?m")
+
+func stripColors(s string) string {
+	return colorRegExp.ReplaceAllString(s, "")
+}
+
 func (m *urlModule) createURLSearchParamsPrototype() *goja.Object {
 	p := m.r.NewObject()
 
@@ -173,6 +230,7 @@ func (m *urlModule) createURLSearchParamsPrototype() *goja.Object {
 	})
 	p.Set("entries", entries)
 	p.DefineDataPropertySymbol(goja.SymIterator, entries, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	p.SetSymbol(customInspectSymbol(m.r), m.r.ToValue(m.urlSearchParamsInspect))
 
 	p.Set("forEach", m.r.ToValue(func(call goja.FunctionCall) goja.Value {
 		u := toUrlSearchParams(m.r, call.This)
@@ -314,8 +372,8 @@ func (m *urlModule) createURLSearchParamsPrototype() *goja.Object {
 }
 
 func (sp *urlSearchParams) markUpdated() {
-	if sp.url != nil && sp.url.RawQuery != "" {
-		sp.url.RawQuery = ""
+	if sp.url != nil {
+		(*nodeURL)(sp).updateQuery()
 	}
 }
 
@@ -0,0 +1,357 @@
+package util
+
+import (
+	"bytes"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/util/types"
+)
+
+// This is a port of the strict mode of the deep comparison from nodejs lib/internal/util/comparisons.js
+
+const (
+	kNoIterator = iota
+	kIsArray
+	kIsSet
+	kIsMap
+)
+
+type deepEqualContext struct {
+	u     *Util
+	r     *goja.Runtime
+	intr  *intrinsics
+	types *types.Types
+
+	memo1, memo2 map[*goja.Object]int
+	position     int
+}
+
+// IsDeepStrictEqual returns true if the values are deeply strictly equal, as defined by util.isDeepStrictEqual().
+func (u *Util) IsDeepStrictEqual(a, b goja.Value) bool {
+	ctx := &deepEqualContext{
+		u:     u,
+		r:     u.runtime,
+		intr:  u.intrinsics(),
+		types: u.typeChecker(),
+	}
+	return ctx.innerDeepEqual(a, b)
+}
+
+func (u *Util) js_isDeepStrictEqual(call goja.FunctionCall) goja.Value {
+	return u.runtime.ToValue(u.IsDeepStrictEqual(call.Argument(0), call.Argument(1)))
+}
+
+func (ctx *deepEqualContext) call(fn goja.Callable, this goja.Value, args ...goja.Value) goja.Value {
+	return ctx.u.callIntrinsic(fn, this, args...)
+}
+
+func (ctx *deepEqualContext) hasOwnProperty(o *goja.Object, key goja.Value) bool {
+	return ctx.call(ctx.intr.hasOwnProperty, o, key).ToBoolean()
+}
+
+func (ctx *deepEqualContext) isEnumerable(o *goja.Object, key goja.Value) bool {
+	return ctx.call(ctx.intr.propertyIsEnumerable, o, key).ToBoolean()
+}
+
+func (ctx *deepEqualContext) innerDeepEqual(val1, val2 goja.Value) bool {
+	if val1.SameAs(val2) {
+		return true
+	}
+	o1, ok1 := val1.(*goja.Object)
+	o2, ok2 := val2.(*goja.Object)
+	if !ok1 || !ok2 || isFunction(o1) || isFunction(o2) {
+		return false
+	}
+	if o1.Prototype() != o2.Prototype() {
+		return false
+	}
+	tag1 := ctx.call(ctx.intr.objectToString, o1).String()
+	if tag1 != ctx.call(ctx.intr.objectToString, o2).String() {
+		return false
+	}
+
+	c1, c2 := o1.ClassName(), o2.ClassName()
+	switch {
+	case c1 == "Array":
+		if c2 != "Array" || o1.Get("length").ToInteger() != o2.Get("length").ToInteger() {
+			return false
+		}
+		keys1, keys2 := getEnumerableNonIndexKeys(o1), getEnumerableNonIndexKeys(o2)
+		if len(keys1) != len(keys2) {
+			return false
+		}
+		return ctx.keyCheck(o1, o2, kIsArray, keys1)
+	case tag1 == "[object Object]" && !ctx.types.IsBoxedPrimitive(o1) && !ctx.types.IsBoxedPrimitive(o2) && !ctx.isMapOrSet(o1) && !ctx.isMapOrSet(o2):
+		return ctx.keyCheck(o1, o2, kNoIterator, nil)
+	case c1 == "Date":
+		if c2 != "Date" || ctx.call(ctx.intr.dateGetTime, o1).ToFloat() != ctx.call(ctx.intr.dateGetTime, o2).ToFloat() {
+			return false
+		}
+	case c1 == "RegExp":
+		if c2 != "RegExp" || !areSimilarRegExps(o1, o2) {
+			return false
+		}
+	case c1 == "Error":
+		if c2 != "Error" || !o1.Get("message").SameAs(o2.Get("message")) || !o1.Get("name").SameAs(o2.Get("name")) {
+			return false
+		}
+	case ctx.types.IsArrayBufferView(o1):
+		if !ctx.types.IsArrayBufferView(o2) || !bytes.Equal(arrayBufferViewBytes(o1), arrayBufferViewBytes(o2)) {
+			return false
+		}
+		keys1, keys2 := getEnumerableNonIndexKeys(o1), getEnumerableNonIndexKeys(o2)
+		if len(keys1) != len(keys2) {
+			return false
+		}
+		return ctx.keyCheck(o1, o2, kNoIterator, keys1)
+	case ctx.types.IsSet(o1):
+		if !ctx.types.IsSet(o2) || o1.Get("size").ToInteger() != o2.Get("size").ToInteger() {
+			return false
+		}
+		return ctx.keyCheck(o1, o2, kIsSet, nil)
+	case ctx.types.IsMap(o1):
+		if !ctx.types.IsMap(o2) || o1.Get("size").ToInteger() != o2.Get("size").ToInteger() {
+			return false
+		}
+		return ctx.keyCheck(o1, o2, kIsMap, nil)
+	default:
+		if b1, ok := o1.Export().(goja.ArrayBuffer); ok {
+			if b2, ok := o2.Export().(goja.ArrayBuffer); !ok || !bytes.Equal(b1.Bytes(), b2.Bytes()) {
+				return false
+			}
+		} else if ctx.types.IsBoxedPrimitive(o1) {
+			if !ctx.types.IsBoxedPrimitive(o2) || !ctx.unbox(o1).SameAs(ctx.unbox(o2)) {
+				return false
+			}
+		} else if c2 == "Array" || ctx.types.IsArrayBufferView(o2) || ctx.isMapOrSet(o2) || c2 == "Date" || c2 == "RegExp" ||
+			c2 == "Error" || ctx.types.IsBoxedPrimitive(o2) {
+			return false
+		} else if _, ok := o2.Export().(goja.ArrayBuffer); ok {
+			return false
+		}
+	}
+	return ctx.keyCheck(o1, o2, kNoIterator, nil)
+}
+
+func (ctx *deepEqualContext) isMapOrSet(o *goja.Object) bool {
+	return ctx.types.IsMap(o) || ctx.types.IsSet(o)
+}
+
+// unbox returns the primitive value of a boxed primitive.
+func (ctx *deepEqualContext) unbox(o *goja.Object) goja.Value {
+	name := o.ClassName()
+	if ctx.types.IsSymbolObject(o) {
+		name = "Symbol"
+	}
+	return ctx.call(ctx.intr.valueOf[name], o)
+}
+
+func arrayBufferViewBytes(o *goja.Object) []byte {
+	buf := o.Get("buffer").Export().(goja.ArrayBuffer).Bytes()
+	offset := o.Get("byteOffset").ToInteger()
+	return buf[offset : offset+o.Get("byteLength").ToInteger()]
+}
+
+func areSimilarRegExps(a, b *goja.Object) bool {
+	return a.Get("source").SameAs(b.Get("source")) && a.Get("flags").SameAs(b.Get("flags")) &&
+		a.Get("lastIndex").SameAs(b.Get("lastIndex"))
+}
+
+// getEnumerableNonIndexKeys returns the own enumerable string keys that are not array indices.
+func getEnumerableNonIndexKeys(o *goja.Object) []string {
+	var keys []string
+	for _, k := range o.Keys() {
+		if !isArrayIndex(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (ctx *deepEqualContext) enumerableSymbols(o *goja.Object) []*goja.Symbol {
+	var res []*goja.Symbol
+	for _, sym := range o.Symbols() {
+		if ctx.isEnumerable(o, sym) {
+			res = append(res, sym)
+		}
+	}
+	return res
+}
+
+func (ctx *deepEqualContext) keyCheck(val1, val2 *goja.Object, iterationType int, keys1 []string) bool {
+	if keys1 == nil && iterationType != kIsArray {
+		keys1 = val1.Keys()
+		if len(keys1) != len(val2.Keys()) {
+			return false
+		}
+	}
+	for _, k := range keys1 {
+		key := ctx.r.ToValue(k)
+		if !ctx.hasOwnProperty(val2, key) || !ctx.isEnumerable(val2, key) {
+			return false
+		}
+	}
+
+	symbols1 := ctx.enumerableSymbols(val1)
+	for _, sym := range symbols1 {
+		if !ctx.isEnumerable(val2, sym) {
+			return false
+		}
+	}
+	if len(symbols1) != len(ctx.enumerableSymbols(val2)) {
+		return false
+	}
+
+	if len(keys1) == 0 && len(symbols1) == 0 {
+		switch iterationType {
+		case kNoIterator:
+			return true
+		case kIsArray:
+			if val1.Get("length").ToInteger() == 0 {
+				return true
+			}
+		default:
+			if val1.Get("size").ToInteger() == 0 {
+				return true
+			}
+		}
+	}
+
+	// Use memos to handle cycles.
+	if ctx.memo1 == nil {
+		ctx.memo1 = make(map[*goja.Object]int)
+		ctx.memo2 = make(map[*goja.Object]int)
+	} else {
+		pos1, ok1 := ctx.memo1[val1]
+		if ok1 {
+			if pos2, ok2 := ctx.memo2[val2]; ok2 {
+				return pos1 == pos2
+			}
+		}
+	}
+	ctx.position++
+	ctx.memo1[val1] = ctx.position
+	ctx.memo2[val2] = ctx.position
+
+	res := ctx.objEquiv(val1, val2, iterationType, keys1, symbols1)
+
+	delete(ctx.memo1, val1)
+	delete(ctx.memo2, val2)
+	return res
+}
+
+func (ctx *deepEqualContext) objEquiv(a, b *goja.Object, iterationType int, keys []string, symbols []*goja.Symbol) bool {
+	switch iterationType {
+	case kIsArray:
+		for i, l := int64(0), a.Get("length").ToInteger(); i < l; i++ {
+			idx := ctx.r.ToValue(i)
+			if ctx.hasOwnProperty(a, idx) {
+				if !ctx.hasOwnProperty(b, idx) || !ctx.innerDeepEqual(a.Get(idx.String()), b.Get(idx.String())) {
+					return false
+				}
+			} else if ctx.hasOwnProperty(b, idx) {
+				return false
+			}
+		}
+	case kIsSet:
+		if !ctx.setEquiv(a, b) {
+			return false
+		}
+	case kIsMap:
+		if !ctx.mapEquiv(a, b) {
+			return false
+		}
+	}
+	for _, k := range keys {
+		if !ctx.innerDeepEqual(a.Get(k), b.Get(k)) {
+			return false
+		}
+	}
+	for _, sym := range symbols {
+		if !ctx.innerDeepEqual(a.GetSymbol(sym), b.GetSymbol(sym)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ctx *deepEqualContext) setValues(o *goja.Object) []goja.Value {
+	var res []goja.Value
+	ctx.r.ForOf(ctx.call(ctx.intr.setValues, o), func(v goja.Value) bool {
+		res = append(res, v)
+		return true
+	})
+	return res
+}
+
+func (ctx *deepEqualContext) mapEntries(o *goja.Object) [][2]goja.Value {
+	var res [][2]goja.Value
+	ctx.r.ForOf(ctx.call(ctx.intr.mapEntries, o), func(v goja.Value) bool {
+		entry := v.(*goja.Object)
+		res = append(res, [2]goja.Value{entry.Get("0"), entry.Get("1")})
+		return true
+	})
+	return res
+}
+
+// findEqual removes the first value of the list that matches and reports whether there was one.
+func (ctx *deepEqualContext) findEqual(list *[]goja.Value, match func(v goja.Value) bool) bool {
+	for i, v := range *list {
+		if match(v) {
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (ctx *deepEqualContext) setEquiv(a, b *goja.Object) bool {
+	var set []goja.Value
+	for _, val := range ctx.setValues(a) {
+		if _, ok := val.(*goja.Object); ok {
+			set = append(set, val)
+		} else if !ctx.call(ctx.intr.setHas, b, val).ToBoolean() {
+			return false
+		}
+	}
+	if set != nil {
+		for _, val := range ctx.setValues(b) {
+			if _, ok := val.(*goja.Object); ok {
+				if !ctx.findEqual(&set, func(v goja.Value) bool { return ctx.innerDeepEqual(v, val) }) {
+					return false
+				}
+			}
+		}
+		return len(set) == 0
+	}
+	return true
+}
+
+func (ctx *deepEqualContext) mapEquiv(a, b *goja.Object) bool {
+	var set []goja.Value
+	for _, entry := range ctx.mapEntries(a) {
+		key, item1 := entry[0], entry[1]
+		if _, ok := key.(*goja.Object); ok {
+			set = append(set, key)
+		} else {
+			item2 := ctx.call(ctx.intr.mapGet, b, key)
+			if goja.IsUndefined(item2) && !ctx.call(ctx.intr.mapHas, b, key).ToBoolean() || !ctx.innerDeepEqual(item1, item2) {
+				return false
+			}
+		}
+	}
+	if set != nil {
+		for _, entry := range ctx.mapEntries(b) {
+			key, item2 := entry[0], entry[1]
+			if _, ok := key.(*goja.Object); ok {
+				if !ctx.findEqual(&set, func(key1 goja.Value) bool {
+					return ctx.innerDeepEqual(key1, key) && ctx.innerDeepEqual(ctx.call(ctx.intr.mapGet, a, key1), item2)
+				}) {
+					return false
+				}
+			}
+		}
+		return len(set) == 0
+	}
+	return true
+}
@@ -0,0 +1,215 @@
+package util
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// process returns the global process object if there is one.
+func (u *Util) process() *goja.Object {
+	proc, _ := u.runtime.Get("process").(*goja.Object)
+	return proc
+}
+
+// getEnv returns the environment variable from process.env if it's available, otherwise from the OS.
+func (u *Util) getEnv(name string) (string, bool) {
+	if proc := u.process(); proc != nil {
+		if env, ok := proc.Get("env").(*goja.Object); ok {
+			if v := env.Get(name); v != nil && !goja.IsUndefined(v) {
+				return v.String(), true
+			}
+			return "", false
+		}
+	}
+	return os.LookupEnv(name)
+}
+
+// writeStderr writes the string using process.stderr.write() if it's available, otherwise it writes to os.Stderr.
+func (u *Util) writeStderr(s string) {
+	if proc := u.process(); proc != nil {
+		if stderr, ok := proc.Get("stderr").(*goja.Object); ok {
+			if write, ok := goja.AssertFunction(stderr.Get("write")); ok {
+				u.callIntrinsic(write, stderr, u.runtime.ToValue(s))
+				return
+			}
+		}
+	}
+	os.Stderr.WriteString(s)
+}
+
+// emitWarning emits the warning using process.emitWarning() if it's available, otherwise it prints it
+// to stderr the same way nodejs does by default.
+func (u *Util) emitWarning(msg, typ, code string, ctor goja.Value) {
+	r := u.runtime
+	if proc := u.process(); proc != nil {
+		if emit, ok := goja.AssertFunction(proc.Get("emitWarning")); ok {
+			args := []goja.Value{r.ToValue(msg), r.ToValue(typ)}
+			if code != "" {
+				args = append(args, r.ToValue(code))
+			}
+			u.callIntrinsic(emit, proc, append(args, ctor)...)
+			return
+		}
+	}
+	var b strings.Builder
+	b.WriteString("(node:")
+	b.WriteString(strconv.Itoa(os.Getpid()))
+	b.WriteString(") ")
+	if code != "" {
+		b.WriteString("[" + code + "] ")
+	}
+	b.WriteString(typ + ": " + msg + "\n")
+	u.writeStderr(b.String())
+}
+
+func (u *Util) noDeprecation() bool {
+	if proc := u.process(); proc != nil {
+		if v := proc.Get("noDeprecation"); v != nil {
+			return v.StrictEquals(u.runtime.ToValue(true))
+		}
+	}
+	return false
+}
+
+func (u *Util) js_deprecate(call goja.FunctionCall) goja.Value {
+	r := u.runtime
+	fn, ok := call.Argument(0).(*goja.Object)
+	if !ok || !isFunction(fn) {
+		panic(errors.NewArgTypeError(r, "fn", "of type function", call.Argument(0)))
+	}
+	if u.noDeprecation() {
+		return fn
+	}
+	msg := call.Argument(1).String()
+	code := ""
+	if c := call.Argument(2); !goja.IsUndefined(c) {
+		if _, ok := c.(goja.String); !ok {
+			panic(errors.NewArgTypeError(r, "code", "of type string", c))
+		}
+		code = c.String()
+	}
+
+	// A proxy keeps the properties of the original function and makes the result constructable if the
+	// original is.
+	var deprecated goja.Value
+	warned := false
+	warn := func() {
+		if warned || u.noDeprecation() {
+			return
+		}
+		warned = true
+		if code != "" {
+			if u.codesWarned[code] {
+				return
+			}
+			if u.codesWarned == nil {
+				u.codesWarned = make(map[string]bool)
+			}
+			u.codesWarned[code] = true
+		}
+		u.emitWarning(msg, "DeprecationWarning", code, deprecated)
+	}
+	deprecated = r.ToValue(r.NewProxy(fn, &goja.ProxyTrapConfig{
+		Apply: func(target *goja.Object, this goja.Value, args []goja.Value) goja.Value {
+			warn()
+			f, _ := goja.AssertFunction(target)
+			res, err := f(this, args...)
+			if err != nil {
+				panic(err)
+			}
+			return res
+		},
+		Construct: func(target *goja.Object, args []goja.Value, newTarget *goja.Object) *goja.Object {
+			warn()
+			ctor, ok := goja.AssertConstructor(target)
+			if !ok {
+				panic(r.NewTypeError("%s is not a constructor", target.Get("name")))
+			}
+			res, err := ctor(newTarget, args...)
+			if err != nil {
+				panic(err)
+			}
+			return res
+		},
+	}))
+	return deprecated
+}
+
+var debugEnvEscapeRegExp = regexp.MustCompile(`[|\\{}()[\]^$+?.]`)
+
+// debugEnabled reports whether the section is listed in the NODE_DEBUG environment variable.
+func (u *Util) debugEnabled(section string) bool {
+	if u.debugEnv == nil {
+		env, _ := u.getEnv("NODE_DEBUG")
+		re := regexp.MustCompile(`^$`)
+		if env != "" {
+			env = debugEnvEscapeRegExp.ReplaceAllString(env, `\$0`)
+			env = strings.ReplaceAll(env, "*", ".*")
+			env = strings.ReplaceAll(env, ",", "$|^")
+			re = regexp.MustCompile("(?i)^" + env + "$")
+		}
+		u.debugEnv = re
+	}
+	return u.debugEnv.MatchString(section)
+}
+
+// debugImpl returns the logging function for the section. All the loggers of a section share it.
+func (u *Util) debugImpl(section string) goja.Value {
+	if impl, ok := u.debugImpls[section]; ok {
+		return impl
+	}
+	r := u.runtime
+	var impl goja.Value
+	if u.debugEnabled(section) {
+		pid := strconv.Itoa(os.Getpid())
+		impl = r.ToValue(func(call goja.FunctionCall) goja.Value {
+			var b bytes.Buffer
+			b.WriteString(section + " " + pid + ": ")
+			b.WriteString(u.formatArgs(u.defaultInspectOptions(), call.Arguments).String())
+			b.WriteByte('\n')
+			u.writeStderr(b.String())
+			return goja.Undefined()
+		})
+	} else {
+		impl = r.ToValue(func(goja.FunctionCall) goja.Value {
+			return goja.Undefined()
+		})
+	}
+	if u.debugImpls == nil {
+		u.debugImpls = make(map[string]goja.Value)
+	}
+	u.debugImpls[section] = impl
+	return impl
+}
+
+func (u *Util) js_debuglog(call goja.FunctionCall) goja.Value {
+	r := u.runtime
+	section := strings.ToUpper(call.Argument(0).String())
+	cb, _ := goja.AssertFunction(call.Argument(1))
+
+	var debug goja.Callable
+	init := func() goja.Callable {
+		if debug == nil {
+			impl := u.debugImpl(section)
+			debug, _ = goja.AssertFunction(impl)
+			if cb != nil {
+				u.callIntrinsic(cb, goja.Undefined(), impl)
+			}
+		}
+		return debug
+	}
+	logger := r.ToValue(func(call goja.FunctionCall) goja.Value {
+		return u.callIntrinsic(init(), call.This, call.Arguments...)
+	}).(*goja.Object)
+	logger.DefineDataProperty("name", r.ToValue("debug"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	logger.DefineAccessorProperty("enabled", r.ToValue(func(goja.FunctionCall) goja.Value {
+		return r.ToValue(u.debugEnabled(section))
+	}), nil, goja.FLAG_TRUE, goja.FLAG_TRUE)
+	return logger
+}
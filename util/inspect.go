@@ -0,0 +1,1621 @@
+package util
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nuvolaris/goja"
+)
+
+// This is a port of util.inspect() from nodejs lib/internal/util/inspect.js
+
+const (
+	kObjectType = iota
+	kArrayType
+	kArrayExtrasType
+)
+
+// kMinLineWidth is the minimal string length for which multi-line splitting is considered.
+const kMinLineWidth = 16
+
+// compactAll is the value of inspectOptions.compact when the compact option is set to true.
+const compactAll = -1
+
+type inspectOptions struct {
+	showHidden      bool
+	depth           float64
+	colors          bool
+	customInspect   bool
+	maxArrayLength  int
+	maxStringLength int
+	breakLength     float64
+	compact         int
+	sorted          bool
+	sortFn          goja.Callable
+	getters         bool
+
+	// userOptions holds the options object passed to inspect() so that the unknown keys are passed to
+	// custom inspect functions.
+	userOptions *goja.Object
+	stylizeFn   goja.Callable
+}
+
+type inspectContext struct {
+	u *Util
+	r *goja.Runtime
+	inspectOptions
+
+	seen           []*goja.Object
+	circular       map[*goja.Object]int
+	indentationLvl int
+	currentDepth   int
+}
+
+// intrinsics holds the built-in functions used by inspect() and format(), captured before user code has a chance
+// to replace them.
+type intrinsics struct {
+	getOwnPropertyDescriptor goja.Callable
+	getOwnPropertyNames      goja.Callable
+	hasOwnProperty           goja.Callable
+	propertyIsEnumerable     goja.Callable
+	functionToString         goja.Callable
+	regexpToString           goja.Callable
+	dateToISOString          goja.Callable
+	dateToString             goja.Callable
+	dateGetTime              goja.Callable
+	setValues                goja.Callable
+	mapEntries               goja.Callable
+	valueOf                  map[string]goja.Callable
+	symbolFor                goja.Callable
+	parseInt                 goja.Callable
+	parseFloat               goja.Callable
+	jsonStringify            goja.Callable
+
+	getOwnPropertyDescriptors goja.Callable
+	defineProperties          goja.Callable
+	setPrototypeOf            goja.Callable
+	objectToString            goja.Callable
+	mapGet                    goja.Callable
+	mapHas                    goja.Callable
+	setHas                    goja.Callable
+	mapSize                   goja.Callable
+	setSize                   goja.Callable
+	objectKeys                goja.Callable
+}
+
+var (
+	keyStrRegExp   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z_0-9]*$`)
+	colorRegExp    = regexp.MustCompile("\x1b\\[\\d\\d?m")
+	errorNameRegEx = regexp.MustCompile(`^([A-Z][a-z_ A-Z0-9[\]()-]+)(?::|\n\s+at)`)
+	errorNameOnly  = regexp.MustCompile(`^([a-z_A-Z0-9-]*Error)$`)
+)
+
+var (
+	reflectTypeArrayBuffer = reflect.TypeOf(goja.ArrayBuffer{})
+	reflectTypePromise     = reflect.TypeOf((*goja.Promise)(nil))
+	reflectTypeProxy       = reflect.TypeOf(goja.Proxy{})
+)
+
+var defaultInspectColors = []struct {
+	name       string
+	start, end int
+}{
+	{"reset", 0, 0},
+	{"bold", 1, 22},
+	{"dim", 2, 22},
+	{"italic", 3, 23},
+	{"underline", 4, 24},
+	{"blink", 5, 25},
+	{"inverse", 7, 27},
+	{"hidden", 8, 28},
+	{"strikethrough", 9, 29},
+	{"doubleunderline", 21, 24},
+	{"black", 30, 39},
+	{"red", 31, 39},
+	{"green", 32, 39},
+	{"yellow", 33, 39},
+	{"blue", 34, 39},
+	{"magenta", 35, 39},
+	{"cyan", 36, 39},
+	{"white", 37, 39},
+	{"bgBlack", 40, 49},
+	{"bgRed", 41, 49},
+	{"bgGreen", 42, 49},
+	{"bgYellow", 43, 49},
+	{"bgBlue", 44, 49},
+	{"bgMagenta", 45, 49},
+	{"bgCyan", 46, 49},
+	{"bgWhite", 47, 49},
+	{"framed", 51, 54},
+	{"overlined", 53, 55},
+	{"gray", 90, 39},
+	{"redBright", 91, 39},
+	{"greenBright", 92, 39},
+	{"yellowBright", 93, 39},
+	{"blueBright", 94, 39},
+	{"magentaBright", 95, 39},
+	{"cyanBright", 96, 39},
+	{"whiteBright", 97, 39},
+	{"bgGray", 100, 49},
+	{"bgRedBright", 101, 49},
+	{"bgGreenBright", 102, 49},
+	{"bgYellowBright", 103, 49},
+	{"bgBlueBright", 104, 49},
+	{"bgMagentaBright", 105, 49},
+	{"bgCyanBright", 106, 49},
+	{"bgWhiteBright", 107, 49},
+}
+
+var colorAliases = []struct {
+	name, alias string
+}{
+	{"gray", "grey"}, {"gray", "blackBright"}, {"bgGray", "bgGrey"}, {"bgGray", "bgBlackBright"}, {"dim", "faint"},
+	{"strikethrough", "crossedout"}, {"strikethrough", "strikeThrough"}, {"strikethrough", "crossedOut"},
+	{"hidden", "conceal"}, {"inverse", "swapColors"}, {"inverse", "swapcolors"}, {"doubleunderline", "doubleUnderline"},
+}
+
+var defaultInspectStyles = []struct {
+	name, color string
+}{
+	{"special", "cyan"},
+	{"number", "yellow"},
+	{"bigint", "yellow"},
+	{"boolean", "yellow"},
+	{"undefined", "grey"},
+	{"null", "bold"},
+	{"string", "green"},
+	{"symbol", "green"},
+	{"date", "magenta"},
+	{"regexp", "red"},
+	{"module", "underline"},
+}
+
+func defaultInspectOptions() inspectOptions {
+	return inspectOptions{
+		depth:           2,
+		customInspect:   true,
+		maxArrayLength:  100,
+		maxStringLength: 10000,
+		breakLength:     80,
+		compact:         3,
+	}
+}
+
+func (u *Util) intrinsics() *intrinsics {
+	if u.intr != nil {
+		return u.intr
+	}
+	r := u.runtime
+	method := func(ctor, name string) goja.Callable {
+		proto := r.Get(ctor).ToObject(r).Get("prototype").ToObject(r)
+		fn, _ := goja.AssertFunction(proto.Get(name))
+		return fn
+	}
+	object := r.Get("Object").ToObject(r)
+	intr := &intrinsics{
+		hasOwnProperty:       method("Object", "hasOwnProperty"),
+		propertyIsEnumerable: method("Object", "propertyIsEnumerable"),
+		functionToString:     method("Function", "toString"),
+		regexpToString:       method("RegExp", "toString"),
+		dateToISOString:      method("Date", "toISOString"),
+		dateToString:         method("Date", "toString"),
+		dateGetTime:          method("Date", "getTime"),
+		setValues:            method("Set", "values"),
+		mapEntries:           method("Map", "entries"),
+		objectToString:       method("Object", "toString"),
+		mapGet:               method("Map", "get"),
+		mapHas:               method("Map", "has"),
+		setHas:               method("Set", "has"),
+		valueOf: map[string]goja.Callable{
+			"Number":  method("Number", "valueOf"),
+			"String":  method("String", "valueOf"),
+			"Boolean": method("Boolean", "valueOf"),
+			"Symbol":  method("Symbol", "valueOf"),
+		},
+	}
+	intr.getOwnPropertyDescriptor, _ = goja.AssertFunction(object.Get("getOwnPropertyDescriptor"))
+	getter := func(ctor, name string) goja.Callable {
+		proto := r.Get(ctor).ToObject(r).Get("prototype")
+		desc, err := intr.getOwnPropertyDescriptor(nil, proto, r.ToValue(name))
+		if err != nil {
+			panic(err)
+		}
+		fn, _ := goja.AssertFunction(desc.ToObject(r).Get("get"))
+		return fn
+	}
+	intr.mapSize = getter("Map", "size")
+	intr.setSize = getter("Set", "size")
+	intr.objectKeys, _ = goja.AssertFunction(object.Get("keys"))
+	intr.getOwnPropertyNames, _ = goja.AssertFunction(object.Get("getOwnPropertyNames"))
+	intr.getOwnPropertyDescriptors, _ = goja.AssertFunction(object.Get("getOwnPropertyDescriptors"))
+	intr.defineProperties, _ = goja.AssertFunction(object.Get("defineProperties"))
+	intr.setPrototypeOf, _ = goja.AssertFunction(object.Get("setPrototypeOf"))
+	intr.symbolFor, _ = goja.AssertFunction(r.Get("Symbol").ToObject(r).Get("for"))
+	intr.parseInt, _ = goja.AssertFunction(r.Get("parseInt"))
+	intr.parseFloat, _ = goja.AssertFunction(r.Get("parseFloat"))
+	intr.jsonStringify, _ = goja.AssertFunction(r.Get("JSON").ToObject(r).Get("stringify"))
+	u.intr = intr
+	return intr
+}
+
+// customInspectSymbol returns Symbol.for('nodejs.util.inspect.custom').
+func (u *Util) customInspectSymbol() *goja.Symbol {
+	if u.customInspect == nil {
+		sym, err := u.intrinsics().symbolFor(nil, u.runtime.ToValue("nodejs.util.inspect.custom"))
+		if err != nil {
+			panic(err)
+		}
+		u.customInspect = sym.(*goja.Symbol)
+	}
+	return u.customInspect
+}
+
+// inspectFunc returns the util.inspect function object, creating it on first use.
+func (u *Util) inspectFunc() *goja.Object {
+	if u.inspectObj != nil {
+		return u.inspectObj
+	}
+	r := u.runtime
+	inspect := r.ToValue(u.js_inspect).ToObject(r)
+	inspect.DefineDataProperty("name", r.ToValue("inspect"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	inspect.Set("custom", u.customInspectSymbol())
+
+	defaults := defaultInspectOptions()
+	defaultOptions := r.NewObject()
+	defaultOptions.Set("showHidden", defaults.showHidden)
+	defaultOptions.Set("depth", defaults.depth)
+	defaultOptions.Set("colors", defaults.colors)
+	defaultOptions.Set("customInspect", defaults.customInspect)
+	defaultOptions.Set("showProxy", false)
+	defaultOptions.Set("maxArrayLength", defaults.maxArrayLength)
+	defaultOptions.Set("maxStringLength", defaults.maxStringLength)
+	defaultOptions.Set("breakLength", defaults.breakLength)
+	defaultOptions.Set("compact", defaults.compact)
+	defaultOptions.Set("sorted", defaults.sorted)
+	defaultOptions.Set("getters", defaults.getters)
+	inspect.Set("defaultOptions", defaultOptions)
+
+	colors := r.NewObject()
+	for _, c := range defaultInspectColors {
+		colors.Set(c.name, r.NewArray(c.start, c.end))
+	}
+	// The aliases are not enumerable, so they don't show up in Object.keys(inspect.colors).
+	for _, a := range colorAliases {
+		colors.DefineDataProperty(a.alias, colors.Get(a.name), goja.FLAG_TRUE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	}
+	inspect.Set("colors", colors)
+
+	styles := r.NewObject()
+	for _, s := range defaultInspectStyles {
+		styles.Set(s.name, s.color)
+	}
+	inspect.Set("styles", styles)
+
+	u.inspectObj = inspect
+	return inspect
+}
+
+// options returns the current default options taking into account util.inspect.defaultOptions.
+func (u *Util) defaultInspectOptions() inspectOptions {
+	opts := defaultInspectOptions()
+	if u.inspectObj != nil {
+		if o, ok := u.inspectObj.Get("defaultOptions").(*goja.Object); ok {
+			u.applyInspectOptions(&opts, o)
+		}
+	}
+	return opts
+}
+
+func toLength(v goja.Value) int {
+	if goja.IsNull(v) {
+		return math.MaxInt
+	}
+	f := v.ToFloat()
+	if f > math.MaxInt32 {
+		return math.MaxInt
+	}
+	if f > 0 {
+		return int(f)
+	}
+	return 0
+}
+
+func (u *Util) applyInspectOptions(opts *inspectOptions, o *goja.Object) {
+	for _, key := range o.Keys() {
+		v := o.Get(key)
+		switch key {
+		case "showHidden":
+			opts.showHidden = v.ToBoolean()
+		case "depth":
+			if goja.IsNull(v) {
+				opts.depth = math.Inf(1)
+			} else if !goja.IsUndefined(v) {
+				opts.depth = v.ToFloat()
+			}
+		case "colors":
+			opts.colors = v.ToBoolean()
+		case "customInspect":
+			opts.customInspect = v.ToBoolean()
+		case "maxArrayLength":
+			opts.maxArrayLength = toLength(v)
+		case "maxStringLength":
+			opts.maxStringLength = toLength(v)
+		case "breakLength":
+			opts.breakLength = v.ToFloat()
+		case "compact":
+			if isBoolean(v) {
+				if v.ToBoolean() {
+					opts.compact = compactAll
+				} else {
+					opts.compact = 0
+				}
+			} else {
+				opts.compact = int(math.Max(0, math.Min(v.ToFloat(), math.MaxInt32)))
+			}
+		case "sorted":
+			if fn, ok := goja.AssertFunction(v); ok {
+				opts.sorted, opts.sortFn = true, fn
+			} else {
+				opts.sorted, opts.sortFn = v.ToBoolean(), nil
+			}
+		case "getters":
+			opts.getters = v.ToBoolean()
+		case "stylize":
+			opts.stylizeFn, _ = goja.AssertFunction(v)
+		case "showProxy", "numericSeparator":
+		default:
+			if opts.userOptions == nil {
+				opts.userOptions = o
+			}
+		}
+	}
+}
+
+// Inspect returns a string representation of the value the same way util.inspect() does with the default
+// options.
+func (u *Util) Inspect(v goja.Value) string {
+	return u.inspect(v, u.defaultInspectOptions())
+}
+
+func (u *Util) inspect(v goja.Value, opts inspectOptions) string {
+	ctx := &inspectContext{
+		u:              u,
+		r:              u.runtime,
+		inspectOptions: opts,
+	}
+	return ctx.formatValue(v, 0, false)
+}
+
+func (u *Util) js_inspect(call goja.FunctionCall) goja.Value {
+	opts := u.defaultInspectOptions()
+	if len(call.Arguments) > 2 {
+		if depth := call.Argument(2); !goja.IsUndefined(depth) {
+			if goja.IsNull(depth) {
+				opts.depth = math.Inf(1)
+			} else {
+				opts.depth = depth.ToFloat()
+			}
+		}
+		if colors := call.Argument(3); !goja.IsUndefined(colors) {
+			opts.colors = colors.ToBoolean()
+		}
+	}
+	switch arg := call.Argument(1).(type) {
+	case *goja.Object:
+		u.applyInspectOptions(&opts, arg)
+	default:
+		if b, ok := arg.Export().(bool); ok {
+			opts.showHidden = b
+		}
+	}
+	return u.runtime.ToValue(u.inspect(call.Argument(0), opts))
+}
+
+func (ctx *inspectContext) call(fn goja.Callable, this goja.Value, args ...goja.Value) goja.Value {
+	res, err := fn(this, args...)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (ctx *inspectContext) stylize(s, styleType string) string {
+	if ctx.stylizeFn != nil {
+		return ctx.call(ctx.stylizeFn, nil, ctx.r.ToValue(s), ctx.r.ToValue(styleType)).String()
+	}
+	if !ctx.colors {
+		return s
+	}
+	inspect := ctx.u.inspectFunc()
+	styles, ok := inspect.Get("styles").(*goja.Object)
+	if !ok {
+		return s
+	}
+	style := styles.Get(styleType)
+	if style == nil || goja.IsUndefined(style) {
+		return s
+	}
+	colors, ok := inspect.Get("colors").(*goja.Object)
+	if !ok {
+		return s
+	}
+	color, ok := colors.Get(style.String()).(*goja.Object)
+	if !ok {
+		return s
+	}
+	return "\x1b[" + color.Get("0").String() + "m" + s + "\x1b[" + color.Get("1").String() + "m"
+}
+
+func (ctx *inspectContext) userOptionsObject() *goja.Object {
+	r := ctx.r
+	o := r.NewObject()
+	if ctx.userOptions != nil {
+		for _, key := range ctx.userOptions.Keys() {
+			o.Set(key, ctx.userOptions.Get(key))
+		}
+	}
+	o.Set("showHidden", ctx.showHidden)
+	o.Set("depth", ctx.depth)
+	o.Set("colors", ctx.colors)
+	o.Set("customInspect", ctx.customInspect)
+	o.Set("showProxy", false)
+	o.Set("maxArrayLength", ctx.maxArrayLength)
+	o.Set("maxStringLength", ctx.maxStringLength)
+	o.Set("breakLength", ctx.breakLength)
+	if ctx.compact == compactAll {
+		o.Set("compact", true)
+	} else if ctx.compact == 0 {
+		o.Set("compact", false)
+	} else {
+		o.Set("compact", ctx.compact)
+	}
+	if ctx.sortFn != nil {
+		o.Set("sorted", ctx.sortFn)
+	} else {
+		o.Set("sorted", ctx.sorted)
+	}
+	o.Set("getters", ctx.getters)
+	o.Set("stylize", func(call goja.FunctionCall) goja.Value {
+		return r.ToValue(ctx.stylize(call.Argument(0).String(), call.Argument(1).String()))
+	})
+	return o
+}
+
+func (ctx *inspectContext) formatValue(value goja.Value, recurseTimes int, typedArray bool) string {
+	o, ok := value.(*goja.Object)
+	if !ok {
+		return ctx.formatPrimitive(value)
+	}
+
+	if ctx.u.typeChecker().IsProxy(o) {
+		o = o.Export().(goja.Proxy).Target()
+	}
+
+	if ctx.customInspect {
+		sym := ctx.u.customInspectSymbol()
+		if maybeCustom, ok := goja.AssertFunction(o.GetSymbol(sym)); ok &&
+			!o.GetSymbol(sym).SameAs(ctx.u.inspectObj) && !ctx.isPrototypeObject(o) {
+			depth := ctx.depth - float64(recurseTimes)
+			ret := ctx.call(maybeCustom, o, ctx.r.ToValue(depth), ctx.userOptionsObject(), ctx.u.inspectFunc())
+			if !ret.SameAs(o) {
+				if isString(ret) {
+					return strings.ReplaceAll(ret.String(), "\n", "\n"+strings.Repeat(" ", ctx.indentationLvl))
+				}
+				return ctx.formatValue(ret, recurseTimes, false)
+			}
+		}
+	}
+
+	for _, s := range ctx.seen {
+		if s.SameAs(o) {
+			index, exists := ctx.circularIndex(o)
+			if !exists {
+				if ctx.circular == nil {
+					ctx.circular = make(map[*goja.Object]int)
+				}
+				index = len(ctx.circular) + 1
+				ctx.circular[o] = index
+			}
+			return ctx.stylize("[Circular *"+strconv.Itoa(index)+"]", "special")
+		}
+	}
+
+	return ctx.formatRaw(o, recurseTimes, typedArray)
+}
+
+func (ctx *inspectContext) circularIndex(o *goja.Object) (int, bool) {
+	for k, idx := range ctx.circular {
+		if k.SameAs(o) {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+// isPrototypeObject returns true if the object is the prototype of its own constructor, i.e.
+// value.constructor.prototype === value.
+func (ctx *inspectContext) isPrototypeObject(o *goja.Object) bool {
+	ctor, ok := o.Get("constructor").(*goja.Object)
+	if !ok {
+		return false
+	}
+	return ctor.Get("prototype").SameAs(o)
+}
+
+// noColor returns a copy of the context that does not stylize the output.
+func (ctx *inspectContext) noColor() *inspectContext {
+	noColor := *ctx
+	noColor.colors, noColor.stylizeFn = false, nil
+	return &noColor
+}
+
+func (ctx *inspectContext) formatNumber(v goja.Value) string {
+	if f, ok := v.Export().(float64); ok && f == 0 && math.Signbit(f) {
+		return ctx.stylize("-0", "number")
+	}
+	return ctx.stylize(v.String(), "number")
+}
+
+func (ctx *inspectContext) formatPrimitive(v goja.Value) string {
+	switch {
+	case v == nil || goja.IsUndefined(v):
+		return ctx.stylize("undefined", "undefined")
+	case goja.IsNull(v):
+		return ctx.stylize("null", "null")
+	}
+	if sym, ok := v.(*goja.Symbol); ok {
+		return ctx.stylize(symbolString(sym), "symbol")
+	}
+	switch val := v.Export().(type) {
+	case string:
+		return ctx.formatString(val)
+	case bool:
+		return ctx.stylize(v.String(), "boolean")
+	case int64, float64:
+		return ctx.formatNumber(v)
+	}
+	return v.String()
+}
+
+func (ctx *inspectContext) formatString(value string) string {
+	trailer := ""
+	if l := utf8.RuneCountInString(value); l > ctx.maxStringLength {
+		remaining := l - ctx.maxStringLength
+		value = string([]rune(value)[:ctx.maxStringLength])
+		trailer = "... " + strconv.Itoa(remaining) + " more character" + plural(remaining)
+	}
+	if l := float64(utf8.RuneCountInString(value)); ctx.compact != compactAll && l > kMinLineWidth &&
+		l > ctx.breakLength-float64(ctx.indentationLvl)-4 {
+		var sb strings.Builder
+		for len(value) > 0 {
+			line := value
+			if i := strings.IndexByte(value, '\n'); i != -1 {
+				line = value[:i+1]
+			}
+			value = value[len(line):]
+			if sb.Len() > 0 {
+				sb.WriteString(" +\n")
+				sb.WriteString(strings.Repeat(" ", ctx.indentationLvl+2))
+			}
+			sb.WriteString(ctx.stylize(strEscape(line), "string"))
+		}
+		return sb.String() + trailer
+	}
+	return ctx.stylize(strEscape(value), "string") + trailer
+}
+
+func symbolString(sym *goja.Symbol) string {
+	return "Symbol(" + sym.String() + ")"
+}
+
+func plural(n int) string {
+	if n > 1 {
+		return "s"
+	}
+	return ""
+}
+
+func remainingText(remaining int) string {
+	return "... " + strconv.Itoa(remaining) + " more item" + plural(remaining)
+}
+
+// strEscape quotes the string escaping the control characters. Single quotes are used unless the string
+// contains them, in which case double quotes or backticks are tried first.
+func strEscape(s string) string {
+	quote := byte('\'')
+	if strings.IndexByte(s, '\'') != -1 {
+		if strings.IndexByte(s, '"') == -1 {
+			quote = '"'
+		} else if strings.IndexByte(s, '`') == -1 && !strings.Contains(s, "${") {
+			quote = '`'
+		}
+	}
+	var sb strings.Builder
+	sb.Grow(len(s) + 2)
+	sb.WriteByte(quote)
+	for _, c := range s {
+		switch {
+		case c == rune(quote) && quote == '\'':
+			sb.WriteString(`\'`)
+		case c == '\\':
+			sb.WriteString(`\\`)
+		case c == '\b':
+			sb.WriteString(`\b`)
+		case c == '\t':
+			sb.WriteString(`\t`)
+		case c == '\n':
+			sb.WriteString(`\n`)
+		case c == '\f':
+			sb.WriteString(`\f`)
+		case c == '\r':
+			sb.WriteString(`\r`)
+		case c < 0x20 || c >= 0x7f && c < 0xa0:
+			sb.WriteString(`\x`)
+			sb.WriteString(strings.ToUpper(strconv.FormatInt(int64(c)>>4, 16)))
+			sb.WriteString(strings.ToUpper(strconv.FormatInt(int64(c)&0xf, 16)))
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	sb.WriteByte(quote)
+	return sb.String()
+}
+
+// propKey is either a string or a symbol property key.
+type propKey struct {
+	name string
+	sym  *goja.Symbol
+}
+
+func (k propKey) value(r *goja.Runtime) goja.Value {
+	if k.sym != nil {
+		return k.sym
+	}
+	return r.ToValue(k.name)
+}
+
+type propDesc struct {
+	value, get, set goja.Value
+	enumerable      bool
+}
+
+func (ctx *inspectContext) getOwnPropertyDescriptor(o *goja.Object, key propKey) (propDesc, bool) {
+	d, ok := ctx.call(ctx.u.intrinsics().getOwnPropertyDescriptor, nil, o, key.value(ctx.r)).(*goja.Object)
+	if !ok {
+		return propDesc{}, false
+	}
+	return propDesc{
+		value:      d.Get("value"),
+		get:        d.Get("get"),
+		set:        d.Get("set"),
+		enumerable: d.Get("enumerable").ToBoolean(),
+	}, true
+}
+
+func (ctx *inspectContext) hasOwnProperty(o *goja.Object, key string) bool {
+	return ctx.call(ctx.u.intrinsics().hasOwnProperty, o, ctx.r.ToValue(key)).ToBoolean()
+}
+
+func (ctx *inspectContext) getKeys(o *goja.Object, showHidden bool) []propKey {
+	var keys []propKey
+	if showHidden {
+		names := ctx.call(ctx.u.intrinsics().getOwnPropertyNames, nil, o).(*goja.Object)
+		for i, l := 0, int(names.Get("length").ToInteger()); i < l; i++ {
+			keys = append(keys, propKey{name: names.Get(strconv.Itoa(i)).String()})
+		}
+	} else {
+		for _, name := range o.Keys() {
+			keys = append(keys, propKey{name: name})
+		}
+	}
+	isEnumerable := ctx.u.intrinsics().propertyIsEnumerable
+	for _, sym := range o.Symbols() {
+		if showHidden || ctx.call(isEnumerable, o, sym).ToBoolean() {
+			keys = append(keys, propKey{sym: sym})
+		}
+	}
+	return keys
+}
+
+func isArrayIndex(s string) bool {
+	if s == "" || len(s) > 1 && s[0] == '0' {
+		return false
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	return err == nil && n < math.MaxUint32
+}
+
+// getNonIndexKeys returns the keys of an array-like object excluding the indices.
+func (ctx *inspectContext) getNonIndexKeys(o *goja.Object) []propKey {
+	keys := ctx.getKeys(o, ctx.showHidden)
+	res := keys[:0]
+	for _, k := range keys {
+		if k.sym != nil || !isArrayIndex(k.name) {
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+func (ctx *inspectContext) getConstructorName(o *goja.Object) (string, bool) {
+	var firstProto *goja.Object
+	for obj, i := o, 0; obj != nil; i++ {
+		if desc, ok := ctx.getOwnPropertyDescriptor(obj, propKey{name: "constructor"}); ok {
+			if fn, ok := desc.value.(*goja.Object); ok {
+				if _, ok := goja.AssertFunction(fn); ok {
+					if name := fn.Get("name"); name != nil && name.String() != "" && ctx.r.InstanceOf(o, fn) {
+						return name.String(), true
+					}
+				}
+			}
+		}
+		obj = obj.Prototype()
+		if i == 0 {
+			firstProto = obj
+		}
+	}
+	if firstProto == nil {
+		return "", false
+	}
+	protoConstr, ok := ctx.getConstructorName(firstProto)
+	if !ok {
+		return o.ClassName() + " <[Object: null prototype] {}>", true
+	}
+	return o.ClassName() + " <" + protoConstr + ">", true
+}
+
+// getPrefix returns the "Constructor [tag] " prefix. hasCtor is false for objects with null prototype.
+func getPrefix(constructor string, hasCtor bool, tag, fallback, size string) string {
+	if !hasCtor {
+		if tag != "" && fallback != tag {
+			return "[" + fallback + size + ": null prototype] [" + tag + "] "
+		}
+		return "[" + fallback + size + ": null prototype] "
+	}
+	if tag != "" && constructor != tag {
+		return constructor + size + " [" + tag + "] "
+	}
+	return constructor + size + " "
+}
+
+// toStringTag returns the value of the Symbol.toStringTag property if it's a string.
+func toStringTag(o *goja.Object) string {
+	if v := o.GetSymbol(goja.SymToStringTag); v != nil && isString(v) {
+		return v.String()
+	}
+	return ""
+}
+
+type formatter func(o *goja.Object, recurseTimes int) []string
+
+func (ctx *inspectContext) formatRaw(value *goja.Object, recurseTimes int, typedArray bool) string {
+	r := ctx.r
+	intr := ctx.u.intrinsics()
+
+	constructor, hasCtor := ctx.getConstructorName(value)
+	tag := ""
+	if t := toStringTag(value); t != "" {
+		var own goja.Callable
+		if ctx.showHidden {
+			own = intr.hasOwnProperty
+		} else {
+			own = intr.propertyIsEnumerable
+		}
+		if !ctx.call(own, value, goja.SymToStringTag).ToBoolean() {
+			tag = t
+		}
+	}
+
+	var keys []propKey
+	base := ""
+	braces := [2]string{"{", "}"}
+	extrasType := kObjectType
+	var format formatter = func(*goja.Object, int) []string { return nil }
+
+	switch {
+	case value.ClassName() == "Array":
+		keys = ctx.getNonIndexKeys(value)
+		prefix := ""
+		if constructor != "Array" || tag != "" {
+			prefix = getPrefix(constructor, hasCtor, tag, "Array", "("+value.Get("length").String()+")")
+		}
+		braces = [2]string{prefix + "[", "]"}
+		if value.Get("length").ToInteger() == 0 && len(keys) == 0 {
+			return braces[0] + "]"
+		}
+		extrasType = kArrayExtrasType
+		format = ctx.formatArray
+	case value.ClassName() == "Arguments":
+		keys = ctx.getNonIndexKeys(value)
+		braces = [2]string{"[Arguments] [", "]"}
+		if value.Get("length").ToInteger() == 0 && len(keys) == 0 {
+			return braces[0] + "]"
+		}
+		extrasType = kArrayExtrasType
+		format = ctx.formatArray
+	case ctx.u.typeChecker().IsSet(value):
+		keys = ctx.getKeys(value, ctx.showHidden)
+		n := int(ctx.call(intr.setSize, value).ToInteger())
+		prefix := getPrefix(constructor, hasCtor, tag, "Set", "("+strconv.Itoa(n)+")")
+		if n == 0 && len(keys) == 0 {
+			return prefix + "{}"
+		}
+		braces = [2]string{prefix + "{", "}"}
+		format = ctx.formatSet
+	case ctx.u.typeChecker().IsMap(value):
+		keys = ctx.getKeys(value, ctx.showHidden)
+		n := int(ctx.call(intr.mapSize, value).ToInteger())
+		prefix := getPrefix(constructor, hasCtor, tag, "Map", "("+strconv.Itoa(n)+")")
+		if n == 0 && len(keys) == 0 {
+			return prefix + "{}"
+		}
+		braces = [2]string{prefix + "{", "}"}
+		format = ctx.formatMap
+	case ctx.u.typeChecker().IsTypedArray(value):
+		keys = ctx.getNonIndexKeys(value)
+		fallback := ""
+		if !hasCtor {
+			fallback = tag
+		}
+		size := value.Get("length").String()
+		prefix := getPrefix(constructor, hasCtor, tag, fallback, "("+size+")")
+		braces = [2]string{prefix + "[", "]"}
+		if value.Get("length").ToInteger() == 0 && len(keys) == 0 && !ctx.showHidden {
+			return braces[0] + "]"
+		}
+		format = ctx.formatTypedArray
+		extrasType = kArrayExtrasType
+	default:
+		keys = ctx.getKeys(value, ctx.showHidden)
+		if _, isFunc := goja.AssertFunction(value); isFunc {
+			base = ctx.getFunctionBase(value, constructor, hasCtor, tag)
+			if len(keys) == 0 {
+				return ctx.stylize(base, "special")
+			}
+			break
+		}
+		switch value.ClassName() {
+		case "RegExp":
+			base = ctx.call(intr.regexpToString, value).String()
+			if prefix := getPrefix(constructor, hasCtor, tag, "RegExp", ""); prefix != "RegExp " {
+				base = prefix + base
+			}
+			if len(keys) == 0 {
+				return ctx.stylize(base, "regexp")
+			}
+		case "Date":
+			if math.IsNaN(ctx.call(intr.dateGetTime, value).ToFloat()) {
+				base = ctx.call(intr.dateToString, value).String()
+			} else {
+				base = ctx.call(intr.dateToISOString, value).String()
+			}
+			if prefix := getPrefix(constructor, hasCtor, tag, "Date", ""); prefix != "Date " {
+				base = prefix + base
+			}
+			if len(keys) == 0 {
+				return ctx.stylize(base, "date")
+			}
+		case "Error":
+			base = ctx.formatError(value, constructor, hasCtor, tag, &keys)
+			if len(keys) == 0 {
+				return base
+			}
+		case "Number", "String", "Boolean":
+			base = ctx.getBoxedBase(value, &keys, constructor, hasCtor, tag)
+			if len(keys) == 0 {
+				return base
+			}
+		default:
+			// Export() would copy the whole object, running its getters, so only the cheap types are exported.
+			var exported interface{}
+			switch value.ExportType() {
+			case reflectTypeArrayBuffer, reflectTypePromise:
+				exported = value.Export()
+			}
+			switch v := exported.(type) {
+			case goja.ArrayBuffer:
+				prefix := getPrefix(constructor, hasCtor, tag, "ArrayBuffer", "")
+				if !typedArray {
+					format = func(*goja.Object, int) []string {
+						return ctx.formatArrayBuffer(v.Bytes())
+					}
+				} else if len(keys) == 0 {
+					return prefix + "{ byteLength: " + ctx.formatNumber(r.ToValue(len(v.Bytes()))) + " }"
+				}
+				braces[0] = prefix + "{"
+				keys = append([]propKey{{name: "byteLength"}}, keys...)
+			case *goja.Promise:
+				braces[0] = getPrefix(constructor, hasCtor, tag, "Promise", "") + "{"
+				format = func(*goja.Object, int) []string {
+					return ctx.formatPromise(v, recurseTimes)
+				}
+			default:
+				switch {
+				case ctx.u.typeChecker().IsDataView(value):
+					braces[0] = getPrefix(constructor, hasCtor, tag, "DataView", "") + "{"
+					keys = append([]propKey{{name: "byteLength"}, {name: "byteOffset"}, {name: "buffer"}}, keys...)
+				case ctx.u.typeChecker().IsWeakSet(value):
+					braces[0] = getPrefix(constructor, hasCtor, tag, "WeakSet", "") + "{"
+					format = ctx.formatWeakCollection
+				case ctx.u.typeChecker().IsWeakMap(value):
+					braces[0] = getPrefix(constructor, hasCtor, tag, "WeakMap", "") + "{"
+					format = ctx.formatWeakCollection
+				case hasCtor && constructor == "Object" && tag == "":
+					if len(keys) == 0 {
+						return "{}"
+					}
+				default:
+					if len(keys) == 0 {
+						return getPrefix(constructor, hasCtor, tag, "Object", "") + "{}"
+					}
+					braces[0] = getPrefix(constructor, hasCtor, tag, "Object", "") + "{"
+				}
+			}
+		}
+	}
+
+	if float64(recurseTimes) > ctx.depth {
+		name := getPrefix(constructor, hasCtor, tag, "Object", "")
+		name = name[:len(name)-1]
+		if hasCtor {
+			name = "[" + name + "]"
+		}
+		return ctx.stylize(name, "special")
+	}
+	recurseTimes++
+
+	ctx.seen = append(ctx.seen, value)
+	ctx.currentDepth = recurseTimes
+	output := format(value, recurseTimes)
+	for _, key := range keys {
+		output = append(output, ctx.formatProperty(value, recurseTimes, key, extrasType))
+	}
+	ctx.seen = ctx.seen[:len(ctx.seen)-1]
+
+	if ctx.circular != nil {
+		if index, ok := ctx.circularIndex(value); ok {
+			reference := ctx.stylize("<ref *"+strconv.Itoa(index)+">", "special")
+			if ctx.compact != compactAll {
+				if base == "" {
+					base = reference
+				} else {
+					base = reference + " " + base
+				}
+			} else {
+				braces[0] = reference + " " + braces[0]
+			}
+		}
+	}
+
+	if ctx.sorted {
+		if extrasType == kObjectType {
+			ctx.sortStrings(output)
+		} else if len(keys) > 1 {
+			ctx.sortStrings(output[len(output)-len(keys):])
+		}
+	}
+
+	return ctx.reduceToSingleString(output, base, braces, extrasType, recurseTimes, value)
+}
+
+func (ctx *inspectContext) sortStrings(s []string) {
+	if ctx.sortFn == nil {
+		sort.Strings(s)
+		return
+	}
+	sort.SliceStable(s, func(i, j int) bool {
+		return ctx.call(ctx.sortFn, nil, ctx.r.ToValue(s[i]), ctx.r.ToValue(s[j])).ToFloat() < 0
+	})
+}
+
+func (ctx *inspectContext) getFunctionBase(value *goja.Object, constructor string, hasCtor bool, tag string) string {
+	src := ctx.call(ctx.u.intrinsics().functionToString, value).String()
+	if strings.HasPrefix(src, "class") && strings.HasSuffix(src, "}") {
+		return ctx.getClassBase(value, constructor, hasCtor, tag)
+	}
+	typ := "Function"
+	if t := toStringTag(value); t != "" {
+		switch t {
+		case "GeneratorFunction", "AsyncFunction", "AsyncGeneratorFunction":
+			typ = t
+		}
+	}
+	base := "[" + typ
+	if !hasCtor {
+		base += " (null prototype)"
+	}
+	if name := value.Get("name"); name == nil || name.String() == "" {
+		base += " (anonymous)"
+	} else {
+		base += ": " + name.String()
+	}
+	base += "]"
+	if hasCtor && constructor != typ {
+		base += " " + constructor
+	}
+	if tag != "" && constructor != tag {
+		base += " [" + tag + "]"
+	}
+	return base
+}
+
+func (ctx *inspectContext) getClassBase(value *goja.Object, constructor string, hasCtor bool, tag string) string {
+	name := ""
+	if ctx.hasOwnProperty(value, "name") {
+		if n := value.Get("name"); n != nil {
+			name = n.String()
+		}
+	}
+	base := "class"
+	if name != "" {
+		base += " " + name
+	} else {
+		base += " (anonymous)"
+	}
+	if constructor != "Function" && hasCtor {
+		base += " [" + constructor + "]"
+	}
+	if tag != "" && constructor != tag {
+		base += " [" + tag + "]"
+	}
+	if !hasCtor {
+		base += " extends [null prototype]"
+	} else if superClass := value.Prototype(); superClass != nil {
+		if superName := superClass.Get("name"); superName != nil && superName.String() != "" {
+			base += " extends " + superName.String()
+		}
+	}
+	return "[" + base + "]"
+}
+
+func (ctx *inspectContext) getBoxedBase(value *goja.Object, keys *[]propKey, constructor string, hasCtor bool, tag string) string {
+	typ := value.ClassName()
+	prim := ctx.call(ctx.u.intrinsics().valueOf[typ], value)
+	if typ == "String" {
+		n := int(value.Get("length").ToInteger())
+		filtered := (*keys)[:0]
+		for _, k := range *keys {
+			if k.sym == nil && isArrayIndex(k.name) {
+				if idx, _ := strconv.Atoi(k.name); idx < n {
+					continue
+				}
+			}
+			filtered = append(filtered, k)
+		}
+		*keys = filtered
+	}
+	base := "[" + typ
+	if typ != constructor {
+		if !hasCtor {
+			base += " (null prototype)"
+		} else {
+			base += " (" + constructor + ")"
+		}
+	}
+	base += ": " + ctx.noColor().formatPrimitive(prim) + "]"
+	if tag != "" && tag != constructor {
+		base += " [" + tag + "]"
+	}
+	if len(*keys) != 0 {
+		return base
+	}
+	return ctx.stylize(base, strings.ToLower(typ))
+}
+
+func (ctx *inspectContext) formatError(err *goja.Object, constructor string, hasCtor bool, tag string, keys *[]propKey) string {
+	name := "Error"
+	if n := err.Get("name"); n != nil && !goja.IsUndefined(n) && !goja.IsNull(n) {
+		name = n.String()
+	}
+	var stack string
+	if s := err.Get("stack"); s != nil && !goja.IsUndefined(s) && !goja.IsNull(s) && s.String() != "" {
+		stack = strings.TrimRight(s.String(), "\n")
+	} else {
+		stack = err.String()
+	}
+
+	// Remove the duplicate keys if they are contained in the stack.
+	if !ctx.showHidden && len(*keys) != 0 {
+		filtered := (*keys)[:0]
+		for _, k := range *keys {
+			if k.sym == nil && (k.name == "name" || k.name == "message" || k.name == "stack") {
+				if v := err.Get(k.name); v != nil && strings.Contains(stack, v.String()) {
+					continue
+				}
+			}
+			filtered = append(filtered, k)
+		}
+		*keys = filtered
+	}
+
+	if cause := err.Get("cause"); cause != nil && !goja.IsUndefined(cause) && ctx.hasOwnProperty(err, "cause") {
+		found := false
+		for _, k := range *keys {
+			if k.sym == nil && k.name == "cause" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*keys = append(*keys, propKey{name: "cause"})
+		}
+	}
+
+	stack = improveStack(stack, constructor, hasCtor, name, tag)
+
+	// Ignore the error message if it's contained in the stack.
+	pos := -1
+	if msg := err.Get("message"); msg != nil && !goja.IsUndefined(msg) && msg.String() != "" {
+		if pos = strings.Index(stack, msg.String()); pos != -1 {
+			pos += len(msg.String())
+		}
+	}
+	start := 0
+	if pos > 0 {
+		start = pos
+	}
+	if stackStart := stackTraceStart(stack[start:]); stackStart == -1 {
+		stack = "[" + stack + "]"
+	}
+
+	if ctx.indentationLvl != 0 {
+		stack = strings.ReplaceAll(stack, "\n", "\n"+strings.Repeat(" ", ctx.indentationLvl))
+	}
+	return stack
+}
+
+// stackTraceStart returns the position of the first stack frame line or -1.
+func stackTraceStart(s string) int {
+	for i := strings.IndexByte(s, '\n'); i != -1; {
+		line := strings.TrimLeft(s[i+1:], " \t")
+		if strings.HasPrefix(line, "at ") && len(line) < len(s[i+1:]) {
+			return i
+		}
+		next := strings.IndexByte(s[i+1:], '\n')
+		if next == -1 {
+			break
+		}
+		i += next + 1
+	}
+	return -1
+}
+
+func improveStack(stack, constructor string, hasCtor bool, name, tag string) string {
+	l := len(name)
+	if !hasCtor || strings.HasSuffix(name, "Error") && strings.HasPrefix(stack, name) &&
+		(len(stack) == l || stack[l] == ':' || stack[l] == '\n') {
+		fallback := "Error"
+		if !hasCtor {
+			fallback = ""
+			if m := errorNameRegEx.FindStringSubmatch(stack); m != nil {
+				fallback = m[1]
+			} else if m := errorNameOnly.FindStringSubmatch(stack); m != nil {
+				fallback = m[1]
+			}
+			l = len(fallback)
+			if fallback == "" {
+				fallback = "Error"
+			}
+		}
+		prefix := getPrefix(constructor, hasCtor, tag, fallback, "")
+		prefix = prefix[:len(prefix)-1]
+		if name != prefix {
+			if strings.Contains(prefix, name) {
+				if l == 0 {
+					stack = prefix + ": " + stack
+				} else {
+					stack = prefix + stack[l:]
+				}
+			} else {
+				stack = prefix + " [" + name + "]" + stack[l:]
+			}
+		}
+	}
+	return stack
+}
+
+func (ctx *inspectContext) formatProperty(value *goja.Object, recurseTimes int, key propKey, typ int) string {
+	desc, ok := ctx.getOwnPropertyDescriptor(value, key)
+	if !ok {
+		var v goja.Value
+		if key.sym != nil {
+			v = value.GetSymbol(key.sym)
+		} else {
+			v = value.Get(key.name)
+		}
+		desc = propDesc{value: v, enumerable: true}
+	}
+	var str string
+	extra := " "
+	switch {
+	case desc.value != nil && !goja.IsUndefined(desc.value):
+		diff := 2
+		if ctx.compact == compactAll && typ == kObjectType {
+			diff = 3
+		}
+		ctx.indentationLvl += diff
+		str = ctx.formatValue(desc.value, recurseTimes, false)
+		if diff == 3 && ctx.breakLength < float64(getStringWidth(str, ctx.colors)) {
+			extra = "\n" + strings.Repeat(" ", ctx.indentationLvl)
+		}
+		ctx.indentationLvl -= diff
+	case desc.get != nil && !goja.IsUndefined(desc.get):
+		label := "Getter"
+		if desc.set != nil && !goja.IsUndefined(desc.set) {
+			label = "Getter/Setter"
+		}
+		if ctx.getters {
+			str = ctx.formatGetter(value, desc.get, label, recurseTimes)
+		} else {
+			str = ctx.stylize("["+label+"]", "special")
+		}
+	case desc.set != nil && !goja.IsUndefined(desc.set):
+		str = ctx.stylize("[Setter]", "special")
+	default:
+		str = ctx.stylize("undefined", "undefined")
+	}
+	if typ == kArrayType {
+		return str
+	}
+	var name string
+	switch {
+	case key.sym != nil:
+		name = "[" + ctx.stylize(symbolString(key.sym), "symbol") + "]"
+	case key.name == "__proto__":
+		name = "['__proto__']"
+	case !desc.enumerable:
+		name = "[" + key.name + "]"
+	case keyStrRegExp.MatchString(key.name):
+		name = ctx.stylize(key.name, "name")
+	default:
+		name = ctx.stylize(strEscape(key.name), "string")
+	}
+	return name + ":" + extra + str
+}
+
+func (ctx *inspectContext) formatGetter(value *goja.Object, getter goja.Value, label string, recurseTimes int) string {
+	fn, _ := goja.AssertFunction(getter)
+	tmp, err := fn(value)
+	if err != nil {
+		msg := err.Error()
+		if ex, ok := err.(*goja.Exception); ok {
+			if o, ok := ex.Value().(*goja.Object); ok {
+				if m := o.Get("message"); m != nil {
+					msg = m.String()
+				}
+			}
+		}
+		return ctx.stylize("["+label+": <Inspection threw ("+msg+")>]", "special")
+	}
+	if _, ok := tmp.(*goja.Object); ok {
+		return ctx.stylize("["+label+"]", "special") + " " + ctx.formatValue(tmp, recurseTimes, false)
+	}
+	return ctx.stylize("["+label+":", "special") + " " + ctx.formatPrimitive(tmp) + ctx.stylize("]", "special")
+}
+
+func (ctx *inspectContext) formatArray(value *goja.Object, recurseTimes int) []string {
+	valLen := int(value.Get("length").ToInteger())
+	maxLen := ctx.maxArrayLength
+	if valLen < maxLen {
+		maxLen = valLen
+	}
+	var output []string
+	for i := 0; i < maxLen; i++ {
+		key := strconv.Itoa(i)
+		// Special handle sparse arrays.
+		if !ctx.hasOwnProperty(value, key) {
+			return ctx.formatSpecialArray(value, recurseTimes, maxLen, output, i)
+		}
+		output = append(output, ctx.formatProperty(value, recurseTimes, propKey{name: key}, kArrayType))
+	}
+	if remaining := valLen - maxLen; remaining > 0 {
+		output = append(output, remainingText(remaining))
+	}
+	return output
+}
+
+// formatSpecialArray formats a sparse array, starting from the hole at index i, by walking its own keys rather
+// than all the indexes, so that huge sparse arrays are formatted quickly.
+func (ctx *inspectContext) formatSpecialArray(value *goja.Object, recurseTimes, maxLength int, output []string,
+	i int) []string {
+	keys := ctx.call(ctx.u.intrinsics().objectKeys, nil, value).(*goja.Object)
+	keysLen := int(keys.Get("length").ToInteger())
+	index := i
+	for ; i < keysLen && len(output) < maxLength; i++ {
+		key := keys.Get(strconv.Itoa(i)).String()
+		tmp, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || strconv.FormatUint(tmp, 10) != key {
+			break
+		}
+		// Arrays can only have up to 2^32 - 1 entries.
+		if tmp > 1<<32-2 {
+			break
+		}
+		if strconv.Itoa(index) != key {
+			emptyItems := int(tmp) - index
+			output = append(output, ctx.stylize("<"+strconv.Itoa(emptyItems)+" empty item"+plural(emptyItems)+">", "undefined"))
+			index = int(tmp)
+			if len(output) == maxLength {
+				break
+			}
+		}
+		output = append(output, ctx.formatProperty(value, recurseTimes, propKey{name: key}, kArrayType))
+		index++
+	}
+	remaining := int(value.Get("length").ToInteger()) - index
+	if len(output) != maxLength {
+		if remaining > 0 {
+			output = append(output, ctx.stylize("<"+strconv.Itoa(remaining)+" empty item"+plural(remaining)+">", "undefined"))
+		}
+	} else if remaining > 0 {
+		output = append(output, remainingText(remaining))
+	}
+	return output
+}
+
+func (ctx *inspectContext) formatTypedArray(value *goja.Object, recurseTimes int) []string {
+	length := int(value.Get("length").ToInteger())
+	maxLen := ctx.maxArrayLength
+	if length < maxLen {
+		maxLen = length
+	}
+	output := make([]string, 0, maxLen+1)
+	for i := 0; i < maxLen; i++ {
+		output = append(output, ctx.formatNumber(value.Get(strconv.Itoa(i))))
+	}
+	if remaining := length - maxLen; remaining > 0 {
+		output = append(output, remainingText(remaining))
+	}
+	if ctx.showHidden {
+		ctx.indentationLvl += 2
+		for _, key := range []string{"BYTES_PER_ELEMENT", "length", "byteLength", "byteOffset", "buffer"} {
+			str := ctx.formatValue(value.Get(key), recurseTimes, true)
+			output = append(output, "["+key+"]: "+str)
+		}
+		ctx.indentationLvl -= 2
+	}
+	return output
+}
+
+func (ctx *inspectContext) formatArrayBuffer(b []byte) []string {
+	l := len(b)
+	if ctx.maxArrayLength < l {
+		l = ctx.maxArrayLength
+	}
+	str := hexBytes(b[:l])
+	if remaining := len(b) - ctx.maxArrayLength; remaining > 0 {
+		str += " ... " + strconv.Itoa(remaining) + " more byte" + plural(remaining)
+	}
+	return []string{ctx.stylize("[Uint8Contents]", "special") + ": <" + str + ">"}
+}
+
+func hexBytes(b []byte) string {
+	const digits = "0123456789abcdef"
+	var sb strings.Builder
+	for i, c := range b {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteByte(digits[c>>4])
+		sb.WriteByte(digits[c&0xf])
+	}
+	return sb.String()
+}
+
+func (ctx *inspectContext) formatSet(value *goja.Object, recurseTimes int) []string {
+	var output []string
+	remaining := 0
+	ctx.indentationLvl += 2
+	ctx.r.ForOf(ctx.call(ctx.u.intrinsics().setValues, value), func(v goja.Value) bool {
+		if len(output) >= ctx.maxArrayLength {
+			remaining++
+			return true
+		}
+		output = append(output, ctx.formatValue(v, recurseTimes, false))
+		return true
+	})
+	if remaining > 0 {
+		output = append(output, remainingText(remaining))
+	}
+	ctx.indentationLvl -= 2
+	return output
+}
+
+func (ctx *inspectContext) formatMap(value *goja.Object, recurseTimes int) []string {
+	var output []string
+	remaining := 0
+	ctx.indentationLvl += 2
+	ctx.r.ForOf(ctx.call(ctx.u.intrinsics().mapEntries, value), func(v goja.Value) bool {
+		if len(output) >= ctx.maxArrayLength {
+			remaining++
+			return true
+		}
+		entry := v.(*goja.Object)
+		output = append(output, ctx.formatValue(entry.Get("0"), recurseTimes, false)+" => "+
+			ctx.formatValue(entry.Get("1"), recurseTimes, false))
+		return true
+	})
+	if remaining > 0 {
+		output = append(output, remainingText(remaining))
+	}
+	ctx.indentationLvl -= 2
+	return output
+}
+
+func (ctx *inspectContext) formatWeakCollection(*goja.Object, int) []string {
+	return []string{ctx.stylize("<items unknown>", "special")}
+}
+
+func (ctx *inspectContext) formatPromise(p *goja.Promise, recurseTimes int) []string {
+	if p.State() == goja.PromiseStatePending {
+		return []string{ctx.stylize("<pending>", "special")}
+	}
+	ctx.indentationLvl += 2
+	str := ctx.formatValue(p.Result(), recurseTimes, false)
+	ctx.indentationLvl -= 2
+	if p.State() == goja.PromiseStateRejected {
+		return []string{ctx.stylize("<rejected>", "special") + " " + str}
+	}
+	return []string{str}
+}
+
+func getStringWidth(s string, removeColors bool) int {
+	if removeColors {
+		s = colorRegExp.ReplaceAllString(s, "")
+	}
+	return utf8.RuneCountInString(s)
+}
+
+func (ctx *inspectContext) isBelowBreakLength(output []string, start int, base string) bool {
+	totalLength := len(output) + start
+	if float64(totalLength+len(output)) > ctx.breakLength {
+		return false
+	}
+	for _, s := range output {
+		totalLength += getStringWidth(s, ctx.colors)
+		if float64(totalLength) > ctx.breakLength {
+			return false
+		}
+	}
+	return base == "" || !strings.Contains(base, "\n")
+}
+
+// groupArrayElements combines the array entries into columns if the output has many short entries.
+func (ctx *inspectContext) groupArrayElements(output []string, value *goja.Object) []string {
+	totalLength := 0
+	maxLength := 0
+	outputLength := len(output)
+	if ctx.maxArrayLength < len(output) {
+		// This makes sure the "... n more items" part is not taken into account.
+		outputLength--
+	}
+	const separatorSpace = 2 // Add 1 for the space and 1 for the separator.
+	dataLen := make([]int, outputLength)
+	for i := 0; i < outputLength; i++ {
+		l := getStringWidth(output[i], ctx.colors)
+		dataLen[i] = l
+		totalLength += l + separatorSpace
+		if maxLength < l {
+			maxLength = l
+		}
+	}
+	actualMax := maxLength + separatorSpace
+	if float64(actualMax*3+ctx.indentationLvl) < ctx.breakLength &&
+		(float64(totalLength)/float64(actualMax) > 5 || maxLength <= 6) {
+		const approxCharHeights = 2.5
+		averageBias := math.Sqrt(float64(actualMax) - float64(totalLength)/float64(len(output)))
+		biasedMax := math.Max(float64(actualMax)-3-averageBias, 1)
+		columns := math.Min(math.Min(
+			math.Round(math.Sqrt(approxCharHeights*biasedMax*float64(outputLength))/biasedMax),
+			math.Floor((ctx.breakLength-float64(ctx.indentationLvl))/float64(actualMax))),
+			math.Min(float64(ctx.compact*4), 15))
+		if columns <= 1 {
+			return output
+		}
+		cols := int(columns)
+		var tmp []string
+		maxLineLength := make([]int, 0, cols)
+		for i := 0; i < cols; i++ {
+			lineLength := 0
+			for j := i; j < outputLength; j += cols {
+				if dataLen[j] > lineLength {
+					lineLength = dataLen[j]
+				}
+			}
+			maxLineLength = append(maxLineLength, lineLength+separatorSpace)
+		}
+		padStart := true
+		for i := 0; i < outputLength; i++ {
+			if isNumber(value.Get(strconv.Itoa(i))) {
+				continue
+			}
+			padStart = false
+			break
+		}
+		for i := 0; i < outputLength; i += cols {
+			max := i + cols
+			if outputLength < max {
+				max = outputLength
+			}
+			var sb strings.Builder
+			j := i
+			for ; j < max-1; j++ {
+				padding := maxLineLength[j-i] + utf8.RuneCountInString(output[j]) - dataLen[j]
+				sb.WriteString(pad(output[j]+", ", padding, padStart))
+			}
+			if padStart {
+				padding := maxLineLength[j-i] + utf8.RuneCountInString(output[j]) - dataLen[j] - separatorSpace
+				sb.WriteString(pad(output[j], padding, true))
+			} else {
+				sb.WriteString(output[j])
+			}
+			tmp = append(tmp, sb.String())
+		}
+		if ctx.maxArrayLength < len(output) {
+			tmp = append(tmp, output[outputLength])
+		}
+		output = tmp
+	}
+	return output
+}
+
+// pad pads the string with spaces to the given length in characters.
+func pad(s string, length int, start bool) string {
+	if n := length - utf8.RuneCountInString(s); n > 0 {
+		if start {
+			return strings.Repeat(" ", n) + s
+		}
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+func (ctx *inspectContext) reduceToSingleString(output []string, base string, braces [2]string, extrasType, recurseTimes int, value *goja.Object) string {
+	if ctx.compact != compactAll {
+		if ctx.compact >= 1 {
+			entries := len(output)
+			if extrasType == kArrayExtrasType && entries > 6 {
+				output = ctx.groupArrayElements(output, value)
+			}
+			if ctx.currentDepth-recurseTimes < ctx.compact && entries == len(output) {
+				start := len(output) + ctx.indentationLvl + utf8.RuneCountInString(braces[0]) + len(base) + 10
+				if ctx.isBelowBreakLength(output, start, base) {
+					joined := strings.Join(output, ", ")
+					if !strings.Contains(joined, "\n") {
+						if base != "" {
+							base += " "
+						}
+						return base + braces[0] + " " + joined + " " + braces[1]
+					}
+				}
+			}
+		}
+		indentation := "\n" + strings.Repeat(" ", ctx.indentationLvl)
+		if base != "" {
+			base += " "
+		}
+		return base + braces[0] + indentation + "  " + strings.Join(output, ","+indentation+"  ") + indentation + braces[1]
+	}
+	if ctx.isBelowBreakLength(output, 0, base) {
+		if base != "" {
+			base = " " + base
+		}
+		return braces[0] + base + " " + strings.Join(output, ", ") + " " + braces[1]
+	}
+	indentation := strings.Repeat(" ", ctx.indentationLvl)
+	var ln string
+	if base == "" && len(braces[0]) == 1 {
+		ln = " "
+	} else {
+		if base != "" {
+			base = " " + base
+		}
+		ln = base + "\n" + indentation + "  "
+	}
+	return braces[0] + ln + strings.Join(output, ",\n"+indentation+"  ") + " " + braces[1]
+}
@@ -2,32 +2,139 @@ package util
 
 import (
 	"bytes"
+	"regexp"
+	"strings"
+
 	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+	"github.com/nuvolaris/goja_nodejs/internal/hooks"
 	"github.com/nuvolaris/goja_nodejs/require"
+	"github.com/nuvolaris/goja_nodejs/util/types"
 )
 
 const ModuleName = "util"
 
 type Util struct {
 	runtime *goja.Runtime
+
+	intr            *intrinsics
+	inspectObj      *goja.Object
+	customInspect   *goja.Symbol
+	customPromisify *goja.Symbol
+	types           *types.Types
+
+	codesWarned map[string]bool
+	debugEnv    *regexp.Regexp
+	debugImpls  map[string]goja.Value
+}
+
+// builtInObjects contains the names of the built-in constructors. Objects whose toString() comes from one of these
+// are formatted with inspect() by %s.
+var builtInObjects = map[string]struct{}{
+	"Object": {}, "Function": {}, "Array": {}, "Number": {}, "Boolean": {}, "String": {}, "Symbol": {}, "Date": {},
+	"Promise": {}, "RegExp": {}, "Error": {}, "AggregateError": {}, "EvalError": {}, "RangeError": {},
+	"ReferenceError": {}, "SyntaxError": {}, "TypeError": {}, "URIError": {}, "ArrayBuffer": {}, "Uint8Array": {},
+	"Int8Array": {}, "Uint16Array": {}, "Int16Array": {}, "Uint32Array": {}, "Int32Array": {}, "Float32Array": {},
+	"Float64Array": {}, "Uint8ClampedArray": {}, "DataView": {}, "Map": {}, "Set": {}, "WeakMap": {}, "WeakSet": {},
+	"Proxy": {}, "Reflect": {}, "WeakRef": {},
+}
+
+// hasBuiltInToString returns true if the object's toString() method is inherited from a built-in prototype.
+func (ctx *inspectContext) hasBuiltInToString(o *goja.Object) bool {
+	if _, ok := goja.AssertFunction(o.Get("toString")); !ok {
+		return true
+	}
+	if ctx.hasOwnProperty(o, "toString") {
+		return false
+	}
+	pointer := o
+	for {
+		pointer = pointer.Prototype()
+		if pointer == nil {
+			return true
+		}
+		if ctx.hasOwnProperty(pointer, "toString") {
+			break
+		}
+	}
+	desc, ok := ctx.getOwnPropertyDescriptor(pointer, propKey{name: "constructor"})
+	if !ok {
+		return false
+	}
+	ctor, ok := desc.value.(*goja.Object)
+	if !ok {
+		return false
+	}
+	if _, ok := goja.AssertFunction(ctor); !ok {
+		return false
+	}
+	_, ok = builtInObjects[ctor.Get("name").String()]
+	return ok
+}
+
+// toString converts the value to a string the same way String() does.
+func toString(v goja.Value) string {
+	if sym, ok := v.(*goja.Symbol); ok {
+		return symbolString(sym)
+	}
+	return v.String()
+}
+
+func (ctx *inspectContext) jsonStringify(val goja.Value) string {
+	res, err := ctx.u.intrinsics().jsonStringify(goja.Undefined(), val)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			if o, ok := ex.Value().(*goja.Object); ok && strings.Contains(strings.ToLower(o.Get("message").String()), "circular") {
+				return "[Circular]"
+			}
+		}
+		panic(err)
+	}
+	return res.String()
 }
 
-func (u *Util) format(f rune, val goja.Value, w *bytes.Buffer) bool {
+// format writes the formatted argument for the specifier f. It returns false if the specifier does not consume
+// an argument.
+func (ctx *inspectContext) format(f rune, val goja.Value, w *bytes.Buffer) bool {
 	switch f {
 	case 's':
-		w.WriteString(val.String())
+		switch {
+		case isNumber(val):
+			w.WriteString(ctx.noColor().formatNumber(val))
+		case !isObject(val) || isFunction(val) || !ctx.hasBuiltInToString(val.(*goja.Object)):
+			w.WriteString(toString(val))
+		default:
+			opts := ctx.inspectOptions
+			opts.depth, opts.colors, opts.compact = 0, false, 3
+			w.WriteString(ctx.u.inspect(val, opts))
+		}
 	case 'd':
-		w.WriteString(val.ToNumber().String())
-	case 'j':
-		if json, ok := u.runtime.Get("JSON").(*goja.Object); ok {
-			if stringify, ok := goja.AssertFunction(json.Get("stringify")); ok {
-				res, err := stringify(json, val)
-				if err != nil {
-					panic(err)
-				}
-				w.WriteString(res.String())
-			}
+		if _, ok := val.(*goja.Symbol); ok {
+			w.WriteString("NaN")
+		} else {
+			w.WriteString(ctx.noColor().formatNumber(val.ToNumber()))
+		}
+	case 'i':
+		if _, ok := val.(*goja.Symbol); ok {
+			w.WriteString("NaN")
+		} else {
+			w.WriteString(ctx.noColor().formatNumber(ctx.call(ctx.u.intrinsics().parseInt, goja.Undefined(), val)))
+		}
+	case 'f':
+		if _, ok := val.(*goja.Symbol); ok {
+			w.WriteString("NaN")
+		} else {
+			w.WriteString(ctx.noColor().formatNumber(ctx.call(ctx.u.intrinsics().parseFloat, goja.Undefined(), val)))
 		}
+	case 'j':
+		w.WriteString(ctx.jsonStringify(val))
+	case 'o':
+		opts := ctx.inspectOptions
+		opts.showHidden, opts.depth = true, 4
+		w.WriteString(ctx.u.inspect(val, opts))
+	case 'O':
+		w.WriteString(ctx.u.inspect(val, ctx.inspectOptions))
+	case 'c':
 	case '%':
 		w.WriteByte('%')
 		return false
@@ -39,15 +146,52 @@ func (u *Util) format(f rune, val goja.Value, w *bytes.Buffer) bool {
 	return true
 }
 
+func isNumber(v goja.Value) bool {
+	if isObject(v) {
+		return false
+	}
+	switch v.Export().(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+func isFunction(v goja.Value) bool {
+	_, ok := goja.AssertFunction(v)
+	return ok
+}
+
+func isObject(v goja.Value) bool {
+	_, ok := v.(*goja.Object)
+	return ok
+}
+
+// Format formats the arguments like util.format() does and writes the result into the buffer.
 func (u *Util) Format(b *bytes.Buffer, f string, args ...goja.Value) {
+	u.formatWithOptions(b, u.defaultInspectOptions(), f, args...)
+}
+
+func (u *Util) formatWithOptions(b *bytes.Buffer, opts inspectOptions, f string, args ...goja.Value) {
+	if len(args) == 0 {
+		b.WriteString(f)
+		return
+	}
+	ctx := &inspectContext{
+		u:              u,
+		r:              u.runtime,
+		inspectOptions: opts,
+	}
 	pct := false
 	argNum := 0
 	for _, chr := range f {
 		if pct {
 			if argNum < len(args) {
-				if u.format(chr, args[argNum], b) {
+				if ctx.format(chr, args[argNum], b) {
 					argNum++
 				}
+			} else if chr == '%' {
+				b.WriteByte('%')
 			} else {
 				b.WriteByte('%')
 				b.WriteRune(chr)
@@ -61,28 +205,61 @@ func (u *Util) Format(b *bytes.Buffer, f string, args ...goja.Value) {
 			}
 		}
 	}
+	if pct {
+		b.WriteByte('%')
+	}
 
 	for _, arg := range args[argNum:] {
 		b.WriteByte(' ')
-		b.WriteString(arg.String())
+		u.writeInspected(b, opts, arg)
 	}
 }
 
-func (u *Util) js_format(call goja.FunctionCall) goja.Value {
-	var b bytes.Buffer
-	var fmt string
+// writeInspected writes strings as is and the other values using inspect().
+func (u *Util) writeInspected(b *bytes.Buffer, opts inspectOptions, arg goja.Value) {
+	if s, ok := arg.(goja.String); ok {
+		b.WriteString(s.String())
+	} else if s, ok := arg.Export().(string); ok && !isObject(arg) && !isSymbol(arg) {
+		b.WriteString(s)
+	} else {
+		b.WriteString(u.inspect(arg, opts))
+	}
+}
 
-	if arg := call.Argument(0); !goja.IsUndefined(arg) {
-		fmt = arg.String()
+func (u *Util) formatArgs(opts inspectOptions, args []goja.Value) goja.Value {
+	var b bytes.Buffer
+	if len(args) > 0 {
+		if _, ok := args[0].Export().(string); ok && !isObject(args[0]) && !isSymbol(args[0]) {
+			u.formatWithOptions(&b, opts, args[0].String(), args[1:]...)
+		} else {
+			// Like in nodejs, if the first argument is not a string all arguments are inspected.
+			for i, arg := range args {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				u.writeInspected(&b, opts, arg)
+			}
+		}
 	}
+	return u.runtime.ToValue(b.String())
+}
 
+func (u *Util) js_format(call goja.FunctionCall) goja.Value {
+	return u.formatArgs(u.defaultInspectOptions(), call.Arguments)
+}
+
+func (u *Util) js_formatWithOptions(call goja.FunctionCall) goja.Value {
+	o, ok := call.Argument(0).(*goja.Object)
+	if !ok {
+		panic(errors.NewArgTypeError(u.runtime, "inspectOptions", "of type object", call.Argument(0)))
+	}
+	opts := u.defaultInspectOptions()
+	u.applyInspectOptions(&opts, o)
 	var args []goja.Value
-	if len(call.Arguments) > 0 {
+	if len(call.Arguments) > 1 {
 		args = call.Arguments[1:]
 	}
-	u.Format(&b, fmt, args...)
-
-	return u.runtime.ToValue(b.String())
+	return u.formatArgs(opts, args)
 }
 
 func Require(runtime *goja.Runtime, module *goja.Object) {
@@ -91,6 +268,31 @@ func Require(runtime *goja.Runtime, module *goja.Object) {
 	}
 	obj := module.Get("exports").(*goja.Object)
 	obj.Set("format", u.js_format)
+	obj.Set("formatWithOptions", u.js_formatWithOptions)
+	obj.Set("inspect", u.inspectFunc())
+	obj.Set("promisify", u.promisifyFunc())
+	obj.Set("callbackify", u.js_callbackify)
+	obj.Set("inherits", u.js_inherits)
+	obj.Set("deprecate", u.js_deprecate)
+	obj.Set("debuglog", u.js_debuglog)
+	obj.Set("debug", obj.Get("debuglog"))
+	obj.Set("isDeepStrictEqual", u.js_isDeepStrictEqual)
+	obj.Set("toUSVString", u.js_toUSVString)
+	obj.Set("stripVTControlCharacters", u.js_stripVTControlCharacters)
+	obj.Set("getSystemErrorName", u.js_getSystemErrorName)
+	obj.Set("parseArgs", u.js_parseArgs)
+	obj.Set("styleText", u.js_styleText)
+	obj.Set("types", require.Require(runtime, types.ModuleName))
+	u.setLegacyTypeChecks(obj)
+	obj.Set("TextEncoder", u.createTextEncoderConstructor())
+	obj.Set("TextDecoder", u.createTextDecoderConstructor())
+}
+
+// Enable adds the TextEncoder and TextDecoder globals to the runtime.
+func Enable(runtime *goja.Runtime) {
+	m := require.Require(runtime, ModuleName).ToObject(runtime)
+	runtime.Set("TextEncoder", m.Get("TextEncoder"))
+	runtime.Set("TextDecoder", m.Get("TextDecoder"))
 }
 
 func New(runtime *goja.Runtime) *Util {
@@ -101,4 +303,7 @@ func New(runtime *goja.Runtime) *Util {
 
 func init() {
 	require.RegisterCoreModule(ModuleName, Require)
+	hooks.ShouldColorize = func(runtime *goja.Runtime, stream *goja.Object) bool {
+		return New(runtime).shouldColorize(stream)
+	}
 }
@@ -2,10 +2,15 @@ package util
 
 import (
 	"bytes"
+	_ "embed"
+	"fmt"
+	"runtime"
 	"testing"
 
 	"github.com/nuvolaris/goja"
+	_ "github.com/nuvolaris/goja_nodejs/buffer"
 	"github.com/nuvolaris/goja_nodejs/require"
+	_ "github.com/nuvolaris/goja_nodejs/url"
 )
 
 func TestUtil_Format(t *testing.T) {
@@ -56,6 +61,36 @@ func TestUtil_Format_MoreArgs(t *testing.T) {
 	}
 }
 
+func TestUtil_Format_Inspect(t *testing.T) {
+	vm := goja.New()
+	util := New(vm)
+
+	obj := vm.NewObject()
+	obj.Set("a", 1)
+	var b bytes.Buffer
+	util.Format(&b, "Test: %i %o", vm.ToValue("42.5"), vm.NewArray(1), obj, vm.ToValue("str"))
+
+	if res := b.String(); res != "Test: 42 [ 1, [length]: 1 ] { a: 1 } str" {
+		t.Fatalf("Unexpected result: '%s'", res)
+	}
+}
+
+func TestUtil_Format_Symbol(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	res, err := vm.RunString(`
+	const util = require("util");
+	[util.format("a", Symbol("s")), util.format(Symbol("s"), "a"), util.format("%s", Symbol("s"))].join("|")
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := res.String(); res != "a Symbol(s)|Symbol(s) a|Symbol(s)" {
+		t.Fatalf("Unexpected result: '%s'", res)
+	}
+}
+
 func TestJSNoArgs(t *testing.T) {
 	vm := goja.New()
 	new(require.Registry).Enable(vm)
@@ -72,3 +107,179 @@ func TestJSNoArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestTextEncoderDecoder(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+	Enable(vm)
+
+	_, err := vm.RunString(`
+	const assert = require("../assert.js");
+
+	{
+		const enc = new TextEncoder();
+		assert.sameValue(enc.encoding, "utf-8");
+		const b = enc.encode("a€");
+		assert.sameValue(b instanceof Uint8Array, true);
+		assert.sameValue(b.join(), "97,226,130,172");
+		assert.sameValue(enc.encode().length, 0);
+
+		const dest = new Uint8Array(5);
+		const res = enc.encodeInto("a💩b", dest);
+		assert.sameValue(res.read, 3);
+		assert.sameValue(res.written, 5);
+		assert.sameValue(dest.join(), "97,240,159,146,169");
+		assert.throwsNodeError(() => enc.encodeInto("a", []), TypeError, "ERR_INVALID_ARG_TYPE");
+	}
+
+	{
+		const dec = new TextDecoder();
+		assert.sameValue(dec.encoding, "utf-8");
+		assert.sameValue(dec.fatal, false);
+		assert.sameValue(dec.ignoreBOM, false);
+		const b = new Uint8Array([0xEF, 0xBB, 0xBF, 0x61, 0xE2, 0x82, 0xAC]);
+		assert.sameValue(dec.decode(b), "a€");
+		assert.sameValue(dec.decode(b.buffer), "a€");
+		assert.sameValue(dec.decode(new DataView(b.buffer, 4)), "€");
+		assert.sameValue(dec.decode(), "");
+		assert.sameValue(dec.decode(b.subarray(0, 5), {stream: true}), "a");
+		assert.sameValue(dec.decode(b.subarray(5, 6), {stream: true}), "");
+		assert.sameValue(dec.decode(b.subarray(6)), "€");
+		assert.sameValue(dec.decode(b.subarray(4, 6)), "�");
+		assert.sameValue(new TextDecoder("utf-8", {ignoreBOM: true}).decode(b), "\ufeffa€");
+	}
+
+	{
+		const dec = new TextDecoder("utf-8", {fatal: true});
+		assert.sameValue(dec.fatal, true);
+		assert.throwsNodeError(() => dec.decode(new Uint8Array([0x61, 0xFF])), TypeError, "ERR_ENCODING_INVALID_ENCODED_DATA");
+		assert.throwsNodeError(() => dec.decode(new Uint8Array([0xE2, 0x82])), TypeError, "ERR_ENCODING_INVALID_ENCODED_DATA");
+		assert.sameValue(dec.decode(new Uint8Array([0xE2, 0x82]), {stream: true}), "");
+		assert.sameValue(dec.decode(new Uint8Array([0xAC])), "€");
+	}
+
+	{
+		const dec = new TextDecoder("UTF-16");
+		assert.sameValue(dec.encoding, "utf-16le");
+		assert.sameValue(dec.decode(new Uint8Array([0xFF, 0xFE, 0x61, 0x00, 0x3D, 0xD8]), {stream: true}), "a");
+		assert.sameValue(dec.decode(new Uint8Array([0xA9, 0xDC])), "💩");
+		assert.throwsNodeError(() => new TextDecoder("utf-16le", {fatal: true}).decode(new Uint8Array([0x3D, 0xD8])), TypeError, "ERR_ENCODING_INVALID_ENCODED_DATA");
+	}
+
+	{
+		const dec = new TextDecoder(" Latin1 ");
+		assert.sameValue(dec.encoding, "windows-1252");
+		assert.sameValue(dec.decode(new Uint8Array([0x61, 0xE9, 0x80])), "aé€");
+	}
+
+	assert.throwsNodeError(() => new TextDecoder("nope"), RangeError, "ERR_ENCODING_NOT_SUPPORTED");
+	assert.throwsNodeError(() => new TextDecoder().decode("str"), TypeError, "ERR_INVALID_ARG_TYPE");
+	assert.sameValue(require("util").TextDecoder, TextDecoder);
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+//go:embed testdata/inspect.js
+var inspectTest string
+
+func TestInspect(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunScript("testdata/inspect.js", inspectTest)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			t.Fatal(ex.String())
+		}
+		t.Fatal(err)
+	}
+}
+
+//go:embed testdata/format.js
+var formatTest string
+
+func TestFormat(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunScript("testdata/format.js", formatTest)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			t.Fatal(ex.String())
+		}
+		t.Fatal(err)
+	}
+}
+
+//go:embed testdata/util.js
+var utilTest string
+
+func TestUtilFunctions(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunScript("testdata/util.js", utilTest)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			t.Fatal(ex.String())
+		}
+		t.Fatal(err)
+	}
+	res := vm.Get("results").Export()
+	if s := fmt.Sprint(res); s != "[add 3 fail failed throw thrown this 42 cb null 42 cb rejected cb ERR_FALSY_VALUE_REJECTION null]" {
+		t.Fatal(s)
+	}
+}
+
+func TestUtil_GetSystemErrorName(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the error codes are platform specific")
+	}
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	res, err := vm.RunString(`const util = require("util"); [util.getSystemErrorName(-2), util.getSystemErrorName(-13)].join()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.String(); s != "ENOENT,EACCES" {
+		t.Fatal(s)
+	}
+}
+
+func TestDebuglogProcessEnv(t *testing.T) {
+	t.Setenv("NODE_DEBUG", "foo")
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	// The environment of the process takes the place of the one of the OS.
+	res, err := vm.RunString(`
+	globalThis.process = { env: {} };
+	require("util").debuglog("foo").enabled
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ToBoolean() {
+		t.Fatal("debuglog is enabled by the environment of the OS")
+	}
+}
+
+//go:embed testdata/parseargs.js
+var parseArgsTest string
+
+func TestParseArgsStyleText(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunScript("testdata/parseargs.js", parseArgsTest)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			t.Fatal(ex.String())
+		}
+		t.Fatal(err)
+	}
+}
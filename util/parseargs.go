@@ -0,0 +1,480 @@
+package util
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// This is a port of util.parseArgs() from nodejs lib/internal/util/parse_args/parse_args.js
+
+type parseArgsOption struct {
+	name     string
+	typ      string
+	short    string
+	multiple bool
+	def      goja.Value
+}
+
+type parseArgsOptions struct {
+	list   []*parseArgsOption
+	byName map[string]*parseArgsOption
+}
+
+// get returns the configuration of the option or nil if there is no such option.
+func (o *parseArgsOptions) get(name string) *parseArgsOption {
+	return o.byName[name]
+}
+
+func (o *parseArgsOptions) typeOf(name string) string {
+	if opt := o.get(name); opt != nil {
+		return opt.typ
+	}
+	return ""
+}
+
+// findLongOptionForShort returns the name of the option with the given short alias, or the short option itself
+// if there is none.
+func (o *parseArgsOptions) findLongOptionForShort(short string) string {
+	for _, opt := range o.list {
+		if opt.short == short {
+			return opt.name
+		}
+	}
+	return short
+}
+
+const (
+	tokenOption           = "option"
+	tokenPositional       = "positional"
+	tokenOptionTerminator = "option-terminator"
+)
+
+type parseArgsToken struct {
+	kind        string
+	name        string
+	rawName     string
+	index       int
+	value       goja.Value
+	inlineValue goja.Value
+}
+
+func (t *parseArgsToken) toObject(r *goja.Runtime) *goja.Object {
+	o := r.NewObject()
+	o.Set("kind", t.kind)
+	switch t.kind {
+	case tokenOption:
+		o.Set("name", t.name)
+		o.Set("rawName", t.rawName)
+		o.Set("index", t.index)
+		o.Set("value", orUndefined(t.value))
+		o.Set("inlineValue", orUndefined(t.inlineValue))
+	case tokenPositional:
+		o.Set("index", t.index)
+		o.Set("value", t.value)
+	default:
+		o.Set("index", t.index)
+	}
+	return o
+}
+
+func orUndefined(v goja.Value) goja.Value {
+	if v == nil {
+		return goja.Undefined()
+	}
+	return v
+}
+
+func isNullish(v goja.Value) bool {
+	return v == nil || goja.IsUndefined(v) || goja.IsNull(v)
+}
+
+func isLoneShortOption(arg []rune) bool {
+	return len(arg) == 2 && arg[0] == '-' && arg[1] != '-'
+}
+
+func isLoneLongOption(arg []rune) bool {
+	return len(arg) > 2 && arg[0] == '-' && arg[1] == '-' && !strings.ContainsRune(string(arg[3:]), '=')
+}
+
+func isLongOptionAndValue(arg []rune) bool {
+	return len(arg) > 2 && arg[0] == '-' && arg[1] == '-' && strings.ContainsRune(string(arg[3:]), '=')
+}
+
+func isShortOptionGroup(arg []rune, options *parseArgsOptions) bool {
+	if len(arg) <= 2 || arg[0] != '-' || arg[1] == '-' {
+		return false
+	}
+	return options.typeOf(options.findLongOptionForShort(string(arg[1]))) != "string"
+}
+
+func isShortOptionAndValue(arg []rune, options *parseArgsOptions) bool {
+	if len(arg) <= 2 || arg[0] != '-' || arg[1] == '-' {
+		return false
+	}
+	return options.typeOf(options.findLongOptionForShort(string(arg[1]))) == "string"
+}
+
+// isOptionLikeValue returns true if the value looks like an option, i.e. it is ambiguous whether it is meant
+// to be the value of the previous option.
+func isOptionLikeValue(v goja.Value) bool {
+	if isNullish(v) {
+		return false
+	}
+	s := []rune(v.String())
+	return len(s) > 1 && s[0] == '-'
+}
+
+// argsToTokens is the first phase of parsing, it identifies the options, their values and the positionals.
+func (u *Util) argsToTokens(args []string, options *parseArgsOptions) []*parseArgsToken {
+	r := u.runtime
+	var tokens []*parseArgsToken
+	index := -1
+	groupCount := 0
+	remainingArgs := append([]string(nil), args...)
+	for len(remainingArgs) > 0 {
+		arg := []rune(remainingArgs[0])
+		remainingArgs = remainingArgs[1:]
+		hasNext := len(remainingArgs) > 0
+		if groupCount > 0 {
+			groupCount--
+		} else {
+			index++
+		}
+
+		if string(arg) == "--" {
+			tokens = append(tokens, &parseArgsToken{kind: tokenOptionTerminator, index: index})
+			for _, a := range remainingArgs {
+				index++
+				tokens = append(tokens, &parseArgsToken{kind: tokenPositional, index: index, value: r.ToValue(a)})
+			}
+			break
+		}
+
+		if isLoneShortOption(arg) {
+			longOption := options.findLongOptionForShort(string(arg[1]))
+			t := &parseArgsToken{kind: tokenOption, name: longOption, rawName: string(arg), index: index}
+			if options.typeOf(longOption) == "string" && hasNext {
+				t.value = r.ToValue(remainingArgs[0])
+				t.inlineValue = r.ToValue(false)
+				remainingArgs = remainingArgs[1:]
+			}
+			tokens = append(tokens, t)
+			if t.value != nil {
+				index++
+			}
+			continue
+		}
+
+		if isShortOptionGroup(arg, options) {
+			var expanded []string
+			for i := 1; i < len(arg); i++ {
+				shortOption := string(arg[i])
+				longOption := options.findLongOptionForShort(shortOption)
+				if options.typeOf(longOption) != "string" || i == len(arg)-1 {
+					expanded = append(expanded, "-"+shortOption)
+				} else {
+					// The rest of the group is the value of the string option.
+					expanded = append(expanded, "-"+string(arg[i:]))
+					break
+				}
+			}
+			remainingArgs = append(expanded, remainingArgs...)
+			groupCount = len(expanded)
+			continue
+		}
+
+		if isShortOptionAndValue(arg, options) {
+			shortOption := string(arg[1])
+			tokens = append(tokens, &parseArgsToken{
+				kind:        tokenOption,
+				name:        options.findLongOptionForShort(shortOption),
+				rawName:     "-" + shortOption,
+				index:       index,
+				value:       r.ToValue(string(arg[2:])),
+				inlineValue: r.ToValue(true),
+			})
+			continue
+		}
+
+		if isLoneLongOption(arg) {
+			longOption := string(arg[2:])
+			t := &parseArgsToken{kind: tokenOption, name: longOption, rawName: string(arg), index: index}
+			if options.typeOf(longOption) == "string" && hasNext {
+				t.value = r.ToValue(remainingArgs[0])
+				t.inlineValue = r.ToValue(false)
+				remainingArgs = remainingArgs[1:]
+			}
+			tokens = append(tokens, t)
+			if t.value != nil {
+				index++
+			}
+			continue
+		}
+
+		if isLongOptionAndValue(arg) {
+			s := string(arg)
+			equalIndex := strings.IndexByte(s, '=')
+			longOption := s[2:equalIndex]
+			tokens = append(tokens, &parseArgsToken{
+				kind:        tokenOption,
+				name:        longOption,
+				rawName:     "--" + longOption,
+				index:       index,
+				value:       r.ToValue(s[equalIndex+1:]),
+				inlineValue: r.ToValue(true),
+			})
+			continue
+		}
+
+		tokens = append(tokens, &parseArgsToken{kind: tokenPositional, index: index, value: r.ToValue(string(arg))})
+	}
+	return tokens
+}
+
+// objectGetOwn returns the value of the own property or nil if there is no such property.
+func (u *Util) objectGetOwn(o *goja.Object, name string) goja.Value {
+	if u.callIntrinsic(u.intrinsics().hasOwnProperty, o, u.runtime.ToValue(name)).ToBoolean() {
+		return o.Get(name)
+	}
+	return nil
+}
+
+func (u *Util) validateBoolean(v goja.Value, name string) bool {
+	b, ok := v.Export().(bool)
+	if !ok || isObject(v) {
+		panic(errors.NewArgTypeError(u.runtime, name, "of type boolean", v))
+	}
+	return b
+}
+
+func (u *Util) validateString(v goja.Value, name string) string {
+	if !isString(v) {
+		panic(errors.NewArgTypeError(u.runtime, name, "of type string", v))
+	}
+	return v.String()
+}
+
+func (u *Util) validateArray(v goja.Value, name string) *goja.Object {
+	if !isArray(v) {
+		panic(errors.NewArgTypeError(u.runtime, name, "an instance of Array", v))
+	}
+	return v.(*goja.Object)
+}
+
+func (u *Util) validateObject(v goja.Value, name string) *goja.Object {
+	if !isObjectNotFunction(v) || isArray(v) {
+		panic(errors.NewArgTypeError(u.runtime, name, "of type object", v))
+	}
+	return v.(*goja.Object)
+}
+
+func (u *Util) parseArgsOptions(o *goja.Object) *parseArgsOptions {
+	options := &parseArgsOptions{
+		byName: make(map[string]*parseArgsOption),
+	}
+	for _, name := range o.Keys() {
+		prefix := "options." + name
+		optionConfig := u.validateObject(o.Get(name), prefix)
+		opt := &parseArgsOption{name: name}
+
+		switch typ := u.objectGetOwn(optionConfig, "type"); {
+		case typ != nil && isString(typ) && (typ.String() == "string" || typ.String() == "boolean"):
+			opt.typ = typ.String()
+		default:
+			panic(errors.NewArgTypeError(u.runtime, prefix+".type", "('string|boolean')", orUndefined(typ)))
+		}
+
+		if short := u.objectGetOwn(optionConfig, "short"); short != nil {
+			opt.short = u.validateString(short, prefix+".short")
+			if len([]rune(opt.short)) != 1 {
+				panic(errors.NewArgValueError(u.runtime, prefix+".short", "must be a single character", u.Inspect(short)))
+			}
+		}
+
+		if multiple := u.objectGetOwn(optionConfig, "multiple"); multiple != nil {
+			opt.multiple = u.validateBoolean(multiple, prefix+".multiple")
+		}
+
+		if def := optionConfig.Get("default"); def != nil && !goja.IsUndefined(def) {
+			name := prefix + ".default"
+			validate := u.validateBoolean
+			if opt.typ == "string" {
+				validate = func(v goja.Value, name string) bool {
+					u.validateString(v, name)
+					return true
+				}
+			}
+			if opt.multiple {
+				arr := u.validateArray(def, name)
+				for i, l := 0, int(arr.Get("length").ToInteger()); i < l; i++ {
+					validate(arr.Get(strconv.Itoa(i)), name+"["+strconv.Itoa(i)+"]")
+				}
+			} else {
+				validate(def, name)
+			}
+			opt.def = def
+		}
+
+		options.list = append(options.list, opt)
+		options.byName[name] = opt
+	}
+	return options
+}
+
+// mainArgs returns process.argv without the executable and the script.
+func (u *Util) mainArgs() goja.Value {
+	r := u.runtime
+	var argv []string
+	if proc := u.process(); proc != nil && proc.Get("argv") != nil {
+		if err := r.ExportTo(proc.Get("argv"), &argv); err != nil {
+			panic(err)
+		}
+	} else {
+		argv = os.Args
+	}
+	if len(argv) > 2 {
+		argv = argv[2:]
+	} else {
+		argv = nil
+	}
+	return r.ToValue(argv)
+}
+
+func (u *Util) checkOptionUsage(options *parseArgsOptions, allowPositionals bool, t *parseArgsToken) {
+	r := u.runtime
+	opt := options.get(t.name)
+	if opt == nil {
+		suggestDashDash := ""
+		if allowPositionals {
+			suggestDashDash = ". To specify a positional argument starting with a '-', place it at the end of " +
+				"the command after '--', as in '-- " + u.callIntrinsic(u.intrinsics().jsonStringify, nil, r.ToValue(t.rawName)).String() + "'"
+		}
+		panic(errors.NewTypeError(r, errors.ErrCodeParseArgsUnknownOption, "Unknown option '%s'%s", t.rawName, suggestDashDash))
+	}
+	shortAndLong := "--" + t.name
+	if opt.short != "" {
+		shortAndLong = "-" + opt.short + ", " + shortAndLong
+	}
+	if opt.typ == "string" && !(t.value != nil && isString(t.value)) {
+		panic(errors.NewTypeError(r, errors.ErrCodeParseArgsInvalidOptionValue, "Option '%s <value>' argument missing", shortAndLong))
+	}
+	if opt.typ == "boolean" && !isNullish(t.value) {
+		panic(errors.NewTypeError(r, errors.ErrCodeParseArgsInvalidOptionValue, "Option '%s' does not take an argument", shortAndLong))
+	}
+}
+
+func (u *Util) checkOptionLikeValue(t *parseArgsToken) {
+	if (t.inlineValue == nil || !t.inlineValue.ToBoolean()) && isOptionLikeValue(t.value) {
+		var example string
+		if strings.HasPrefix(t.rawName, "--") {
+			example = "'" + t.rawName + "=-XYZ'"
+		} else {
+			example = "'--" + t.name + "=-XYZ' or '" + t.rawName + "-XYZ'"
+		}
+		panic(errors.NewTypeError(u.runtime, errors.ErrCodeParseArgsInvalidOptionValue,
+			"Option '%s' argument is ambiguous.\nDid you forget to specify the option argument for '%s'?\n"+
+				"To specify an option argument starting with a dash use %s.", t.rawName, t.rawName, example))
+	}
+}
+
+func (u *Util) storeOption(values *goja.Object, options *parseArgsOptions, name string, value goja.Value) {
+	if name == "__proto__" {
+		return
+	}
+	if isNullish(value) {
+		value = u.runtime.ToValue(true)
+	}
+	if opt := options.get(name); opt != nil && opt.multiple {
+		if existing, ok := values.Get(name).(*goja.Object); ok {
+			existing.Set(strconv.FormatInt(existing.Get("length").ToInteger(), 10), value)
+		} else {
+			values.Set(name, u.runtime.NewArray(value))
+		}
+		return
+	}
+	values.Set(name, value)
+}
+
+func (u *Util) js_parseArgs(call goja.FunctionCall) goja.Value {
+	r := u.runtime
+	config := r.NewObject()
+	if c := call.Argument(0); !goja.IsUndefined(c) {
+		config = c.ToObject(r)
+	}
+	getOwn := func(name string, def func() goja.Value) goja.Value {
+		if v := u.objectGetOwn(config, name); !isNullish(v) {
+			return v
+		}
+		return def()
+	}
+
+	argsVal := getOwn("args", u.mainArgs)
+	strict := getOwn("strict", func() goja.Value { return r.ToValue(true) })
+	allowPositionals := getOwn("allowPositionals", func() goja.Value { return r.ToValue(!strict.ToBoolean()) })
+	returnTokens := getOwn("tokens", func() goja.Value { return r.ToValue(false) })
+	optionsVal := getOwn("options", func() goja.Value {
+		o := r.NewObject()
+		o.SetPrototype(nil)
+		return o
+	})
+
+	argsObj := u.validateArray(argsVal, "args")
+	isStrict := u.validateBoolean(strict, "strict")
+	positionalsAllowed := u.validateBoolean(allowPositionals, "allowPositionals")
+	withTokens := u.validateBoolean(returnTokens, "tokens")
+	options := u.parseArgsOptions(u.validateObject(optionsVal, "options"))
+
+	var args []string
+	for i, l := 0, int(argsObj.Get("length").ToInteger()); i < l; i++ {
+		args = append(args, argsObj.Get(strconv.Itoa(i)).String())
+	}
+
+	// Phase 1: identify the tokens.
+	tokens := u.argsToTokens(args, options)
+
+	// Phase 2: process the tokens into option values and positionals.
+	values := r.NewObject()
+	values.SetPrototype(nil)
+	var positionals []interface{}
+	for _, t := range tokens {
+		switch t.kind {
+		case tokenOption:
+			if isStrict {
+				u.checkOptionUsage(options, positionalsAllowed, t)
+				u.checkOptionLikeValue(t)
+			}
+			u.storeOption(values, options, t.name, t.value)
+		case tokenPositional:
+			if !positionalsAllowed {
+				panic(errors.NewTypeError(r, errors.ErrCodeParseArgsUnexpectedPositional,
+					"Unexpected argument '%s'. This command does not take positional arguments", t.value))
+			}
+			positionals = append(positionals, t.value)
+		}
+	}
+
+	// Phase 3: fill in the default values of the missing options.
+	for _, opt := range options.list {
+		if opt.def != nil && opt.name != "__proto__" {
+			if v := values.Get(opt.name); v == nil || goja.IsUndefined(v) {
+				values.Set(opt.name, opt.def)
+			}
+		}
+	}
+
+	result := r.NewObject()
+	result.Set("values", values)
+	result.Set("positionals", r.NewArray(positionals...))
+	if withTokens {
+		list := make([]interface{}, 0, len(tokens))
+		for _, t := range tokens {
+			list = append(list, t.toObject(r))
+		}
+		result.Set("tokens", r.NewArray(list...))
+	}
+	return result
+}
@@ -0,0 +1,184 @@
+package util
+
+import (
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// promisifyCustomSymbol returns Symbol.for('nodejs.util.promisify.custom').
+func (u *Util) promisifyCustomSymbol() *goja.Symbol {
+	if u.customPromisify == nil {
+		sym, err := u.intrinsics().symbolFor(nil, u.runtime.ToValue("nodejs.util.promisify.custom"))
+		if err != nil {
+			panic(err)
+		}
+		u.customPromisify = sym.(*goja.Symbol)
+	}
+	return u.customPromisify
+}
+
+func (u *Util) callIntrinsic(fn goja.Callable, this goja.Value, args ...goja.Value) goja.Value {
+	res, err := fn(this, args...)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// copyFunctionProperties makes the wrapper look like the original function: it gets the same prototype and
+// the own properties of the original. The modify callback, if not nil, can adjust the descriptors before
+// they are applied.
+func (u *Util) copyFunctionProperties(wrapper, original *goja.Object, modify func(descriptors *goja.Object)) {
+	intr := u.intrinsics()
+	wrapper.SetPrototype(original.Prototype())
+	descriptors := u.callIntrinsic(intr.getOwnPropertyDescriptors, nil, original).(*goja.Object)
+	if modify != nil {
+		modify(descriptors)
+	}
+	u.callIntrinsic(intr.defineProperties, nil, wrapper, descriptors)
+}
+
+// nextTick calls fn with the arguments using process.nextTick() if it's available, otherwise it calls it
+// immediately.
+func (u *Util) nextTick(fn goja.Value, args ...goja.Value) {
+	if proc, ok := u.runtime.Get("process").(*goja.Object); ok {
+		if nextTick, ok := goja.AssertFunction(proc.Get("nextTick")); ok {
+			if _, err := nextTick(proc, append([]goja.Value{fn}, args...)...); err != nil {
+				panic(err)
+			}
+			return
+		}
+	}
+	f, _ := goja.AssertFunction(fn)
+	if _, err := f(goja.Undefined(), args...); err != nil {
+		panic(err)
+	}
+}
+
+func (u *Util) js_promisify(call goja.FunctionCall) goja.Value {
+	r := u.runtime
+	original, ok := call.Argument(0).(*goja.Object)
+	if !ok || !isFunction(original) {
+		panic(errors.NewArgTypeError(r, "original", "of type function", call.Argument(0)))
+	}
+	custom := u.promisifyCustomSymbol()
+	if fn := original.GetSymbol(custom); fn != nil && !goja.IsUndefined(fn) {
+		if !isFunction(fn) {
+			panic(errors.NewArgTypeError(r, "util.promisify.custom", "of type function", fn))
+		}
+		fn.(*goja.Object).DefineDataPropertySymbol(custom, fn, goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+		return fn
+	}
+
+	orig, _ := goja.AssertFunction(original)
+	wrapper := r.ToValue(func(call goja.FunctionCall) goja.Value {
+		p, resolve, reject := r.NewPromise()
+		args := make([]goja.Value, 0, len(call.Arguments)+1)
+		args = append(args, call.Arguments...)
+		args = append(args, r.ToValue(func(call goja.FunctionCall) goja.Value {
+			if err := call.Argument(0); err.ToBoolean() {
+				reject(err)
+			} else {
+				resolve(call.Argument(1))
+			}
+			return goja.Undefined()
+		}))
+		if _, err := orig(call.This, args...); err != nil {
+			if ex, ok := err.(*goja.Exception); ok {
+				reject(ex.Value())
+			} else {
+				panic(err)
+			}
+		}
+		return r.ToValue(p)
+	}).(*goja.Object)
+	wrapper.DefineDataPropertySymbol(custom, wrapper, goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	u.copyFunctionProperties(wrapper, original, nil)
+	return wrapper
+}
+
+func (u *Util) promisifyFunc() *goja.Object {
+	r := u.runtime
+	fn := r.ToValue(u.js_promisify).(*goja.Object)
+	fn.DefineDataProperty("name", r.ToValue("promisify"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	fn.DefineDataProperty("custom", u.promisifyCustomSymbol(), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+	return fn
+}
+
+func (u *Util) js_callbackify(call goja.FunctionCall) goja.Value {
+	r := u.runtime
+	original, ok := call.Argument(0).(*goja.Object)
+	if !ok || !isFunction(original) {
+		panic(errors.NewArgTypeError(r, "original", "of type function", call.Argument(0)))
+	}
+	orig, _ := goja.AssertFunction(original)
+
+	wrapper := r.ToValue(func(call goja.FunctionCall) goja.Value {
+		var cb goja.Value = goja.Undefined()
+		args := call.Arguments
+		if len(args) > 0 {
+			cb = args[len(args)-1]
+			args = args[:len(args)-1]
+		}
+		if !isFunction(cb) {
+			panic(errors.NewArgTypeError(r, "last argument", "of type function", cb))
+		}
+		res, err := orig(call.This, args...)
+		if err != nil {
+			panic(err)
+		}
+		then, ok := goja.AssertFunction(res.ToObject(r).Get("then"))
+		if !ok {
+			panic(r.NewTypeError("The result of the original function is not a thenable"))
+		}
+		onFulfilled := func(call goja.FunctionCall) goja.Value {
+			u.nextTick(cb, goja.Null(), call.Argument(0))
+			return goja.Undefined()
+		}
+		onRejected := func(call goja.FunctionCall) goja.Value {
+			reason := call.Argument(0)
+			if !reason.ToBoolean() {
+				e := errors.NewError(r, nil, errors.ErrCodeFalsyValueRejection, "Promise was rejected with falsy value")
+				e.Set("reason", reason)
+				reason = e
+			}
+			u.nextTick(cb, reason)
+			return goja.Undefined()
+		}
+		if _, err := then(res, r.ToValue(onFulfilled), r.ToValue(onRejected)); err != nil {
+			panic(err)
+		}
+		return goja.Undefined()
+	}).(*goja.Object)
+
+	u.copyFunctionProperties(wrapper, original, func(descriptors *goja.Object) {
+		if d, ok := descriptors.Get("length").(*goja.Object); ok && isNumber(d.Get("value")) {
+			d.Set("value", d.Get("value").ToInteger()+1)
+		}
+		if d, ok := descriptors.Get("name").(*goja.Object); ok {
+			if name, ok := d.Get("value").(goja.String); ok {
+				d.Set("value", name.String()+"Callbackified")
+			}
+		}
+	})
+	return wrapper
+}
+
+func (u *Util) js_inherits(call goja.FunctionCall) goja.Value {
+	r := u.runtime
+	ctor, superCtor := call.Argument(0), call.Argument(1)
+	if goja.IsUndefined(ctor) || goja.IsNull(ctor) {
+		panic(errors.NewArgTypeError(r, "ctor", "of type function", ctor))
+	}
+	if goja.IsUndefined(superCtor) || goja.IsNull(superCtor) {
+		panic(errors.NewArgTypeError(r, "superCtor", "of type function", superCtor))
+	}
+	superProto := superCtor.ToObject(r).Get("prototype")
+	if superProto == nil || goja.IsUndefined(superProto) {
+		panic(errors.NewArgTypeError(r, "superCtor.prototype", "of type object", goja.Undefined()))
+	}
+	c := ctor.ToObject(r)
+	c.DefineDataProperty("super_", superCtor, goja.FLAG_TRUE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	u.callIntrinsic(u.intrinsics().setPrototypeOf, nil, c.Get("prototype"), superProto)
+	return goja.Undefined()
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"regexp"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// ansiRegExp matches the ANSI escape sequences, it's the same expression as in nodejs lib/internal/util/inspect.js
+var ansiRegExp = regexp.MustCompile(`[\x{001B}\x{009B}][[\]()#;?]*` +
+	`(?:(?:(?:(?:;[-a-zA-Z\d\/#&.:=?%@~_]+)*` +
+	`|[a-zA-Z\d]+(?:;[-a-zA-Z\d\/#&.:=?%@~_]*)*)?` +
+	`(?:\x{0007}|\x{001B}\x{005C}|\x{009C}))` +
+	`|(?:(?:\d{1,4}(?:;\d{0,4})*)?[\dA-PR-TZcf-ntqry=><~]))`)
+
+func (u *Util) js_toUSVString(call goja.FunctionCall) goja.Value {
+	v := call.Argument(0)
+	if _, ok := v.(*goja.Symbol); ok {
+		panic(u.runtime.NewTypeError("Cannot convert a Symbol value to a string"))
+	}
+	// Converting to a Go string replaces the lone surrogates with U+FFFD.
+	return u.runtime.ToValue(v.ToString().String())
+}
+
+func (u *Util) js_stripVTControlCharacters(call goja.FunctionCall) goja.Value {
+	s, ok := call.Argument(0).(goja.String)
+	if !ok {
+		panic(errors.NewArgTypeError(u.runtime, "str", "of type string", call.Argument(0)))
+	}
+	return u.runtime.ToValue(ansiRegExp.ReplaceAllString(s.String(), ""))
+}
@@ -0,0 +1,131 @@
+package util
+
+import (
+	"os"
+	"strings"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// colorDepth returns the number of bits of color the stream supports, following the logic of
+// tty.WriteStream.getColorDepth() for the FORCE_COLOR, NODE_DISABLE_COLORS, NO_COLOR and TERM variables.
+func (u *Util) colorDepth() int {
+	if force, ok := u.getEnv("FORCE_COLOR"); ok {
+		switch force {
+		case "", "1", "true":
+			return 4
+		case "2":
+			return 8
+		case "3":
+			return 24
+		default:
+			return 1
+		}
+	}
+	if _, ok := u.getEnv("NODE_DISABLE_COLORS"); ok {
+		return 1
+	}
+	if _, ok := u.getEnv("NO_COLOR"); ok {
+		return 1
+	}
+	if term, _ := u.getEnv("TERM"); term == "dumb" {
+		return 1
+	}
+	return 4
+}
+
+// isTerminal reports whether the file is a character device.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// shouldColorize reports whether the ANSI escape codes should be written to the stream, which is a writable
+// stream object such as process.stdout. A nil stream means the standard output of the process.
+func (u *Util) shouldColorize(stream *goja.Object) bool {
+	if _, ok := u.getEnv("FORCE_COLOR"); ok {
+		return u.colorDepth() > 2
+	}
+	if stream == nil {
+		return isTerminal(os.Stdout) && u.colorDepth() > 2
+	}
+	if isTTY := stream.Get("isTTY"); isTTY == nil || !isTTY.ToBoolean() {
+		return false
+	}
+	if getColorDepth, ok := goja.AssertFunction(stream.Get("getColorDepth")); ok {
+		return u.callIntrinsic(getColorDepth, stream).ToInteger() > 2
+	}
+	return true
+}
+
+func escapeStyleCode(code goja.Value) string {
+	return "\x1b[" + code.String() + "m"
+}
+
+func (u *Util) js_styleText(call goja.FunctionCall) goja.Value {
+	r := u.runtime
+	format, text := call.Argument(0), call.Argument(1)
+	u.validateString(text, "text")
+
+	validateStream := true
+	var stream *goja.Object
+	if opts := call.Argument(2); !goja.IsUndefined(opts) {
+		o := opts.ToObject(r)
+		if v := o.Get("validateStream"); v != nil && !goja.IsUndefined(v) {
+			validateStream = u.validateBoolean(v, "options.validateStream")
+		}
+		if v := o.Get("stream"); v != nil && !goja.IsUndefined(v) {
+			s, ok := v.(*goja.Object)
+			if !ok && validateStream {
+				panic(errors.NewArgTypeError(r, "stream", "an instance of ReadableStream, WritableStream, or Stream", v))
+			}
+			stream = s
+		}
+	}
+	if stream == nil {
+		if proc := u.process(); proc != nil {
+			stream, _ = proc.Get("stdout").(*goja.Object)
+		}
+	}
+	skipColorize := validateStream && !u.shouldColorize(stream)
+
+	var formats []goja.Value
+	if isArray(format) {
+		r.ForOf(format, func(v goja.Value) bool {
+			formats = append(formats, v)
+			return true
+		})
+	} else {
+		formats = []goja.Value{format}
+	}
+
+	colors := u.inspectFunc().Get("colors").ToObject(r)
+	var left, right strings.Builder
+	var rights []string
+	for _, key := range formats {
+		if isString(key) && key.String() == "none" {
+			continue
+		}
+		codes, ok := u.objectGetOwn(colors, key.String()).(*goja.Object)
+		if !ok || !isString(key) {
+			var allowed []string
+			for _, name := range colors.Keys() {
+				allowed = append(allowed, "'"+name+"'")
+			}
+			panic(errors.NewArgValueError(r, "format", "must be one of: "+strings.Join(allowed, ", "), u.Inspect(key)))
+		}
+		if skipColorize {
+			continue
+		}
+		left.WriteString(escapeStyleCode(codes.Get("0")))
+		rights = append(rights, escapeStyleCode(codes.Get("1")))
+	}
+	if skipColorize {
+		return text
+	}
+	for i := len(rights) - 1; i >= 0; i-- {
+		right.WriteString(rights[i])
+	}
+	return r.ToValue(left.String() + text.String() + right.String())
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/errors"
+)
+
+// uvErrNames maps the (negative) libuv error codes to their names. The codes that libuv defines itself
+// are the same on all platforms, the others are added by the platform specific files.
+var uvErrNames = map[int]string{
+	-4095: "EOF",
+	-4094: "UNKNOWN",
+	-4080: "ECHARSET",
+	-4028: "EFTYPE",
+	-3000: "EAI_ADDRFAMILY",
+	-3001: "EAI_AGAIN",
+	-3002: "EAI_BADFLAGS",
+	-3003: "EAI_CANCELED",
+	-3004: "EAI_FAIL",
+	-3005: "EAI_FAMILY",
+	-3006: "EAI_MEMORY",
+	-3007: "EAI_NODATA",
+	-3008: "EAI_NONAME",
+	-3009: "EAI_OVERFLOW",
+	-3010: "EAI_SERVICE",
+	-3011: "EAI_SOCKTYPE",
+	-3013: "EAI_BADHINTS",
+	-3014: "EAI_PROTOCOL",
+}
+
+func (u *Util) js_getSystemErrorName(call goja.FunctionCall) goja.Value {
+	r := u.runtime
+	arg := call.Argument(0)
+	if !isNumber(arg) {
+		panic(errors.NewArgTypeError(r, "err", "of type number", arg))
+	}
+	f := arg.ToFloat()
+	if f >= 0 || f != math.Trunc(f) || f < -(1<<53-1) {
+		panic(errors.NewOutOfRangeError(r, "err", "a negative integer", arg))
+	}
+	if name, ok := uvErrNames[int(f)]; ok {
+		return r.ToValue(name)
+	}
+	return r.ToValue("Unknown system error " + strconv.FormatInt(int64(f), 10))
+}
@@ -0,0 +1,72 @@
+//go:build unix
+
+package util
+
+import "syscall"
+
+func init() {
+	for errno, name := range map[syscall.Errno]string{
+		syscall.E2BIG:           "E2BIG",
+		syscall.EACCES:          "EACCES",
+		syscall.EADDRINUSE:      "EADDRINUSE",
+		syscall.EADDRNOTAVAIL:   "EADDRNOTAVAIL",
+		syscall.EAFNOSUPPORT:    "EAFNOSUPPORT",
+		syscall.EAGAIN:          "EAGAIN",
+		syscall.EALREADY:        "EALREADY",
+		syscall.EBADF:           "EBADF",
+		syscall.EBUSY:           "EBUSY",
+		syscall.ECANCELED:       "ECANCELED",
+		syscall.ECONNABORTED:    "ECONNABORTED",
+		syscall.ECONNREFUSED:    "ECONNREFUSED",
+		syscall.ECONNRESET:      "ECONNRESET",
+		syscall.EDESTADDRREQ:    "EDESTADDRREQ",
+		syscall.EEXIST:          "EEXIST",
+		syscall.EFAULT:          "EFAULT",
+		syscall.EFBIG:           "EFBIG",
+		syscall.EHOSTDOWN:       "EHOSTDOWN",
+		syscall.EHOSTUNREACH:    "EHOSTUNREACH",
+		syscall.EILSEQ:          "EILSEQ",
+		syscall.EINTR:           "EINTR",
+		syscall.EINVAL:          "EINVAL",
+		syscall.EIO:             "EIO",
+		syscall.EISCONN:         "EISCONN",
+		syscall.EISDIR:          "EISDIR",
+		syscall.ELOOP:           "ELOOP",
+		syscall.EMFILE:          "EMFILE",
+		syscall.EMLINK:          "EMLINK",
+		syscall.EMSGSIZE:        "EMSGSIZE",
+		syscall.ENAMETOOLONG:    "ENAMETOOLONG",
+		syscall.ENETDOWN:        "ENETDOWN",
+		syscall.ENETUNREACH:     "ENETUNREACH",
+		syscall.ENFILE:          "ENFILE",
+		syscall.ENOBUFS:         "ENOBUFS",
+		syscall.ENODEV:          "ENODEV",
+		syscall.ENOENT:          "ENOENT",
+		syscall.ENOMEM:          "ENOMEM",
+		syscall.ENOPROTOOPT:     "ENOPROTOOPT",
+		syscall.ENOSPC:          "ENOSPC",
+		syscall.ENOSYS:          "ENOSYS",
+		syscall.ENOTCONN:        "ENOTCONN",
+		syscall.ENOTDIR:         "ENOTDIR",
+		syscall.ENOTEMPTY:       "ENOTEMPTY",
+		syscall.ENOTSOCK:        "ENOTSOCK",
+		syscall.ENOTSUP:         "ENOTSUP",
+		syscall.ENOTTY:          "ENOTTY",
+		syscall.ENXIO:           "ENXIO",
+		syscall.EPERM:           "EPERM",
+		syscall.EPIPE:           "EPIPE",
+		syscall.EPROTO:          "EPROTO",
+		syscall.EPROTONOSUPPORT: "EPROTONOSUPPORT",
+		syscall.EPROTOTYPE:      "EPROTOTYPE",
+		syscall.ERANGE:          "ERANGE",
+		syscall.EROFS:           "EROFS",
+		syscall.ESHUTDOWN:       "ESHUTDOWN",
+		syscall.ESPIPE:          "ESPIPE",
+		syscall.ESRCH:           "ESRCH",
+		syscall.ETIMEDOUT:       "ETIMEDOUT",
+		syscall.ETXTBSY:         "ETXTBSY",
+		syscall.EXDEV:           "EXDEV",
+	} {
+		uvErrNames[-int(errno)] = name
+	}
+}
@@ -0,0 +1,66 @@
+const assert = require("../../assert.js");
+const util = require("util");
+const { format, formatWithOptions } = util;
+
+// %s
+assert.sameValue(format("%s", "str"), "str");
+assert.sameValue(format("%s", 42), "42");
+assert.sameValue(format("%s", -0), "-0");
+assert.sameValue(format("%s", null), "null");
+assert.sameValue(format("%s", undefined), "undefined");
+assert.sameValue(format("%s", Symbol("s")), "Symbol(s)");
+assert.sameValue(format("%s", { a: { b: 1 } }), "{ a: [Object] }");
+assert.sameValue(format("%s", [1, [2]]), "[ 1, [Array] ]");
+assert.sameValue(format("%s", { toString() { return "custom"; } }), "custom");
+{
+  class Foo {
+    toString() {
+      return "Foo!";
+    }
+  }
+  assert.sameValue(format("%s", new Foo()), "Foo!");
+}
+assert.sameValue(format("%s", function f() {}), "function f() {}");
+
+// %d, %i, %f
+assert.sameValue(format("%d", "42.5"), "42.5");
+assert.sameValue(format("%d", -0), "-0");
+assert.sameValue(format("%d", {}), "NaN");
+assert.sameValue(format("%d", Symbol()), "NaN");
+assert.sameValue(format("%i", "42.9"), "42");
+assert.sameValue(format("%i", "0x10"), "16");
+assert.sameValue(format("%i", Symbol()), "NaN");
+assert.sameValue(format("%f", "1.5e3abc"), "1500");
+assert.sameValue(format("%f", "abc"), "NaN");
+assert.sameValue(format("%f", Symbol()), "NaN");
+
+// %j
+assert.sameValue(format("%j", { a: [1] }), '{"a":[1]}');
+assert.sameValue(format("%j", undefined), "undefined");
+{
+  const o = {};
+  o.o = o;
+  assert.sameValue(format("%j", o), "[Circular]");
+}
+
+// %o, %O, %c, %%
+assert.sameValue(format("%o", [1]), "[ 1, [length]: 1 ]");
+assert.sameValue(format("%O", { a: { b: { c: { d: 1 } } } }), "{ a: { b: { c: [Object] } } }");
+assert.sameValue(format("%cstyled", "color: red"), "styled");
+assert.sameValue(format("%% %s", "a"), "% a");
+assert.sameValue(format("%%"), "%%");
+assert.sameValue(format("%s %x", "a", "b"), "a %x b");
+assert.sameValue(format("%s %s", "a"), "a %s");
+assert.sameValue(format("100%", 1), "100% 1");
+
+// extra arguments and non-string first argument
+assert.sameValue(format("a", { b: 1 }, [2], "c", 3), "a { b: 1 } [ 2 ] c 3");
+assert.sameValue(format({ a: 1 }, "b"), "{ a: 1 } b");
+assert.sameValue(format(1, 2), "1 2");
+assert.sameValue(format(), "");
+
+// formatWithOptions
+assert.sameValue(formatWithOptions({ colors: true }, "%O", 1), "\x1b[33m1\x1b[39m");
+assert.sameValue(formatWithOptions({ depth: 0 }, "a", { b: { c: 1 } }), "a { b: [Object] }");
+assert.sameValue(formatWithOptions({ compact: false }, { a: 1 }), "{\n  a: 1\n}");
+assert.throwsNodeError(() => formatWithOptions(null, "a"), TypeError, "ERR_INVALID_ARG_TYPE");
@@ -0,0 +1,144 @@
+const assert = require("../../assert.js");
+const util = require("util");
+const { inspect } = util;
+
+// primitives
+assert.sameValue(inspect("abc"), "'abc'");
+assert.sameValue(inspect("it's"), `"it's"`);
+assert.sameValue(inspect("a\nb\x01"), "'a\\nb\\x01'");
+assert.sameValue(inspect(-0), "-0");
+assert.sameValue(inspect(42), "42");
+assert.sameValue(inspect(null), "null");
+assert.sameValue(inspect(undefined), "undefined");
+assert.sameValue(inspect(Symbol("s")), "Symbol(s)");
+
+// objects and arrays
+assert.sameValue(inspect({}), "{}");
+assert.sameValue(inspect([]), "[]");
+assert.sameValue(inspect({ a: 1, "b-c": "x", [Symbol("s")]: true }), "{ a: 1, 'b-c': 'x', [Symbol(s)]: true }");
+assert.sameValue(inspect([1, , 3]), "[ 1, <1 empty item>, 3 ]");
+assert.sameValue(inspect({ a: { b: { c: { d: 1 } } } }), "{ a: { b: { c: [Object] } } }");
+assert.sameValue(inspect({ a: { b: { c: { d: 1 } } } }, { depth: null }), "{\n  a: { b: { c: { d: 1 } } }\n}");
+assert.sameValue(inspect({ a: [1, 2] }, { depth: 0 }), "{ a: [Array] }");
+assert.sameValue(inspect(Object.create(null)), "[Object: null prototype] {}");
+assert.sameValue(inspect({ get a() { return 1; }, set b(v) {}, get c() { return 1; }, set c(v) {} }),
+  "{ a: [Getter], b: [Setter], c: [Getter/Setter] }");
+assert.sameValue(inspect({ get a() { return 1; } }, { getters: true }), "{ a: [Getter: 1] }");
+{
+  // The getters are not run unless the getters option is set.
+  let calls = 0;
+  assert.sameValue(inspect({ get x() { calls++; throw new Error("boom"); } }), "{ x: [Getter] }");
+  assert.sameValue(calls, 0);
+}
+assert.sameValue(inspect(new Proxy({ a: 1 }, { get() { throw new Error("trap"); } })), "{ a: 1 }");
+
+// sparse arrays are formatted by their keys
+assert.sameValue(inspect(new Array(2 ** 30)), "[ <1073741824 empty items> ]");
+{
+  const a = [1];
+  a[1e8] = 2;
+  assert.sameValue(inspect(a), "[ 1, <99999999 empty items>, 2 ]");
+  a.length = 1e8 + 5;
+  assert.sameValue(inspect(a), "[ 1, <99999999 empty items>, 2, <4 empty items> ]");
+  assert.sameValue(inspect(a, { maxArrayLength: 2 }), "[ 1, <99999999 empty items>, ... 5 more items ]");
+}
+
+// circular references
+{
+  const o = { name: "o" };
+  o.self = o;
+  o.arr = [o];
+  assert.sameValue(inspect(o), "<ref *1> { name: 'o', self: [Circular *1], arr: [ [Circular *1] ] }");
+  // The reference counts in the line width, like the rest of the line.
+  const long = { k: "x".repeat(40) };
+  long.self = long;
+  assert.sameValue(inspect(long), "<ref *1> {\n  k: '" + "x".repeat(40) + "',\n  self: [Circular *1]\n}");
+  long.k = "x".repeat(30);
+  assert.sameValue(inspect(long), "<ref *1> { k: '" + "x".repeat(30) + "', self: [Circular *1] }");
+}
+
+// classes and functions
+{
+  class Foo {
+    constructor() {
+      this.x = 1;
+    }
+  }
+  class Bar extends Foo {}
+  assert.sameValue(inspect(new Foo()), "Foo { x: 1 }");
+  assert.sameValue(inspect(new Bar()), "Bar { x: 1 }");
+  assert.sameValue(inspect(Foo), "[class Foo]");
+  assert.sameValue(inspect(Bar), "[class Bar extends Foo]");
+  assert.sameValue(inspect(function named() {}), "[Function: named]");
+  assert.sameValue(inspect(() => {}), "[Function (anonymous)]");
+  assert.sameValue(inspect(async function af() {}), "[AsyncFunction: af]");
+  assert.sameValue(inspect(Object.assign(function f() {}, { x: 1 })), "[Function: f] { x: 1 }");
+}
+
+// built-in types
+assert.sameValue(inspect(new Map([[1, { a: 1 }], ["b", 2]])), "Map(2) { 1 => { a: 1 }, 'b' => 2 }");
+assert.sameValue(inspect(new Set([1, "a"])), "Set(2) { 1, 'a' }");
+assert.sameValue(inspect(new Map()), "Map(0) {}");
+{
+  const m = new Map([[1, 2]]);
+  Object.defineProperty(m, "size", { get() { throw new Error("size"); } });
+  assert.sameValue(inspect(m), "Map(1) { 1 => 2 }");
+}
+assert.sameValue(inspect(new Uint8Array([1, 2, 3])), "Uint8Array(3) [ 1, 2, 3 ]");
+assert.sameValue(inspect(new ArrayBuffer(2)), "ArrayBuffer { [Uint8Contents]: <00 00>, byteLength: 2 }");
+assert.sameValue(inspect(/ab+/g), "/ab+/g");
+assert.sameValue(inspect(new Date(0)), "1970-01-01T00:00:00.000Z");
+assert.sameValue(inspect(new Number(3)), "[Number: 3]");
+assert.sameValue(inspect(new String("ab")), "[String: 'ab']");
+assert.sameValue(inspect(Promise.resolve(4)), "Promise { 4 }");
+assert.sameValue(inspect(new WeakMap()), "WeakMap { <items unknown> }");
+{
+  class M extends Map {}
+  assert.sameValue(inspect(new M([[1, 2]])), "M(1) [Map] { 1 => 2 }");
+}
+assert.sameValue(inspect(require("buffer").Buffer.from("hello")), "<Buffer 68 65 6c 6c 6f>");
+assert.sameValue(inspect(new (require("url").URLSearchParams)("a=1&b=2")), "URLSearchParams { 'a' => '1', 'b' => '2' }");
+assert.sameValue(inspect(new (require("url").URL)("https://example.org/?a=1")).split("\n")[1], "  href: 'https://example.org/?a=1',");
+
+// errors
+{
+  const err = new TypeError("boom");
+  assert.sameValue(inspect(err), err.stack.trimEnd());
+  err.code = "E_BOOM";
+  assert.sameValue(inspect(err).endsWith(" {\n  code: 'E_BOOM'\n}"), true);
+  const noStack = new Error("nostack");
+  noStack.stack = "";
+  assert.sameValue(inspect(noStack), "[Error: nostack]");
+}
+
+// options
+assert.sameValue(inspect({ b: 1, a: 2 }, { sorted: true }), "{ a: 2, b: 1 }");
+assert.sameValue(inspect([1, 2, 3], { showHidden: true }), "[ 1, 2, 3, [length]: 3 ]");
+assert.sameValue(inspect([1, 2, 3, 4], { maxArrayLength: 2 }), "[ 1, 2, ... 2 more items ]");
+assert.sameValue(inspect("x".repeat(20), { maxStringLength: 5 }), "'xxxxx'... 15 more characters");
+assert.sameValue(inspect({ a: 1, b: 2 }, { compact: false }), "{\n  a: 1,\n  b: 2\n}");
+assert.sameValue(inspect({ a: 1 }, { colors: true }), "{ a: \x1b[33m1\x1b[39m }");
+assert.sameValue(inspect({ a: "x".repeat(30), b: "y".repeat(30) }, { breakLength: 40 }),
+  `{\n  a: '${"x".repeat(30)}',\n  b: '${"y".repeat(30)}'\n}`);
+assert.sameValue(inspect({ a: 1 }, false, 0, true), "{ a: \x1b[33m1\x1b[39m }");
+// the aliases of the colors are not enumerable
+assert.sameValue(Object.keys(inspect.colors).includes("grey"), false);
+assert.sameValue(inspect.colors.grey.join(), "90,39");
+assert.sameValue(inspect.colors.faint.join(), "2,22");
+assert.sameValue(inspect.colors.swapcolors.join(), "7,27");
+assert.sameValue(inspect.colors.crossedOut.join(), "9,29");
+assert.sameValue(inspect(Array.from({ length: 30 }, (_, i) => i)),
+  "[\n   0,  1,  2,  3,  4,  5,  6,  7,  8,\n   9, 10, 11, 12, 13, 14, 15, 16, 17,\n" +
+  "  18, 19, 20, 21, 22, 23, 24, 25, 26,\n  27, 28, 29\n]");
+
+// custom inspect
+assert.sameValue(inspect.custom, Symbol.for("nodejs.util.inspect.custom"));
+assert.sameValue(inspect({ [inspect.custom](depth, options) { return "custom " + depth + " " + options.depth; } }), "custom 2 2");
+assert.sameValue(inspect({ a: { [inspect.custom]() { return { b: 1 }; } } }), "{ a: { b: 1 } }");
+assert.sameValue(inspect({ [inspect.custom]() { return "x"; } }, { customInspect: false }),
+  "{\n  [Symbol(nodejs.util.inspect.custom)]: [Function: [nodejs.util.inspect.custom]]\n}");
+
+// format
+assert.sameValue(util.format("%o", { a: [1] }), "{ a: [ 1, [length]: 1 ] }");
+assert.sameValue(util.format("%O", { a: { b: { c: { d: 1 } } } }), "{ a: { b: { c: [Object] } } }");
+assert.sameValue(util.format({ a: 1 }, "b", 3), "{ a: 1 } b 3");
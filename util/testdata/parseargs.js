@@ -0,0 +1,224 @@
+const assert = require("../../assert.js");
+const { parseArgs, styleText, inspect, isDeepStrictEqual } = require("util");
+
+function deepEqual(actual, expected) {
+  if (!isDeepStrictEqual(actual, expected)) {
+    throw new Error("Expected " + inspect(expected, { depth: 5 }) + " but got " + inspect(actual, { depth: 5 }));
+  }
+}
+
+function values(obj) {
+  return Object.assign(Object.create(null), obj);
+}
+
+// parseArgs
+
+// boolean and string options, short and long
+{
+  const args = ["-f", "--bar", "b", "--baz=c", "-xy", "pos"];
+  const options = {
+    foo: { type: "boolean", short: "f" },
+    bar: { type: "string" },
+    baz: { type: "string" },
+    x: { type: "boolean" },
+    y: { type: "boolean" },
+  };
+  const result = parseArgs({ args, options, allowPositionals: true });
+  deepEqual(result, {
+    values: values({ foo: true, bar: "b", baz: "c", x: true, y: true }),
+    positionals: ["pos"],
+  });
+}
+
+// short option with an inline value and a short option group ending with a string option
+{
+  const options = { file: { type: "string", short: "f" }, verbose: { type: "boolean", short: "v" } };
+  deepEqual(parseArgs({ args: ["-fout.txt"], options }).values, values({ file: "out.txt" }));
+  deepEqual(parseArgs({ args: ["-vfout.txt"], options }).values, values({ verbose: true, file: "out.txt" }));
+  deepEqual(parseArgs({ args: ["-vf", "out.txt"], options }).values, values({ verbose: true, file: "out.txt" }));
+}
+
+// multiple
+{
+  const options = { inc: { type: "string", short: "I", multiple: true }, v: { type: "boolean", multiple: true } };
+  deepEqual(parseArgs({ args: ["-I", "a", "--inc=b", "-vv"], options }).values, values({ inc: ["a", "b"], v: [true, true] }));
+}
+
+// defaults
+{
+  const options = {
+    name: { type: "string", default: "anon" },
+    flag: { type: "boolean", default: false },
+    list: { type: "string", multiple: true, default: ["x"] },
+  };
+  deepEqual(parseArgs({ args: [], options }).values, values({ name: "anon", flag: false, list: ["x"] }));
+  deepEqual(parseArgs({ args: ["--name", "me", "--list", "y"], options }).values, values({ name: "me", flag: false, list: ["y"] }));
+  assert.throwsNodeError(() => parseArgs({ args: [], options: { a: { type: "string", default: 1 } } }), TypeError, "ERR_INVALID_ARG_TYPE");
+  assert.throwsNodeError(() => parseArgs({ args: [], options: { a: { type: "boolean", multiple: true, default: [1] } } }), TypeError, "ERR_INVALID_ARG_TYPE");
+}
+
+// option terminator and tokens
+{
+  const { values: v, positionals, tokens } = parseArgs({
+    args: ["-a", "--", "-b", "c"],
+    options: { a: { type: "boolean" } },
+    allowPositionals: true,
+    tokens: true,
+  });
+  deepEqual(v, values({ a: true }));
+  deepEqual(positionals, ["-b", "c"]);
+  deepEqual(tokens, [
+    { kind: "option", name: "a", rawName: "-a", index: 0, value: undefined, inlineValue: undefined },
+    { kind: "option-terminator", index: 1 },
+    { kind: "positional", index: 2, value: "-b" },
+    { kind: "positional", index: 3, value: "c" },
+  ]);
+}
+
+{
+  const { tokens } = parseArgs({
+    args: ["-ab", "--file", "f", "-cvalue", "--x=y"],
+    options: { a: { type: "boolean" }, b: { type: "boolean" }, file: { type: "string" }, c: { type: "string" }, x: { type: "string" } },
+    tokens: true,
+  });
+  deepEqual(tokens, [
+    { kind: "option", name: "a", rawName: "-a", index: 0, value: undefined, inlineValue: undefined },
+    { kind: "option", name: "b", rawName: "-b", index: 0, value: undefined, inlineValue: undefined },
+    { kind: "option", name: "file", rawName: "--file", index: 1, value: "f", inlineValue: false },
+    { kind: "option", name: "c", rawName: "-c", index: 3, value: "value", inlineValue: true },
+    { kind: "option", name: "x", rawName: "--x", index: 4, value: "y", inlineValue: true },
+  ]);
+}
+
+// non-strict mode
+{
+  const { values: v, positionals } = parseArgs({ args: ["--unknown", "-u=x", "--str", "p"], strict: false });
+  deepEqual(v, values({ unknown: true, u: true, "=": true, x: true, str: true }));
+  deepEqual(positionals, ["p"]);
+  deepEqual(parseArgs({ args: ["--s"], options: { s: { type: "string" } }, strict: false }).values, values({ s: true }));
+  deepEqual(parseArgs({ args: ["--b=x"], options: { b: { type: "boolean" } }, strict: false }).values, values({ b: "x" }));
+}
+
+// __proto__ is ignored
+{
+  const result = parseArgs({ args: ["--__proto__=x"], strict: false });
+  assert.sameValue(Object.getPrototypeOf(result.values), null);
+  assert.sameValue(Object.keys(result.values).length, 0);
+}
+
+// process.argv is used by default
+{
+  const saved = globalThis.process;
+  globalThis.process = { argv: ["node", "script.js", "--x", "pos"] };
+  try {
+    deepEqual(parseArgs({ options: { x: { type: "boolean" } }, allowPositionals: true }).values, values({ x: true }));
+    deepEqual(parseArgs({ strict: false }).positionals, ["pos"]);
+  } finally {
+    globalThis.process = saved;
+  }
+}
+
+// errors
+{
+  assert.throwsNodeError(() => parseArgs({ args: ["--nope"] }), TypeError, "ERR_PARSE_ARGS_UNKNOWN_OPTION");
+  try {
+    parseArgs({ args: ["-z"], allowPositionals: true });
+  } catch (e) {
+    assert.sameValue(e.message, "Unknown option '-z'. To specify a positional argument starting with a '-', place it at the end of the command after '--', as in '-- \"-z\"'");
+  }
+  try {
+    parseArgs({ args: ["--nope"] });
+  } catch (e) {
+    assert.sameValue(e.message, "Unknown option '--nope'");
+  }
+
+  assert.throwsNodeError(() => parseArgs({ args: ["pos"] }), TypeError, "ERR_PARSE_ARGS_UNEXPECTED_POSITIONAL");
+  try {
+    parseArgs({ args: ["pos"] });
+  } catch (e) {
+    assert.sameValue(e.message, "Unexpected argument 'pos'. This command does not take positional arguments");
+  }
+
+  const options = { str: { type: "string", short: "s" }, bool: { type: "boolean" } };
+  assert.throwsNodeError(() => parseArgs({ args: ["--str"], options }), TypeError, "ERR_PARSE_ARGS_INVALID_OPTION_VALUE");
+  try {
+    parseArgs({ args: ["-s"], options });
+  } catch (e) {
+    assert.sameValue(e.message, "Option '-s, --str <value>' argument missing");
+  }
+  try {
+    parseArgs({ args: ["--bool=x"], options });
+  } catch (e) {
+    assert.sameValue(e.code, "ERR_PARSE_ARGS_INVALID_OPTION_VALUE");
+    assert.sameValue(e.message, "Option '--bool' does not take an argument");
+  }
+  try {
+    parseArgs({ args: ["-s", "-x"], options });
+    throw new Error("no exception");
+  } catch (e) {
+    assert.sameValue(e.code, "ERR_PARSE_ARGS_INVALID_OPTION_VALUE");
+    assert.sameValue(e.message, "Option '-s' argument is ambiguous.\nDid you forget to specify the option argument for '-s'?\nTo specify an option argument starting with a dash use '--str=-XYZ' or '-s-XYZ'.");
+  }
+  deepEqual(parseArgs({ args: ["--str=-x"], options }).values, values({ str: "-x" }));
+  deepEqual(parseArgs({ args: ["-s-x"], options }).values, values({ str: "-x" }));
+  deepEqual(parseArgs({ args: ["--str", "-"], options }).values, values({ str: "-" }));
+
+  assert.throwsNodeError(() => parseArgs({ args: "x" }), TypeError, "ERR_INVALID_ARG_TYPE");
+  assert.throwsNodeError(() => parseArgs({ args: [], strict: "no" }), TypeError, "ERR_INVALID_ARG_TYPE");
+  assert.throwsNodeError(() => parseArgs({ args: [], tokens: 1 }), TypeError, "ERR_INVALID_ARG_TYPE");
+  assert.throwsNodeError(() => parseArgs({ args: [], options: [] }), TypeError, "ERR_INVALID_ARG_TYPE");
+  assert.throwsNodeError(() => parseArgs({ args: [], options: { a: true } }), TypeError, "ERR_INVALID_ARG_TYPE");
+  assert.throwsNodeError(() => parseArgs({ args: [], options: { a: { type: "number" } } }), TypeError, "ERR_INVALID_ARG_TYPE");
+  try {
+    parseArgs({ args: [], options: { a: {} } });
+  } catch (e) {
+    assert.sameValue(e.message, "The \"options.a.type\" property must be ('string|boolean'). Received undefined");
+  }
+  try {
+    parseArgs({ args: [], options: { a: { type: "x" } } });
+  } catch (e) {
+    assert.sameValue(e.message, "The \"options.a.type\" property must be ('string|boolean'). Received type string ('x')");
+  }
+  try {
+    parseArgs({ args: [], options: { a: { type: "boolean", short: "ab" } } });
+    throw new Error("no exception");
+  } catch (e) {
+    assert.sameValue(e.code, "ERR_INVALID_ARG_VALUE");
+    assert.sameValue(e.message, "The property 'options.a.short' must be a single character. Received 'ab'");
+  }
+  assert.throwsNodeError(() => parseArgs({ args: [], options: { a: { type: "boolean", multiple: 1 } } }), TypeError, "ERR_INVALID_ARG_TYPE");
+}
+
+// styleText
+{
+  const saved = globalThis.process;
+  globalThis.process = { env: { FORCE_COLOR: "1" } };
+  try {
+    assert.sameValue(styleText("red", "text"), "\u001b[31mtext\u001b[39m");
+    assert.sameValue(styleText(["bold", "red"], "text"), "\u001b[1m\u001b[31mtext\u001b[39m\u001b[22m");
+    assert.sameValue(styleText(["none", "grey"], "text"), "\u001b[90mtext\u001b[39m");
+    assert.sameValue(styleText("red", "text", { validateStream: false }), "\u001b[31mtext\u001b[39m");
+
+    process.env.FORCE_COLOR = "0";
+    assert.sameValue(styleText("red", "text"), "text");
+
+    delete process.env.FORCE_COLOR;
+    assert.sameValue(styleText("red", "text", { stream: { isTTY: true } }), "\u001b[31mtext\u001b[39m");
+    assert.sameValue(styleText("red", "text", { stream: { isTTY: false } }), "text");
+    assert.sameValue(styleText("red", "text", { stream: { isTTY: true, getColorDepth: () => 1 } }), "text");
+
+    assert.throwsNodeError(() => styleText("red", 1), TypeError, "ERR_INVALID_ARG_TYPE");
+    assert.throwsNodeError(() => styleText("nope", "text"), TypeError, "ERR_INVALID_ARG_VALUE");
+    assert.throwsNodeError(() => styleText("constructor", "text"), TypeError, "ERR_INVALID_ARG_VALUE");
+    assert.throwsNodeError(() => styleText("red", "text", { stream: 1 }), TypeError, "ERR_INVALID_ARG_TYPE");
+    assert.throwsNodeError(() => styleText("red", "text", { validateStream: 1 }), TypeError, "ERR_INVALID_ARG_TYPE");
+    try {
+      styleText("nope", "text");
+    } catch (e) {
+      assert.sameValue(e.message.startsWith("The argument 'format' must be one of: 'reset', 'bold', 'dim', "), true);
+      assert.sameValue(e.message.endsWith("'bgWhiteBright'. Received 'nope'"), true);
+    }
+  } finally {
+    globalThis.process = saved;
+  }
+}
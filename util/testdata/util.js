@@ -0,0 +1,280 @@
+const assert = require("../../assert.js");
+const util = require("util");
+
+var results = [];
+
+const warnings = [];
+const stderr = [];
+globalThis.process = {
+  env: { NODE_DEBUG: "foo,ba*" },
+  emitWarning(...args) {
+    warnings.push(args);
+  },
+  stderr: {
+    write(s) {
+      stderr.push(s);
+    },
+  },
+};
+
+// promisify
+{
+  function add(a, b, cb) {
+    cb(null, a + b);
+  }
+  add.extra = "x";
+  const padd = util.promisify(add);
+  assert.sameValue(padd.extra, "x");
+  assert.sameValue(padd.name, "add");
+  assert.sameValue(padd.length, 3);
+  assert.sameValue(util.promisify(padd), padd);
+  padd(1, 2).then(v => results.push("add " + v));
+
+  const fail = util.promisify((cb) => cb(new Error("failed")));
+  fail().catch(e => results.push("fail " + e.message));
+
+  const thrower = util.promisify(() => {
+    throw new Error("thrown");
+  });
+  thrower().catch(e => results.push("throw " + e.message));
+
+  const obj = {
+    v: 42,
+    get(cb) {
+      cb(null, this.v);
+    },
+  };
+  obj.pget = util.promisify(obj.get);
+  obj.pget().then(v => results.push("this " + v));
+
+  function custom() {}
+  const customFn = () => Promise.resolve("custom");
+  custom[util.promisify.custom] = customFn;
+  assert.sameValue(util.promisify(custom), customFn);
+  assert.sameValue(util.promisify.custom, Symbol.for("nodejs.util.promisify.custom"));
+
+  function badCustom() {}
+  badCustom[util.promisify.custom] = 1;
+  assert.throwsNodeError(() => util.promisify(badCustom), TypeError, "ERR_INVALID_ARG_TYPE");
+  assert.throwsNodeError(() => util.promisify({}), TypeError, "ERR_INVALID_ARG_TYPE");
+}
+
+// callbackify
+{
+  async function ok(a) {
+    return a * 2;
+  }
+  const cbOk = util.callbackify(ok);
+  assert.sameValue(cbOk.name, "okCallbackified");
+  assert.sameValue(cbOk.length, 2);
+  cbOk(21, (err, v) => results.push("cb " + err + " " + v));
+
+  util.callbackify(() => Promise.reject(new Error("rejected")))(err => results.push("cb " + err.message));
+  util.callbackify(() => Promise.reject(null))(err => {
+    results.push("cb " + err.code + " " + err.reason);
+  });
+
+  assert.throwsNodeError(() => cbOk(1), TypeError, "ERR_INVALID_ARG_TYPE");
+  assert.throwsNodeError(() => util.callbackify("x"), TypeError, "ERR_INVALID_ARG_TYPE");
+}
+
+// inherits
+{
+  function Base() {}
+  Base.prototype.hello = function () {
+    return "hello";
+  };
+  function Derived() {
+    Base.call(this);
+  }
+  util.inherits(Derived, Base);
+  assert.sameValue(Derived.super_, Base);
+  assert.sameValue(new Derived().hello(), "hello");
+  assert.sameValue(new Derived() instanceof Base, true);
+  assert.sameValue(Object.keys(Derived).length, 0);
+  assert.throwsNodeError(() => util.inherits(Derived, null), TypeError, "ERR_INVALID_ARG_TYPE");
+  assert.throwsNodeError(() => util.inherits(null, Base), TypeError, "ERR_INVALID_ARG_TYPE");
+  assert.throwsNodeError(() => util.inherits(Derived, Object.create(null)), TypeError, "ERR_INVALID_ARG_TYPE");
+}
+
+// deprecate
+{
+  const fn = util.deprecate(function (a) {
+    return a + 1;
+  }, "fn is deprecated", "DEP0001");
+  assert.sameValue(fn(1), 2);
+  assert.sameValue(fn(2), 3);
+  assert.sameValue(warnings.length, 1);
+  assert.sameValue(warnings[0][0], "fn is deprecated");
+  assert.sameValue(warnings[0][1], "DeprecationWarning");
+  assert.sameValue(warnings[0][2], "DEP0001");
+  assert.sameValue(warnings[0][3], fn);
+
+  const fn2 = util.deprecate(() => 0, "fn2 is deprecated", "DEP0001");
+  fn2();
+  assert.sameValue(warnings.length, 1);
+
+  class C {
+    constructor(v) {
+      this.v = v;
+    }
+  }
+  const D = util.deprecate(C, "C is deprecated");
+  const d = new D(5);
+  assert.sameValue(d.v, 5);
+  assert.sameValue(d instanceof C, true);
+  assert.sameValue(D.prototype, C.prototype);
+  assert.sameValue(warnings.length, 2);
+  assert.sameValue(warnings[1].length, 3);
+  new D(6);
+  assert.sameValue(warnings.length, 2);
+
+  process.noDeprecation = true;
+  const quiet = () => 1;
+  assert.sameValue(util.deprecate(quiet, "quiet"), quiet);
+  delete process.noDeprecation;
+
+  assert.throwsNodeError(() => util.deprecate(() => {}, "msg", 1), TypeError, "ERR_INVALID_ARG_TYPE");
+}
+
+// debuglog
+{
+  let cbCalled = 0;
+  const foo = util.debuglog("foo", () => cbCalled++);
+  assert.sameValue(foo.enabled, true);
+  assert.sameValue(cbCalled, 0);
+  foo("hello %s", "world", { a: 1 });
+  foo("again");
+  assert.sameValue(cbCalled, 1);
+  assert.sameValue(stderr.length, 2);
+  assert.sameValue(/^FOO \d+: hello world { a: 1 }\n$/.test(stderr[0]), true);
+  assert.sameValue(/^FOO \d+: again\n$/.test(stderr[1]), true);
+
+  assert.sameValue(util.debuglog("bar").enabled, true);
+  assert.sameValue(util.debuglog("baz").enabled, true);
+  const other = util.debug("other");
+  assert.sameValue(other.enabled, false);
+  other("nothing");
+  assert.sameValue(stderr.length, 2);
+}
+
+// isDeepStrictEqual
+{
+  const eq = util.isDeepStrictEqual;
+  assert.sameValue(eq(1, 1), true);
+  assert.sameValue(eq(1, "1"), false);
+  assert.sameValue(eq(NaN, NaN), true);
+  assert.sameValue(eq(0, -0), false);
+  assert.sameValue(eq({ a: [1, 2], b: { c: "x" } }, { a: [1, 2], b: { c: "x" } }), true);
+  assert.sameValue(eq({ a: 1 }, { a: 1, b: undefined }), false);
+  assert.sameValue(eq({ a: 1 }, { a: "1" }), false);
+  assert.sameValue(eq([1, , 3], [1, undefined, 3]), false);
+  assert.sameValue(eq([1, 2], { 0: 1, 1: 2, length: 2 }), false);
+  assert.sameValue(eq(Object.create(null), {}), false);
+  assert.sameValue(eq(new Date(1), new Date(1)), true);
+  assert.sameValue(eq(new Date(1), new Date(2)), false);
+  assert.sameValue(eq(/a/g, /a/g), true);
+  assert.sameValue(eq(/a/g, /a/i), false);
+  assert.sameValue(eq(new Error("a"), new Error("a")), true);
+  assert.sameValue(eq(new Error("a"), new Error("b")), false);
+  assert.sameValue(eq(new Error("a"), new TypeError("a")), false);
+  assert.sameValue(eq(new Number(1), new Number(1)), true);
+  assert.sameValue(eq(new Number(1), new Number(2)), false);
+  assert.sameValue(eq(new String("a"), "a"), false);
+  assert.sameValue(eq(new Uint8Array([1, 2]), new Uint8Array([1, 2])), true);
+  assert.sameValue(eq(new Uint8Array([1, 2]), new Uint8Array([1, 3])), false);
+  assert.sameValue(eq(new Uint8Array([1, 2]), new Int8Array([1, 2])), false);
+  assert.sameValue(eq(new Float64Array([0]), new Float64Array([-0])), false);
+  assert.sameValue(eq(new DataView(new ArrayBuffer(2)), new DataView(new ArrayBuffer(2))), true);
+  assert.sameValue(eq(new ArrayBuffer(2), new ArrayBuffer(2)), true);
+  assert.sameValue(eq(new ArrayBuffer(2), new ArrayBuffer(3)), false);
+  assert.sameValue(eq(new Set([1, { a: 1 }]), new Set([{ a: 1 }, 1])), true);
+  assert.sameValue(eq(new Set([1, { a: 1 }]), new Set([{ a: 2 }, 1])), false);
+  assert.sameValue(eq(new Map([["a", 1], [{ k: 1 }, 2]]), new Map([[{ k: 1 }, 2], ["a", 1]])), true);
+  assert.sameValue(eq(new Map([["a", 1]]), new Map([["a", 2]])), false);
+  assert.sameValue(eq(new Map([["a", undefined]]), new Map([["b", undefined]])), false);
+  const sym = Symbol("s");
+  assert.sameValue(eq({ [sym]: 1 }, { [sym]: 1 }), true);
+  assert.sameValue(eq({ [sym]: 1 }, { [sym]: 2 }), false);
+  assert.sameValue(eq({ [sym]: 1 }, {}), false);
+  const f = () => {};
+  assert.sameValue(eq(f, f), true);
+  assert.sameValue(eq(() => {}, () => {}), false);
+
+  const a = { x: 1 };
+  a.self = a;
+  const b = { x: 1 };
+  b.self = b;
+  assert.sameValue(eq(a, b), true);
+  b.x = 2;
+  assert.sameValue(eq(a, b), false);
+}
+
+// toUSVString
+{
+  assert.sameValue(util.toUSVString("a\ud800b"), "a�b");
+  assert.sameValue(util.toUSVString("\udc00"), "�");
+  assert.sameValue(util.toUSVString("ok 💩"), "ok 💩");
+  assert.sameValue(util.toUSVString(42), "42");
+  assert.throws(() => util.toUSVString(Symbol()), TypeError);
+}
+
+// stripVTControlCharacters
+{
+  assert.sameValue(util.stripVTControlCharacters("\u001B[4mvalue\u001B[0m"), "value");
+  assert.sameValue(util.stripVTControlCharacters("\u001b[31;1mred\u001b[39;22m plain"), "red plain");
+  assert.sameValue(util.stripVTControlCharacters("\u001b]8;;https://nodejs.org\u0007link\u001b]8;;\u0007"), "link");
+  assert.sameValue(util.stripVTControlCharacters(util.inspect("str", { colors: true })), "'str'");
+  assert.throwsNodeError(() => util.stripVTControlCharacters(1), TypeError, "ERR_INVALID_ARG_TYPE");
+}
+
+// getSystemErrorName
+{
+  assert.sameValue(util.getSystemErrorName(-4095), "EOF");
+  assert.sameValue(util.getSystemErrorName(-3008), "EAI_NONAME");
+  assert.sameValue(util.getSystemErrorName(-424242), "Unknown system error -424242");
+  assert.throwsNodeError(() => util.getSystemErrorName(1), RangeError, "ERR_OUT_OF_RANGE");
+  assert.throwsNodeError(() => util.getSystemErrorName(-1.5), RangeError, "ERR_OUT_OF_RANGE");
+  assert.throwsNodeError(() => util.getSystemErrorName("-1"), TypeError, "ERR_INVALID_ARG_TYPE");
+}
+
+// types and the deprecated type checks
+{
+  assert.sameValue(util.types, require("util/types"));
+  assert.sameValue(util.types.isDate(new Date()), true);
+
+  assert.sameValue(util.isArray([]), true);
+  assert.sameValue(util.isArray(new Proxy([], {})), true);
+  assert.sameValue(util.isArray({ length: 0 }), false);
+  assert.sameValue(util.isBoolean(false), true);
+  assert.sameValue(util.isBoolean(new Boolean(false)), false);
+  assert.sameValue(util.isBuffer(require("buffer").Buffer.from("a")), true);
+  assert.sameValue(util.isBuffer(new Uint8Array(1)), false);
+  assert.sameValue(util.isDate(new Date()), true);
+  assert.sameValue(util.isError(new RangeError()), true);
+  assert.sameValue(util.isError(Object.create(Error.prototype)), true);
+  assert.sameValue(util.isError({}), false);
+  assert.sameValue(util.isFunction(() => {}), true);
+  assert.sameValue(util.isFunction({}), false);
+  assert.sameValue(util.isNull(null), true);
+  assert.sameValue(util.isNull(undefined), false);
+  assert.sameValue(util.isNullOrUndefined(undefined), true);
+  assert.sameValue(util.isNullOrUndefined(0), false);
+  assert.sameValue(util.isNumber(1.5), true);
+  assert.sameValue(util.isNumber(NaN), true);
+  assert.sameValue(util.isNumber("1"), false);
+  assert.sameValue(util.isObject({}), true);
+  assert.sameValue(util.isObject(null), false);
+  assert.sameValue(util.isObject(() => {}), false);
+  assert.sameValue(util.isPrimitive(Symbol()), true);
+  assert.sameValue(util.isPrimitive(null), true);
+  assert.sameValue(util.isPrimitive([]), false);
+  assert.sameValue(util.isRegExp(/a/), true);
+  assert.sameValue(util.isString(""), true);
+  assert.sameValue(util.isString(new String("")), false);
+  assert.sameValue(util.isSymbol(Symbol()), true);
+  assert.sameValue(util.isUndefined(undefined), true);
+  assert.sameValue(util.isUndefined(null), false);
+}
+
+results;
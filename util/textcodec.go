@@ -0,0 +1,262 @@
+package util
+
+import (
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/buffer"
+	"github.com/nuvolaris/goja_nodejs/errors"
+	"github.com/nuvolaris/goja_nodejs/stringdecoder"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Labels supported by TextDecoder mapped to the encoding names, see https://encoding.spec.whatwg.org/#names-and-labels
+var textDecoderEncodings = map[string]string{
+	"unicode-1-1-utf-8": "utf-8",
+	"unicode11utf8":     "utf-8",
+	"unicode20utf8":     "utf-8",
+	"utf-8":             "utf-8",
+	"utf8":              "utf-8",
+	"x-unicode20utf8":   "utf-8",
+
+	"csunicode":       "utf-16le",
+	"iso-10646-ucs-2": "utf-16le",
+	"ucs-2":           "utf-16le",
+	"unicode":         "utf-16le",
+	"unicodefeff":     "utf-16le",
+	"utf-16":          "utf-16le",
+	"utf-16le":        "utf-16le",
+
+	"ansi_x3.4-1968":  "windows-1252",
+	"ascii":           "windows-1252",
+	"cp1252":          "windows-1252",
+	"cp819":           "windows-1252",
+	"csisolatin1":     "windows-1252",
+	"ibm819":          "windows-1252",
+	"iso-8859-1":      "windows-1252",
+	"iso-ir-100":      "windows-1252",
+	"iso8859-1":       "windows-1252",
+	"iso88591":        "windows-1252",
+	"iso_8859-1":      "windows-1252",
+	"iso_8859-1:1987": "windows-1252",
+	"l1":              "windows-1252",
+	"latin1":          "windows-1252",
+	"us-ascii":        "windows-1252",
+	"windows-1252":    "windows-1252",
+	"x-cp1252":        "windows-1252",
+}
+
+type textDecoder struct {
+	encoding  string
+	fatal     bool
+	ignoreBOM bool
+
+	d       *stringdecoder.Decoder
+	bomSeen bool
+}
+
+type textEncoder struct{}
+
+var (
+	reflectTypeTextDecoder = reflect.TypeOf((*textDecoder)(nil))
+	reflectTypeTextEncoder = reflect.TypeOf((*textEncoder)(nil))
+	reflectTypeBytes       = reflect.TypeOf(([]byte)(nil))
+)
+
+func (u *Util) toTextDecoder(v goja.Value) *textDecoder {
+	if v.ExportType() == reflectTypeTextDecoder {
+		if td := v.Export().(*textDecoder); td != nil {
+			return td
+		}
+	}
+	panic(errors.NewTypeError(u.runtime, errors.ErrCodeInvalidThis, `Value of "this" must be of type TextDecoder`))
+}
+
+func (u *Util) toTextEncoder(v goja.Value) {
+	if v.ExportType() != reflectTypeTextEncoder {
+		panic(errors.NewTypeError(u.runtime, errors.ErrCodeInvalidThis, `Value of "this" must be of type TextEncoder`))
+	}
+}
+
+func (u *Util) optionsArg(v goja.Value, name string) *goja.Object {
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil
+	}
+	if o, ok := v.(*goja.Object); ok {
+		return o
+	}
+	panic(errors.NewArgTypeError(u.runtime, name, "of type object", v))
+}
+
+func optionFlag(o *goja.Object, name string) bool {
+	if o == nil {
+		return false
+	}
+	if v := o.Get(name); v != nil {
+		return v.ToBoolean()
+	}
+	return false
+}
+
+func (u *Util) newUint8Array(data []byte) *goja.Object {
+	res, err := u.runtime.New(u.runtime.Get("Uint8Array"), u.runtime.ToValue(u.runtime.NewArrayBuffer(data)))
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (u *Util) textDecoder_decode(call goja.FunctionCall) goja.Value {
+	td := u.toTextDecoder(call.This)
+	var data []byte
+	if input := call.Argument(0); !goja.IsUndefined(input) {
+		var ok bool
+		if data, ok = buffer.SourceBytes(u.runtime, input); !ok {
+			panic(errors.NewArgTypeError(u.runtime, "input", "an instance of ArrayBuffer or ArrayBufferView", input))
+		}
+	}
+	stream := optionFlag(u.optionsArg(call.Argument(1), "options"), "stream")
+
+	var s string
+	if td.d == nil {
+		s, _ = charmap.Windows1252.NewDecoder().String(string(data))
+	} else {
+		var err error
+		switch {
+		case td.fatal && stream:
+			s, err = td.d.WriteStrict(data)
+		case td.fatal:
+			s, err = td.d.EndStrict(data)
+		case stream:
+			s = td.d.Write(data)
+		default:
+			s = td.d.End(data)
+		}
+		if err != nil {
+			td.bomSeen = false
+			panic(errors.NewTypeError(u.runtime, errors.ErrCodeEncodingInvalidData, "The encoded data was not valid for encoding %s", td.encoding))
+		}
+	}
+
+	if !td.bomSeen && s != "" {
+		if !td.ignoreBOM && td.d != nil {
+			s = strings.TrimPrefix(s, "\uFEFF")
+		}
+		td.bomSeen = true
+	}
+	if !stream {
+		td.bomSeen = false
+	}
+	return u.runtime.ToValue(s)
+}
+
+func (u *Util) createTextDecoderConstructor() *goja.Object {
+	r := u.runtime
+	ctor := r.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		label := "utf-8"
+		if arg := call.Argument(0); !goja.IsUndefined(arg) {
+			label = arg.String()
+		}
+		enc, ok := textDecoderEncodings[strings.ToLower(strings.Trim(label, "\t\n\f\r "))]
+		if !ok {
+			panic(errors.NewRangeError(r, errors.ErrCodeEncodingNotSupported, "The %q encoding is not supported", label))
+		}
+		opts := u.optionsArg(call.Argument(1), "options")
+		td := &textDecoder{
+			encoding:  enc,
+			fatal:     optionFlag(opts, "fatal"),
+			ignoreBOM: optionFlag(opts, "ignoreBOM"),
+		}
+		switch enc {
+		case "utf-8":
+			td.d = stringdecoder.NewDecoder("utf8")
+		case "utf-16le":
+			td.d = stringdecoder.NewDecoder("utf16le")
+		}
+		res := r.ToValue(td).(*goja.Object)
+		res.SetPrototype(call.This.Prototype())
+		return res
+	}).(*goja.Object)
+	ctor.DefineDataProperty("name", r.ToValue("TextDecoder"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+
+	proto := r.NewObject()
+	proto.Set("decode", u.textDecoder_decode)
+	getter := func(get func(td *textDecoder) interface{}) goja.Value {
+		return r.ToValue(func(call goja.FunctionCall) goja.Value {
+			return r.ToValue(get(u.toTextDecoder(call.This)))
+		})
+	}
+	proto.DefineAccessorProperty("encoding", getter(func(td *textDecoder) interface{} {
+		return td.encoding
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	proto.DefineAccessorProperty("fatal", getter(func(td *textDecoder) interface{} {
+		return td.fatal
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	proto.DefineAccessorProperty("ignoreBOM", getter(func(td *textDecoder) interface{} {
+		return td.ignoreBOM
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	proto.DefineDataProperty("constructor", ctor, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	ctor.Set("prototype", proto)
+	return ctor
+}
+
+func (u *Util) textEncoder_encode(call goja.FunctionCall) goja.Value {
+	u.toTextEncoder(call.This)
+	var s string
+	if arg := call.Argument(0); !goja.IsUndefined(arg) {
+		s = arg.String()
+	}
+	return u.newUint8Array([]byte(s))
+}
+
+func (u *Util) textEncoder_encodeInto(call goja.FunctionCall) goja.Value {
+	u.toTextEncoder(call.This)
+	src := call.Argument(0).String()
+	destArg := call.Argument(1)
+	dest, ok := buffer.SourceBytes(u.runtime, destArg)
+	if !ok || destArg.ExportType() != reflectTypeBytes {
+		panic(errors.NewArgTypeError(u.runtime, "dest", "an instance of Uint8Array", destArg))
+	}
+	read, written := 0, 0
+	for _, c := range src {
+		l := utf8.RuneLen(c)
+		if written+l > len(dest) {
+			break
+		}
+		utf8.EncodeRune(dest[written:], c)
+		written += l
+		if c > 0xFFFF {
+			read += 2
+		} else {
+			read++
+		}
+	}
+	res := u.runtime.NewObject()
+	res.Set("read", read)
+	res.Set("written", written)
+	return res
+}
+
+func (u *Util) createTextEncoderConstructor() *goja.Object {
+	r := u.runtime
+	ctor := r.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		res := r.ToValue(&textEncoder{}).(*goja.Object)
+		res.SetPrototype(call.This.Prototype())
+		return res
+	}).(*goja.Object)
+	ctor.DefineDataProperty("name", r.ToValue("TextEncoder"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+
+	proto := r.NewObject()
+	proto.Set("encode", u.textEncoder_encode)
+	proto.Set("encodeInto", u.textEncoder_encodeInto)
+	proto.DefineAccessorProperty("encoding", r.ToValue(func(call goja.FunctionCall) goja.Value {
+		u.toTextEncoder(call.This)
+		return r.ToValue("utf-8")
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	proto.DefineDataProperty("constructor", ctor, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	ctor.Set("prototype", proto)
+	return ctor
+}
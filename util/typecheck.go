@@ -0,0 +1,96 @@
+package util
+
+import (
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/buffer"
+	"github.com/nuvolaris/goja_nodejs/require"
+	"github.com/nuvolaris/goja_nodejs/util/types"
+)
+
+// typeChecker returns the type checks of the util/types module.
+func (u *Util) typeChecker() *types.Types {
+	if u.types == nil {
+		u.types = types.New(u.runtime)
+	}
+	return u.types
+}
+
+// The deprecated util.isXxx() functions.
+
+func (u *Util) isBuffer(v goja.Value) bool {
+	ctor := require.Require(u.runtime, buffer.ModuleName).ToObject(u.runtime).Get("Buffer")
+	return u.runtime.InstanceOf(v, ctor.ToObject(u.runtime))
+}
+
+func (u *Util) isError(v goja.Value) bool {
+	if u.typeChecker().IsNativeError(v) {
+		return true
+	}
+	errorCtor := u.runtime.Get("Error").ToObject(u.runtime)
+	return u.runtime.InstanceOf(v, errorCtor)
+}
+
+func isPrimitive(v goja.Value) bool {
+	return !isObject(v)
+}
+
+func isObjectNotFunction(v goja.Value) bool {
+	return isObject(v) && !isFunction(v)
+}
+
+func isNullOrUndefined(v goja.Value) bool {
+	return goja.IsNull(v) || goja.IsUndefined(v)
+}
+
+func isBoolean(v goja.Value) bool {
+	if isObject(v) {
+		return false
+	}
+	_, ok := v.Export().(bool)
+	return ok
+}
+
+func isString(v goja.Value) bool {
+	_, ok := v.(goja.String)
+	return ok
+}
+
+func isSymbol(v goja.Value) bool {
+	_, ok := v.(*goja.Symbol)
+	return ok
+}
+
+func isArray(v goja.Value) bool {
+	if o, ok := v.(*goja.Object); ok {
+		if o.ExportType() == reflectTypeProxy {
+			return isArray(o.Export().(goja.Proxy).Target())
+		}
+		return o.ClassName() == "Array"
+	}
+	return false
+}
+
+func (u *Util) check(fn func(goja.Value) bool) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		return u.runtime.ToValue(fn(call.Argument(0)))
+	}
+}
+
+func (u *Util) setLegacyTypeChecks(obj *goja.Object) {
+	t := u.typeChecker()
+	obj.Set("isArray", u.check(isArray))
+	obj.Set("isBoolean", u.check(isBoolean))
+	obj.Set("isBuffer", u.check(u.isBuffer))
+	obj.Set("isDate", u.check(t.IsDate))
+	obj.Set("isError", u.check(u.isError))
+	obj.Set("isFunction", u.check(isFunction))
+	obj.Set("isNull", u.check(goja.IsNull))
+	obj.Set("isNullOrUndefined", u.check(isNullOrUndefined))
+	obj.Set("isNumber", u.check(isNumber))
+	obj.Set("isObject", u.check(isObjectNotFunction))
+	obj.Set("isPrimitive", u.check(isPrimitive))
+	obj.Set("isRegExp", u.check(t.IsRegExp))
+	obj.Set("isString", u.check(isString))
+	obj.Set("isSymbol", u.check(isSymbol))
+	obj.Set("isUndefined", u.check(goja.IsUndefined))
+}
@@ -0,0 +1,295 @@
+// Package types implements the nodejs util/types module.
+//
+// The checks are based on the internal types of the goja objects (or on calling built-in methods that only
+// work on objects of a given type), so unlike the checks based on Object.prototype.toString() they can't be
+// spoofed with Symbol.toStringTag.
+package types
+
+import (
+	"reflect"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/require"
+)
+
+const ModuleName = "util/types"
+
+var (
+	typeMap         = reflect.TypeOf([][2]interface{}{})
+	typeSet         = reflect.TypeOf([]interface{}{})
+	typeArrayBuffer = reflect.TypeOf(goja.ArrayBuffer{})
+	typePromise     = reflect.TypeOf((*goja.Promise)(nil))
+	typeProxy       = reflect.TypeOf(goja.Proxy{})
+)
+
+// Types holds the built-in functions and prototypes the checks rely on. It must only be used with the
+// runtime it was created for.
+type Types struct {
+	r *goja.Runtime
+
+	typedArrayTag      goja.Callable
+	dataViewByteLength goja.Callable
+	weakMapHas         goja.Callable
+	weakSetHas         goja.Callable
+	symbolValueOf      goja.Callable
+
+	asyncFunctionProto     *goja.Object
+	generatorFunctionProto *goja.Object
+	generatorProto         *goja.Object
+	mapIteratorProto       *goja.Object
+	setIteratorProto       *goja.Object
+}
+
+// New returns the Types for the runtime.
+func New(r *goja.Runtime) *Types {
+	eval := func(src string) goja.Value {
+		v, err := r.RunString(src)
+		if err != nil {
+			panic(err)
+		}
+		return v
+	}
+	fn := func(src string) goja.Callable {
+		f, _ := goja.AssertFunction(eval(src))
+		return f
+	}
+	obj := func(src string) *goja.Object {
+		return eval(src).(*goja.Object)
+	}
+	return &Types{
+		r: r,
+
+		typedArrayTag:      fn("Object.getOwnPropertyDescriptor(Object.getPrototypeOf(Uint8Array.prototype), Symbol.toStringTag).get"),
+		dataViewByteLength: fn("Object.getOwnPropertyDescriptor(DataView.prototype, 'byteLength').get"),
+		weakMapHas:         fn("WeakMap.prototype.has"),
+		weakSetHas:         fn("WeakSet.prototype.has"),
+		symbolValueOf:      fn("Symbol.prototype.valueOf"),
+
+		asyncFunctionProto:     obj("Object.getPrototypeOf(async function() {})"),
+		generatorFunctionProto: obj("Object.getPrototypeOf(function*() {})"),
+		generatorProto:         obj("Object.getPrototypeOf(function*() {}).prototype"),
+		mapIteratorProto:       obj("Object.getPrototypeOf(new Map().entries())"),
+		setIteratorProto:       obj("Object.getPrototypeOf(new Set().values())"),
+	}
+}
+
+func asObject(v goja.Value) (*goja.Object, bool) {
+	o, ok := v.(*goja.Object)
+	return o, ok
+}
+
+// brandCheck returns true if calling fn with the value as this does not throw.
+func brandCheck(fn goja.Callable, v goja.Value, args ...goja.Value) bool {
+	_, err := fn(v, args...)
+	return err == nil
+}
+
+// inherits returns true if proto is in the prototype chain of the value.
+func inherits(v goja.Value, proto *goja.Object) bool {
+	o, ok := asObject(v)
+	if !ok {
+		return false
+	}
+	for p := o.Prototype(); p != nil; p = p.Prototype() {
+		if p == proto {
+			return true
+		}
+	}
+	return false
+}
+
+func exportType(v goja.Value) reflect.Type {
+	if o, ok := asObject(v); ok {
+		return o.ExportType()
+	}
+	return nil
+}
+
+func className(v goja.Value) string {
+	if o, ok := asObject(v); ok {
+		return o.ClassName()
+	}
+	return ""
+}
+
+// TypedArrayName returns the name of the typed array constructor (e.g. "Uint8Array") if the value is
+// a typed array, or an empty string otherwise.
+func (t *Types) TypedArrayName(v goja.Value) string {
+	if _, ok := asObject(v); !ok {
+		return ""
+	}
+	res, err := t.typedArrayTag(v)
+	if err != nil || goja.IsUndefined(res) {
+		return ""
+	}
+	return res.String()
+}
+
+func (t *Types) IsArrayBuffer(v goja.Value) bool {
+	return exportType(v) == typeArrayBuffer
+}
+
+// IsAnyArrayBuffer is the same as IsArrayBuffer because SharedArrayBuffer is not supported.
+func (t *Types) IsAnyArrayBuffer(v goja.Value) bool {
+	return t.IsArrayBuffer(v)
+}
+
+func (t *Types) IsArrayBufferView(v goja.Value) bool {
+	return t.IsTypedArray(v) || t.IsDataView(v)
+}
+
+func (t *Types) IsArgumentsObject(v goja.Value) bool {
+	return className(v) == "Arguments"
+}
+
+func (t *Types) IsAsyncFunction(v goja.Value) bool {
+	return inherits(v, t.asyncFunctionProto)
+}
+
+func (t *Types) IsGeneratorFunction(v goja.Value) bool {
+	return inherits(v, t.generatorFunctionProto)
+}
+
+func (t *Types) IsGeneratorObject(v goja.Value) bool {
+	return inherits(v, t.generatorProto)
+}
+
+func (t *Types) IsMapIterator(v goja.Value) bool {
+	return inherits(v, t.mapIteratorProto)
+}
+
+func (t *Types) IsSetIterator(v goja.Value) bool {
+	return inherits(v, t.setIteratorProto)
+}
+
+func (t *Types) IsBooleanObject(v goja.Value) bool {
+	return className(v) == "Boolean"
+}
+
+func (t *Types) IsNumberObject(v goja.Value) bool {
+	return className(v) == "Number"
+}
+
+func (t *Types) IsStringObject(v goja.Value) bool {
+	return className(v) == "String"
+}
+
+func (t *Types) IsSymbolObject(v goja.Value) bool {
+	_, ok := asObject(v)
+	return ok && brandCheck(t.symbolValueOf, v)
+}
+
+func (t *Types) IsBoxedPrimitive(v goja.Value) bool {
+	return t.IsNumberObject(v) || t.IsStringObject(v) || t.IsBooleanObject(v) || t.IsSymbolObject(v)
+}
+
+func (t *Types) IsDataView(v goja.Value) bool {
+	_, ok := asObject(v)
+	return ok && brandCheck(t.dataViewByteLength, v)
+}
+
+func (t *Types) IsDate(v goja.Value) bool {
+	return className(v) == "Date"
+}
+
+func (t *Types) IsMap(v goja.Value) bool {
+	return exportType(v) == typeMap
+}
+
+func (t *Types) IsSet(v goja.Value) bool {
+	// Arrays export to the same type.
+	return exportType(v) == typeSet && className(v) == "Object"
+}
+
+func (t *Types) IsWeakMap(v goja.Value) bool {
+	_, ok := asObject(v)
+	return ok && brandCheck(t.weakMapHas, v, t.r.NewObject())
+}
+
+func (t *Types) IsWeakSet(v goja.Value) bool {
+	_, ok := asObject(v)
+	return ok && brandCheck(t.weakSetHas, v, t.r.NewObject())
+}
+
+func (t *Types) IsNativeError(v goja.Value) bool {
+	return className(v) == "Error"
+}
+
+func (t *Types) IsPromise(v goja.Value) bool {
+	return exportType(v) == typePromise
+}
+
+func (t *Types) IsProxy(v goja.Value) bool {
+	return exportType(v) == typeProxy
+}
+
+func (t *Types) IsRegExp(v goja.Value) bool {
+	return className(v) == "RegExp"
+}
+
+func (t *Types) IsTypedArray(v goja.Value) bool {
+	return t.TypedArrayName(v) != ""
+}
+
+func (t *Types) IsUint8Array(v goja.Value) bool {
+	return t.TypedArrayName(v) == "Uint8Array"
+}
+
+func (t *Types) typedArrayCheck(name string) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		return t.r.ToValue(t.TypedArrayName(call.Argument(0)) == name)
+	}
+}
+
+func (t *Types) check(fn func(goja.Value) bool) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		return t.r.ToValue(fn(call.Argument(0)))
+	}
+}
+
+func Require(runtime *goja.Runtime, module *goja.Object) {
+	t := New(runtime)
+	o := module.Get("exports").(*goja.Object)
+	o.Set("isAnyArrayBuffer", t.check(t.IsAnyArrayBuffer))
+	o.Set("isArrayBuffer", t.check(t.IsArrayBuffer))
+	o.Set("isArrayBufferView", t.check(t.IsArrayBufferView))
+	o.Set("isArgumentsObject", t.check(t.IsArgumentsObject))
+	o.Set("isAsyncFunction", t.check(t.IsAsyncFunction))
+	o.Set("isBooleanObject", t.check(t.IsBooleanObject))
+	o.Set("isBoxedPrimitive", t.check(t.IsBoxedPrimitive))
+	o.Set("isDataView", t.check(t.IsDataView))
+	o.Set("isDate", t.check(t.IsDate))
+	o.Set("isGeneratorFunction", t.check(t.IsGeneratorFunction))
+	o.Set("isGeneratorObject", t.check(t.IsGeneratorObject))
+	o.Set("isMap", t.check(t.IsMap))
+	o.Set("isMapIterator", t.check(t.IsMapIterator))
+	o.Set("isNativeError", t.check(t.IsNativeError))
+	o.Set("isNumberObject", t.check(t.IsNumberObject))
+	o.Set("isPromise", t.check(t.IsPromise))
+	o.Set("isProxy", t.check(t.IsProxy))
+	o.Set("isRegExp", t.check(t.IsRegExp))
+	o.Set("isSet", t.check(t.IsSet))
+	o.Set("isSetIterator", t.check(t.IsSetIterator))
+	o.Set("isStringObject", t.check(t.IsStringObject))
+	o.Set("isSymbolObject", t.check(t.IsSymbolObject))
+	o.Set("isTypedArray", t.check(t.IsTypedArray))
+	o.Set("isWeakMap", t.check(t.IsWeakMap))
+	o.Set("isWeakSet", t.check(t.IsWeakSet))
+	for _, name := range []string{"Int8Array", "Uint8Array", "Uint8ClampedArray", "Int16Array", "Uint16Array",
+		"Int32Array", "Uint32Array", "Float32Array", "Float64Array"} {
+		o.Set("is"+name, t.typedArrayCheck(name))
+	}
+
+	// The runtime has no BigInt, SharedArrayBuffer, modules or external values.
+	never := func(goja.FunctionCall) goja.Value {
+		return runtime.ToValue(false)
+	}
+	for _, name := range []string{"isBigInt64Array", "isBigUint64Array", "isBigIntObject", "isSharedArrayBuffer",
+		"isModuleNamespaceObject", "isExternal", "isKeyObject", "isCryptoKey"} {
+		o.Set(name, never)
+	}
+}
+
+func init() {
+	require.RegisterCoreModule(ModuleName, Require)
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/nuvolaris/goja"
+	"github.com/nuvolaris/goja_nodejs/require"
+)
+
+func TestTypes(t *testing.T) {
+	vm := goja.New()
+	new(require.Registry).Enable(vm)
+
+	_, err := vm.RunString(`
+	const assert = require("../../assert.js");
+	const types = require("util/types");
+
+	assert.sameValue(require("node:util/types"), types);
+
+	assert.sameValue(types.isPromise(Promise.resolve()), true);
+	assert.sameValue(types.isPromise({ then() {} }), false);
+	assert.sameValue(types.isDate(new Date()), true);
+	assert.sameValue(types.isDate(Object.create(Date.prototype)), false);
+	assert.sameValue(types.isRegExp(/a/), true);
+	assert.sameValue(types.isRegExp({ [Symbol.toStringTag]: "RegExp" }), false);
+	assert.sameValue(types.isMap(new Map()), true);
+	assert.sameValue(types.isMap(new Set()), false);
+	assert.sameValue(types.isSet(new Set()), true);
+	assert.sameValue(types.isSet([]), false);
+	assert.sameValue(types.isWeakMap(new WeakMap()), true);
+	assert.sameValue(types.isWeakMap(new Map()), false);
+	assert.sameValue(types.isWeakSet(new WeakSet()), true);
+	assert.sameValue(types.isWeakSet(new WeakMap()), false);
+	assert.sameValue(types.isMapIterator(new Map().keys()), true);
+	assert.sameValue(types.isMapIterator(new Set().keys()), false);
+	assert.sameValue(types.isSetIterator(new Set().entries()), true);
+
+	assert.sameValue(types.isTypedArray(new Float64Array(1)), true);
+	assert.sameValue(types.isTypedArray(new ArrayBuffer(1)), false);
+	assert.sameValue(types.isTypedArray(Object.create(Uint8Array.prototype)), false);
+	assert.sameValue(types.isUint8Array(new Uint8Array(1)), true);
+	assert.sameValue(types.isUint8Array(new Uint8ClampedArray(1)), false);
+	assert.sameValue(types.isUint8ClampedArray(new Uint8ClampedArray(1)), true);
+	assert.sameValue(types.isInt32Array(new Int32Array(1)), true);
+	assert.sameValue(types.isInt32Array(new Uint32Array(1)), false);
+	assert.sameValue(types.isArrayBuffer(new ArrayBuffer(1)), true);
+	assert.sameValue(types.isAnyArrayBuffer(new ArrayBuffer(1)), true);
+	assert.sameValue(types.isArrayBuffer(new Uint8Array(1)), false);
+	assert.sameValue(types.isDataView(new DataView(new ArrayBuffer(1))), true);
+	assert.sameValue(types.isDataView(Object.create(DataView.prototype)), false);
+	assert.sameValue(types.isArrayBufferView(new DataView(new ArrayBuffer(1))), true);
+	assert.sameValue(types.isArrayBufferView(new Int8Array(1)), true);
+	assert.sameValue(types.isArrayBufferView([]), false);
+
+	assert.sameValue(types.isProxy(new Proxy({}, {})), true);
+	assert.sameValue(types.isProxy({}), false);
+	assert.sameValue(types.isMap(new Proxy(new Map(), {})), false);
+
+	assert.sameValue(types.isAsyncFunction(async function() {}), true);
+	assert.sameValue(types.isAsyncFunction(async () => {}), true);
+	assert.sameValue(types.isAsyncFunction(function() {}), false);
+	assert.sameValue(types.isGeneratorFunction(function*() {}), true);
+	assert.sameValue(types.isGeneratorFunction(function() {}), false);
+	assert.sameValue(types.isGeneratorObject((function*() {})()), true);
+	assert.sameValue(types.isGeneratorObject({}), false);
+
+	assert.sameValue(types.isNumberObject(new Number(1)), true);
+	assert.sameValue(types.isNumberObject(1), false);
+	assert.sameValue(types.isStringObject(new String("")), true);
+	assert.sameValue(types.isBooleanObject(new Boolean(false)), true);
+	assert.sameValue(types.isSymbolObject(Object(Symbol())), true);
+	assert.sameValue(types.isSymbolObject(Symbol()), false);
+	assert.sameValue(types.isBoxedPrimitive(Object(Symbol())), true);
+	assert.sameValue(types.isBoxedPrimitive(new String("")), true);
+	assert.sameValue(types.isBoxedPrimitive("str"), false);
+
+	assert.sameValue(types.isNativeError(new TypeError()), true);
+	assert.sameValue(types.isNativeError({ name: "Error", message: "" }), false);
+	assert.sameValue(types.isNativeError(Object.create(Error.prototype)), false);
+	assert.sameValue(types.isArgumentsObject((function() { return arguments; })()), true);
+	assert.sameValue(types.isArgumentsObject([]), false);
+
+	assert.sameValue(types.isSharedArrayBuffer(new ArrayBuffer(1)), false);
+	assert.sameValue(types.isBigInt64Array(new Float64Array(1)), false);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}